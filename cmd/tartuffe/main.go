@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -12,11 +13,18 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/TetsujinOni/go-tartuffe/internal/api"
 	"github.com/TetsujinOni/go-tartuffe/internal/config"
+	"github.com/TetsujinOni/go-tartuffe/internal/formatter"
+	"github.com/TetsujinOni/go-tartuffe/internal/imposter"
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+	"github.com/TetsujinOni/go-tartuffe/internal/openapi"
+	"github.com/TetsujinOni/go-tartuffe/internal/recorder"
+	"github.com/TetsujinOni/go-tartuffe/internal/validate"
 	"github.com/TetsujinOni/go-tartuffe/pkg/version"
 )
 
@@ -33,6 +41,15 @@ func main() {
 		case "stop":
 			runStop()
 			return
+		case "import":
+			runImport()
+			return
+		case "record":
+			runRecord()
+			return
+		case "validate":
+			runValidate()
+			return
 		}
 	}
 
@@ -51,6 +68,8 @@ func runStart() {
 	// Config file options
 	configFile := flag.String("configfile", "", "file to load imposters from, can be an EJS template")
 	noParse := flag.Bool("noParse", false, "prevent EJS template rendering, treat config as raw JSON")
+	configData := flag.String("configdata", "", "JSON or YAML file providing the data variable for EJS config templates")
+	watch := flag.Bool("watch", false, "reload --configfile when it or its included files change")
 
 	// Logging options
 	logLevel := flag.String("loglevel", "info", "level for logging (debug, info, warn, error)")
@@ -59,14 +78,21 @@ func runStart() {
 
 	// Other options
 	pidFile := flag.String("pidfile", "mb.pid", "where the pid is stored for the stop command")
-	debug := flag.Bool("debug", false, "include stub match information in imposter retrievals")
+	debug := flag.Bool("debug", false, "log why requests matched no stub and include the jsonSchema violations in imposter retrievals")
 	enablePprof := flag.Bool("pprof", false, "enable pprof debugging endpoints at /debug/pprof")
 	ipWhitelist := flag.String("ipWhitelist", "*", "pipe-delimited list of allowed IP addresses")
 	origin := flag.String("origin", "", "safe origin for CORS requests")
 	apiKey := flag.String("apikey", "", "API key for authentication")
+	tenantsFile := flag.String("tenants", "", "JSON or YAML file mapping API keys to tenants, their port ranges and roles")
 
 	// Persistence options
 	dataDir := flag.String("datadir", "", "directory to persist imposters to")
+	bodyDir := flag.String("bodyDir", "", "directory that response bodyFile, proxy bodyDir and jsonSchema file paths are confined to")
+
+	// Request recording options
+	maxRequests := flag.Int("maxRequests", 0, "most recorded requests kept per imposter (0 = unlimited)")
+	maxRequestBytes := flag.Int64("maxRequestBytes", 0, "most bytes of recorded requests kept per imposter (0 = unlimited)")
+	maxRequestAge := flag.Int("maxRequestAge", 0, "milliseconds recorded requests are kept (0 = unlimited)")
 
 	// Plugin options
 	protoFile := flag.String("protofile", "", "path to protocols.json for custom protocols")
@@ -74,15 +100,10 @@ func runStart() {
 	impostersRepository := flag.String("impostersRepository", "", "repository connection string (e.g., redis://localhost:6379)")
 
 	// Formatter options
-	formatter := flag.String("formatter", "", "path to custom formatter module (Go plugin)")
+	formatterName := flag.String("formatter", "", "config file format (json, wiremock, har, postman) or path to a Go plugin .so")
 
 	flag.Parse()
 
-	// Formatter is a future enhancement - for now just log if specified
-	if *formatter != "" {
-		log.Printf("custom formatter specified: %s (not yet implemented)", *formatter)
-	}
-
 	// Handle version flag
 	if *showVersion {
 		fmt.Printf("go-tartuffe version %s (compatible with mountebank %s)\n",
@@ -111,10 +132,17 @@ func runStart() {
 		IPWhitelist:         *ipWhitelist,
 		Origin:              *origin,
 		APIKey:              *apiKey,
+		TenantsFile:         *tenantsFile,
 		DataDir:             *dataDir,
+		BodyDir:             *bodyDir,
 		ProtoFile:           *protoFile,
 		PluginsDir:          *pluginsDir,
 		ImpostersRepository: *impostersRepository,
+		RequestRetention: models.RequestRetention{
+			MaxCount: *maxRequests,
+			MaxBytes: *maxRequestBytes,
+			MaxAge:   *maxRequestAge,
+		},
 	})
 
 	// Handle graceful shutdown
@@ -138,15 +166,42 @@ func runStart() {
 	}
 
 	// Load config file if specified
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
 	if *configFile != "" {
 		log.Printf("loading config from %s", *configFile)
-		cfg, err := config.LoadFile(*configFile, *noParse)
+		fm, err := formatter.Lookup(*formatterName)
+		if err != nil {
+			log.Fatalf("failed to load formatter: %v", err)
+		}
+
+		// The default formatter also reports EJS includes, so --watch sees them
+		load := config.WatchFile(config.LoadOptions{ConfigFile: *configFile, NoParse: *noParse, DataFile: *configData})
+		if _, ok := fm.(*formatter.JSONFormatter); !ok {
+			load = func() (*config.Config, []string, error) {
+				cfg, err := fm.Load(formatter.Options{ConfigFile: *configFile, NoParse: *noParse, DataFile: *configData})
+				return cfg, []string{*configFile}, err
+			}
+		}
+		watcher := config.NewWatcher(load, time.Second)
+		cfg, err := watcher.Load()
 		if err != nil {
 			log.Fatalf("failed to load config file: %v", err)
 		}
 
 		// Load imposters into server
-		if err := srv.LoadImposters(cfg.Imposters); err != nil {
+		if *watch {
+			reloader := api.NewConfigReloader(srv)
+			if err := reloader.Apply(cfg.Imposters); err != nil {
+				log.Fatalf("failed to load imposters: %v", err)
+			}
+			go watcher.Watch(watchCtx, func(cfg *config.Config) {
+				log.Printf("config file changed, reloading %d imposters", len(cfg.Imposters))
+				if err := reloader.Apply(cfg.Imposters); err != nil {
+					log.Printf("[WARN] config reload: %v", err)
+				}
+			})
+		} else if err := srv.LoadImposters(cfg.Imposters); err != nil {
 			log.Fatalf("failed to load imposters: %v", err)
 		}
 		log.Printf("loaded %d imposters from config file", len(cfg.Imposters))
@@ -177,14 +232,17 @@ func runSave() {
 	host := saveFlags.String("host", "localhost", "the hostname mountebank is running on")
 	saveFile := saveFlags.String("savefile", "mb.json", "file to save imposters to")
 	removeProxies := saveFlags.Bool("removeProxies", false, "removes proxies from the configuration")
-	formatterPath := saveFlags.String("formatter", "", "path to custom formatter (not implemented)")
+	formatterName := saveFlags.String("formatter", "", "save file format (json, wiremock, har, postman) or path to a Go plugin .so")
 	apiKey := saveFlags.String("apikey", "", "API key for authentication")
 
 	saveFlags.Parse(os.Args[2:])
 
-	// Formatter is a future enhancement
-	if *formatterPath != "" {
-		log.Printf("custom formatter specified: %s (not yet implemented)", *formatterPath)
+	var fm formatter.Formatter
+	if *formatterName != "" {
+		var err error
+		if fm, err = formatter.Lookup(*formatterName); err != nil {
+			log.Fatalf("failed to load formatter: %v", err)
+		}
 	}
 
 	// Get imposters from running server
@@ -218,19 +276,40 @@ func runSave() {
 		log.Fatalf("failed to read response: %v", err)
 	}
 
-	// Pretty print the JSON
-	var data interface{}
-	if err := json.Unmarshal(body, &data); err != nil {
-		log.Fatalf("failed to parse response: %v", err)
+	if fm != nil {
+		var wrapper formatter.ImpostersWrapper
+		if err := json.Unmarshal(body, &wrapper); err != nil {
+			log.Fatalf("failed to parse response: %v", err)
+		}
+		opts := formatter.Options{SaveFile: *saveFile, RemoveProxies: *removeProxies}
+		if err := fm.Save(opts, &wrapper); err != nil {
+			log.Fatalf("failed to save imposters: %v", err)
+		}
+		fmt.Printf("saved imposters to %s\n", *saveFile)
+		return
 	}
 
-	prettyJSON, err := json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		log.Fatalf("failed to format JSON: %v", err)
+	// Pretty print the JSON, or convert it for a .yaml/.yml save file
+	var output []byte
+	if config.IsYAMLFile(*saveFile) {
+		output, err = config.EncodeYAML(json.RawMessage(body))
+		if err != nil {
+			log.Fatalf("failed to format YAML: %v", err)
+		}
+	} else {
+		var data interface{}
+		if err := json.Unmarshal(body, &data); err != nil {
+			log.Fatalf("failed to parse response: %v", err)
+		}
+
+		output, err = json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			log.Fatalf("failed to format JSON: %v", err)
+		}
 	}
 
 	// Write to file
-	if err := os.WriteFile(*saveFile, prettyJSON, 0644); err != nil {
+	if err := os.WriteFile(*saveFile, output, 0644); err != nil {
 		log.Fatalf("failed to write save file: %v", err)
 	}
 
@@ -297,6 +376,173 @@ func runReplay() {
 	fmt.Println("switched to replay mode (proxies removed)")
 }
 
+func runImport() {
+	if len(os.Args) < 3 || os.Args[2] != "openapi" {
+		fmt.Fprintln(os.Stderr, "usage: tartuffe import openapi [options] <spec.yaml|spec.json>")
+		os.Exit(1)
+	}
+
+	importFlags := flag.NewFlagSet("import openapi", flag.ExitOnError)
+	port := importFlags.Int("port", 4545, "the port for the generated imposter")
+	name := importFlags.String("name", "", "the name for the generated imposter (defaults to info.title)")
+	saveFile := importFlags.String("savefile", "", "file to write the imposter config to (defaults to stdout)")
+
+	importFlags.Parse(os.Args[3:])
+	if importFlags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: tartuffe import openapi [options] <spec.yaml|spec.json>")
+		os.Exit(1)
+	}
+
+	data, err := os.ReadFile(importFlags.Arg(0))
+	if err != nil {
+		log.Fatalf("failed to read OpenAPI document: %v", err)
+	}
+
+	imp, err := openapi.Convert(data, openapi.Options{Port: *port, Name: *name})
+	if err != nil {
+		log.Fatalf("failed to import OpenAPI document: %v", err)
+	}
+
+	output, err := json.MarshalIndent(config.Config{Imposters: []models.Imposter{*imp}}, "", "  ")
+	if err != nil {
+		log.Fatalf("failed to format JSON: %v", err)
+	}
+
+	if *saveFile == "" {
+		fmt.Println(string(output))
+		return
+	}
+	if err := os.WriteFile(*saveFile, output, 0644); err != nil {
+		log.Fatalf("failed to write save file: %v", err)
+	}
+	fmt.Printf("imported %d stubs to %s\n", len(imp.Stubs), *saveFile)
+}
+
+func runValidate() {
+	validateFlags := flag.NewFlagSet("validate", flag.ExitOnError)
+	noParse := validateFlags.Bool("noParse", false, "prevent EJS template rendering, treat config as raw JSON")
+	configData := validateFlags.String("configdata", "", "JSON or YAML file providing the data variable for EJS config templates")
+	bodyDir := validateFlags.String("bodyDir", "", "directory that jsonSchema file paths are resolved against, as for start")
+
+	validateFlags.Parse(os.Args[2:])
+	if validateFlags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: tartuffe validate [--noParse] [--configdata file] [--bodyDir dir] <configfile>")
+		os.Exit(1)
+	}
+
+	// The loader's progress logging would bury the problems
+	log.SetOutput(io.Discard)
+
+	file := validateFlags.Arg(0)
+	cfg, err := config.NewLoader(config.LoadOptions{ConfigFile: file, NoParse: *noParse, DataFile: *configData}).Load()
+	if err != nil {
+		var problems validate.Problems
+		if !errors.As(err, &problems) {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			os.Exit(1)
+		}
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, p.Error())
+		}
+		os.Exit(1)
+	}
+
+	for _, imp := range cfg.Imposters {
+		if err := imposter.ValidateStubSchemas(*bodyDir, imp.Stubs); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: imposter on port %d: %v\n", file, imp.Port, err)
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("%s: %d imposters OK\n", file, len(cfg.Imposters))
+}
+
+func runRecord() {
+	recordFlags := flag.NewFlagSet("record", flag.ExitOnError)
+	target := recordFlags.String("target", "", "the URL to proxy to and record (required)")
+	port := recordFlags.Int("port", 4545, "the port for the recording imposter")
+	out := recordFlags.String("out", "recording.json", "file to write the recorded imposter to (.json, .yaml or .yml)")
+	name := recordFlags.String("name", "", "the name for the recorded imposter")
+	match := recordFlags.String("match", "method,path,query", "comma-separated request fields that distinguish recorded stubs")
+	predicateGenerators := recordFlags.String("predicateGenerators", "", "JSON array of predicateGenerators, overriding --match")
+	ignoreHeaders := recordFlags.String("ignoreHeaders", strings.Join(recorder.DefaultIgnoreHeaders, ","), "comma-separated response headers to leave out of the recording")
+	redactHeaders := recordFlags.String("redactHeaders", "", "comma-separated response headers to record as [REDACTED]")
+	redactFields := recordFlags.String("redactFields", "", "comma-separated JSON body fields (dot paths) to record as [REDACTED]")
+	bodyDir := recordFlags.String("bodyDir", "", "directory to write response bodies to as content-addressed files; replay with the same --bodyDir")
+
+	recordFlags.Parse(os.Args[2:])
+	if *target == "" {
+		fmt.Fprintln(os.Stderr, "usage: tartuffe record --target <url> [--port 4545] [--out recording.json]")
+		os.Exit(1)
+	}
+
+	var generators []models.PredicateGen
+	if *predicateGenerators != "" {
+		if err := json.Unmarshal([]byte(*predicateGenerators), &generators); err != nil {
+			log.Fatalf("invalid --predicateGenerators: %v", err)
+		}
+	} else {
+		matches := make(map[string]interface{})
+		for _, field := range splitList(*match) {
+			matches[field] = true
+		}
+		if len(matches) > 0 {
+			generators = []models.PredicateGen{{Matches: matches}}
+		}
+	}
+
+	imp, err := recorder.NewProxyImposter(recorder.Options{
+		Target:              *target,
+		Port:                *port,
+		Name:                *name,
+		PredicateGenerators: generators,
+		RedactHeaders:       splitList(*redactHeaders),
+		RedactFields:        splitList(*redactFields),
+		BodyDir:             bodyDirOption(*bodyDir),
+	})
+	if err != nil {
+		log.Fatalf("failed to create recording imposter: %v", err)
+	}
+
+	manager := imposter.NewManager()
+	manager.SetBodyDir(*bodyDir)
+	if err := manager.Start(imp); err != nil {
+		log.Fatalf("failed to start recording imposter: %v", err)
+	}
+
+	done := make(chan os.Signal, 1)
+	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
+	fmt.Printf("recording %s on port %d, press Ctrl-C to save to %s\n", *target, *port, *out)
+	<-done
+
+	// Stopping first means no response is recorded while the file is written
+	manager.StopAll()
+	recording := recorder.Replayable(imp, splitList(*ignoreHeaders))
+	if err := config.SaveFile([]*models.Imposter{recording}, *out, false, true); err != nil {
+		log.Fatalf("failed to save recording: %v", err)
+	}
+	fmt.Printf("saved %d recorded stubs to %s\n", len(recording.Stubs), *out)
+}
+
+// bodyDirOption records bodies straight into the record command's
+// --bodyDir, which is also the recording imposter's root for body files
+func bodyDirOption(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	return "."
+}
+
+// splitList splits a comma-separated flag value, skipping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func runStop() {
 	stopFlags := flag.NewFlagSet("stop", flag.ExitOnError)
 	pidFile := stopFlags.String("pidfile", "mb.pid", "where the pid is stored")
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/events"
+	"github.com/TetsujinOni/go-tartuffe/internal/response"
+	"github.com/TetsujinOni/go-tartuffe/internal/tenant"
+)
+
+// eventsKeepAlive is how often an idle event stream sends a comment so
+// proxies don't close it
+const eventsKeepAlive = 15 * time.Second
+
+// EventsHandler handles the server-wide event stream
+type EventsHandler struct {
+	bus *events.Bus
+}
+
+// NewEventsHandler creates an events handler streaming from bus
+func NewEventsHandler(bus *events.Bus) *EventsHandler {
+	return &EventsHandler{bus: bus}
+}
+
+// StreamAllEvents handles GET /events, streaming every imposter's events
+// as server-sent events
+func (h *EventsHandler) StreamAllEvents(w http.ResponseWriter, r *http.Request) {
+	streamEvents(w, r, h.bus, 0)
+}
+
+// StreamEvents handles GET /imposters/{id}/events, streaming the
+// imposter's events as server-sent events
+func (h *ImposterHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	imp, ok := h.getImposter(w, r)
+	if !ok {
+		return
+	}
+	streamEvents(w, r, h.manager.Events(), imp.Port)
+}
+
+// streamEvents writes the events on bus for port (0 for all imposters)
+// until the client goes away. The types query parameter is a
+// comma-separated list of event types to send.
+func streamEvents(w http.ResponseWriter, r *http.Request, bus *events.Bus, port int) {
+	var types []string
+	if param := r.URL.Query().Get("types"); param != "" {
+		for _, t := range strings.Split(param, ",") {
+			t = strings.TrimSpace(t)
+			if !slices.Contains(events.Types, t) {
+				response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData,
+					fmt.Sprintf("unknown event type %q; expected one of %s", t, strings.Join(events.Types, ", ")))
+				return
+			}
+			types = append(types, t)
+		}
+	}
+
+	// The stream outlives the API server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	sub := bus.Subscribe(port, types)
+	defer sub.Close()
+	caller := tenant.FromContext(r.Context())
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if !caller.Allows(msg.Imposter) {
+				continue // another tenant's imposter
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, msg.Data); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -31,6 +31,9 @@ func (h *ImposterHandler) GetImposter(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.manager != nil {
+		h.manager.PruneExpiredRequests(port)
+	}
 	imp, err := h.repo.Get(port)
 	if err != nil {
 		if _, ok := err.(repository.ErrNotFound); ok {
@@ -73,6 +76,10 @@ func (h *ImposterHandler) GetImposter(w http.ResponseWriter, r *http.Request) {
 	options := parseOptions(r)
 	result := applyOptionsWithRequest(imp, options, r)
 
+	if acceptsYAML(r) {
+		response.WriteYAML(w, http.StatusOK, result)
+		return
+	}
 	response.WriteJSON(w, http.StatusOK, result)
 }
 
@@ -106,6 +113,10 @@ func (h *ImposterHandler) DeleteImposter(w http.ResponseWriter, r *http.Request)
 	}
 	result := applyOptionsWithRequest(imp, options, r)
 
+	if acceptsYAML(r) {
+		response.WriteYAML(w, http.StatusOK, result)
+		return
+	}
 	response.WriteJSON(w, http.StatusOK, result)
 }
 
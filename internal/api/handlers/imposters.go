@@ -3,13 +3,21 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/TetsujinOni/go-tartuffe/internal/imposter"
 	"github.com/TetsujinOni/go-tartuffe/internal/models"
+	"github.com/TetsujinOni/go-tartuffe/internal/openapi"
 	"github.com/TetsujinOni/go-tartuffe/internal/repository"
 	"github.com/TetsujinOni/go-tartuffe/internal/response"
+	"github.com/TetsujinOni/go-tartuffe/internal/tenant"
+	"github.com/TetsujinOni/go-tartuffe/internal/validate"
 	"github.com/TetsujinOni/go-tartuffe/internal/web"
 )
 
@@ -32,11 +40,16 @@ type ImpostersResponse struct {
 
 // GetImposters handles GET /imposters
 func (h *ImpostersHandler) GetImposters(w http.ResponseWriter, r *http.Request) {
-	imposters, err := h.repo.All()
+	imposters, err := h.callerImposters(r)
 	if err != nil {
 		response.WriteError(w, http.StatusInternalServerError, response.ErrCodeBadData, err.Error())
 		return
 	}
+	if h.manager != nil {
+		for _, imp := range imposters {
+			h.manager.PruneExpiredRequests(imp.Port)
+		}
+	}
 
 	// Content negotiation: HTML for browsers, JSON for API clients
 	if web.AcceptsHTML(r) {
@@ -74,41 +87,147 @@ func (h *ImpostersHandler) GetImposters(w http.ResponseWriter, r *http.Request)
 		result[i] = applyOptionsWithRequest(imp, options, r)
 	}
 
+	if acceptsYAML(r) {
+		response.WriteYAML(w, http.StatusOK, ImpostersResponse{Imposters: result})
+		return
+	}
 	response.WriteJSON(w, http.StatusOK, ImpostersResponse{Imposters: result})
 }
 
 // CreateImposter handles POST /imposters
 func (h *ImpostersHandler) CreateImposter(w http.ResponseWriter, r *http.Request) {
 	var imp models.Imposter
-	if err := json.NewDecoder(r.Body).Decode(&imp); err != nil {
-		response.WriteError(w, http.StatusBadRequest, response.ErrCodeInvalidJSON, "Unable to parse body as JSON")
+	if !decodeDefinition(w, r, validate.Imposter, &imp) {
+		return
+	}
+
+	h.createImposter(w, r, imp)
+}
+
+// CreateImposterFromOpenAPI handles POST /imposters/_fromOpenAPI. The body is
+// an OpenAPI 3.x document in JSON or YAML; the optional port and name query
+// parameters override the generated imposter's port and name.
+func (h *ImpostersHandler) CreateImposterFromOpenAPI(w http.ResponseWriter, r *http.Request) {
+	opts := openapi.Options{Name: r.URL.Query().Get("name")}
+	if portStr := r.URL.Query().Get("port"); portStr != "" {
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "'port' must be a valid port number")
+			return
+		}
+		opts.Port = port
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "unable to read request body")
+		return
+	}
+
+	imp, err := openapi.Convert(data, opts)
+	if err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, err.Error())
+		return
+	}
+
+	h.createImposter(w, r, *imp)
+}
+
+// createImposter validates, starts and stores a decoded imposter
+func (h *ImpostersHandler) createImposter(w http.ResponseWriter, r *http.Request, imp models.Imposter) {
+	caller := tenant.FromContext(r.Context())
+	if imp.Port != 0 {
+		if err := caller.CheckPort(imp.Port); err != nil {
+			response.WriteError(w, http.StatusForbidden, response.ErrCodeForbidden, err.Error())
+			return
+		}
+	}
+
+	if status, apiErr := h.prepareImposter(&imp); apiErr != nil {
+		response.WriteError(w, status, apiErr.Code, apiErr.Message)
+		return
+	}
+
+	if isDryRun(r) {
+		response.WriteJSON(w, http.StatusOK, &imp)
+		return
+	}
+
+	// A tenant's auto-assigned port comes from its own range
+	if imp.Port == 0 && !caller.IsAdmin() {
+		port, ok := h.freeTenantPort(caller, imp.Host)
+		if !ok {
+			response.WriteError(w, http.StatusBadRequest, response.ErrCodeResourceConflict,
+				"no free port in the ports of tenant "+strconv.Quote(caller.Tenant))
+			return
+		}
+		imp.Port = port
+	}
+
+	// Start the imposter server first
+	// This must happen before adding to repository so that auto-assigned port (port=0) is resolved
+	if h.manager != nil {
+		if err := h.manager.Start(&imp); err != nil {
+			response.WriteError(w, http.StatusBadRequest, response.ErrCodeResourceConflict,
+				"cannot start server: "+err.Error())
+			return
+		}
+	}
+
+	// Now imp.Port has the actual port (auto-assigned if it was 0)
+	// Add to repository with the actual port
+	if err := h.repo.Add(&imp); err != nil {
+		// Failed to add to repository, stop the server we just started
+		if h.manager != nil {
+			h.manager.Stop(imp.Port)
+		}
+		if _, ok := err.(repository.ErrConflict); ok {
+			response.WriteError(w, http.StatusBadRequest, response.ErrCodeResourceConflict,
+				"port "+strconv.Itoa(imp.Port)+" is already in use")
+			return
+		}
+		response.WriteError(w, http.StatusInternalServerError, response.ErrCodeBadData, err.Error())
 		return
 	}
 
+	// Add location header
+	baseURL := buildBaseURL(r)
+	w.Header().Set("Location", baseURL+"/imposters/"+strconv.Itoa(imp.Port))
+
+	// Return created imposter with links
+	result := applyOptionsWithRequest(&imp, models.SerializeOptions{}, r)
+	response.WriteJSON(w, http.StatusCreated, result)
+}
+
+// prepareImposter validates a decoded imposter and fills in the defaults
+// it runs with, returning the status and error to report if it is invalid
+func (h *ImpostersHandler) prepareImposter(imp *models.Imposter) (int, *response.Error) {
+	badData := func(message string) (int, *response.Error) {
+		return http.StatusBadRequest, &response.Error{Code: response.ErrCodeBadData, Message: message}
+	}
+
 	// Validate required fields
 	if imp.Protocol == "" {
-		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "'protocol' is a required field")
-		return
+		return badData("'protocol' is a required field")
 	}
 
 	// Validate port (port=0 or missing means auto-assign, negative is invalid, >65535 is invalid)
 	if imp.Port < 0 || imp.Port > 65535 {
-		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "'port' must be a valid port number")
-		return
+		return badData("'port' must be a valid port number")
 	}
 
 	// Check if port conflicts with API server (skip for auto-assign port=0)
 	if imp.Port != 0 && imp.Port == h.apiPort {
-		response.WriteError(w, http.StatusBadRequest, response.ErrCodeResourceConflict,
-			"port "+strconv.Itoa(imp.Port)+" is already in use")
-		return
+		return http.StatusBadRequest, &response.Error{
+			Code:    response.ErrCodeResourceConflict,
+			Message: "port " + strconv.Itoa(imp.Port) + " is already in use",
+		}
 	}
 
 	// Validate protocol
-	validProtocols := map[string]bool{"http": true, "https": true, "tcp": true, "smtp": true}
+	validProtocols := map[string]bool{"http": true, "https": true, "tcp": true, "smtp": true, "grpc": true}
 	if !validProtocols[imp.Protocol] {
-		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "unsupported protocol: "+imp.Protocol)
-		return
+		return badData("unsupported protocol: " + imp.Protocol)
 	}
 
 	// Initialize stubs if nil
@@ -116,6 +235,31 @@ func (h *ImpostersHandler) CreateImposter(w http.ResponseWriter, r *http.Request
 		imp.Stubs = []models.Stub{}
 	}
 
+	if err := imposter.ValidateStubSchemas(h.manager.BodyDir(), imp.Stubs); err != nil {
+		return badData(err.Error())
+	}
+
+	if err := imposter.ValidateBodyPaths(h.manager.BodyDir(), imp.Stubs, imp.DefaultResponse); err != nil {
+		return badData(err.Error())
+	}
+
+	stubIDs := make(map[string]bool, len(imp.Stubs))
+	for _, stub := range imp.Stubs {
+		if stub.ID == "" {
+			continue
+		}
+		if stubIDs[stub.ID] {
+			return badData("stub id " + strconv.Quote(stub.ID) + " is used more than once")
+		}
+		stubIDs[stub.ID] = true
+	}
+
+	if retention := imp.RequestRetention; retention != nil {
+		if retention.MaxCount < 0 || retention.MaxBytes < 0 || retention.MaxAge < 0 {
+			return badData("'requestRetention' bounds must not be negative")
+		}
+	}
+
 	// Initialize request counter
 	if imp.NumberOfRequests == nil {
 		count := 0
@@ -139,14 +283,11 @@ func (h *ImpostersHandler) CreateImposter(w http.ResponseWriter, r *http.Request
 			for _, stub := range imp.Stubs {
 				for _, pred := range stub.Predicates {
 					if pred.Matches != nil {
-						response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData,
-							"the matches predicate is not allowed in binary mode")
-						return
+						return badData("the matches predicate is not allowed in binary mode")
 					}
 				}
 			}
 		}
-
 	}
 
 	// For HTTPS imposters, extract certificate metadata
@@ -154,54 +295,17 @@ func (h *ImpostersHandler) CreateImposter(w http.ResponseWriter, r *http.Request
 		imp.ExtractCertMetadata()
 	}
 
-	// Start the imposter server first (HTTP, HTTPS, TCP, or SMTP)
-	// This must happen before adding to repository so that auto-assigned port (port=0) is resolved
-	if (imp.Protocol == "http" || imp.Protocol == "https" || imp.Protocol == "tcp" || imp.Protocol == "smtp") && h.manager != nil {
-		if err := h.manager.Start(&imp); err != nil {
-			response.WriteError(w, http.StatusBadRequest, response.ErrCodeResourceConflict,
-				"cannot start server: "+err.Error())
-			return
-		}
-	}
-
-	// Now imp.Port has the actual port (auto-assigned if it was 0)
-	// Add to repository with the actual port
-	if err := h.repo.Add(&imp); err != nil {
-		// Failed to add to repository, stop the server we just started
-		if h.manager != nil {
-			h.manager.Stop(imp.Port)
-		}
-		if _, ok := err.(repository.ErrConflict); ok {
-			response.WriteError(w, http.StatusBadRequest, response.ErrCodeResourceConflict,
-				"port "+strconv.Itoa(imp.Port)+" is already in use")
-			return
-		}
-		response.WriteError(w, http.StatusInternalServerError, response.ErrCodeBadData, err.Error())
-		return
-	}
-
-	// Add location header
-	baseURL := buildBaseURL(r)
-	w.Header().Set("Location", baseURL+"/imposters/"+strconv.Itoa(imp.Port))
-
-	// Return created imposter with links
-	result := applyOptionsWithRequest(&imp, models.SerializeOptions{}, r)
-	response.WriteJSON(w, http.StatusCreated, result)
+	return 0, nil
 }
 
 // DeleteImposters handles DELETE /imposters
 func (h *ImpostersHandler) DeleteImposters(w http.ResponseWriter, r *http.Request) {
-	imposters, err := h.repo.DeleteAll()
+	imposters, err := h.deleteCallerImposters(r)
 	if err != nil {
 		response.WriteError(w, http.StatusInternalServerError, response.ErrCodeBadData, err.Error())
 		return
 	}
 
-	// Stop all imposter servers
-	if h.manager != nil {
-		h.manager.StopAll()
-	}
-
 	options := parseOptions(r)
 	// Default to replayable mode for DELETE
 	if r.URL.Query().Get("replayable") == "" {
@@ -216,13 +320,85 @@ func (h *ImpostersHandler) DeleteImposters(w http.ResponseWriter, r *http.Reques
 	response.WriteJSON(w, http.StatusOK, ImpostersResponse{Imposters: result})
 }
 
-// ReplaceImposters handles PUT /imposters
+// deleteCallerImposters deletes and stops every imposter the caller may
+// act on, returning them
+func (h *ImpostersHandler) deleteCallerImposters(r *http.Request) ([]*models.Imposter, error) {
+	caller := tenant.FromContext(r.Context())
+	if caller.IsAdmin() {
+		imposters, err := h.repo.DeleteAll()
+		if err != nil {
+			return nil, err
+		}
+		if h.manager != nil {
+			h.manager.StopAll()
+		}
+		return imposters, nil
+	}
+
+	owned, err := h.callerImposters(r)
+	if err != nil {
+		return nil, err
+	}
+	var imposters []*models.Imposter
+	for _, imp := range owned {
+		deleted, err := h.repo.Delete(imp.Port)
+		if err != nil {
+			continue // deleted since it was listed
+		}
+		if h.manager != nil {
+			h.manager.Stop(imp.Port)
+		}
+		imposters = append(imposters, deleted)
+	}
+	return imposters, nil
+}
+
+// callerImposters returns the imposters the caller may act on
+func (h *ImpostersHandler) callerImposters(r *http.Request) ([]*models.Imposter, error) {
+	imposters, err := h.repo.All()
+	if err != nil {
+		return nil, err
+	}
+	caller := tenant.FromContext(r.Context())
+	if caller.IsAdmin() {
+		return imposters, nil
+	}
+	owned := imposters[:0:0]
+	for _, imp := range imposters {
+		if caller.Allows(imp.Port) {
+			owned = append(owned, imp)
+		}
+	}
+	return owned, nil
+}
+
+// freeTenantPort returns the first port in a tenant's range that no
+// imposter holds and nothing else is listening on
+func (h *ImpostersHandler) freeTenantPort(caller *tenant.Caller, host string) (int, bool) {
+	for port := caller.Ports.From; port <= caller.Ports.To; port++ {
+		if port == h.apiPort || h.repo.Exists(port) {
+			continue
+		}
+		listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			continue
+		}
+		listener.Close()
+		return port, true
+	}
+	return 0, false
+}
+
+// ReplaceImposters handles PUT /imposters. The replacement is all or
+// nothing: every imposter is validated and every new port checked before
+// anything changes, imposters whose definition is unchanged keep running
+// with their recorded requests, and if any imposter fails to start the
+// previous set is restored.
 func (h *ImpostersHandler) ReplaceImposters(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Imposters []models.Imposter `json:"imposters"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.WriteError(w, http.StatusBadRequest, response.ErrCodeInvalidJSON, "Unable to parse body as JSON")
+	if !decodeDefinition(w, r, validate.Imposters, &req) {
 		return
 	}
 
@@ -232,56 +408,177 @@ func (h *ImpostersHandler) ReplaceImposters(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Validate all imposters first
-	for _, imp := range req.Imposters {
-		if imp.Protocol == "" {
-			response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "'protocol' is a required field")
+	caller := tenant.FromContext(r.Context())
+	requested := make(map[int]bool, len(req.Imposters))
+	for i := range req.Imposters {
+		imp := &req.Imposters[i]
+		if imp.Protocol != "" && imp.Port == 0 {
+			response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "'port' must be a valid port number")
 			return
 		}
-		if imp.Port <= 0 || imp.Port > 65535 {
-			response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "'port' must be a valid port number")
+		if err := caller.CheckPort(imp.Port); err != nil {
+			response.WriteError(w, http.StatusForbidden, response.ErrCodeForbidden, err.Error())
+			return
+		}
+		if status, apiErr := h.prepareImposter(imp); apiErr != nil {
+			response.WriteError(w, status, apiErr.Code, apiErr.Message)
+			return
+		}
+		if requested[imp.Port] {
+			response.WriteError(w, http.StatusBadRequest, response.ErrCodeResourceConflict,
+				"port "+strconv.Itoa(imp.Port)+" is defined more than once")
 			return
 		}
+		requested[imp.Port] = true
 	}
 
-	// Delete all existing imposters and stop servers
-	h.repo.DeleteAll()
-	if h.manager != nil {
-		h.manager.StopAll()
+	if isDryRun(r) {
+		result := make([]*models.Imposter, len(req.Imposters))
+		for i := range req.Imposters {
+			result[i] = &req.Imposters[i]
+		}
+		response.WriteJSON(w, http.StatusOK, ImpostersResponse{Imposters: result})
+		return
 	}
 
-	// Create new imposters
+	// A tenant replaces only its own imposters
+	existing, err := h.callerImposters(r)
+	if err != nil {
+		response.WriteError(w, http.StatusInternalServerError, response.ErrCodeBadData, err.Error())
+		return
+	}
+	current := make(map[int]*models.Imposter, len(existing))
+	for _, imp := range existing {
+		current[imp.Port] = imp
+	}
+
+	// Diff against the running imposters
+	var removed, started []*models.Imposter
 	result := make([]*models.Imposter, len(req.Imposters))
 	for i := range req.Imposters {
 		imp := &req.Imposters[i]
-		if imp.Stubs == nil {
-			imp.Stubs = []models.Stub{}
+		old, ok := current[imp.Port]
+		if ok && sameDefinition(old, imp) {
+			result[i] = old
+			continue
 		}
-
-		// Initialize request counter
-		if imp.NumberOfRequests == nil {
-			count := 0
-			imp.NumberOfRequests = &count
+		if ok {
+			removed = append(removed, old)
+		} else if err := h.checkPortFree(imp); err != nil {
+			response.WriteError(w, http.StatusBadRequest, response.ErrCodeResourceConflict,
+				"cannot start server: "+err.Error())
+			return
 		}
+		started = append(started, imp)
+		result[i] = imp
+	}
+	for _, imp := range existing {
+		if !requested[imp.Port] {
+			removed = append(removed, imp)
+		}
+	}
+
+	if err := h.swapImposters(removed, started); err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeResourceConflict,
+			"cannot start server: "+err.Error())
+		return
+	}
 
-		// For HTTPS imposters, extract certificate metadata
-		if imp.Protocol == "https" {
-			imp.ExtractCertMetadata()
+	for i, imp := range result {
+		result[i] = applyOptionsWithRequest(imp, models.SerializeOptions{}, r)
+	}
+	response.WriteJSON(w, http.StatusOK, ImpostersResponse{Imposters: result})
+}
+
+// swapImposters stops and removes one set of imposters and starts and adds
+// another. If any step fails, everything done so far is undone and the
+// removed imposters are started again.
+func (h *ImpostersHandler) swapImposters(removed, started []*models.Imposter) error {
+	var stopped, added []*models.Imposter
+
+	rollback := func() {
+		for _, imp := range added {
+			h.repo.Delete(imp.Port)
+			if h.manager != nil {
+				h.manager.Stop(imp.Port)
+			}
+		}
+		for _, imp := range stopped {
+			if h.manager != nil {
+				if err := h.manager.Start(imp); err != nil {
+					log.Printf("[WARN] failed to restore imposter on port %d: %v", imp.Port, err)
+					continue
+				}
+			}
+			h.repo.Add(imp)
 		}
+	}
 
-		if err := h.repo.Add(imp); err != nil {
-			response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, err.Error())
-			return
+	for _, imp := range removed {
+		if h.manager != nil {
+			if err := h.manager.Stop(imp.Port); err != nil {
+				rollback()
+				return fmt.Errorf("failed to stop imposter on port %d: %w", imp.Port, err)
+			}
 		}
+		h.repo.Delete(imp.Port)
+		stopped = append(stopped, imp)
+	}
 
-		// Start imposter server (HTTP, HTTPS, TCP, or SMTP)
-		if (imp.Protocol == "http" || imp.Protocol == "https" || imp.Protocol == "tcp" || imp.Protocol == "smtp") && h.manager != nil {
-			h.manager.Start(imp)
+	for _, imp := range started {
+		if h.manager != nil {
+			if err := h.manager.Start(imp); err != nil {
+				rollback()
+				return fmt.Errorf("port %d: %w", imp.Port, err)
+			}
 		}
+		if err := h.repo.Add(imp); err != nil {
+			if h.manager != nil {
+				h.manager.Stop(imp.Port)
+			}
+			rollback()
+			return fmt.Errorf("port %d: %w", imp.Port, err)
+		}
+		added = append(added, imp)
+	}
+	return nil
+}
 
-		result[i] = applyOptionsWithRequest(imp, models.SerializeOptions{}, r)
+// checkPortFree binds and releases an imposter's port, so a port held by
+// another process fails the replacement before any imposter is touched
+func (h *ImpostersHandler) checkPortFree(imp *models.Imposter) error {
+	if h.manager == nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(imp.Host, strconv.Itoa(imp.Port)))
+	if err != nil {
+		return err
 	}
+	return listener.Close()
+}
 
-	response.WriteJSON(w, http.StatusOK, ImpostersResponse{Imposters: result})
+// sameDefinition reports whether a running imposter was created from the
+// same definition as a requested one, ignoring what it has recorded since
+func sameDefinition(running, requested *models.Imposter) bool {
+	key := definitionKey(running)
+	return key != "" && key == definitionKey(requested)
+}
+
+func definitionKey(imp *models.Imposter) string {
+	definition := *imp
+	definition.Requests = nil
+	definition.TCPRequests = nil
+	definition.SMTPRequests = nil
+	definition.GRPCRequests = nil
+	definition.Links = nil
+	definition.NumberOfRequests = nil
+	definition.DroppedRequests = 0
+	definition.SchemaViolations = nil
+	data, err := json.Marshal(&definition)
+	if err != nil {
+		return ""
+	}
+	return string(data)
 }
 
 // parseOptions extracts serialization options from query parameters
@@ -292,6 +589,41 @@ func parseOptions(r *http.Request) models.SerializeOptions {
 	}
 }
 
+// acceptsYAML reports whether the client prefers YAML to JSON. Each media
+// range in Accept is weighed by its q-value; JSON wins ties unless it was
+// only accepted through a wildcard.
+func acceptsYAML(r *http.Request) bool {
+	var yamlQ, jsonQ float64
+	jsonExact := false
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(accepted)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case "application/yaml", "application/x-yaml", "text/yaml":
+			yamlQ = max(yamlQ, q)
+		case "application/json":
+			if !jsonExact || q > jsonQ {
+				jsonQ = q
+			}
+			jsonExact = true
+		case "application/*", "*/*":
+			if !jsonExact {
+				jsonQ = max(jsonQ, q)
+			}
+		}
+	}
+	return yamlQ > 0 && (yamlQ > jsonQ || yamlQ == jsonQ && !jsonExact)
+}
+
 // buildBaseURL constructs the base URL from the request
 func buildBaseURL(r *http.Request) string {
 	scheme := "http"
@@ -335,6 +667,8 @@ func applyOptionsWithRequest(imp *models.Imposter, options models.SerializeOptio
 		result.GRPCRequests = nil
 		result.Links = nil
 		result.NumberOfRequests = nil
+		result.DroppedRequests = 0
+		result.SchemaViolations = nil
 	} else {
 		// Add links only in non-replayable mode
 		result.Links = &models.Links{
@@ -391,9 +725,11 @@ func applyOptionsWithRequest(imp *models.Imposter, options models.SerializeOptio
 		stubsWithLinks := make([]models.Stub, len(result.Stubs))
 		for i, stub := range result.Stubs {
 			stubsWithLinks[i] = stub
-			stubsWithLinks[i].Links = &models.StubLinks{
-				Self: &models.Link{Href: baseURL + "/imposters/" + strconv.Itoa(imp.Port) + "/stubs/" + strconv.Itoa(i)},
+			self := baseURL + "/imposters/" + strconv.Itoa(imp.Port) + "/stubs/" + strconv.Itoa(i)
+			if stub.ID != "" {
+				self = baseURL + stubPath(imp.Port, stub.ID)
 			}
+			stubsWithLinks[i].Links = &models.StubLinks{Self: &models.Link{Href: self}}
 		}
 		result.Stubs = stubsWithLinks
 	}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// TestAcceptsYAML tests choosing YAML or JSON from the Accept media ranges
+func TestAcceptsYAML(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/json", false},
+		{"application/yaml", true},
+		{"application/x-yaml", true},
+		{"text/yaml; charset=utf-8", true},
+		{"application/json, application/yaml;q=0.1", false},
+		{"application/json;q=0.5, application/yaml", true},
+		{"application/yaml;q=0.8, application/json;q=0.8", false},
+		{"application/yaml, */*", true},
+		{"application/yaml;q=0.5, */*", false},
+		{"application/yaml;q=0", false},
+		{"application/yaml;q=oops, application/json", false},
+		{"text/yamlish", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/imposters", nil)
+			req.Header.Set("Accept", tt.accept)
+			if got := acceptsYAML(req); got != tt.want {
+				t.Errorf("acceptsYAML(%q) = %v, want %v", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
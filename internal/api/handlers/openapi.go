@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/response"
+	"github.com/TetsujinOni/go-tartuffe/pkg/version"
+)
+
+// openAPISpec is the OpenAPI 3 description of the admin API. Keep it in
+// step with the routes registered in api.NewServer.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  map[string]json.RawMessage
+)
+
+// OpenAPI handles GET /openapi.json
+func OpenAPI(w http.ResponseWriter, r *http.Request) {
+	response.WriteJSON(w, http.StatusOK, OpenAPIDocument())
+}
+
+// OpenAPIDocument returns the admin API's OpenAPI document, with the info
+// version set to the running server's
+func OpenAPIDocument() map[string]json.RawMessage {
+	openAPIOnce.Do(func() {
+		if err := json.Unmarshal(openAPISpec, &openAPIDoc); err != nil {
+			panic("invalid embedded openapi.json: " + err.Error())
+		}
+
+		var info map[string]interface{}
+		if err := json.Unmarshal(openAPIDoc["info"], &info); err != nil {
+			panic("invalid info in embedded openapi.json: " + err.Error())
+		}
+		info["version"] = version.Version
+		openAPIDoc["info"], _ = json.Marshal(info)
+	})
+	return openAPIDoc
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/imposter"
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+	"github.com/TetsujinOni/go-tartuffe/internal/repository"
+	"github.com/TetsujinOni/go-tartuffe/internal/response"
+)
+
+// defaultRequestsLimit is the page size when a requests query gives no limit
+const defaultRequestsLimit = 100
+
+// RequestsResponse is the response for GET /imposters/{id}/requests
+type RequestsResponse struct {
+	Requests []interface{} `json:"requests"`
+	Total    int           `json:"total"`   // requests recorded
+	Matched  int           `json:"matched"` // requests matching the filters
+	Offset   int           `json:"offset"`
+	Limit    int           `json:"limit"`
+}
+
+// VerifyRequest is the body of POST /imposters/{id}/requests/verify. At most
+// one of Count, AtLeast and AtMost may be given; with none, at least one
+// matching request is expected.
+type VerifyRequest struct {
+	Predicates []models.Predicate `json:"predicates"`
+	From       string             `json:"from,omitempty"`
+	To         string             `json:"to,omitempty"`
+	Count      *int               `json:"count,omitempty"`
+	AtLeast    *int               `json:"atLeast,omitempty"`
+	AtMost     *int               `json:"atMost,omitempty"`
+}
+
+// VerifyResponse is the response for POST /imposters/{id}/requests/verify
+type VerifyResponse struct {
+	Verified bool   `json:"verified"`
+	Count    int    `json:"count"`
+	Expected string `json:"expected"`
+}
+
+// GetRequests handles GET /imposters/{id}/requests. The predicates query
+// parameter is a JSON array of stub predicates; from and to are RFC 3339
+// timestamps; offset and limit page through the matching requests.
+func (h *ImposterHandler) GetRequests(w http.ResponseWriter, r *http.Request) {
+	imp, ok := h.getImposter(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	query, err := requestQuery(q.Get("predicates"), q.Get("from"), q.Get("to"))
+	if err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, err.Error())
+		return
+	}
+
+	offset, err := queryInt(q.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "'offset' must be a non-negative integer")
+		return
+	}
+	limit, err := queryInt(q.Get("limit"), defaultRequestsLimit)
+	if err != nil || limit < 0 {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "'limit' must be a non-negative integer")
+		return
+	}
+
+	matched, total := imposter.QueryRequests(imp, query)
+	page := []interface{}{}
+	if offset < len(matched) {
+		// Clamp before adding so a huge limit cannot overflow
+		page = matched[offset : offset+min(limit, len(matched)-offset)]
+	}
+
+	response.WriteJSON(w, http.StatusOK, RequestsResponse{
+		Requests: page,
+		Total:    total,
+		Matched:  len(matched),
+		Offset:   offset,
+		Limit:    limit,
+	})
+}
+
+// VerifyRequests handles POST /imposters/{id}/requests/verify, reporting
+// whether the imposter received the expected number of matching requests
+func (h *ImposterHandler) VerifyRequests(w http.ResponseWriter, r *http.Request) {
+	imp, ok := h.getImposter(w, r)
+	if !ok {
+		return
+	}
+
+	var req VerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeInvalidJSON, "Unable to parse body as JSON")
+		return
+	}
+
+	query, err := requestQuery("", req.From, req.To)
+	if err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, err.Error())
+		return
+	}
+	query.Predicates = req.Predicates
+
+	given := 0
+	for _, bound := range []*int{req.Count, req.AtLeast, req.AtMost} {
+		if bound != nil {
+			given++
+		}
+	}
+	if given > 1 {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData,
+			"only one of 'count', 'atLeast' and 'atMost' may be given")
+		return
+	}
+
+	matched, _ := imposter.QueryRequests(imp, query)
+	count := len(matched)
+
+	result := VerifyResponse{Count: count}
+	switch {
+	case req.Count != nil:
+		result.Verified = count == *req.Count
+		result.Expected = fmt.Sprintf("exactly %d", *req.Count)
+	case req.AtMost != nil:
+		result.Verified = count <= *req.AtMost
+		result.Expected = fmt.Sprintf("at most %d", *req.AtMost)
+	default:
+		atLeast := 1
+		if req.AtLeast != nil {
+			atLeast = *req.AtLeast
+		}
+		result.Verified = count >= atLeast
+		result.Expected = fmt.Sprintf("at least %d", atLeast)
+	}
+
+	response.WriteJSON(w, http.StatusOK, result)
+}
+
+// getImposter looks up the imposter named by the id parameter, writing the
+// error response if there isn't one
+func (h *ImposterHandler) getImposter(w http.ResponseWriter, r *http.Request) (*models.Imposter, bool) {
+	port, err := strconv.Atoi(getParam(r, "id"))
+	if err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "invalid port number")
+		return nil, false
+	}
+
+	if h.manager != nil {
+		h.manager.PruneExpiredRequests(port)
+	}
+	imp, err := h.repo.Get(port)
+	if err != nil {
+		if _, ok := err.(repository.ErrNotFound); ok {
+			response.WriteError(w, http.StatusNotFound, response.ErrCodeNoSuchResource,
+				"imposter on port "+strconv.Itoa(port)+" does not exist")
+			return nil, false
+		}
+		response.WriteError(w, http.StatusInternalServerError, response.ErrCodeBadData, err.Error())
+		return nil, false
+	}
+	return imp, true
+}
+
+// requestQuery parses the filters shared by the requests endpoints. The
+// predicates may be a JSON array or a single predicate object.
+func requestQuery(predicates, from, to string) (imposter.RequestQuery, error) {
+	var query imposter.RequestQuery
+
+	if predicates = strings.TrimSpace(predicates); predicates != "" {
+		if strings.HasPrefix(predicates, "{") {
+			predicates = "[" + predicates + "]"
+		}
+		if err := json.Unmarshal([]byte(predicates), &query.Predicates); err != nil {
+			return query, fmt.Errorf("'predicates' must be a JSON array of predicates: %v", err)
+		}
+	}
+
+	var err error
+	if from != "" {
+		if query.From, err = time.Parse(time.RFC3339Nano, from); err != nil {
+			return query, fmt.Errorf("'from' must be an RFC 3339 timestamp")
+		}
+	}
+	if to != "" {
+		if query.To, err = time.Parse(time.RFC3339Nano, to); err != nil {
+			return query, fmt.Errorf("'to' must be an RFC 3339 timestamp")
+		}
+	}
+	return query, nil
+}
+
+func queryInt(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
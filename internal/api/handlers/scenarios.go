@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/imposter"
+	"github.com/TetsujinOni/go-tartuffe/internal/repository"
+	"github.com/TetsujinOni/go-tartuffe/internal/response"
+)
+
+// ScenarioState is the JSON form of a single scenario
+type ScenarioState struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// ScenariosResponse is the response for the scenarios endpoints
+type ScenariosResponse struct {
+	Scenarios []ScenarioState `json:"scenarios"`
+}
+
+// ScenariosHandler handles scenario state inspection and reset
+type ScenariosHandler struct {
+	repo    repository.Repository
+	manager *imposter.Manager
+}
+
+// NewScenariosHandler creates a new scenarios handler
+func NewScenariosHandler(repo repository.Repository, manager *imposter.Manager) *ScenariosHandler {
+	return &ScenariosHandler{repo: repo, manager: manager}
+}
+
+// GetScenarios handles GET /imposters/{id}/scenarios
+func (h *ScenariosHandler) GetScenarios(w http.ResponseWriter, r *http.Request) {
+	port, store, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	h.writeScenarios(w, port, store)
+}
+
+// SetScenario handles PUT /imposters/{id}/scenarios/{name}
+func (h *ScenariosHandler) SetScenario(w http.ResponseWriter, r *http.Request) {
+	port, store, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		State string `json:"state"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeInvalidJSON, "Unable to parse body as JSON")
+		return
+	}
+	if req.State == "" {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "'state' is a required field")
+		return
+	}
+
+	store.Set(getParam(r, "name"), req.State)
+	h.writeScenarios(w, port, store)
+}
+
+// ResetScenarios handles DELETE /imposters/{id}/scenarios
+func (h *ScenariosHandler) ResetScenarios(w http.ResponseWriter, r *http.Request) {
+	port, store, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	store.ResetAll()
+	h.writeScenarios(w, port, store)
+}
+
+// ResetScenario handles DELETE /imposters/{id}/scenarios/{name}
+func (h *ScenariosHandler) ResetScenario(w http.ResponseWriter, r *http.Request) {
+	port, store, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	store.Reset(getParam(r, "name"))
+	h.writeScenarios(w, port, store)
+}
+
+// lookup resolves the imposter's scenario store, writing an error response if it cannot
+func (h *ScenariosHandler) lookup(w http.ResponseWriter, r *http.Request) (int, *imposter.ScenarioStore, bool) {
+	port, err := strconv.Atoi(getParam(r, "id"))
+	if err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "invalid port number")
+		return 0, nil, false
+	}
+
+	var srv imposter.ImposterServer
+	if h.manager != nil {
+		srv = h.manager.GetImposterServer(port)
+	}
+	if srv == nil || !h.repo.Exists(port) {
+		response.WriteError(w, http.StatusNotFound, response.ErrCodeNoSuchResource,
+			"imposter on port "+strconv.Itoa(port)+" does not exist")
+		return 0, nil, false
+	}
+
+	return port, srv.Scenarios(), true
+}
+
+func (h *ScenariosHandler) writeScenarios(w http.ResponseWriter, port int, store *imposter.ScenarioStore) {
+	imp, err := h.repo.Get(port)
+	if err != nil {
+		response.WriteError(w, http.StatusInternalServerError, response.ErrCodeBadData, err.Error())
+		return
+	}
+
+	states := store.Snapshot(imp.Stubs)
+	result := ScenariosResponse{Scenarios: make([]ScenarioState, 0, len(states))}
+	for name, state := range states {
+		result.Scenarios = append(result.Scenarios, ScenarioState{Name: name, State: state})
+	}
+	sort.Slice(result.Scenarios, func(i, j int) bool {
+		return result.Scenarios[i].Name < result.Scenarios[j].Name
+	})
+
+	response.WriteJSON(w, http.StatusOK, result)
+}
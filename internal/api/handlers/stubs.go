@@ -3,21 +3,25 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"strconv"
 
+	"github.com/TetsujinOni/go-tartuffe/internal/imposter"
 	"github.com/TetsujinOni/go-tartuffe/internal/models"
 	"github.com/TetsujinOni/go-tartuffe/internal/repository"
 	"github.com/TetsujinOni/go-tartuffe/internal/response"
+	"github.com/TetsujinOni/go-tartuffe/internal/validate"
 )
 
 // StubsHandler handles stub management operations
 type StubsHandler struct {
-	repo repository.Repository
+	repo    repository.Repository
+	bodyDir string // the server's --bodyDir
 }
 
 // NewStubsHandler creates a new stubs handler
-func NewStubsHandler(repo repository.Repository) *StubsHandler {
-	return &StubsHandler{repo: repo}
+func NewStubsHandler(repo repository.Repository, bodyDir string) *StubsHandler {
+	return &StubsHandler{repo: repo, bodyDir: bodyDir}
 }
 
 // ReplaceStubs handles PUT /imposters/{id}/stubs
@@ -31,8 +35,7 @@ func (h *StubsHandler) ReplaceStubs(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Stubs []models.Stub `json:"stubs"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.WriteError(w, http.StatusBadRequest, response.ErrCodeInvalidJSON, "Unable to parse body as JSON")
+	if !decodeDefinition(w, r, validate.Stubs, &req) {
 		return
 	}
 
@@ -41,13 +44,21 @@ func (h *StubsHandler) ReplaceStubs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := imposter.ValidateStubSchemas(h.bodyDir, req.Stubs); err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, err.Error())
+		return
+	}
+	if err := imposter.ValidateBodyPaths(h.bodyDir, req.Stubs, nil); err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, err.Error())
+		return
+	}
+
+	if h.dryRun(w, r, port, map[string]interface{}{"stubs": req.Stubs}) {
+		return
+	}
+
 	if err := h.repo.UpdateStubs(port, req.Stubs); err != nil {
-		if _, ok := err.(repository.ErrNotFound); ok {
-			response.WriteError(w, http.StatusNotFound, response.ErrCodeNoSuchResource,
-				"imposter on port "+strconv.Itoa(port)+" does not exist")
-			return
-		}
-		response.WriteError(w, http.StatusInternalServerError, response.ErrCodeBadData, err.Error())
+		writeStubError(w, port, err)
 		return
 	}
 
@@ -65,44 +76,62 @@ func (h *StubsHandler) AddStub(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// First decode into a generic map to check for required fields
-	var raw map[string]interface{}
-	body := r.Body
-	if err := json.NewDecoder(body).Decode(&raw); err != nil {
-		response.WriteError(w, http.StatusBadRequest, response.ErrCodeInvalidJSON, "Unable to parse body as JSON")
+	var req struct {
+		Stub   *models.Stub `json:"stub"`
+		Index  *int         `json:"index,omitempty"`
+		Before string       `json:"before,omitempty"`
+		After  string       `json:"after,omitempty"`
+	}
+	if !decodeDefinition(w, r, validate.AddStub, &req) {
 		return
 	}
 
 	// Check that 'stub' field is present
-	if _, ok := raw["stub"]; !ok {
+	if req.Stub == nil {
 		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "must contain 'stub' field")
 		return
 	}
 
-	// Now decode the structured data
-	rawBytes, _ := json.Marshal(raw)
-	var req struct {
-		Stub  models.Stub `json:"stub"`
-		Index *int        `json:"index,omitempty"`
+	if err := imposter.ValidateStubSchemas(h.bodyDir, []models.Stub{*req.Stub}); err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, err.Error())
+		return
 	}
-	if err := json.Unmarshal(rawBytes, &req); err != nil {
-		response.WriteError(w, http.StatusBadRequest, response.ErrCodeInvalidJSON, "Unable to parse body as JSON")
+	if err := imposter.ValidateBodyPaths(h.bodyDir, []models.Stub{*req.Stub}, nil); err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, err.Error())
 		return
 	}
 
-	// Determine index
-	index := -1
-	if req.Index != nil {
-		index = *req.Index
+	positions := 0
+	for _, given := range []bool{req.Index != nil, req.Before != "", req.After != ""} {
+		if given {
+			positions++
+		}
+	}
+	if positions > 1 {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData,
+			"only one of 'index', 'before' and 'after' may be given")
+		return
 	}
 
-	if err := h.repo.AddStub(port, req.Stub, index); err != nil {
-		if _, ok := err.(repository.ErrNotFound); ok {
-			response.WriteError(w, http.StatusNotFound, response.ErrCodeNoSuchResource,
-				"imposter on port "+strconv.Itoa(port)+" does not exist")
-			return
+	if h.dryRun(w, r, port, req.Stub) {
+		return
+	}
+
+	switch {
+	case req.Before != "":
+		err = h.repo.InsertStubByID(port, *req.Stub, req.Before, false)
+	case req.After != "":
+		err = h.repo.InsertStubByID(port, *req.Stub, req.After, true)
+	default:
+		// Determine index
+		index := -1
+		if req.Index != nil {
+			index = *req.Index
 		}
-		response.WriteError(w, http.StatusInternalServerError, response.ErrCodeBadData, err.Error())
+		err = h.repo.AddStub(port, *req.Stub, index)
+	}
+	if err != nil {
+		writeStubError(w, port, err)
 		return
 	}
 
@@ -127,8 +156,16 @@ func (h *StubsHandler) ReplaceStub(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var stub models.Stub
-	if err := json.NewDecoder(r.Body).Decode(&stub); err != nil {
-		response.WriteError(w, http.StatusBadRequest, response.ErrCodeInvalidJSON, "Unable to parse body as JSON")
+	if !decodeDefinition(w, r, validate.Stub, &stub) {
+		return
+	}
+
+	if err := imposter.ValidateStubSchemas(h.bodyDir, []models.Stub{stub}); err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, err.Error())
+		return
+	}
+	if err := imposter.ValidateBodyPaths(h.bodyDir, []models.Stub{stub}, nil); err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, err.Error())
 		return
 	}
 
@@ -150,6 +187,19 @@ func (h *StubsHandler) ReplaceStub(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if stub.ID != "" {
+		for i, existing := range imp.Stubs {
+			if i != stubIndex && existing.ID == stub.ID {
+				writeStubError(w, port, repository.ErrDuplicateStubID{ID: stub.ID})
+				return
+			}
+		}
+	}
+
+	if h.dryRun(w, r, port, stub) {
+		return
+	}
+
 	// Replace by deleting and adding
 	_ = h.repo.DeleteStub(port, stubIndex)
 	_ = h.repo.AddStub(port, stub, stubIndex)
@@ -194,3 +244,206 @@ func (h *StubsHandler) DeleteStub(w http.ResponseWriter, r *http.Request) {
 
 	response.WriteJSON(w, http.StatusOK, result)
 }
+
+// GetStubByID handles GET /imposters/{id}/stubs/by-id/{stubId}
+func (h *StubsHandler) GetStubByID(w http.ResponseWriter, r *http.Request) {
+	port, err := strconv.Atoi(getParam(r, "id"))
+	if err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "invalid port number")
+		return
+	}
+	stubID := getParam(r, "stubId")
+
+	stub, err := h.repo.GetStubByID(port, stubID)
+	if err != nil {
+		writeStubError(w, port, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, stubWithLinks(stub, port, r))
+}
+
+// ReplaceStubByID handles PUT /imposters/{id}/stubs/by-id/{stubId}. The stub
+// keeps its position and id; the body may repeat the id but not change it.
+func (h *StubsHandler) ReplaceStubByID(w http.ResponseWriter, r *http.Request) {
+	port, err := strconv.Atoi(getParam(r, "id"))
+	if err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "invalid port number")
+		return
+	}
+	stubID := getParam(r, "stubId")
+
+	var stub models.Stub
+	if !decodeDefinition(w, r, validate.Stub, &stub) {
+		return
+	}
+
+	h.replaceStubByID(w, r, port, stubID, stub)
+}
+
+// PatchStubByID handles PATCH /imposters/{id}/stubs/by-id/{stubId}, applying
+// the body to the stub as a JSON merge patch (RFC 7396)
+func (h *StubsHandler) PatchStubByID(w http.ResponseWriter, r *http.Request) {
+	port, err := strconv.Atoi(getParam(r, "id"))
+	if err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "invalid port number")
+		return
+	}
+	stubID := getParam(r, "stubId")
+
+	var patch interface{}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeInvalidJSON, "Unable to parse body as JSON")
+		return
+	}
+
+	current, err := h.repo.GetStubByID(port, stubID)
+	if err != nil {
+		writeStubError(w, port, err)
+		return
+	}
+
+	var document interface{}
+	data, _ := json.Marshal(current)
+	json.Unmarshal(data, &document)
+	patched := mergePatch(document, patch)
+	if problems := validate.Stub(patched); len(problems) > 0 {
+		writeProblems(w, problems)
+		return
+	}
+	data, err = json.Marshal(patched)
+	if err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, err.Error())
+		return
+	}
+
+	var stub models.Stub
+	if err := json.Unmarshal(data, &stub); err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "patched stub is invalid: "+err.Error())
+		return
+	}
+
+	h.replaceStubByID(w, r, port, stubID, stub)
+}
+
+// replaceStubByID validates and stores a replacement for the stub with the
+// given id, and writes it back
+func (h *StubsHandler) replaceStubByID(w http.ResponseWriter, r *http.Request, port int, stubID string, stub models.Stub) {
+	if stub.ID != "" && stub.ID != stubID {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData,
+			"stub id cannot be changed from "+strconv.Quote(stubID))
+		return
+	}
+	stub.ID = stubID
+	stub.Links = nil
+
+	if err := imposter.ValidateStubSchemas(h.bodyDir, []models.Stub{stub}); err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, err.Error())
+		return
+	}
+	if err := imposter.ValidateBodyPaths(h.bodyDir, []models.Stub{stub}, nil); err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, err.Error())
+		return
+	}
+
+	if h.dryRun(w, r, port, stub) {
+		return
+	}
+
+	if err := h.repo.ReplaceStubByID(port, stubID, stub); err != nil {
+		writeStubError(w, port, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, stubWithLinks(stub, port, r))
+}
+
+// DeleteStubByID handles DELETE /imposters/{id}/stubs/by-id/{stubId},
+// returning the removed stub
+func (h *StubsHandler) DeleteStubByID(w http.ResponseWriter, r *http.Request) {
+	port, err := strconv.Atoi(getParam(r, "id"))
+	if err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "invalid port number")
+		return
+	}
+	stubID := getParam(r, "stubId")
+
+	stub, err := h.repo.GetStubByID(port, stubID)
+	if err != nil {
+		writeStubError(w, port, err)
+		return
+	}
+	if err := h.repo.DeleteStubByID(port, stubID); err != nil {
+		writeStubError(w, port, err)
+		return
+	}
+
+	stub.Links = nil
+	response.WriteJSON(w, http.StatusOK, stub)
+}
+
+// dryRun answers a dry run with the stubs the request would store,
+// reporting whether it did
+func (h *StubsHandler) dryRun(w http.ResponseWriter, r *http.Request, port int, result interface{}) bool {
+	if !isDryRun(r) {
+		return false
+	}
+	if !h.repo.Exists(port) {
+		response.WriteError(w, http.StatusNotFound, response.ErrCodeNoSuchResource,
+			"imposter on port "+strconv.Itoa(port)+" does not exist")
+		return true
+	}
+	response.WriteJSON(w, http.StatusOK, result)
+	return true
+}
+
+// stubWithLinks returns a copy of a stub with its by-id self link
+func stubWithLinks(stub models.Stub, port int, r *http.Request) models.Stub {
+	stub.Links = &models.StubLinks{
+		Self: &models.Link{Href: buildBaseURL(r) + stubPath(port, stub.ID)},
+	}
+	return stub
+}
+
+// stubPath is the by-id path of a stub
+func stubPath(port int, stubID string) string {
+	return "/imposters/" + strconv.Itoa(port) + "/stubs/by-id/" + url.PathEscape(stubID)
+}
+
+// writeStubError reports a repository error from a stub operation
+func writeStubError(w http.ResponseWriter, port int, err error) {
+	switch e := err.(type) {
+	case repository.ErrNotFound:
+		response.WriteError(w, http.StatusNotFound, response.ErrCodeNoSuchResource,
+			"imposter on port "+strconv.Itoa(port)+" does not exist")
+	case repository.ErrStubNotFound:
+		response.WriteError(w, http.StatusNotFound, response.ErrCodeNoSuchResource,
+			"stub "+strconv.Quote(e.ID)+" does not exist")
+	case repository.ErrDuplicateStubID:
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeResourceConflict,
+			"stub id "+strconv.Quote(e.ID)+" is already in use")
+	default:
+		response.WriteError(w, http.StatusInternalServerError, response.ErrCodeBadData, err.Error())
+	}
+}
+
+// mergePatch applies a JSON merge patch: objects merge recursively, null
+// removes a field and anything else replaces the target
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = mergePatch(targetObject[key], value)
+		}
+	}
+	return targetObject
+}
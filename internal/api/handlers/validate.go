@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/response"
+	"github.com/TetsujinOni/go-tartuffe/internal/validate"
+)
+
+// decodeDefinition reads a JSON request body, checks it against the
+// imposter schema and decodes it into out. It writes the error response
+// and returns false if the body is not valid JSON or breaks the schema.
+func decodeDefinition(w http.ResponseWriter, r *http.Request, check func(interface{}) validate.Problems, out interface{}) bool {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, "unable to read request body")
+		return false
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeInvalidJSON, "Unable to parse body as JSON")
+		return false
+	}
+	if problems := check(doc); len(problems) > 0 {
+		writeProblems(w, problems)
+		return false
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		response.WriteError(w, http.StatusBadRequest, response.ErrCodeBadData, err.Error())
+		return false
+	}
+	return true
+}
+
+// writeProblems writes a bad data error for each schema problem
+func writeProblems(w http.ResponseWriter, problems validate.Problems) {
+	errs := make([]response.Error, len(problems))
+	for i, p := range problems {
+		errs[i] = response.Error{Code: response.ErrCodeBadData, Message: p.Error(), Pointer: p.Pointer}
+	}
+	response.WriteJSON(w, http.StatusBadRequest, response.ErrorResponse{Errors: errs})
+}
+
+// isDryRun reports whether the request only asks for its definitions to be
+// checked. A dry run validates exactly as the real request would, short of
+// starting imposters or checking that their ports are free, and answers
+// with the definitions as they would be stored.
+func isDryRun(r *http.Request) bool {
+	return r.URL.Query().Get("dryRun") == "true"
+}
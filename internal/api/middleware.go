@@ -7,9 +7,11 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/TetsujinOni/go-tartuffe/internal/response"
+	"github.com/TetsujinOni/go-tartuffe/internal/tenant"
 )
 
 // StaticFiles middleware serves static files from /public/ path
@@ -53,7 +55,7 @@ func CORSWithOrigin(origin string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Api-Key")
 
 			if r.Method == "OPTIONS" {
@@ -75,21 +77,79 @@ func APIKeyAuth(apiKey string) func(http.Handler) http.Handler {
 				return
 			}
 
-			providedKey := r.Header.Get("X-Api-Key")
-			if providedKey == "" {
-				providedKey = r.URL.Query().Get("apikey")
+			if requestAPIKey(r) != apiKey {
+				response.WriteError(w, http.StatusUnauthorized, response.ErrCodeUnauthorized, "API key required")
+				return
 			}
 
-			if providedKey != apiKey {
-				response.WriteError(w, http.StatusUnauthorized, "unauthorized", "API key required")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestAPIKey returns the API key a request was made with, from the
+// X-Api-Key header or the apikey query parameter
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-Api-Key"); key != "" {
+		return key
+	}
+	return r.URL.Query().Get("apikey")
+}
+
+// TenantAuth middleware resolves the request's API key to its caller and
+// keeps tenants to the imposters on their own ports. Read-only keys may
+// only read, and only admin keys reach the logs, metrics and debugging
+// routes, which cover every tenant's imposters.
+func TenantAuth(tenants *tenant.Registry) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caller, ok := tenants.Lookup(requestAPIKey(r))
+			if !ok {
+				response.WriteError(w, http.StatusUnauthorized, response.ErrCodeUnauthorized, "API key required")
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			if !caller.CanWrite() && !isReadOnlyRequest(r) {
+				response.WriteError(w, http.StatusForbidden, response.ErrCodeForbidden, "API key is read-only")
+				return
+			}
+			if !caller.IsAdmin() {
+				if r.URL.Path == "/logs" || r.URL.Path == "/metrics" || strings.HasPrefix(r.URL.Path, "/debug/") {
+					response.WriteError(w, http.StatusForbidden, response.ErrCodeForbidden, "admin API key required")
+					return
+				}
+				if port, ok := imposterPort(r.URL.Path); ok {
+					if err := caller.CheckPort(port); err != nil {
+						response.WriteError(w, http.StatusForbidden, response.ErrCodeForbidden, err.Error())
+						return
+					}
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(tenant.NewContext(r.Context(), caller)))
 		})
 	}
 }
 
+// isReadOnlyRequest reports whether a request leaves imposters unchanged
+func isReadOnlyRequest(r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+	return r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/requests/verify")
+}
+
+// imposterPort returns the port in a /imposters/{id} path
+func imposterPort(path string) (int, bool) {
+	rest, ok := strings.CutPrefix(path, "/imposters/")
+	if !ok {
+		return 0, false
+	}
+	id, _, _ := strings.Cut(rest, "/")
+	port, err := strconv.Atoi(id)
+	return port, err == nil
+}
+
 // IPWhitelist middleware validates client IP against whitelist
 func IPWhitelist(whitelist string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -123,7 +183,7 @@ func IPWhitelist(whitelist string) func(http.Handler) http.Handler {
 				}
 			}
 
-			response.WriteError(w, http.StatusForbidden, "forbidden", "IP not allowed")
+			response.WriteError(w, http.StatusForbidden, response.ErrCodeForbidden, "IP not allowed")
 		})
 	}
 }
@@ -148,7 +208,7 @@ func LocalOnly(enabled bool) func(http.Handler) http.Handler {
 				return
 			}
 
-			response.WriteError(w, http.StatusForbidden, "forbidden", "only localhost connections allowed")
+			response.WriteError(w, http.StatusForbidden, response.ErrCodeForbidden, "only localhost connections allowed")
 		})
 	}
 }
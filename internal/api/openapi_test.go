@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/api/handlers"
+	"github.com/TetsujinOni/go-tartuffe/pkg/version"
+)
+
+var refPattern = regexp.MustCompile(`"\$ref":\s*"#/components/(\w+)/(\w+)"`)
+
+// TestOpenAPICoversRoutes tests that the OpenAPI document describes every
+// route the server registers, and nothing else
+func TestOpenAPICoversRoutes(t *testing.T) {
+	srv := NewServer(ServerConfig{Port: 2525, EnablePprof: true})
+
+	var paths map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(handlers.OpenAPIDocument()["paths"], &paths); err != nil {
+		t.Fatalf("failed to parse paths: %v", err)
+	}
+
+	registered := map[string]bool{}
+	for _, rt := range srv.router.routes {
+		path := strings.ReplaceAll(rt.pattern, ":.*}", "}")
+		method := strings.ToLower(rt.method)
+		registered[method+" "+path] = true
+		if _, ok := paths[path][method]; !ok {
+			t.Errorf("%s %s is not in openapi.json", rt.method, path)
+		}
+	}
+
+	for path, item := range paths {
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+			if !registered[method+" "+path] {
+				t.Errorf("openapi.json describes %s %s, which is not a route", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+// TestOpenAPIReferences tests that every $ref in the OpenAPI document
+// names a component it defines
+func TestOpenAPIReferences(t *testing.T) {
+	doc := handlers.OpenAPIDocument()
+
+	var components map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(doc["components"], &components); err != nil {
+		t.Fatalf("failed to parse components: %v", err)
+	}
+
+	data, _ := json.Marshal(doc)
+	for _, m := range refPattern.FindAllStringSubmatch(string(data), -1) {
+		if _, ok := components[m[1]][m[2]]; !ok {
+			t.Errorf("unresolved reference #/components/%s/%s", m[1], m[2])
+		}
+	}
+}
+
+// TestOpenAPIEndpoint tests serving the document at /openapi.json
+func TestOpenAPIEndpoint(t *testing.T) {
+	srv := NewServer(ServerConfig{Port: 2525})
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Version string `json:"version"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") || doc.Info.Version != version.Version {
+		t.Errorf("unexpected document header %+v", doc)
+	}
+}
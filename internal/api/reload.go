@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/imposter"
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// ConfigReloader applies successive versions of a config file to a running
+// server. Each version is diffed against the previous one rather than the
+// running imposters, so requests, proxy-recorded stubs and API changes on
+// imposters whose config didn't change are left alone.
+type ConfigReloader struct {
+	server *Server
+	mu     sync.Mutex
+	loaded map[int]imposterSnapshot
+}
+
+// imposterSnapshot is the JSON form of an imposter as the config defined it,
+// taken before starting it mutates the imposter
+type imposterSnapshot struct {
+	definition string
+	stubs      string
+}
+
+// NewConfigReloader creates a reloader for the given server
+func NewConfigReloader(server *Server) *ConfigReloader {
+	return &ConfigReloader{server: server, loaded: make(map[int]imposterSnapshot)}
+}
+
+// Apply brings the server in line with a config. New ports are started,
+// ports whose settings changed are restarted, ports where only the stubs
+// changed get the new stubs in place, and ports that were in the previous
+// config but not this one are stopped. Imposters created through the API
+// are only touched if the config now defines their port.
+func (c *ConfigReloader) Apply(imposters []models.Imposter) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	next := make(map[int]imposterSnapshot, len(imposters))
+
+	for i := range imposters {
+		imp := &imposters[i]
+		if imp.Stubs == nil {
+			imp.Stubs = []models.Stub{}
+		}
+		snapshot, err := snapshotOf(imp)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("imposter on port %d: %w", imp.Port, err))
+			continue
+		}
+
+		previous, wasLoaded := c.loaded[imp.Port]
+		_, getErr := c.server.repo.Get(imp.Port)
+		running := getErr == nil
+
+		switch {
+		case running && wasLoaded && previous == snapshot:
+			// Unchanged
+		case running && wasLoaded && previous.definition == snapshot.definition:
+			if err := c.updateStubs(imp); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			log.Printf("config reload: updated %d stubs on port %d", len(imp.Stubs), imp.Port)
+		default:
+			if running {
+				c.stop(imp.Port)
+			}
+			if err := c.server.LoadImposters(imposters[i : i+1]); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if running {
+				log.Printf("config reload: restarted imposter on port %d", imp.Port)
+			}
+		}
+		next[imp.Port] = snapshot
+	}
+
+	for port := range c.loaded {
+		if _, ok := next[port]; !ok {
+			c.stop(port)
+			log.Printf("config reload: stopped imposter on port %d", port)
+		}
+	}
+
+	c.loaded = next
+	return errors.Join(errs...)
+}
+
+// updateStubs replaces an imposter's stubs in the repository and the server
+func (c *ConfigReloader) updateStubs(imp *models.Imposter) error {
+	bodyDir := ""
+	if c.server.imposterManager != nil {
+		bodyDir = c.server.imposterManager.BodyDir()
+	}
+	if err := imposter.ValidateStubSchemas(bodyDir, imp.Stubs); err != nil {
+		return fmt.Errorf("imposter on port %d: %w", imp.Port, err)
+	}
+	if c.server.imposterManager != nil {
+		if err := imposter.ValidateBodyPaths(bodyDir, imp.Stubs, nil); err != nil {
+			return fmt.Errorf("imposter on port %d: %w", imp.Port, err)
+		}
+	}
+	if err := c.server.repo.UpdateStubs(imp.Port, imp.Stubs); err != nil {
+		return fmt.Errorf("imposter on port %d: %w", imp.Port, err)
+	}
+	if c.server.imposterManager != nil {
+		if srv := c.server.imposterManager.GetImposterServer(imp.Port); srv != nil {
+			srv.UpdateStubs(imp.Stubs)
+		}
+	}
+	return nil
+}
+
+// stop stops and removes an imposter if it is still running
+func (c *ConfigReloader) stop(port int) {
+	c.server.repo.Delete(port)
+	if c.server.imposterManager != nil {
+		c.server.imposterManager.Stop(port)
+	}
+}
+
+func snapshotOf(imp *models.Imposter) (imposterSnapshot, error) {
+	stubs, err := json.Marshal(imp.Stubs)
+	if err != nil {
+		return imposterSnapshot{}, err
+	}
+
+	definition := *imp
+	definition.Stubs = nil
+	definition.Requests = nil
+	data, err := json.Marshal(&definition)
+	if err != nil {
+		return imposterSnapshot{}, err
+	}
+	return imposterSnapshot{definition: string(data), stubs: string(stubs)}, nil
+}
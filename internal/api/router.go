@@ -48,6 +48,11 @@ func (rt *Router) DELETE(pattern string, handler http.HandlerFunc) {
 	rt.Handle("DELETE", pattern, handler)
 }
 
+// PATCH registers a PATCH route
+func (rt *Router) PATCH(pattern string, handler http.HandlerFunc) {
+	rt.Handle("PATCH", pattern, handler)
+}
+
 // ServeHTTP implements http.Handler
 func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	for _, route := range rt.routes {
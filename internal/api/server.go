@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"net/http/pprof"
 	"time"
@@ -15,12 +16,14 @@ import (
 	"github.com/TetsujinOni/go-tartuffe/internal/plugin/builtin"
 	pluginrepo "github.com/TetsujinOni/go-tartuffe/internal/plugin/repository"
 	"github.com/TetsujinOni/go-tartuffe/internal/repository"
+	"github.com/TetsujinOni/go-tartuffe/internal/tenant"
 	"github.com/TetsujinOni/go-tartuffe/internal/web"
 )
 
 // Server is the main API server
 type Server struct {
 	httpServer      *http.Server
+	router          *Router
 	repo            repository.Repository
 	imposterManager *imposter.Manager
 	pluginRegistry  *plugin.Registry
@@ -38,15 +41,23 @@ type ServerConfig struct {
 	IPWhitelist         string
 	Origin              string
 	APIKey              string
+	TenantsFile         string // If set, map API keys to tenants, their ports and roles
 	DataDir             string // If set, use filesystem-backed repository
+	BodyDir             string // Directory bodyFile and proxy bodyDir paths are confined to
 	ProtoFile           string // Path to protocols.json for custom protocols
 	PluginsDir          string // Directory containing Go plugin .so files
 	ImpostersRepository string // Repository connection string (e.g., redis://localhost:6379)
+
+	// RequestRetention bounds recorded requests for imposters that don't set their own
+	RequestRetention models.RequestRetention
 }
 
 // NewServer creates a new API server
 func NewServer(cfg ServerConfig) *Server {
 	imposterMgr := imposter.NewManager()
+	imposterMgr.SetRequestRetention(cfg.RequestRetention)
+	imposterMgr.SetDebug(cfg.Debug)
+	imposterMgr.SetBodyDir(cfg.BodyDir)
 	startTime := time.Now()
 
 	// Create plugin registry and register built-in protocols and repositories
@@ -57,7 +68,6 @@ func NewServer(cfg ServerConfig) *Server {
 
 	// Initialize repository based on configuration
 	var repo repository.Repository
-	var err error
 
 	if cfg.ImpostersRepository != "" {
 		// Use plugin-based repository from connection string
@@ -65,6 +75,7 @@ func NewServer(cfg ServerConfig) *Server {
 		if err != nil {
 			log.Fatalf("failed to parse repository connection string: %v", err)
 		}
+		repoConfig.RequestRetention = cfg.RequestRetention
 
 		factory, ok := registry.GetRepositoryFactory(repoConfig.Scheme)
 		if !ok {
@@ -79,10 +90,12 @@ func NewServer(cfg ServerConfig) *Server {
 		log.Printf("using %s repository", repoConfig.Scheme)
 	} else if cfg.DataDir != "" {
 		// Legacy: use filesystem repository if datadir is specified
-		repo, err = repository.NewFilesystem(cfg.DataDir)
+		fsRepo, err := repository.NewFilesystem(cfg.DataDir)
 		if err != nil {
 			log.Fatalf("failed to create filesystem repository: %v", err)
 		}
+		fsRepo.SetRequestRetention(cfg.RequestRetention)
+		repo = fsRepo
 		log.Printf("using filesystem repository at %s", cfg.DataDir)
 	} else {
 		// Default: in-memory repository
@@ -116,7 +129,9 @@ func NewServer(cfg ServerConfig) *Server {
 	// Create handlers
 	impostersHandler := handlers.NewImpostersHandler(repo, imposterMgr, cfg.Port)
 	imposterHandler := handlers.NewImposterHandler(repo, imposterMgr)
-	stubsHandler := handlers.NewStubsHandler(repo)
+	stubsHandler := handlers.NewStubsHandler(repo, cfg.BodyDir)
+	eventsHandler := handlers.NewEventsHandler(imposterMgr.Events())
+	scenariosHandler := handlers.NewScenariosHandler(repo, imposterMgr)
 	configHandler := handlers.NewConfigHandler(cfg.Port, cfg.Host, cfg.AllowInjection, cfg.LocalOnly, cfg.Debug, cfg.IPWhitelist, cfg.Origin, startTime.Unix())
 	logsHandler := handlers.NewLogsHandler()
 	metricsHandler := handlers.NewMetricsHandler()
@@ -128,18 +143,26 @@ func NewServer(cfg ServerConfig) *Server {
 	// Register routes
 	// Home
 	router.GET("/", handlers.Home)
+	router.GET("/openapi.json", handlers.OpenAPI)
 
 	// Imposters collection
 	router.GET("/imposters", impostersHandler.GetImposters)
 	router.POST("/imposters", impostersHandler.CreateImposter)
+	router.POST("/imposters/_fromOpenAPI", impostersHandler.CreateImposterFromOpenAPI)
 	router.DELETE("/imposters", impostersHandler.DeleteImposters)
 	router.PUT("/imposters", impostersHandler.ReplaceImposters)
 
+	// Event streams
+	router.GET("/events", eventsHandler.StreamAllEvents)
+
 	// Individual imposter
 	router.GET("/imposters/{id}", imposterHandler.GetImposter)
 	router.DELETE("/imposters/{id}", imposterHandler.DeleteImposter)
 
 	// Imposter requests/proxies
+	router.GET("/imposters/{id}/requests", imposterHandler.GetRequests)
+	router.POST("/imposters/{id}/requests/verify", imposterHandler.VerifyRequests)
+	router.GET("/imposters/{id}/events", imposterHandler.StreamEvents)
 	router.DELETE("/imposters/{id}/requests", imposterHandler.DeleteRequests)
 	router.DELETE("/imposters/{id}/savedRequests", imposterHandler.ResetRequests)
 	router.DELETE("/imposters/{id}/savedProxyResponses", imposterHandler.ResetRequests) // Same handler
@@ -149,6 +172,16 @@ func NewServer(cfg ServerConfig) *Server {
 	router.POST("/imposters/{id}/stubs", stubsHandler.AddStub)
 	router.PUT("/imposters/{id}/stubs/{stubIndex}", stubsHandler.ReplaceStub)
 	router.DELETE("/imposters/{id}/stubs/{stubIndex}", stubsHandler.DeleteStub)
+	router.GET("/imposters/{id}/stubs/by-id/{stubId}", stubsHandler.GetStubByID)
+	router.PUT("/imposters/{id}/stubs/by-id/{stubId}", stubsHandler.ReplaceStubByID)
+	router.PATCH("/imposters/{id}/stubs/by-id/{stubId}", stubsHandler.PatchStubByID)
+	router.DELETE("/imposters/{id}/stubs/by-id/{stubId}", stubsHandler.DeleteStubByID)
+
+	// Scenario states
+	router.GET("/imposters/{id}/scenarios", scenariosHandler.GetScenarios)
+	router.DELETE("/imposters/{id}/scenarios", scenariosHandler.ResetScenarios)
+	router.PUT("/imposters/{id}/scenarios/{name}", scenariosHandler.SetScenario)
+	router.DELETE("/imposters/{id}/scenarios/{name}", scenariosHandler.ResetScenario)
 
 	// Plugin callback endpoint (for out-of-process protocol plugins)
 	router.POST("/imposters/{id}/_requests", callbackHandler.HandleCallback)
@@ -202,27 +235,47 @@ func NewServer(cfg ServerConfig) *Server {
 		})
 	}
 
+	// Authenticate with the single --apikey, or with per-tenant keys, in
+	// which case --apikey is one more admin key
+	auth := APIKeyAuth(cfg.APIKey)
+	if cfg.TenantsFile != "" {
+		tenants, err := tenant.Load(cfg.TenantsFile)
+		if err != nil {
+			log.Fatalf("failed to load tenants: %v", err)
+		}
+		if cfg.APIKey != "" {
+			tenants.AddAdminKey(cfg.APIKey)
+		}
+		auth = TenantAuth(tenants)
+		log.Printf("loaded tenants from %s", cfg.TenantsFile)
+	}
+
 	// Apply middleware chain
 	// StaticFiles serves static assets from /public/
 	staticHandler := web.StaticHandler()
 	handler := StaticFiles(staticHandler)(
 		Logger(
 			CORSWithOrigin(cfg.Origin)(
-				APIKeyAuth(cfg.APIKey)(
+				auth(
 					IPWhitelist(cfg.IPWhitelist)(
 						LocalOnly(cfg.LocalOnly)(
 							JSONBody(router)))))))
 
 	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
 
+	httpServer := &http.Server{
+		Addr:         addr,
+		Handler:      handler,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  120 * time.Second,
+	}
+	// End event streams so shutdown doesn't wait on them
+	httpServer.RegisterOnShutdown(imposterMgr.Events().CloseAll)
+
 	return &Server{
-		httpServer: &http.Server{
-			Addr:         addr,
-			Handler:      handler,
-			ReadTimeout:  30 * time.Second,
-			WriteTimeout: 30 * time.Second,
-			IdleTimeout:  120 * time.Second,
-		},
+		httpServer:      httpServer,
+		router:          router,
 		repo:            repo,
 		imposterManager: imposterMgr,
 		pluginRegistry:  registry,
@@ -236,6 +289,13 @@ func (s *Server) Start() error {
 	return s.httpServer.ListenAndServe()
 }
 
+// Serve serves the API on an existing listener, such as one bound to an
+// ephemeral port
+func (s *Server) Serve(l net.Listener) error {
+	log.Printf("mountebank (go-tartuffe) running on %s", l.Addr())
+	return s.httpServer.Serve(l)
+}
+
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
 	// Stop all imposter servers first
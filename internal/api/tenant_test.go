@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/response"
+)
+
+const tenantsFile = `{
+  "tenants": [
+    {"name": "payments", "ports": {"from": 10410, "to": 10414}},
+    {"name": "search", "ports": {"from": 10420, "to": 10424}}
+  ],
+  "keys": [
+    {"key": "pay", "tenant": "payments"},
+    {"key": "pay-view", "tenant": "payments", "role": "readOnly"},
+    {"key": "search", "tenant": "search"},
+    {"key": "ops", "role": "admin"}
+  ]
+}`
+
+// newTenantServer starts a server whose API keys map to two tenants
+func newTenantServer(t *testing.T) *Server {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	if err := os.WriteFile(path, []byte(tenantsFile), 0644); err != nil {
+		t.Fatalf("failed to write tenants file: %v", err)
+	}
+	srv := NewServer(ServerConfig{Port: 2525, IPWhitelist: "*", APIKey: "global", TenantsFile: path})
+	t.Cleanup(func() { srv.Shutdown(context.Background()) })
+	return srv
+}
+
+// call makes an admin API request with key and decodes the JSON response
+func call(srv *Server, key, method, path, body string) (int, map[string]interface{}) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("X-Api-Key", key)
+	}
+	rec := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+
+	var result map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	return rec.Code, result
+}
+
+// imposterPorts returns the sorted ports of the imposters in a response
+func imposterPorts(result map[string]interface{}) []float64 {
+	var ports []float64
+	imposters, _ := result["imposters"].([]interface{})
+	for _, imp := range imposters {
+		ports = append(ports, imp.(map[string]interface{})["port"].(float64))
+	}
+	sort.Float64s(ports)
+	return ports
+}
+
+// TestTenantAccess tests which requests each kind of key may make
+func TestTenantAccess(t *testing.T) {
+	srv := newTenantServer(t)
+
+	tests := []struct {
+		name   string
+		key    string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"no key", "", http.MethodGet, "/imposters", "", http.StatusUnauthorized},
+		{"unknown key", "nope", http.MethodGet, "/imposters", "", http.StatusUnauthorized},
+		{"own port", "pay", http.MethodPost, "/imposters", `{"protocol": "http", "port": 10410}`, http.StatusCreated},
+		{"another tenant's port", "pay", http.MethodPost, "/imposters", `{"protocol": "http", "port": 10420}`, http.StatusForbidden},
+		{"unowned port", "pay", http.MethodPost, "/imposters", `{"protocol": "http", "port": 10430}`, http.StatusForbidden},
+		{"another tenant's imposter", "search", http.MethodGet, "/imposters/10410", "", http.StatusForbidden},
+		{"another tenant's stubs", "search", http.MethodPut, "/imposters/10410/stubs", `{"stubs": []}`, http.StatusForbidden},
+		{"read only reads", "pay-view", http.MethodGet, "/imposters/10410", "", http.StatusOK},
+		{"read only verifies", "pay-view", http.MethodPost, "/imposters/10410/requests/verify", `{"count": 0}`, http.StatusOK},
+		{"read only writes", "pay-view", http.MethodDelete, "/imposters/10410", "", http.StatusForbidden},
+		{"tenant logs", "pay", http.MethodGet, "/logs", "", http.StatusForbidden},
+		{"admin logs", "ops", http.MethodGet, "/logs", "", http.StatusOK},
+		{"tenant metrics", "pay", http.MethodGet, "/metrics", "", http.StatusForbidden},
+		{"admin metrics", "ops", http.MethodGet, "/metrics", "", http.StatusOK},
+		{"admin any port", "ops", http.MethodPost, "/imposters", `{"protocol": "http", "port": 10430}`, http.StatusCreated},
+		{"apikey is an admin key", "global", http.MethodGet, "/imposters/10430", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, result := call(srv, tt.key, tt.method, tt.path, tt.body)
+			if status != tt.status {
+				t.Errorf("expected %d, got %d: %v", tt.status, status, result)
+			}
+			code := map[int]string{
+				http.StatusUnauthorized: response.ErrCodeUnauthorized,
+				http.StatusForbidden:    response.ErrCodeForbidden,
+			}[tt.status]
+			if errors, _ := result["errors"].([]interface{}); code != "" && (len(errors) == 0 || errors[0].(map[string]interface{})["code"] != code) {
+				t.Errorf("expected error code %q, got %v", code, result)
+			}
+		})
+	}
+}
+
+// TestTenantCollections tests that GET, PUT and DELETE /imposters only
+// see the caller's imposters
+func TestTenantCollections(t *testing.T) {
+	srv := newTenantServer(t)
+
+	for _, c := range []struct{ key, body string }{
+		{"pay", `{"protocol": "http", "port": 10411}`},
+		{"search", `{"protocol": "http", "port": 10421}`},
+		{"ops", `{"protocol": "http", "port": 10431}`},
+	} {
+		if status, result := call(srv, c.key, http.MethodPost, "/imposters", c.body); status != http.StatusCreated {
+			t.Fatalf("failed to create imposter: %d %v", status, result)
+		}
+	}
+
+	// Port 0 is assigned from the tenant's range
+	status, result := call(srv, "pay", http.MethodPost, "/imposters", `{"protocol": "http"}`)
+	if status != http.StatusCreated || result["port"] != float64(10410) {
+		t.Fatalf("expected the first free tenant port, got %d %v", status, result)
+	}
+
+	_, result = call(srv, "pay-view", http.MethodGet, "/imposters", "")
+	if ports := imposterPorts(result); len(ports) != 2 || ports[0] != 10410 || ports[1] != 10411 {
+		t.Errorf("expected the payments imposters, got %v", ports)
+	}
+
+	// Replacing the tenant's imposters leaves the others running
+	status, result = call(srv, "search", http.MethodPut, "/imposters", `{"imposters": [{"protocol": "http", "port": 10422}]}`)
+	if status != http.StatusOK {
+		t.Fatalf("failed to replace imposters: %d %v", status, result)
+	}
+	status, _ = call(srv, "search", http.MethodPut, "/imposters", `{"imposters": [{"protocol": "http", "port": 10410}]}`)
+	if status != http.StatusForbidden {
+		t.Errorf("expected replacing with another tenant's port to be forbidden, got %d", status)
+	}
+
+	_, result = call(srv, "pay", http.MethodDelete, "/imposters", "")
+	if ports := imposterPorts(result); len(ports) != 2 {
+		t.Errorf("expected the payments imposters to be deleted, got %v", ports)
+	}
+
+	_, result = call(srv, "ops", http.MethodGet, "/imposters", "")
+	if ports := imposterPorts(result); len(ports) != 2 || ports[0] != 10422 || ports[1] != 10431 {
+		t.Errorf("expected the other tenants' imposters to remain, got %v", ports)
+	}
+}
@@ -5,14 +5,25 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
+
+	"github.com/dop251/goja"
 )
 
-// EJSRenderer renders EJS templates with mountebank-compatible functions
+// maxIncludeDepth stops templates that include themselves from recursing forever
+const maxIncludeDepth = 64
+
+// EJSRenderer renders EJS templates with mountebank-compatible functions.
+// Templates are compiled to JavaScript and run on goja, so scriptlets
+// (<% %>), escaped output (<%= %>), raw output (<%- %>), comments (<%# %>)
+// and the -%>, <%_ and _%> whitespace controls behave as they do in EJS.
+// Templates can use include(path, locals), stringify(filename, path, data),
+// inject(filename, path), filename and data.
 type EJSRenderer struct {
 	basePath string
+	filename string
 	data     map[string]interface{}
+	files    []string // every file read through include, stringify or inject
 }
 
 // NewEJSRenderer creates a new EJS renderer
@@ -28,172 +39,285 @@ func (r *EJSRenderer) SetData(data map[string]interface{}) {
 	r.data = data
 }
 
+// Files returns the files read by Render, including those read by nested
+// templates, in the order they were first read
+func (r *EJSRenderer) Files() []string {
+	return r.files
+}
+
+// addFiles records files read while rendering, skipping duplicates
+func (r *EJSRenderer) addFiles(files ...string) {
+	for _, file := range files {
+		seen := false
+		for _, existing := range r.files {
+			if existing == file {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			r.files = append(r.files, file)
+		}
+	}
+}
+
 // Render processes an EJS template and returns the result
 func (r *EJSRenderer) Render(content string) (string, error) {
-	result := content
+	filename := r.filename
+	if filename == "" {
+		// Relative paths resolve against the directory of filename
+		filename = filepath.Join(r.basePath, "template.ejs")
+	}
 
-	// Process includes: <%- include('filename') -%> or <%- include('filename') %>
-	includeRegex := regexp.MustCompile(`<%-\s*include\s*\(\s*['"]([^'"]+)['"]\s*\)\s*-?%>`)
-	for {
-		matches := includeRegex.FindStringSubmatchIndex(result)
-		if matches == nil {
-			break
-		}
+	vm := goja.New()
+	locals := map[string]interface{}{"data": r.data}
+	return r.render(vm, content, filename, locals, 0)
+}
 
-		filename := result[matches[2]:matches[3]]
-		includePath := filepath.Join(r.basePath, filename)
+// RenderFile reads and renders an EJS file
+func (r *EJSRenderer) RenderFile(filename string) (string, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
 
-		includeContent, err := os.ReadFile(includePath)
-		if err != nil {
-			return "", fmt.Errorf("failed to include file %s: %w", filename, err)
-		}
+	r.basePath = filepath.Dir(filename)
+	r.filename = filename
+	return r.Render(string(content))
+}
 
-		// Recursively render included content
-		subRenderer := NewEJSRenderer(filepath.Dir(includePath))
-		subRenderer.SetData(r.data)
-		renderedInclude, err := subRenderer.Render(string(includeContent))
-		if err != nil {
-			return "", fmt.Errorf("failed to render included file %s: %w", filename, err)
-		}
+// render compiles and runs one template. Each template gets its own locals
+// object so include, stringify and inject resolve paths against it.
+func (r *EJSRenderer) render(vm *goja.Runtime, content, filename string, locals map[string]interface{}, depth int) (string, error) {
+	if depth > maxIncludeDepth {
+		return "", fmt.Errorf("includes nested more than %d deep", maxIncludeDepth)
+	}
 
-		result = result[:matches[0]] + renderedInclude + result[matches[1]:]
+	source, err := compileEJS(content)
+	if err != nil {
+		return "", err
+	}
+	value, err := vm.RunString(source)
+	if err != nil {
+		return "", fmt.Errorf("invalid template code: %w", err)
+	}
+	fn, ok := goja.AssertFunction(value)
+	if !ok {
+		return "", fmt.Errorf("template did not compile to a function")
 	}
 
-	// Process stringify: <%- stringify(filename, 'path/to/file') %> or <%- stringify(filename, 'path/to/file', {data}) %>
-	// This reads a file and JSON-escapes its contents for embedding in a string
-	// The third parameter (data) is optional and provides data context for nested templates
-	stringifyRegex := regexp.MustCompile(`<%-\s*stringify\s*\(\s*filename\s*,\s*['"]([^'"]+)['"]\s*(?:,\s*(\{[^}]*\}))?\s*\)\s*%>`)
-	for {
-		matches := stringifyRegex.FindStringSubmatchIndex(result)
-		if matches == nil {
-			break
+	dir := filepath.Dir(filename)
+	scope := vm.NewObject()
+	for k, v := range locals {
+		scope.Set(k, v)
+	}
+	scope.Set("filename", filename)
+	scope.Set("include", r.includeFunc(vm, dir, locals, depth))
+	scope.Set("stringify", r.stringifyFunc(vm, dir, locals, depth))
+	scope.Set("inject", r.injectFunc(vm, dir, locals, depth))
+
+	result, err := fn(goja.Undefined(), scope, vm.ToValue(escapeXML))
+	if err != nil {
+		if ex, ok := err.(*goja.Exception); ok {
+			return "", fmt.Errorf("%s", ex.Value().String())
 		}
+		return "", err
+	}
+	return result.String(), nil
+}
 
-		filename := result[matches[2]:matches[3]]
-		filePath := filepath.Join(r.basePath, filename)
+// includeFunc implements include(path, locals): the included template sees
+// the including template's locals plus any it is given
+func (r *EJSRenderer) includeFunc(vm *goja.Runtime, dir string, locals map[string]interface{}, depth int) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		name := call.Argument(0).String()
+		path := filepath.Join(dir, name)
+		if filepath.Ext(path) == "" {
+			if _, err := os.Stat(path); err != nil {
+				path += ".ejs"
+			}
+		}
+		r.addFiles(path)
 
-		fileContent, err := os.ReadFile(filePath)
+		content, err := os.ReadFile(path)
 		if err != nil {
-			return "", fmt.Errorf("failed to stringify file %s: %w", filename, err)
+			panic(vm.NewGoError(fmt.Errorf("failed to include file %s: %w", name, err)))
 		}
 
-		// Check if there's data context specified
-		var localData map[string]interface{}
-		if matches[4] != -1 && matches[5] != -1 {
-			dataStr := result[matches[4]:matches[5]]
-			// Convert JavaScript object notation to JSON
-			jsonStr := jsObjectToJSON(dataStr)
-			if err := json.Unmarshal([]byte(jsonStr), &localData); err != nil {
-				return "", fmt.Errorf("invalid data context in stringify '%s' (converted to '%s'): %w", dataStr, jsonStr, err)
-			}
+		merged := make(map[string]interface{}, len(locals))
+		for k, v := range locals {
+			merged[k] = v
 		}
-
-		// If file is .ejs or needs EJS processing, render it first
-		contentStr := string(fileContent)
-		if strings.HasSuffix(filename, ".ejs") || strings.Contains(contentStr, "<%-") {
-			subRenderer := NewEJSRenderer(filepath.Dir(filePath))
-			if localData != nil {
-				subRenderer.SetData(localData)
-			} else {
-				subRenderer.SetData(r.data)
-			}
-			contentStr, err = subRenderer.Render(contentStr)
-			if err != nil {
-				return "", fmt.Errorf("failed to render stringify file %s: %w", filename, err)
+		if extra, ok := call.Argument(1).Export().(map[string]interface{}); ok {
+			for k, v := range extra {
+				merged[k] = v
 			}
 		}
 
-		// JSON-escape the content for embedding in a JSON string
-		escaped, err := json.Marshal(contentStr)
+		rendered, err := r.render(vm, string(content), path, merged, depth+1)
 		if err != nil {
-			return "", fmt.Errorf("failed to JSON-escape content: %w", err)
+			panic(vm.NewGoError(fmt.Errorf("failed to render included file %s: %w", name, err)))
 		}
-		// Remove the surrounding quotes from json.Marshal
-		escapedStr := string(escaped[1 : len(escaped)-1])
-
-		result = result[:matches[0]] + escapedStr + result[matches[1]:]
+		return vm.ToValue(rendered)
 	}
+}
 
-	// Process inject: <%- inject(filename, 'path/to/file') %>
-	// This is similar to stringify but for JavaScript injection
-	injectRegex := regexp.MustCompile(`<%-\s*inject\s*\(\s*filename\s*,\s*['"]([^'"]+)['"]\s*\)\s*%>`)
-	for {
-		matches := injectRegex.FindStringSubmatchIndex(result)
-		if matches == nil {
-			break
-		}
-
-		filename := result[matches[2]:matches[3]]
-		filePath := filepath.Join(r.basePath, filename)
+// stringifyFunc implements stringify(filename, path, data), which renders a
+// file and JSON-escapes it for embedding in a string. The rendered file sees
+// data as its data variable, or the caller's data if none is given.
+func (r *EJSRenderer) stringifyFunc(vm *goja.Runtime, dir string, locals map[string]interface{}, depth int) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		name := call.Argument(1).String()
+		path := filepath.Join(dir, name)
+		r.addFiles(path)
 
-		fileContent, err := os.ReadFile(filePath)
+		content, err := os.ReadFile(path)
 		if err != nil {
-			return "", fmt.Errorf("failed to inject file %s: %w", filename, err)
+			panic(vm.NewGoError(fmt.Errorf("failed to stringify file %s: %w", name, err)))
 		}
 
-		// If file is .ejs, render it first
-		contentStr := string(fileContent)
-		if strings.HasSuffix(filename, ".ejs") {
-			subRenderer := NewEJSRenderer(filepath.Dir(filePath))
-			subRenderer.SetData(r.data)
-			contentStr, err = subRenderer.Render(contentStr)
+		rendered := string(content)
+		if strings.HasSuffix(name, ".ejs") || strings.Contains(rendered, "<%") {
+			data := locals["data"]
+			if arg := call.Argument(2); !goja.IsUndefined(arg) && !goja.IsNull(arg) {
+				data = arg.Export()
+			}
+			rendered, err = r.render(vm, rendered, path, map[string]interface{}{"data": data}, depth+1)
 			if err != nil {
-				return "", fmt.Errorf("failed to render inject file %s: %w", filename, err)
+				panic(vm.NewGoError(fmt.Errorf("failed to render stringify file %s: %w", name, err)))
 			}
 		}
+		return vm.ToValue(jsonEscape(rendered))
+	}
+}
+
+// injectFunc implements inject(filename, path), which JSON-escapes a script
+// file for embedding in a string, rendering it first if it is an .ejs file
+func (r *EJSRenderer) injectFunc(vm *goja.Runtime, dir string, locals map[string]interface{}, depth int) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		name := call.Argument(1).String()
+		path := filepath.Join(dir, name)
+		r.addFiles(path)
 
-		// JSON-escape for embedding
-		escaped, err := json.Marshal(contentStr)
+		content, err := os.ReadFile(path)
 		if err != nil {
-			return "", fmt.Errorf("failed to JSON-escape inject content: %w", err)
+			panic(vm.NewGoError(fmt.Errorf("failed to inject file %s: %w", name, err)))
 		}
-		escapedStr := string(escaped[1 : len(escaped)-1])
 
-		result = result[:matches[0]] + escapedStr + result[matches[1]:]
+		rendered := string(content)
+		if strings.HasSuffix(name, ".ejs") {
+			rendered, err = r.render(vm, rendered, path, locals, depth+1)
+			if err != nil {
+				panic(vm.NewGoError(fmt.Errorf("failed to render inject file %s: %w", name, err)))
+			}
+		}
+		return vm.ToValue(jsonEscape(rendered))
 	}
+}
 
-	// Process data variable access: <%- data.varName %>
-	dataRegex := regexp.MustCompile(`<%-\s*data\.(\w+)\s*%>`)
-	result = dataRegex.ReplaceAllStringFunc(result, func(match string) string {
-		submatches := dataRegex.FindStringSubmatch(match)
-		if len(submatches) < 2 {
-			return match
-		}
-		varName := submatches[1]
-		if val, ok := r.data[varName]; ok {
-			return fmt.Sprintf("%v", val)
-		}
-		return ""
-	})
+// jsonEscape escapes s for embedding inside a JSON string literal
+func jsonEscape(s string) string {
+	escaped, _ := json.Marshal(s)
+	return string(escaped[1 : len(escaped)-1])
+}
 
-	return result, nil
+// escapeXML is the escaping EJS applies to <%= %> output
+func escapeXML(value goja.Value) string {
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return ""
+	}
+	return strings.NewReplacer(
+		"&", "&amp;",
+		"<", "&lt;",
+		">", "&gt;",
+		`"`, "&#34;",
+		"'", "&#39;",
+	).Replace(value.String())
 }
 
-// RenderFile reads and renders an EJS file
-func (r *EJSRenderer) RenderFile(filename string) (string, error) {
-	content, err := os.ReadFile(filename)
-	if err != nil {
-		return "", fmt.Errorf("failed to read file %s: %w", filename, err)
+// compileEJS turns a template into the source of a JavaScript function
+// taking (locals, escape) and returning the rendered text
+func compileEJS(template string) (string, error) {
+	var js strings.Builder
+	js.WriteString("(function(__locals, __escape) {\n")
+	js.WriteString("var __out = '';\n")
+	js.WriteString("function __append(s) { if (s !== undefined && s !== null) __out += s; }\n")
+	js.WriteString("with (__locals) {\n")
+
+	appendText := func(text string) {
+		if text != "" {
+			quoted, _ := json.Marshal(text)
+			js.WriteString("__append(" + string(quoted) + ");\n")
+		}
 	}
 
-	r.basePath = filepath.Dir(filename)
-	return r.Render(string(content))
-}
+	rest := template
+	for {
+		start := strings.Index(rest, "<%")
+		if start < 0 {
+			appendText(rest)
+			break
+		}
 
-// jsObjectToJSON converts JavaScript object notation to valid JSON
-// Handles unquoted keys and single-quoted values
-func jsObjectToJSON(jsObj string) string {
-	result := jsObj
+		text := rest[:start]
+		rest = rest[start+2:]
 
-	// Replace single quotes with double quotes for values
-	// Match: key: 'value' and convert to key: "value"
-	singleQuoteRegex := regexp.MustCompile(`:\s*'([^']*)'`)
-	result = singleQuoteRegex.ReplaceAllString(result, `: "$1"`)
+		// <%% is a literal <%
+		if strings.HasPrefix(rest, "%") {
+			appendText(text + "<%")
+			rest = rest[1:]
+			continue
+		}
 
-	// Quote unquoted keys
-	// Match: { key: or , key: and convert to { "key": or , "key":
-	unquotedKeyRegex := regexp.MustCompile(`([{,])\s*(\w+)\s*:`)
-	result = unquotedKeyRegex.ReplaceAllString(result, `$1 "$2":`)
+		kind := byte(0)
+		if len(rest) > 0 && strings.ContainsRune("=-#_", rune(rest[0])) {
+			kind = rest[0]
+			rest = rest[1:]
+		}
+		if kind == '_' {
+			// <%_ strips the whitespace before it on the same line
+			text = strings.TrimRight(text, " \t")
+		}
+		appendText(text)
 
-	return result
+		end := strings.Index(rest, "%>")
+		if end < 0 {
+			return "", fmt.Errorf("could not find matching close tag for \"<%%%s\"", kindString(kind))
+		}
+		code := rest[:end]
+		rest = rest[end+2:]
+
+		switch {
+		case strings.HasSuffix(code, "-"):
+			// -%> drops the newline that follows
+			code = code[:len(code)-1]
+			rest = strings.TrimPrefix(strings.TrimPrefix(rest, "\r"), "\n")
+		case strings.HasSuffix(code, "_"):
+			// _%> drops all whitespace that follows
+			code = code[:len(code)-1]
+			rest = strings.TrimLeft(rest, " \t\r\n")
+		}
+
+		expr := strings.TrimRight(strings.TrimSpace(code), ";")
+		switch kind {
+		case '#':
+			// Comment
+		case '=':
+			js.WriteString("__append(__escape(" + expr + "));\n")
+		case '-':
+			js.WriteString("__append(" + expr + ");\n")
+		default:
+			js.WriteString(code + "\n")
+		}
+	}
+
+	js.WriteString("}\nreturn __out;\n})")
+	return js.String(), nil
+}
+
+func kindString(kind byte) string {
+	if kind == 0 {
+		return ""
+	}
+	return string(kind)
 }
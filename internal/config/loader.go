@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/TetsujinOni/go-tartuffe/internal/models"
+	"github.com/TetsujinOni/go-tartuffe/internal/validate"
 )
 
 // Config represents a mountebank configuration file structure
@@ -20,12 +21,14 @@ type Config struct {
 // LoadOptions contains options for loading configuration
 type LoadOptions struct {
 	ConfigFile string
-	NoParse    bool // If true, skip EJS rendering
+	NoParse    bool   // If true, skip EJS rendering
+	DataFile   string // JSON or YAML file whose object is the EJS data variable
 }
 
 // Loader handles loading imposter configurations from files
 type Loader struct {
 	options LoadOptions
+	files   []string
 }
 
 // NewLoader creates a new configuration loader
@@ -46,23 +49,51 @@ func (l *Loader) Load() (*Config, error) {
 	}
 
 	contentStr := string(content)
+	l.files = []string{l.options.ConfigFile}
 
 	// Check if we need to render EJS
 	if !l.options.NoParse && needsEJSRendering(l.options.ConfigFile, contentStr) {
 		renderer := NewEJSRenderer(filepath.Dir(l.options.ConfigFile))
+		renderer.filename = l.options.ConfigFile
+		if l.options.DataFile != "" {
+			l.files = append(l.files, l.options.DataFile)
+			data, err := loadDataFile(l.options.DataFile)
+			if err != nil {
+				return nil, err
+			}
+			renderer.SetData(data)
+		}
 		contentStr, err = renderer.Render(contentStr)
+		l.files = append(l.files, renderer.Files()...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to render EJS template: %w", err)
 		}
 	}
 
-	// Parse JSON
-	var config Config
-	contentStr = RemoveJSComments(contentStr)
-	if err := json.Unmarshal([]byte(contentStr), &config); err != nil {
+	// Parse JSON, or YAML for .yaml/.yml files
+	var doc interface{}
+	data := []byte(RemoveJSComments(contentStr))
+	if IsYAMLFile(l.options.ConfigFile) {
+		if doc, err = decodeYAMLDocuments(data); err != nil {
+			return nil, fmt.Errorf("failed to parse config YAML: %w", err)
+		}
+		// Round-trip through JSON so the models' custom unmarshalling applies
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, err
+		}
+	} else if err := json.Unmarshal(data, &doc); err != nil {
 		return nil, fmt.Errorf("failed to parse config JSON: %w", err)
 	}
 
+	if err := validate.Config(doc).Err(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
 	// Validate imposters
 	for i, imp := range config.Imposters {
 		if imp.Protocol == "" {
@@ -80,6 +111,29 @@ func (l *Loader) Load() (*Config, error) {
 	return &config, nil
 }
 
+// Files returns the config file and every file the last Load read through
+// EJS include, stringify or inject, so callers can watch them for changes
+func (l *Loader) Files() []string {
+	return l.files
+}
+
+// loadDataFile reads the --configdata file, which must hold a JSON or YAML object
+func loadDataFile(filename string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config data file: %w", err)
+	}
+	decoded, err := DecodeYAML(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config data file: %w", err)
+	}
+	data, ok := decoded.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("config data file %s must contain an object", filename)
+	}
+	return data, nil
+}
+
 // needsEJSRendering checks if a file needs EJS rendering
 func needsEJSRendering(filename, content string) bool {
 	// Check file extension
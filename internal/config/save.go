@@ -40,6 +40,8 @@ func (s *Saver) Save(imposters []*models.Imposter) error {
 		if s.options.Replayable {
 			impCopy.Requests = nil
 			impCopy.NumberOfRequests = nil
+			impCopy.DroppedRequests = 0
+			impCopy.SchemaViolations = nil
 		}
 
 		// Remove proxy responses if requested
@@ -85,8 +87,14 @@ func (s *Saver) Save(imposters []*models.Imposter) error {
 		Imposters: processed,
 	}
 
-	// Marshal to JSON with indentation
-	data, err := json.MarshalIndent(config, "", "  ")
+	// Marshal to YAML for .yaml/.yml files, otherwise indented JSON
+	var data []byte
+	var err error
+	if IsYAMLFile(s.options.SaveFile) {
+		data, err = EncodeYAML(config)
+	} else {
+		data, err = json.MarshalIndent(config, "", "  ")
+	}
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+)
+
+// LoadFunc loads a configuration and returns the files it was read from
+type LoadFunc func() (*Config, []string, error)
+
+// fileStamp identifies a version of a file; a missing file has a zero stamp
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher reloads a configuration when any of the files it was read from
+// changes. It polls file modification times and sizes, which works the same
+// on every platform and for editors that replace files on save.
+type Watcher struct {
+	load     LoadFunc
+	interval time.Duration
+	stamps   map[string]fileStamp
+}
+
+// NewWatcher creates a watcher that polls every interval
+func NewWatcher(load LoadFunc, interval time.Duration) *Watcher {
+	return &Watcher{load: load, interval: interval}
+}
+
+// WatchFile returns a LoadFunc for a config file and everything it includes
+func WatchFile(options LoadOptions) LoadFunc {
+	return func() (*Config, []string, error) {
+		loader := NewLoader(options)
+		cfg, err := loader.Load()
+		return cfg, loader.Files(), err
+	}
+}
+
+// Load loads the configuration and remembers the state of its files
+func (w *Watcher) Load() (*Config, error) {
+	cfg, files, err := w.load()
+	// Files read before a failure are still watched, so fixing them retries
+	w.stamps = make(map[string]fileStamp, len(files))
+	for _, file := range files {
+		w.stamps[file] = stampOf(file)
+	}
+	return cfg, err
+}
+
+// Watch polls until ctx is done, calling onChange with each configuration
+// that loads successfully after a change. Configurations that fail to load
+// are logged and skipped, leaving the previous one in place.
+func (w *Watcher) Watch(ctx context.Context, onChange func(*Config)) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !w.changed() {
+				continue
+			}
+			cfg, err := w.Load()
+			if err != nil {
+				log.Printf("[WARN] config reload failed, keeping running imposters: %v", err)
+				continue
+			}
+			onChange(cfg)
+		}
+	}
+}
+
+// changed reports whether any watched file differs from when it was loaded
+func (w *Watcher) changed() bool {
+	for file, stamp := range w.stamps {
+		if stampOf(file) != stamp {
+			return true
+		}
+	}
+	return false
+}
+
+func stampOf(file string) fileStamp {
+	info, err := os.Stat(file)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
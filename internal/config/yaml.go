@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DecodeYAML parses a YAML (or JSON) document into the same shapes that
+// encoding/json produces for interface{}: objects become
+// map[string]interface{} even when YAML keys are numbers, as with the
+// status codes under an OpenAPI operation's responses
+func DecodeYAML(data []byte) (interface{}, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return normalizeYAML(doc), nil
+}
+
+func normalizeYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = normalizeYAML(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[fmt.Sprint(k)] = normalizeYAML(item)
+		}
+		return out
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeYAML(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// IsYAMLFile reports whether a config or save file should be read or written
+// as YAML, judging by its extension (ignoring a trailing .ejs)
+func IsYAMLFile(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(strings.TrimSuffix(filename, ".ejs")))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// decodeYAMLDocuments gathers the imposters from every document of a YAML
+// config file into a single config object. Each document is either a config
+// with an imposters list or a single imposter, so a multi-document file can
+// hold one imposter per document.
+func decodeYAMLDocuments(data []byte) (map[string]interface{}, error) {
+	imposters := []interface{}{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for i := 0; ; i++ {
+		var doc interface{}
+		if err := decoder.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+
+		switch v := normalizeYAML(doc).(type) {
+		case nil:
+			// Empty documents, such as a leading "---", are ignored
+		case map[string]interface{}:
+			if list, ok := v["imposters"]; ok {
+				items, ok := list.([]interface{})
+				if !ok {
+					return nil, fmt.Errorf("document %d: imposters must be a list", i)
+				}
+				imposters = append(imposters, items...)
+			} else {
+				imposters = append(imposters, v)
+			}
+		default:
+			return nil, fmt.Errorf("document %d: expected an imposter or an imposters list", i)
+		}
+	}
+	return map[string]interface{}{"imposters": imposters}, nil
+}
+
+// EncodeYAML renders v as block-style YAML. It goes through the JSON
+// encoding so field names, omitempty and custom marshalling match the JSON
+// API, and keeps the JSON field order rather than sorting keys.
+func EncodeYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	clearYAMLStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// clearYAMLStyle drops the flow and quoting styles a JSON document parses
+// with; the encoder still quotes strings that would otherwise change type
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
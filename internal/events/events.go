@@ -0,0 +1,159 @@
+// Package events publishes what imposters do as it happens, so API clients
+// can stream requests, matches and lifecycle changes instead of polling.
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event types
+const (
+	RequestReceived = "request-received"
+	StubMatched     = "stub-matched"
+	NoMatch         = "no-match"
+	ProxyRecorded   = "proxy-recorded"
+	ImposterStarted = "imposter-started"
+	ImposterStopped = "imposter-stopped"
+)
+
+// Types lists every event type
+var Types = []string{RequestReceived, StubMatched, NoMatch, ProxyRecorded, ImposterStarted, ImposterStopped}
+
+// subscriberBuffer is how many messages a subscriber may fall behind by
+// before further messages to it are dropped
+const subscriberBuffer = 256
+
+// Event is something an imposter did
+type Event struct {
+	Type      string      `json:"type"`
+	Imposter  int         `json:"imposter"` // Imposter port
+	Protocol  string      `json:"protocol,omitempty"`
+	Timestamp string      `json:"timestamp"`
+	StubIndex *int        `json:"stubIndex,omitempty"`
+	StubID    string      `json:"stubId,omitempty"`
+	Request   interface{} `json:"request,omitempty"` // The protocol's request record
+	Stub      interface{} `json:"stub,omitempty"`    // The stub a proxy recorded
+}
+
+// Message is a published event, encoded once for all of its subscribers
+type Message struct {
+	ID       uint64
+	Type     string
+	Imposter int
+	Data     []byte
+}
+
+// Bus fans published events out to subscribers. Publishing never blocks:
+// a subscriber that falls behind misses messages rather than slowing the
+// imposters down. Each imposter manager has its own bus, so servers in
+// the same process don't see each other's events.
+type Bus struct {
+	subscribers map[*Subscription]struct{}
+	active      atomic.Int32
+	lastID      atomic.Uint64
+	mu          sync.RWMutex
+}
+
+// Subscription receives the messages for one subscriber
+type Subscription struct {
+	C       <-chan Message
+	ch      chan Message
+	port    int
+	types   map[string]bool
+	dropped atomic.Int64
+	bus     *Bus
+	once    sync.Once
+}
+
+// NewBus creates an event bus
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[*Subscription]struct{})}
+}
+
+// Subscribe returns a subscription to the events of the imposter on port,
+// or of every imposter when port is 0. With no types, every type is sent.
+func (b *Bus) Subscribe(port int, types []string) *Subscription {
+	ch := make(chan Message, subscriberBuffer)
+	sub := &Subscription{C: ch, ch: ch, port: port, bus: b}
+	if len(types) > 0 {
+		sub.types = make(map[string]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+	b.active.Add(1)
+	return sub
+}
+
+// Publish sends an event to every subscriber that wants it. Events are
+// only encoded when someone is subscribed, and a nil bus discards them.
+func (b *Bus) Publish(e Event) {
+	if b == nil || b.active.Load() == 0 {
+		return
+	}
+	if e.Timestamp == "" {
+		e.Timestamp = time.Now().Format(time.RFC3339Nano)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	msg := Message{ID: b.lastID.Add(1), Type: e.Type, Imposter: e.Imposter, Data: data}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subscribers {
+		if !sub.wants(msg) {
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// CloseAll closes every subscription, ending their streams
+func (b *Bus) CloseAll() {
+	b.mu.RLock()
+	subs := make([]*Subscription, 0, len(b.subscribers))
+	for sub := range b.subscribers {
+		subs = append(subs, sub)
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		sub.Close()
+	}
+}
+
+// Dropped returns how many messages the subscription missed by falling behind
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close unsubscribes and closes the subscription's channel
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subscribers, s)
+		s.bus.mu.Unlock()
+		s.bus.active.Add(-1)
+		close(s.ch)
+	})
+}
+
+func (s *Subscription) wants(msg Message) bool {
+	if s.port != 0 && s.port != msg.Imposter {
+		return false
+	}
+	return s.types == nil || s.types[msg.Type]
+}
@@ -0,0 +1,64 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestBusFiltersSubscriptions tests that subscribers only receive events
+// for their imposter and types
+func TestBusFiltersSubscriptions(t *testing.T) {
+	bus := NewBus()
+	all := bus.Subscribe(0, nil)
+	defer all.Close()
+	one := bus.Subscribe(4545, []string{NoMatch})
+	defer one.Close()
+
+	bus.Publish(Event{Type: RequestReceived, Imposter: 4545})
+	bus.Publish(Event{Type: NoMatch, Imposter: 4546})
+	bus.Publish(Event{Type: NoMatch, Imposter: 4545})
+
+	if got := len(all.C); got != 3 {
+		t.Errorf("unfiltered subscription got %d messages, want 3", got)
+	}
+	if got := len(one.C); got != 1 {
+		t.Fatalf("filtered subscription got %d messages, want 1", got)
+	}
+
+	msg := <-one.C
+	var e Event
+	if err := json.Unmarshal(msg.Data, &e); err != nil {
+		t.Fatalf("message data is not an event: %v", err)
+	}
+	if e.Type != NoMatch || e.Imposter != 4545 || e.Timestamp == "" {
+		t.Errorf("unexpected event %+v", e)
+	}
+	if msg.ID != 3 {
+		t.Errorf("message ID = %d, want 3", msg.ID)
+	}
+}
+
+// TestBusDropsForSlowSubscribers tests that publishing doesn't block on a
+// subscriber that has stopped reading
+func TestBusDropsForSlowSubscribers(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe(0, nil)
+
+	for i := 0; i < subscriberBuffer+10; i++ {
+		bus.Publish(Event{Type: RequestReceived, Imposter: 4545})
+	}
+	if sub.Dropped() != 10 {
+		t.Errorf("Dropped() = %d, want 10", sub.Dropped())
+	}
+
+	bus.CloseAll()
+	bus.Publish(Event{Type: RequestReceived, Imposter: 4545})
+	count := 0
+	for range sub.C {
+		count++
+	}
+	if count != subscriberBuffer {
+		t.Errorf("received %d buffered messages after close, want %d", count, subscriberBuffer)
+	}
+	sub.Close()
+}
@@ -0,0 +1,210 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// DefaultPort is the imposter port used when neither the file nor the
+// options say which port to use
+const DefaultPort = 4545
+
+// requestShape is the concrete request a stub describes, recovered from its
+// equals-style predicates so it can be written to formats that store
+// example requests rather than match rules
+type requestShape struct {
+	Method  string
+	Path    string
+	Query   map[string]string
+	Headers map[string]string
+	Body    string
+}
+
+// shapeOf collects the method, path, query, headers and body a stub matches
+// on. Only equals and deepEquals predicates (including inside "and") are
+// considered; anything else cannot be expressed as an example request.
+func shapeOf(stub models.Stub) requestShape {
+	shape := requestShape{Query: map[string]string{}, Headers: map[string]string{}}
+
+	var visit func(preds []models.Predicate)
+	visit = func(preds []models.Predicate) {
+		for _, pred := range preds {
+			visit(pred.And)
+			for _, op := range []interface{}{pred.Equals, pred.DeepEquals} {
+				fields, ok := op.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if v, ok := fields["method"].(string); ok {
+					shape.Method = strings.ToUpper(v)
+				}
+				if v, ok := fields["path"].(string); ok {
+					shape.Path = v
+				}
+				if v, ok := fields["body"]; ok {
+					shape.Body = bodyString(v)
+				}
+				copyStringMap(shape.Query, fields["query"])
+				copyStringMap(shape.Headers, fields["headers"])
+			}
+		}
+	}
+	visit(stub.Predicates)
+
+	if shape.Method == "" {
+		shape.Method = "GET"
+	}
+	if shape.Path == "" {
+		shape.Path = "/"
+	}
+	return shape
+}
+
+// copyStringMap copies the string-valued entries of a predicate field map
+func copyStringMap(dst map[string]string, src interface{}) {
+	m, ok := src.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			dst[k] = s
+		}
+	}
+}
+
+// isResponses returns the stub's is responses, skipping proxies, injections
+// and faults, which have no static equivalent
+func isResponses(stub models.Stub) []*models.IsResponse {
+	var result []*models.IsResponse
+	for _, resp := range stub.Responses {
+		if resp.Is != nil {
+			result = append(result, resp.Is)
+		}
+	}
+	return result
+}
+
+// statusOf returns a response status code, defaulting to 200
+func statusOf(is *models.IsResponse) int {
+	switch v := is.StatusCode.(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case string:
+		var code int
+		if _, err := fmt.Sscanf(v, "%d", &code); err == nil {
+			return code
+		}
+	}
+	return 200
+}
+
+// bodyString renders a response or predicate body as text
+func bodyString(body interface{}) string {
+	switch v := body.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// headerValues flattens a response header value, which may be a string or a
+// list of strings
+func headerValues(v interface{}) []string {
+	switch h := v.(type) {
+	case string:
+		return []string{h}
+	case []string:
+		return h
+	case []interface{}:
+		values := make([]string, 0, len(h))
+		for _, item := range h {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	default:
+		return []string{fmt.Sprint(h)}
+	}
+}
+
+// sortedKeys returns the keys of a string map in sorted order, so exported
+// files are stable between saves
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// saveableImposters returns the HTTP and HTTPS imposters, optionally without
+// proxy stubs. Other protocols have no representation in the HTTP-centric
+// formats.
+func saveableImposters(options Options, wrapper *ImpostersWrapper) []models.Imposter {
+	var result []models.Imposter
+	for _, imp := range wrapper.Imposters {
+		if imp.Protocol != "http" && imp.Protocol != "https" {
+			continue
+		}
+		if options.RemoveProxies {
+			imp.Stubs = removeProxyStubs(imp.Stubs)
+		}
+		result = append(result, imp)
+	}
+	return result
+}
+
+// portOf returns the port for imposters loaded from formats without one
+func portOf(options Options) int {
+	if options.Port != 0 {
+		return options.Port
+	}
+	return DefaultPort
+}
+
+// writeJSONFile writes v to path as indented JSON
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal imposters: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write save file: %w", err)
+	}
+	return nil
+}
+
+// equalsPredicate builds an equals predicate for a method, path and query,
+// leaving out any that are empty
+func equalsPredicate(method, path string, query map[string]string) models.Predicate {
+	fields := map[string]interface{}{}
+	if method != "" {
+		fields["method"] = method
+	}
+	if path != "" {
+		fields["path"] = path
+	}
+	if len(query) > 0 {
+		q := make(map[string]interface{}, len(query))
+		for k, v := range query {
+			q[k] = v
+		}
+		fields["query"] = q
+	}
+	return models.Predicate{Equals: fields}
+}
@@ -14,7 +14,10 @@ type Options struct {
 	ConfigFile    string
 	SaveFile      string
 	NoParse       bool
+	DataFile      string // JSON or YAML object used as the EJS data variable
 	RemoveProxies bool
+	// Port is used for imposters loaded from formats that don't record one
+	Port int
 	// Additional custom options can be added via map
 	Custom map[string]string
 }
@@ -33,7 +36,8 @@ type ImpostersWrapper struct {
 	Imposters []models.Imposter `json:"imposters"`
 }
 
-// JSONFormatter is the default JSON formatter
+// JSONFormatter is the default formatter for mountebank's JSON format, and
+// its YAML equivalent for .yaml/.yml files
 type JSONFormatter struct{}
 
 // NewJSONFormatter creates a new JSON formatter
@@ -41,12 +45,16 @@ func NewJSONFormatter() *JSONFormatter {
 	return &JSONFormatter{}
 }
 
-// Load reads a JSON/EJS config file
+// Load reads a JSON, YAML or EJS config file
 func (f *JSONFormatter) Load(options Options) (*config.Config, error) {
-	return config.LoadFile(options.ConfigFile, options.NoParse)
+	return config.NewLoader(config.LoadOptions{
+		ConfigFile: options.ConfigFile,
+		NoParse:    options.NoParse,
+		DataFile:   options.DataFile,
+	}).Load()
 }
 
-// Save writes imposters to a JSON file
+// Save writes imposters to a JSON or YAML file
 func (f *JSONFormatter) Save(options Options, imposters *ImpostersWrapper) error {
 	// Optionally remove proxy responses
 	if options.RemoveProxies {
@@ -55,8 +63,14 @@ func (f *JSONFormatter) Save(options Options, imposters *ImpostersWrapper) error
 		}
 	}
 
-	// Marshal to pretty JSON
-	data, err := json.MarshalIndent(imposters, "", "  ")
+	// Marshal to YAML for .yaml/.yml files, otherwise pretty JSON
+	var data []byte
+	var err error
+	if config.IsYAMLFile(options.SaveFile) {
+		data, err = config.EncodeYAML(imposters)
+	} else {
+		data, err = json.MarshalIndent(imposters, "", "  ")
+	}
 	if err != nil {
 		return fmt.Errorf("failed to marshal imposters: %w", err)
 	}
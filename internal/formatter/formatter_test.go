@@ -0,0 +1,311 @@
+package formatter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/imposter"
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+func writeFile(t *testing.T, path, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// matchStatus returns the status code of the stub a request matches, or 0
+func matchStatus(imp *models.Imposter, req *models.Request) int {
+	result := imposter.NewMatcher(imp).Match(req)
+	if result.StubIndex < 0 || result.Response == nil {
+		return 0
+	}
+	return statusOf(result.Response)
+}
+
+// TestLookup tests resolving --formatter values
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected interface{}
+		contains string
+	}{
+		{"", &JSONFormatter{}, ""},
+		{"json", &JSONFormatter{}, ""},
+		{"WireMock", &WireMockFormatter{}, ""},
+		{"har", &HARFormatter{}, ""},
+		{"postman", &PostmanFormatter{}, ""},
+		{"swagger", nil, "unknown formatter"},
+		{"missing.so", nil, "failed to open formatter plugin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Lookup(tt.name)
+			if tt.contains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.contains) {
+					t.Errorf("expected error containing %q, got %v", tt.contains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if reflect.TypeOf(f) != reflect.TypeOf(tt.expected) {
+				t.Errorf("expected %T, got %T", tt.expected, f)
+			}
+		})
+	}
+}
+
+// TestWireMockLoad tests converting a mappings directory into stubs
+func TestWireMockLoad(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "__files", "pets.json"), `[{"id": 1}]`)
+	writeFile(t, filepath.Join(root, "mappings", "a-pets.json"), `{
+		"request": {"method": "GET", "urlPath": "/pets", "queryParameters": {"limit": {"matches": "[0-9]+"}}},
+		"response": {"status": 200, "bodyFileName": "pets.json", "fixedDelayMilliseconds": 25}
+	}`)
+	writeFile(t, filepath.Join(root, "mappings", "b-more.json"), `{"mappings": [
+		{
+			"priority": 1,
+			"request": {"method": "POST", "urlPathTemplate": "/pets/{id}/tags",
+				"headers": {"Content-Type": {"contains": "json"}},
+				"bodyPatterns": [{"equalToJson": "{\"tag\": \"good\"}"}]},
+			"response": {"status": 201, "jsonBody": {"ok": true}}
+		},
+		{
+			"request": {"method": "ANY", "url": "/health?verbose=true"},
+			"response": {"fault": "CONNECTION_RESET_BY_PEER"}
+		}
+	]}`)
+
+	cfg, err := NewWireMockFormatter().Load(Options{ConfigFile: root, Port: 5555})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Imposters) != 1 || cfg.Imposters[0].Port != 5555 {
+		t.Fatalf("expected one imposter on port 5555, got %+v", cfg.Imposters)
+	}
+	imp := &cfg.Imposters[0]
+	if len(imp.Stubs) != 3 {
+		t.Fatalf("expected 3 stubs, got %d", len(imp.Stubs))
+	}
+
+	tagged := &models.Request{
+		Method:  "POST",
+		Path:    "/pets/7/tags",
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Body:    `{"tag":"good"}`,
+	}
+	if status := matchStatus(imp, tagged); status != 201 {
+		t.Errorf("expected priority 1 mapping to match first, got %d", status)
+	}
+
+	pets := imp.Stubs[1].Responses[0]
+	if pets.Is.Body != `[{"id": 1}]` || len(pets.Behaviors) != 1 || pets.Behaviors[0].Wait != 25 {
+		t.Errorf("expected body file and delay, got %+v", pets)
+	}
+	if status := matchStatus(imp, &models.Request{Method: "GET", Path: "/pets", Query: map[string]string{"limit": "10x"}}); status != 0 {
+		t.Errorf("expected WireMock regex to match the whole value, got %d", status)
+	}
+
+	if fault := imp.Stubs[2].Responses[0].Fault; fault != models.FaultConnectionResetByPeer {
+		t.Errorf("expected fault, got %q", fault)
+	}
+}
+
+// TestWireMockLoadErrors tests mappings that cannot be converted
+func TestWireMockLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		mapping  string
+		contains string
+	}{
+		{"matcher", `{"request": {"headers": {"Accept": {"hasExactly": []}}}, "response": {}}`, "unsupported WireMock matcher for headers Accept"},
+		{"body file", `{"request": {}, "response": {"bodyFileName": "nope.json"}}`, "bodyFileName nope.json not found"},
+		{"fault", `{"request": {}, "response": {"fault": "SLOW"}}`, "unsupported WireMock fault"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, filepath.Join(dir, tt.name+".json"), tt.mapping)
+			_, err := NewWireMockFormatter().Load(Options{ConfigFile: path})
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("expected error containing %q, got %v", tt.contains, err)
+			}
+		})
+	}
+}
+
+// sampleImposters is the config the round trip tests save and reload
+func sampleImposters() *ImpostersWrapper {
+	return &ImpostersWrapper{Imposters: []models.Imposter{
+		{
+			Port:     4546,
+			Protocol: "http",
+			Name:     "orders",
+			Stubs: []models.Stub{
+				{
+					Predicates: []models.Predicate{{Equals: map[string]interface{}{
+						"method": "POST",
+						"path":   "/orders",
+						"body":   `{"sku":"a1"}`,
+					}}},
+					Responses: []models.Response{
+						{Is: &models.IsResponse{StatusCode: 201, Headers: map[string]interface{}{"Location": "/orders/1"}}},
+						{Is: &models.IsResponse{StatusCode: 409, Body: map[string]interface{}{"error": "duplicate"}}},
+					},
+				},
+				{
+					Predicates: []models.Predicate{{Equals: map[string]interface{}{
+						"path":  "/orders",
+						"query": map[string]interface{}{"page": "2"},
+					}}},
+					Responses: []models.Response{{Is: &models.IsResponse{StatusCode: 200, Body: "[]"}}},
+				},
+				{
+					Responses: []models.Response{{Proxy: &models.ProxyResponse{To: "http://upstream"}}},
+				},
+			},
+		},
+		{Port: 4547, Protocol: "tcp", Stubs: []models.Stub{{Responses: []models.Response{{Is: &models.IsResponse{Data: "x"}}}}}},
+	}}
+}
+
+// TestRoundTrip tests that saved files load back into equivalent imposters
+func TestRoundTrip(t *testing.T) {
+	formatters := map[string]Formatter{
+		"wiremock": NewWireMockFormatter(),
+		"har":      NewHARFormatter(),
+		"postman":  NewPostmanFormatter(),
+	}
+
+	for name, f := range formatters {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "saved.json")
+			if err := f.Save(Options{SaveFile: path, RemoveProxies: true}, sampleImposters()); err != nil {
+				t.Fatalf("save failed: %v", err)
+			}
+
+			var generic interface{}
+			data, _ := os.ReadFile(path)
+			if err := json.Unmarshal(data, &generic); err != nil {
+				t.Fatalf("saved file is not JSON: %v", err)
+			}
+
+			cfg, err := f.Load(Options{ConfigFile: path})
+			if err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+			if len(cfg.Imposters) != 1 || cfg.Imposters[0].Port != 4546 {
+				t.Fatalf("expected only the http imposter on 4546, got %+v", cfg.Imposters)
+			}
+			imp := &cfg.Imposters[0]
+
+			order := &models.Request{Method: "POST", Path: "/orders", Body: `{"sku":"a1"}`}
+			if status := matchStatus(imp, order); status != 201 {
+				t.Errorf("expected first order to get 201, got %d", status)
+			}
+			switch name {
+			case "har":
+				if status := matchStatus(imp, order); status != 409 {
+					t.Errorf("expected repeated order to cycle to 409, got %d", status)
+				}
+			case "wiremock":
+				// Cycling becomes a scenario chain, which the server advances
+				states := [][2]string{}
+				for _, stub := range imp.Stubs[:2] {
+					states = append(states, [2]string{stub.RequiredScenarioState, stub.NewScenarioState})
+				}
+				expected := [][2]string{{"Started", "response 2"}, {"response 2", "Started"}}
+				if !reflect.DeepEqual(states, expected) {
+					t.Errorf("expected a looping scenario chain, got %v", states)
+				}
+			}
+			list := &models.Request{Method: "GET", Path: "/orders", Query: map[string]string{"page": "2"}}
+			if status := matchStatus(imp, list); status != 200 {
+				t.Errorf("expected list to get 200, got %d", status)
+			}
+		})
+	}
+}
+
+// TestHARLoad tests grouping captured exchanges into cycling stubs
+func TestHARLoad(t *testing.T) {
+	path := writeFile(t, filepath.Join(t.TempDir(), "capture.har"), `{"log": {"version": "1.2", "entries": [
+		{"request": {"method": "GET", "url": "https://api.example.com/status?x=1", "queryString": [{"name": "x", "value": "1"}]},
+		 "response": {"status": 200, "headers": [{"name": "Content-Encoding", "value": "gzip"}, {"name": "Set-Cookie", "value": "a=1"}, {"name": "Set-Cookie", "value": "b=2"}],
+		              "content": {"mimeType": "application/json", "text": "{\"up\":true}"}}},
+		{"request": {"method": "GET", "url": "https://api.example.com/status?x=1", "queryString": [{"name": "x", "value": "1"}]},
+		 "response": {"status": 503, "content": {"text": "aGk=", "encoding": "base64"}}},
+		{"request": {"method": "GET", "url": "https://api.example.com/blocked"}, "response": {"status": 0}}
+	]}}`)
+
+	cfg, err := NewHARFormatter().Load(Options{ConfigFile: path, Port: 6000})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Imposters) != 1 || cfg.Imposters[0].Port != 6000 || len(cfg.Imposters[0].Stubs) != 1 {
+		t.Fatalf("expected one stub on port 6000, got %+v", cfg.Imposters)
+	}
+
+	responses := cfg.Imposters[0].Stubs[0].Responses
+	if len(responses) != 2 {
+		t.Fatalf("expected both captured responses, got %d", len(responses))
+	}
+	first := responses[0].Is
+	if _, ok := first.Headers["Content-Encoding"]; ok {
+		t.Errorf("expected transfer headers to be dropped, got %v", first.Headers)
+	}
+	if !reflect.DeepEqual(first.Headers["Set-Cookie"], []string{"a=1", "b=2"}) {
+		t.Errorf("expected repeated headers as a list, got %v", first.Headers["Set-Cookie"])
+	}
+	if second := responses[1].Is; second.Mode != "binary" || second.Body != "aGk=" {
+		t.Errorf("expected base64 content as binary, got %+v", second)
+	}
+}
+
+// TestPostmanLoad tests converting collection examples into stubs
+func TestPostmanLoad(t *testing.T) {
+	path := writeFile(t, filepath.Join(t.TempDir(), "pets.postman_collection.json"), `{
+		"info": {"name": "Pets", "schema": "`+postmanSchema+`"},
+		"item": [{"name": "pets", "item": [
+			{"name": "get pet", "request": {"method": "GET", "url": {"raw": "{{baseUrl}}/pets/:id", "path": ["pets", ":id"]}},
+			 "response": [
+				{"name": "missing", "originalRequest": {"method": "GET", "url": "{{baseUrl}}/pets/999"}, "code": 404},
+				{"name": "found", "code": 200, "header": [{"key": "Content-Type", "value": "application/json"}], "body": "{\"id\": 1}"}
+			 ]},
+			{"name": "no examples", "request": "{{baseUrl}}/ping"}
+		]}]
+	}`)
+
+	cfg, err := NewPostmanFormatter().Load(Options{ConfigFile: path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Imposters) != 1 || cfg.Imposters[0].Port != DefaultPort || cfg.Imposters[0].Name != "Pets" {
+		t.Fatalf("unexpected imposters: %+v", cfg.Imposters)
+	}
+	imp := &cfg.Imposters[0]
+	if len(imp.Stubs) != 2 {
+		t.Fatalf("expected a stub per example, got %d", len(imp.Stubs))
+	}
+
+	if status := matchStatus(imp, &models.Request{Method: "GET", Path: "/pets/999"}); status != 404 {
+		t.Errorf("expected the missing example for its original request, got %d", status)
+	}
+	if status := matchStatus(imp, &models.Request{Method: "GET", Path: "/pets/3"}); status != 200 {
+		t.Errorf("expected path variables to match any segment, got %d", status)
+	}
+}
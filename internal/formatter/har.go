@@ -0,0 +1,330 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/config"
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+	"github.com/TetsujinOni/go-tartuffe/pkg/version"
+)
+
+// HARFormatter reads and writes HTTP Archive (HAR 1.2) files. Loading turns
+// each captured exchange into a stub; repeated requests become one stub
+// that cycles through the captured responses in order. Saved entries carry
+// their imposter port in a custom _imposterPort field.
+type HARFormatter struct{}
+
+// NewHARFormatter creates a new HAR formatter
+func NewHARFormatter() *HARFormatter {
+	return &HARFormatter{}
+}
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string                 `json:"startedDateTime"`
+	Time            float64                `json:"time"`
+	Request         harRequest             `json:"request"`
+	Response        harResponse            `json:"response"`
+	Cache           map[string]interface{} `json:"cache"`
+	Timings         harTimings             `json:"timings"`
+	ImposterPort    int                    `json:"_imposterPort,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harSkippedHeaders describe the captured transfer rather than the response,
+// and would be wrong once the decoded content is served again
+var harSkippedHeaders = map[string]bool{
+	"content-length":    true,
+	"content-encoding":  true,
+	"transfer-encoding": true,
+	"connection":        true,
+	"keep-alive":        true,
+}
+
+// Load reads a HAR file and converts its entries into stubs
+func (f *HARFormatter) Load(options Options) (*config.Config, error) {
+	data, err := os.ReadFile(options.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("failed to parse HAR file: %w", err)
+	}
+
+	type group struct {
+		port int
+		stub models.Stub
+	}
+	var groups []*group
+	byKey := map[string]*group{}
+
+	for i, entry := range har.Log.Entries {
+		// Status 0 marks requests the browser aborted or blocked
+		if entry.Response.Status == 0 {
+			continue
+		}
+
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: invalid url %q: %w", i, entry.Request.URL, err)
+		}
+		port := portOf(options)
+		if entry.ImposterPort != 0 {
+			port = entry.ImposterPort
+		}
+
+		query := map[string]string{}
+		for _, q := range entry.Request.QueryString {
+			query[q.Name] = q.Value
+		}
+		body := ""
+		if entry.Request.PostData != nil {
+			body = entry.Request.PostData.Text
+		}
+
+		path := u.Path
+		if path == "" {
+			path = "/"
+		}
+		key := fmt.Sprintf("%d %s %s?%s\n%s", port, entry.Request.Method, path, encodeQuery(query), body)
+		g, ok := byKey[key]
+		if !ok {
+			preds := []models.Predicate{equalsPredicate(entry.Request.Method, path, query)}
+			if body != "" {
+				preds = append(preds, models.Predicate{Equals: map[string]interface{}{"body": body}, CaseSensitive: true})
+			}
+			g = &group{port: port, stub: models.Stub{Predicates: preds}}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.stub.Responses = append(g.stub.Responses, models.Response{Is: entry.Response.isResponse()})
+	}
+
+	byPort := map[int]*models.Imposter{}
+	var ports []int
+	for _, g := range groups {
+		imp, ok := byPort[g.port]
+		if !ok {
+			imp = &models.Imposter{Port: g.port, Protocol: "http"}
+			byPort[g.port] = imp
+			ports = append(ports, g.port)
+		}
+		imp.Stubs = append(imp.Stubs, g.stub)
+	}
+
+	sort.Ints(ports)
+	cfg := &config.Config{}
+	for _, port := range ports {
+		cfg.Imposters = append(cfg.Imposters, *byPort[port])
+	}
+	return cfg, nil
+}
+
+// encodeQuery renders a query map in a stable order for grouping
+func encodeQuery(query map[string]string) string {
+	values := url.Values{}
+	for k, v := range query {
+		values.Set(k, v)
+	}
+	return values.Encode()
+}
+
+// isResponse converts a captured response into an is response
+func (r harResponse) isResponse() *models.IsResponse {
+	is := &models.IsResponse{StatusCode: r.Status}
+
+	headers := map[string]interface{}{}
+	for _, h := range r.Headers {
+		if harSkippedHeaders[strings.ToLower(h.Name)] || strings.HasPrefix(h.Name, ":") {
+			continue
+		}
+		switch existing := headers[h.Name].(type) {
+		case nil:
+			headers[h.Name] = h.Value
+		case string:
+			headers[h.Name] = []string{existing, h.Value}
+		case []string:
+			headers[h.Name] = append(existing, h.Value)
+		}
+	}
+	if len(headers) > 0 {
+		is.Headers = headers
+	}
+
+	if r.Content.Text != "" {
+		is.Body = r.Content.Text
+		if r.Content.Encoding == "base64" {
+			is.Mode = "binary"
+		}
+	}
+	return is
+}
+
+// Save writes the is responses of HTTP imposters as HAR entries
+func (f *HARFormatter) Save(options Options, imposters *ImpostersWrapper) error {
+	har := harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "tartuffe", Version: version.Version},
+		Entries: []harEntry{},
+	}}
+	started := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+
+	for _, imp := range saveableImposters(options, imposters) {
+		host := imp.Host
+		if host == "" {
+			host = "localhost"
+		}
+		base := fmt.Sprintf("%s://%s:%d", imp.Protocol, host, imp.Port)
+
+		for _, stub := range imp.Stubs {
+			shape := shapeOf(stub)
+			request := harRequestOf(base, shape)
+			for _, is := range isResponses(stub) {
+				har.Log.Entries = append(har.Log.Entries, harEntry{
+					StartedDateTime: started,
+					Request:         request,
+					Response:        harResponseOf(is),
+					Cache:           map[string]interface{}{},
+					ImposterPort:    imp.Port,
+				})
+			}
+		}
+	}
+	return writeJSONFile(options.SaveFile, har)
+}
+
+// harRequestOf builds an example request for a stub
+func harRequestOf(base string, shape requestShape) harRequest {
+	req := harRequest{
+		Method:      shape.Method,
+		URL:         base + shape.Path,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     []harNameValue{},
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    len(shape.Body),
+	}
+	if len(shape.Query) > 0 {
+		req.URL += "?" + encodeQuery(shape.Query)
+		for _, k := range sortedKeys(shape.Query) {
+			req.QueryString = append(req.QueryString, harNameValue{Name: k, Value: shape.Query[k]})
+		}
+	}
+	for _, k := range sortedKeys(shape.Headers) {
+		req.Headers = append(req.Headers, harNameValue{Name: k, Value: shape.Headers[k]})
+	}
+	if shape.Body != "" {
+		mimeType := shape.Headers["Content-Type"]
+		if mimeType == "" {
+			mimeType = "text/plain"
+		}
+		req.PostData = &harPostData{MimeType: mimeType, Text: shape.Body}
+	}
+	return req
+}
+
+// harResponseOf converts an is response into a captured response
+func harResponseOf(is *models.IsResponse) harResponse {
+	status := statusOf(is)
+	resp := harResponse{
+		Status:      status,
+		StatusText:  http.StatusText(status),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     []harNameValue{},
+		HeadersSize: -1,
+	}
+
+	mimeType := ""
+	for _, name := range sortedKeys(is.Headers) {
+		for _, value := range headerValues(is.Headers[name]) {
+			resp.Headers = append(resp.Headers, harNameValue{Name: name, Value: value})
+			if strings.EqualFold(name, "Content-Type") {
+				mimeType = value
+			}
+		}
+	}
+
+	body := bodyString(is.Body)
+	if mimeType == "" {
+		if _, isString := is.Body.(string); !isString && is.Body != nil {
+			mimeType = "application/json"
+		}
+	}
+	resp.Content = harContent{Size: len(body), MimeType: mimeType, Text: body}
+	if is.Mode == "binary" {
+		resp.Content.Encoding = "base64"
+	}
+	resp.BodySize = len(body)
+	return resp
+}
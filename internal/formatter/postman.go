@@ -0,0 +1,361 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/config"
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// postmanSchema identifies a Postman v2.1 collection
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// PostmanFormatter reads and writes Postman v2.1 collections. Loading turns
+// each saved example response into a stub matched on its original request;
+// requests without examples are skipped. Saving writes one folder per
+// imposter, with the port kept in an imposterPort folder variable.
+type PostmanFormatter struct{}
+
+// NewPostmanFormatter creates a new Postman formatter
+func NewPostmanFormatter() *PostmanFormatter {
+	return &PostmanFormatter{}
+}
+
+type postmanCollection struct {
+	Info postmanInfo   `json:"info"`
+	Item []postmanItem `json:"item"`
+}
+
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+type postmanItem struct {
+	Name     string            `json:"name"`
+	Item     []postmanItem     `json:"item,omitempty"`
+	Request  *postmanRequest   `json:"request,omitempty"`
+	Response []postmanResponse `json:"response,omitempty"`
+	Variable []postmanKeyValue `json:"variable,omitempty"`
+}
+
+type postmanRequest struct {
+	Method string            `json:"method"`
+	Header []postmanKeyValue `json:"header"`
+	Body   *postmanBody      `json:"body,omitempty"`
+	URL    postmanURL        `json:"url"`
+}
+
+type postmanBody struct {
+	Mode string `json:"mode"`
+	Raw  string `json:"raw,omitempty"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Protocol string            `json:"protocol,omitempty"`
+	Host     []string          `json:"host,omitempty"`
+	Port     string            `json:"port,omitempty"`
+	Path     []string          `json:"path,omitempty"`
+	Query    []postmanKeyValue `json:"query,omitempty"`
+}
+
+type postmanKeyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+type postmanResponse struct {
+	Name            string            `json:"name"`
+	OriginalRequest *postmanRequest   `json:"originalRequest,omitempty"`
+	Status          string            `json:"status,omitempty"`
+	Code            int               `json:"code,omitempty"`
+	Header          []postmanKeyValue `json:"header"`
+	Body            string            `json:"body,omitempty"`
+}
+
+// UnmarshalJSON accepts the string shorthand Postman allows for a request
+func (r *postmanRequest) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*r = postmanRequest{Method: "GET", URL: postmanURL{Raw: raw}}
+		return nil
+	}
+	type requestAlias postmanRequest
+	var alias requestAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*r = postmanRequest(alias)
+	return nil
+}
+
+// UnmarshalJSON accepts the string shorthand Postman allows for a URL
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*u = postmanURL{Raw: raw}
+		return nil
+	}
+	type urlAlias postmanURL
+	var alias urlAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*u = postmanURL(alias)
+	return nil
+}
+
+// postmanVariable matches {{name}} variable references
+var postmanVariable = regexp.MustCompile(`\{\{[^}]*\}\}`)
+
+// Load reads a Postman collection and converts its examples into stubs
+func (f *PostmanFormatter) Load(options Options) (*config.Config, error) {
+	data, err := os.ReadFile(options.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var collection postmanCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("failed to parse Postman collection: %w", err)
+	}
+
+	byPort := map[int]*models.Imposter{}
+	var ports []int
+	var visit func(items []postmanItem, port int)
+	visit = func(items []postmanItem, port int) {
+		for _, item := range items {
+			itemPort := port
+			for _, v := range item.Variable {
+				if p, err := strconv.Atoi(v.Value); err == nil && v.Key == "imposterPort" {
+					itemPort = p
+				}
+			}
+			visit(item.Item, itemPort)
+
+			if item.Request == nil || len(item.Response) == 0 {
+				continue
+			}
+			imp, ok := byPort[itemPort]
+			if !ok {
+				imp = &models.Imposter{Port: itemPort, Protocol: "http", Name: collection.Info.Name}
+				byPort[itemPort] = imp
+				ports = append(ports, itemPort)
+			}
+			for _, example := range item.Response {
+				request := item.Request
+				if example.OriginalRequest != nil {
+					request = example.OriginalRequest
+				}
+				imp.Stubs = append(imp.Stubs, models.Stub{
+					Predicates: request.predicates(),
+					Responses:  []models.Response{{Is: example.isResponse()}},
+				})
+			}
+		}
+	}
+	visit(collection.Item, portOf(options))
+
+	sort.Ints(ports)
+	cfg := &config.Config{}
+	for _, port := range ports {
+		cfg.Imposters = append(cfg.Imposters, *byPort[port])
+	}
+	return cfg, nil
+}
+
+// predicates converts a request into predicates. Path segments written as
+// :name or {{name}} match any single segment, and query parameters or bodies
+// that use variables are not matched on.
+func (r *postmanRequest) predicates() []models.Predicate {
+	segments := r.URL.Path
+	if len(segments) == 0 {
+		segments = strings.Split(strings.Trim(rawPath(r.URL.Raw), "/"), "/")
+	}
+
+	templated := false
+	literal := make([]string, 0, len(segments))
+	pattern := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		if strings.HasPrefix(seg, ":") || postmanVariable.MatchString(seg) {
+			templated = true
+			pattern = append(pattern, "[^/]+")
+		} else {
+			pattern = append(pattern, regexp.QuoteMeta(seg))
+		}
+		literal = append(literal, seg)
+	}
+	path := "/" + strings.Join(literal, "/")
+
+	query := map[string]string{}
+	params := r.URL.Query
+	if len(params) == 0 {
+		if _, rawQuery, ok := strings.Cut(r.URL.Raw, "?"); ok {
+			values, _ := url.ParseQuery(rawQuery)
+			for _, k := range sortedKeys(values) {
+				params = append(params, postmanKeyValue{Key: k, Value: values.Get(k)})
+			}
+		}
+	}
+	for _, q := range params {
+		if !q.Disabled && !postmanVariable.MatchString(q.Value) {
+			query[q.Key] = q.Value
+		}
+	}
+
+	method := strings.ToUpper(r.Method)
+	if method == "" {
+		method = "GET"
+	}
+
+	var preds []models.Predicate
+	if templated {
+		preds = append(preds,
+			equalsPredicate(method, "", query),
+			models.Predicate{Matches: map[string]interface{}{"path": "^/" + strings.Join(pattern, "/") + "$"}},
+		)
+	} else {
+		preds = append(preds, equalsPredicate(method, path, query))
+	}
+
+	if r.Body != nil && r.Body.Mode == "raw" && r.Body.Raw != "" && !postmanVariable.MatchString(r.Body.Raw) {
+		preds = append(preds, models.Predicate{Equals: map[string]interface{}{"body": r.Body.Raw}, CaseSensitive: true})
+	}
+	return preds
+}
+
+// rawPath strips the scheme, host and query from a raw Postman URL, which
+// may start with a {{baseUrl}} style variable instead of a host
+func rawPath(raw string) string {
+	raw, _, _ = strings.Cut(raw, "?")
+	if strings.HasPrefix(raw, "{{") {
+		if end := strings.Index(raw, "}}"); end >= 0 {
+			return raw[end+2:]
+		}
+	}
+	if _, rest, ok := strings.Cut(raw, "://"); ok {
+		raw = rest
+	}
+	if i := strings.Index(raw, "/"); i >= 0 {
+		return raw[i:]
+	}
+	return "/"
+}
+
+// isResponse converts a saved example into an is response
+func (r postmanResponse) isResponse() *models.IsResponse {
+	code := r.Code
+	if code == 0 {
+		code = 200
+	}
+	is := &models.IsResponse{StatusCode: code}
+	if len(r.Header) > 0 {
+		is.Headers = map[string]interface{}{}
+		for _, h := range r.Header {
+			if !h.Disabled && !harSkippedHeaders[strings.ToLower(h.Key)] {
+				is.Headers[h.Key] = h.Value
+			}
+		}
+	}
+	if r.Body != "" {
+		is.Body = r.Body
+	}
+	return is
+}
+
+// Save writes the HTTP imposters as a Postman collection
+func (f *PostmanFormatter) Save(options Options, imposters *ImpostersWrapper) error {
+	collection := postmanCollection{
+		Info: postmanInfo{Name: "tartuffe imposters", Schema: postmanSchema},
+		Item: []postmanItem{},
+	}
+
+	for _, imp := range saveableImposters(options, imposters) {
+		folder := postmanItem{
+			Name:     imp.Name,
+			Item:     []postmanItem{},
+			Variable: []postmanKeyValue{{Key: "imposterPort", Value: strconv.Itoa(imp.Port)}},
+		}
+		if folder.Name == "" {
+			folder.Name = fmt.Sprintf("imposter %d", imp.Port)
+		}
+
+		host := imp.Host
+		if host == "" {
+			host = "localhost"
+		}
+		for _, stub := range imp.Stubs {
+			responses := isResponses(stub)
+			if len(responses) == 0 {
+				continue
+			}
+			shape := shapeOf(stub)
+			request := postmanRequestOf(imp.Protocol, host, imp.Port, shape)
+
+			item := postmanItem{Name: shape.Method + " " + shape.Path, Request: request}
+			for _, is := range responses {
+				item.Response = append(item.Response, postmanResponseOf(request, is))
+			}
+			folder.Item = append(folder.Item, item)
+		}
+		collection.Item = append(collection.Item, folder)
+	}
+	return writeJSONFile(options.SaveFile, collection)
+}
+
+// postmanRequestOf builds the example request for a stub
+func postmanRequestOf(protocol, host string, port int, shape requestShape) *postmanRequest {
+	req := &postmanRequest{
+		Method: shape.Method,
+		Header: []postmanKeyValue{},
+		URL: postmanURL{
+			Raw:      fmt.Sprintf("%s://%s:%d%s", protocol, host, port, shape.Path),
+			Protocol: protocol,
+			Host:     strings.Split(host, "."),
+			Port:     strconv.Itoa(port),
+			Path:     strings.Split(strings.Trim(shape.Path, "/"), "/"),
+		},
+	}
+	if len(shape.Query) > 0 {
+		req.URL.Raw += "?" + encodeQuery(shape.Query)
+		for _, k := range sortedKeys(shape.Query) {
+			req.URL.Query = append(req.URL.Query, postmanKeyValue{Key: k, Value: shape.Query[k]})
+		}
+	}
+	for _, k := range sortedKeys(shape.Headers) {
+		req.Header = append(req.Header, postmanKeyValue{Key: k, Value: shape.Headers[k]})
+	}
+	if shape.Body != "" {
+		req.Body = &postmanBody{Mode: "raw", Raw: shape.Body}
+	}
+	return req
+}
+
+// postmanResponseOf converts an is response into a saved example
+func postmanResponseOf(request *postmanRequest, is *models.IsResponse) postmanResponse {
+	code := statusOf(is)
+	resp := postmanResponse{
+		Name:            fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		OriginalRequest: request,
+		Status:          http.StatusText(code),
+		Code:            code,
+		Header:          []postmanKeyValue{},
+		Body:            bodyString(is.Body),
+	}
+	for _, name := range sortedKeys(is.Headers) {
+		for _, value := range headerValues(is.Headers[name]) {
+			resp.Header = append(resp.Header, postmanKeyValue{Key: name, Value: value})
+		}
+	}
+	return resp
+}
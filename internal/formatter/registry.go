@@ -0,0 +1,84 @@
+package formatter
+
+import (
+	"fmt"
+	goplugin "plugin"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Formatter{
+		"json":     NewJSONFormatter(),
+		"wiremock": NewWireMockFormatter(),
+		"har":      NewHARFormatter(),
+		"postman":  NewPostmanFormatter(),
+	}
+)
+
+// Register makes a formatter available under name for --formatter
+func Register(name string, f Formatter) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(name)] = f
+}
+
+// Names returns the registered formatter names in sorted order
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup resolves a --formatter value. An empty value selects the JSON
+// formatter, a registered name selects that formatter, and a path ending in
+// .so is opened as a Go plugin exporting a "Formatter" symbol.
+func Lookup(name string) (Formatter, error) {
+	if name == "" {
+		return DefaultFormatter(), nil
+	}
+
+	registryMu.RLock()
+	f, ok := registry[strings.ToLower(name)]
+	registryMu.RUnlock()
+	if ok {
+		return f, nil
+	}
+
+	if strings.HasSuffix(name, ".so") {
+		return loadPlugin(name)
+	}
+
+	return nil, fmt.Errorf("unknown formatter %q (available: %s)", name, strings.Join(Names(), ", "))
+}
+
+// loadPlugin opens a Go plugin and returns its exported Formatter
+func loadPlugin(path string) (Formatter, error) {
+	p, err := goplugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open formatter plugin: %w", err)
+	}
+
+	sym, err := p.Lookup("Formatter")
+	if err != nil {
+		return nil, fmt.Errorf("formatter plugin does not export a Formatter symbol")
+	}
+
+	// Exported variables are looked up as pointers, so accept either a
+	// Formatter implementation or a pointer to a Formatter interface value
+	switch f := sym.(type) {
+	case *Formatter:
+		return *f, nil
+	case Formatter:
+		return f, nil
+	}
+	return nil, fmt.Errorf("formatter plugin symbol is not a Formatter")
+}
@@ -0,0 +1,589 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/config"
+	"github.com/TetsujinOni/go-tartuffe/internal/imposter"
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// wireMockDefaultPriority is the priority WireMock gives mappings without one
+const wireMockDefaultPriority = 5
+
+// WireMockFormatter reads and writes WireMock stub mappings. A config file
+// may be a single mapping, a {"mappings": [...]} file, or a directory of
+// mapping files; bodyFileName is resolved against a sibling __files
+// directory. Saved mappings keep their imposter port in metadata.
+type WireMockFormatter struct{}
+
+// NewWireMockFormatter creates a new WireMock formatter
+func NewWireMockFormatter() *WireMockFormatter {
+	return &WireMockFormatter{}
+}
+
+type wireMockFile struct {
+	Mappings []wireMockMapping `json:"mappings"`
+}
+
+type wireMockMapping struct {
+	Name                  string                 `json:"name,omitempty"`
+	Priority              int                    `json:"priority,omitempty"`
+	Request               wireMockRequest        `json:"request"`
+	Response              wireMockResponse       `json:"response"`
+	ScenarioName          string                 `json:"scenarioName,omitempty"`
+	RequiredScenarioState string                 `json:"requiredScenarioState,omitempty"`
+	NewScenarioState      string                 `json:"newScenarioState,omitempty"`
+	Metadata              map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type wireMockRequest struct {
+	Method          string                            `json:"method,omitempty"`
+	URL             string                            `json:"url,omitempty"`
+	URLPath         string                            `json:"urlPath,omitempty"`
+	URLPattern      string                            `json:"urlPattern,omitempty"`
+	URLPathPattern  string                            `json:"urlPathPattern,omitempty"`
+	URLPathTemplate string                            `json:"urlPathTemplate,omitempty"`
+	Headers         map[string]map[string]interface{} `json:"headers,omitempty"`
+	QueryParameters map[string]map[string]interface{} `json:"queryParameters,omitempty"`
+	BodyPatterns    []map[string]interface{}          `json:"bodyPatterns,omitempty"`
+}
+
+type wireMockResponse struct {
+	Status                 int                    `json:"status,omitempty"`
+	Headers                map[string]interface{} `json:"headers,omitempty"`
+	Body                   string                 `json:"body,omitempty"`
+	JSONBody               interface{}            `json:"jsonBody,omitempty"`
+	Base64Body             string                 `json:"base64Body,omitempty"`
+	BodyFileName           string                 `json:"bodyFileName,omitempty"`
+	FixedDelayMilliseconds int                    `json:"fixedDelayMilliseconds,omitempty"`
+	ProxyBaseURL           string                 `json:"proxyBaseUrl,omitempty"`
+	Fault                  string                 `json:"fault,omitempty"`
+}
+
+// wireMockFaults maps WireMock faults onto the closest tartuffe fault
+var wireMockFaults = map[string]string{
+	"CONNECTION_RESET_BY_PEER": models.FaultConnectionResetByPeer,
+	"EMPTY_RESPONSE":           models.FaultConnectionResetByPeer,
+	"RANDOM_DATA_THEN_CLOSE":   models.FaultRandomDataThenClose,
+	"MALFORMED_RESPONSE_CHUNK": models.FaultRandomDataThenClose,
+}
+
+var pathTemplateParam = regexp.MustCompile(`\{[^/}]+\}`)
+
+// Load reads WireMock mappings and groups them into imposters by port
+func (f *WireMockFormatter) Load(options Options) (*config.Config, error) {
+	mappings, filesDirs, err := readWireMockMappings(options.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// WireMock tries the lowest priority number first; tartuffe tries stubs
+	// in order, so a stable sort keeps file order within a priority
+	sort.SliceStable(mappings, func(i, j int) bool {
+		return priorityOf(mappings[i]) < priorityOf(mappings[j])
+	})
+
+	byPort := map[int]*models.Imposter{}
+	var ports []int
+	for i, m := range mappings {
+		stub, err := m.toStub(filesDirs)
+		if err != nil {
+			return nil, fmt.Errorf("mapping %d (%s): %w", i, m.describe(), err)
+		}
+
+		port := portOf(options)
+		if p, ok := m.Metadata["imposterPort"].(float64); ok {
+			port = int(p)
+		}
+		imp, ok := byPort[port]
+		if !ok {
+			imp = &models.Imposter{Port: port, Protocol: "http"}
+			byPort[port] = imp
+			ports = append(ports, port)
+		}
+		imp.Stubs = append(imp.Stubs, stub)
+	}
+
+	sort.Ints(ports)
+	cfg := &config.Config{}
+	for _, port := range ports {
+		cfg.Imposters = append(cfg.Imposters, *byPort[port])
+	}
+	return cfg, nil
+}
+
+// Save writes the HTTP imposters as a single WireMock mappings file
+func (f *WireMockFormatter) Save(options Options, imposters *ImpostersWrapper) error {
+	file := wireMockFile{Mappings: []wireMockMapping{}}
+	for _, imp := range saveableImposters(options, imposters) {
+		for i, stub := range imp.Stubs {
+			file.Mappings = append(file.Mappings, stubToWireMock(imp.Port, i, stub)...)
+		}
+	}
+	return writeJSONFile(options.SaveFile, file)
+}
+
+// readWireMockMappings reads mappings from a file or directory, returning
+// the directories to search for bodyFileName references
+func readWireMockMappings(path string) ([]wireMockMapping, []string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	files := []string{path}
+	dir := filepath.Dir(path)
+	if info.IsDir() {
+		dir = path
+		if sub := filepath.Join(path, "mappings"); isDir(sub) {
+			dir = sub
+		}
+		files, err = filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return nil, nil, err
+		}
+		sort.Strings(files)
+	}
+	filesDirs := []string{filepath.Join(dir, "__files"), filepath.Join(filepath.Dir(dir), "__files")}
+
+	var mappings []wireMockMapping
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		var probe map[string]json.RawMessage
+		if err := json.Unmarshal(data, &probe); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		if _, ok := probe["mappings"]; ok {
+			var wf wireMockFile
+			if err := json.Unmarshal(data, &wf); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse %s: %w", file, err)
+			}
+			mappings = append(mappings, wf.Mappings...)
+			continue
+		}
+
+		var m wireMockMapping
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings, filesDirs, nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func priorityOf(m wireMockMapping) int {
+	if m.Priority == 0 {
+		return wireMockDefaultPriority
+	}
+	return m.Priority
+}
+
+// describe names a mapping in error messages
+func (m wireMockMapping) describe() string {
+	if m.Name != "" {
+		return m.Name
+	}
+	r := m.Request
+	for _, url := range []string{r.URL, r.URLPath, r.URLPathTemplate, r.URLPathPattern, r.URLPattern} {
+		if url != "" {
+			return strings.TrimSpace(r.Method + " " + url)
+		}
+	}
+	return r.Method
+}
+
+// toStub converts a WireMock mapping into a stub
+func (m wireMockMapping) toStub(filesDirs []string) (models.Stub, error) {
+	preds, err := m.Request.predicates()
+	if err != nil {
+		return models.Stub{}, err
+	}
+	resp, err := m.Response.response(filesDirs)
+	if err != nil {
+		return models.Stub{}, err
+	}
+	return models.Stub{
+		Predicates:            preds,
+		Responses:             []models.Response{resp},
+		ScenarioName:          m.ScenarioName,
+		RequiredScenarioState: m.RequiredScenarioState,
+		NewScenarioState:      m.NewScenarioState,
+	}, nil
+}
+
+// predicates converts WireMock request matching into predicates
+func (r wireMockRequest) predicates() ([]models.Predicate, error) {
+	var preds []models.Predicate
+	if r.Method != "" && r.Method != "ANY" {
+		preds = append(preds, models.Predicate{Equals: map[string]interface{}{"method": r.Method}})
+	}
+
+	switch {
+	case r.URL != "":
+		path, rawQuery, _ := strings.Cut(r.URL, "?")
+		query := map[string]string{}
+		for _, pair := range strings.Split(rawQuery, "&") {
+			if k, v, ok := strings.Cut(pair, "="); ok {
+				query[k] = v
+			}
+		}
+		preds = append(preds, equalsPredicate("", path, query))
+	case r.URLPath != "":
+		preds = append(preds, models.Predicate{Equals: map[string]interface{}{"path": r.URLPath}})
+	case r.URLPathTemplate != "":
+		preds = append(preds, models.Predicate{Matches: map[string]interface{}{"path": templatePattern(r.URLPathTemplate)}})
+	case r.URLPathPattern != "":
+		preds = append(preds, models.Predicate{Matches: map[string]interface{}{"path": anchored(r.URLPathPattern)}})
+	case r.URLPattern != "":
+		// tartuffe matches paths without the query string, so this is only
+		// exact for patterns that don't match on the query
+		preds = append(preds, models.Predicate{Matches: map[string]interface{}{"path": anchored(r.URLPattern)}})
+	}
+
+	for _, name := range sortedKeys(r.Headers) {
+		pred, err := wireMockMatcher("headers", name, r.Headers[name])
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, pred)
+	}
+	for _, name := range sortedKeys(r.QueryParameters) {
+		pred, err := wireMockMatcher("query", name, r.QueryParameters[name])
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, pred)
+	}
+	for _, pattern := range r.BodyPatterns {
+		pred, err := wireMockMatcher("body", "", pattern)
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, pred)
+	}
+	return preds, nil
+}
+
+// templatePattern turns a path template such as /pets/{id} into an anchored
+// regular expression with one path segment per parameter
+func templatePattern(template string) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+	last := 0
+	for _, loc := range pathTemplateParam.FindAllStringIndex(template, -1) {
+		sb.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		sb.WriteString("[^/]+")
+		last = loc[1]
+	}
+	sb.WriteString(regexp.QuoteMeta(template[last:]))
+	sb.WriteString("$")
+	return sb.String()
+}
+
+// anchored makes a regular expression match the whole value, as WireMock's
+// Java regexes do
+func anchored(pattern string) string {
+	return "^(?:" + pattern + ")$"
+}
+
+// wireMockMatcher converts one WireMock value matcher into a predicate on a
+// request field, or on one key of it when key is non-empty
+func wireMockMatcher(field, key string, spec map[string]interface{}) (models.Predicate, error) {
+	wrap := func(v interface{}) map[string]interface{} {
+		if key == "" {
+			return map[string]interface{}{field: v}
+		}
+		return map[string]interface{}{field: map[string]interface{}{key: v}}
+	}
+	caseInsensitive, _ := spec["caseInsensitive"].(bool)
+
+	switch {
+	case spec["equalTo"] != nil:
+		return models.Predicate{Equals: wrap(spec["equalTo"]), CaseSensitive: !caseInsensitive}, nil
+	case spec["contains"] != nil:
+		return models.Predicate{Contains: wrap(spec["contains"]), CaseSensitive: true}, nil
+	case spec["matches"] != nil:
+		return models.Predicate{Matches: wrap(anchored(fmt.Sprint(spec["matches"]))), CaseSensitive: true}, nil
+	case spec["doesNotMatch"] != nil:
+		inner := models.Predicate{Matches: wrap(anchored(fmt.Sprint(spec["doesNotMatch"]))), CaseSensitive: true}
+		return models.Predicate{Not: &inner}, nil
+	case spec["absent"] == true:
+		return models.Predicate{Exists: wrap(false)}, nil
+	case spec["equalToXml"] != nil && field == "body":
+		return models.Predicate{Equals: wrap(spec["equalToXml"]), CaseSensitive: true}, nil
+	case spec["equalToJson"] != nil && field == "body":
+		expected := spec["equalToJson"]
+		if s, ok := expected.(string); ok {
+			if err := json.Unmarshal([]byte(s), &expected); err != nil {
+				return models.Predicate{}, fmt.Errorf("equalToJson is not valid JSON: %w", err)
+			}
+		}
+		return models.Predicate{DeepEquals: wrap(expected)}, nil
+	case spec["matchesJsonPath"] != nil && field == "body":
+		switch jp := spec["matchesJsonPath"].(type) {
+		case string:
+			return models.Predicate{Exists: wrap(true), JSONPath: &models.Selector{Selector: jp}}, nil
+		case map[string]interface{}:
+			expr, _ := jp["expression"].(string)
+			if expected, ok := jp["equalTo"]; ok && expr != "" {
+				return models.Predicate{Equals: wrap(expected), JSONPath: &models.Selector{Selector: expr}}, nil
+			}
+		}
+	}
+
+	name := field
+	if key != "" {
+		name += " " + key
+	}
+	return models.Predicate{}, fmt.Errorf("unsupported WireMock matcher for %s: %v", name, spec)
+}
+
+// response converts a WireMock response definition
+func (r wireMockResponse) response(filesDirs []string) (models.Response, error) {
+	if r.Fault != "" {
+		fault, ok := wireMockFaults[r.Fault]
+		if !ok {
+			return models.Response{}, fmt.Errorf("unsupported WireMock fault %s", r.Fault)
+		}
+		return models.Response{Fault: fault}, nil
+	}
+	if r.ProxyBaseURL != "" {
+		return models.Response{Proxy: &models.ProxyResponse{To: r.ProxyBaseURL, Mode: "proxyTransparent"}}, nil
+	}
+
+	status := r.Status
+	if status == 0 {
+		status = 200
+	}
+	is := &models.IsResponse{StatusCode: status}
+	if len(r.Headers) > 0 {
+		is.Headers = r.Headers
+	}
+
+	switch {
+	case r.JSONBody != nil:
+		is.Body = r.JSONBody
+	case r.Base64Body != "":
+		is.Body = r.Base64Body
+		is.Mode = "binary"
+	case r.BodyFileName != "":
+		data, err := readBodyFile(r.BodyFileName, filesDirs)
+		if err != nil {
+			return models.Response{}, err
+		}
+		is.Body = string(data)
+	case r.Body != "":
+		is.Body = r.Body
+	}
+
+	resp := models.Response{Is: is}
+	if r.FixedDelayMilliseconds > 0 {
+		resp.Behaviors = []models.Behavior{{Wait: r.FixedDelayMilliseconds}}
+	}
+	return resp, nil
+}
+
+// readBodyFile finds a bodyFileName in the first __files directory that has it
+func readBodyFile(name string, filesDirs []string) ([]byte, error) {
+	for _, dir := range filesDirs {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("bodyFileName %s not found in __files", name)
+}
+
+// stubToWireMock converts a stub into WireMock mappings. A stub that cycles
+// through several responses becomes a chain of scenario states that loops
+// back to the start, since a WireMock mapping has a single response.
+func stubToWireMock(port, index int, stub models.Stub) []wireMockMapping {
+	request := wireMockRequestOf(stub.Predicates)
+
+	var responses []wireMockResponse
+	for _, resp := range stub.Responses {
+		if wr, ok := wireMockResponseOf(resp); ok {
+			responses = append(responses, wr)
+		}
+	}
+	if len(responses) == 0 {
+		return nil
+	}
+
+	mapping := func(resp wireMockResponse) wireMockMapping {
+		return wireMockMapping{
+			Priority: index + 1,
+			Request:  request,
+			Response: resp,
+			Metadata: map[string]interface{}{"imposterPort": port},
+		}
+	}
+
+	if len(responses) == 1 || stub.ScenarioName != "" {
+		m := mapping(responses[0])
+		m.ScenarioName = stub.ScenarioName
+		m.RequiredScenarioState = stub.RequiredScenarioState
+		m.NewScenarioState = stub.NewScenarioState
+		return []wireMockMapping{m}
+	}
+
+	scenario := fmt.Sprintf("imposter %d stub %d", port, index)
+	state := func(i int) string {
+		if i%len(responses) == 0 {
+			return imposter.ScenarioStarted
+		}
+		return fmt.Sprintf("response %d", i+1)
+	}
+	mappings := make([]wireMockMapping, 0, len(responses))
+	for i, resp := range responses {
+		m := mapping(resp)
+		m.ScenarioName = scenario
+		m.RequiredScenarioState = state(i)
+		m.NewScenarioState = state(i + 1)
+		mappings = append(mappings, m)
+	}
+	return mappings
+}
+
+// wireMockRequestOf converts the predicates WireMock can express into request
+// matching; other predicates are dropped
+func wireMockRequestOf(preds []models.Predicate) wireMockRequest {
+	req := wireMockRequest{Method: "ANY"}
+	keyed := func(target *map[string]map[string]interface{}, key string, spec map[string]interface{}) {
+		if *target == nil {
+			*target = map[string]map[string]interface{}{}
+		}
+		(*target)[key] = spec
+	}
+
+	var visit func(preds []models.Predicate)
+	visit = func(preds []models.Predicate) {
+		for _, pred := range preds {
+			visit(pred.And)
+			if pred.XPath != nil || pred.JSONPath != nil || pred.Except != "" {
+				continue
+			}
+
+			ops := []struct {
+				value interface{}
+				spec  func(v interface{}) map[string]interface{}
+			}{
+				{pred.Equals, func(v interface{}) map[string]interface{} {
+					return map[string]interface{}{"equalTo": v, "caseInsensitive": !pred.CaseSensitive}
+				}},
+				{pred.DeepEquals, func(v interface{}) map[string]interface{} {
+					return map[string]interface{}{"equalTo": v, "caseInsensitive": !pred.CaseSensitive}
+				}},
+				{pred.Contains, func(v interface{}) map[string]interface{} {
+					return map[string]interface{}{"contains": v}
+				}},
+				{pred.Matches, func(v interface{}) map[string]interface{} {
+					return map[string]interface{}{"matches": ".*(?:" + fmt.Sprint(v) + ").*"}
+				}},
+				{pred.StartsWith, func(v interface{}) map[string]interface{} {
+					return map[string]interface{}{"matches": regexp.QuoteMeta(fmt.Sprint(v)) + ".*"}
+				}},
+				{pred.EndsWith, func(v interface{}) map[string]interface{} {
+					return map[string]interface{}{"matches": ".*" + regexp.QuoteMeta(fmt.Sprint(v))}
+				}},
+			}
+
+			for _, op := range ops {
+				fields, ok := op.value.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				for _, field := range sortedKeys(fields) {
+					value := fields[field]
+					spec := op.spec(value)
+					switch field {
+					case "method":
+						if _, eq := spec["equalTo"]; eq {
+							req.Method = strings.ToUpper(fmt.Sprint(value))
+						}
+					case "path":
+						if _, eq := spec["equalTo"]; eq {
+							req.URLPath = fmt.Sprint(value)
+						} else if m, ok := spec["matches"].(string); ok {
+							req.URLPathPattern = m
+						}
+					case "query", "headers":
+						values, ok := value.(map[string]interface{})
+						if !ok {
+							continue
+						}
+						target := &req.QueryParameters
+						if field == "headers" {
+							target = &req.Headers
+						}
+						for _, key := range sortedKeys(values) {
+							keyed(target, key, op.spec(values[key]))
+						}
+					case "body":
+						if _, isString := value.(string); !isString && spec["equalTo"] != nil {
+							spec = map[string]interface{}{"equalToJson": value}
+						}
+						delete(spec, "caseInsensitive")
+						req.BodyPatterns = append(req.BodyPatterns, spec)
+					}
+				}
+			}
+		}
+	}
+	visit(preds)
+	return req
+}
+
+// wireMockResponseOf converts a response, reporting false for injected
+// responses, which WireMock cannot express
+func wireMockResponseOf(resp models.Response) (wireMockResponse, bool) {
+	var wr wireMockResponse
+	switch {
+	case resp.Fault != "":
+		wr.Fault = resp.Fault
+	case resp.Proxy != nil:
+		wr.ProxyBaseURL = resp.Proxy.To
+	case resp.Is != nil:
+		wr.Status = statusOf(resp.Is)
+		if len(resp.Is.Headers) > 0 {
+			wr.Headers = resp.Is.Headers
+		}
+		switch body := resp.Is.Body.(type) {
+		case nil:
+		case string:
+			if resp.Is.Mode == "binary" {
+				wr.Base64Body = body
+			} else {
+				wr.Body = body
+			}
+		default:
+			wr.JSONBody = body
+		}
+	default:
+		return wr, false
+	}
+
+	for _, b := range resp.Behaviors {
+		if ms, ok := b.Wait.(float64); ok {
+			wr.FixedDelayMilliseconds = int(ms)
+		} else if ms, ok := b.Wait.(int); ok {
+			wr.FixedDelayMilliseconds = ms
+		}
+	}
+	return wr, true
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"regexp"
 	"strconv"
@@ -50,6 +51,17 @@ func (e *BehaviorExecutor) Execute(req *models.Request, resp *models.IsResponse,
 			}
 		}
 
+		// Handle chaos behavior; an injected error short-circuits the remaining behaviors
+		if behavior.Chaos != nil {
+			chaosResp, err := e.executeChaos(behavior.Chaos)
+			if err != nil {
+				return nil, err
+			}
+			if chaosResp != nil {
+				return chaosResp, nil
+			}
+		}
+
 		// Handle copy behavior (can be array of copy operations)
 		if len(behavior.Copy) > 0 {
 			for _, copyOp := range behavior.Copy {
@@ -125,6 +137,86 @@ func (e *BehaviorExecutor) executeWait(req *models.Request, wait interface{}) er
 	return nil
 }
 
+// ChaosFaultError is returned by Execute when a chaos behavior decides to
+// inject a connection fault. Protocol servers translate it into their own
+// fault handling instead of treating it as a behavior failure.
+type ChaosFaultError struct {
+	Fault string
+}
+
+func (e *ChaosFaultError) Error() string {
+	return fmt.Sprintf("chaos fault: %s", e.Fault)
+}
+
+// executeChaos rolls the configured latency, fault and error probabilities.
+// It returns a replacement response when an error is injected, or a
+// *ChaosFaultError when a fault is injected.
+func (e *BehaviorExecutor) executeChaos(chaos *models.Chaos) (*models.IsResponse, error) {
+	if chaos.Latency != nil && chaos.Float64() < chaos.Latency.Probability {
+		ms, err := chaosLatency(chaos, chaos.Latency)
+		if err != nil {
+			return nil, fmt.Errorf("chaos behavior error: %w", err)
+		}
+		if ms > 0 {
+			time.Sleep(time.Duration(ms * float64(time.Millisecond)))
+		}
+	}
+
+	if chaos.Fault != nil && chaos.Float64() < chaos.Fault.Probability {
+		fault := chaos.Fault.Type
+		if fault == "" {
+			fault = models.FaultConnectionResetByPeer
+		}
+		return nil, &ChaosFaultError{Fault: fault}
+	}
+
+	if chaos.Error != nil && chaos.Float64() < chaos.Error.Probability {
+		if chaos.Error.Response == nil {
+			return &models.IsResponse{StatusCode: 500, Headers: make(map[string]interface{})}, nil
+		}
+		errResp := *chaos.Error.Response
+		errResp.Headers = copyHeadersInterface(chaos.Error.Response.Headers)
+		return &errResp, nil
+	}
+
+	return nil, nil
+}
+
+// chaosLatency draws a latency in milliseconds from the configured distribution
+func chaosLatency(chaos *models.Chaos, latency *models.ChaosLatency) (float64, error) {
+	var ms float64
+
+	switch latency.Distribution {
+	case "", models.DistributionFixed:
+		ms = latency.Value
+	case models.DistributionUniform:
+		ms = latency.Min + chaos.Float64()*(latency.Max-latency.Min)
+	case models.DistributionNormal:
+		ms = latency.Mean + chaos.NormFloat64()*latency.StdDev
+	case models.DistributionP99:
+		// Log-normal shaped so that the median is p50 and the 99th percentile is p99
+		if latency.P50 <= 0 || latency.P99 < latency.P50 {
+			return 0, fmt.Errorf("p99 distribution requires 0 < p50 <= p99")
+		}
+		mu := math.Log(latency.P50)
+		sigma := (math.Log(latency.P99) - mu) / 2.326
+		ms = math.Exp(mu + sigma*chaos.NormFloat64())
+	default:
+		return 0, fmt.Errorf("unknown latency distribution: %s", latency.Distribution)
+	}
+
+	if latency.Max > 0 && ms > latency.Max {
+		ms = latency.Max
+	}
+	if ms < latency.Min {
+		ms = latency.Min
+	}
+	if ms < 0 {
+		ms = 0
+	}
+	return ms, nil
+}
+
 // executeWaitFunction executes a JavaScript function to get wait time
 func (e *BehaviorExecutor) executeWaitFunction(req *models.Request, script string) (int, error) {
 	vm := e.jsEngine.vmPool.Acquire()
@@ -0,0 +1,238 @@
+package imposter
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+func chaosSeed(v int64) *int64 {
+	return &v
+}
+
+// TestChaosFault tests that a fault at probability 1 surfaces as ChaosFaultError
+func TestChaosFault(t *testing.T) {
+	tests := []struct {
+		name      string
+		faultType string
+		wantFault string
+	}{
+		{"default fault type", "", models.FaultConnectionResetByPeer},
+		{"random data fault", models.FaultRandomDataThenClose, models.FaultRandomDataThenClose},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewBehaviorExecutor(NewJSEngine())
+			chaos := &models.Chaos{
+				Seed:  chaosSeed(1),
+				Fault: &models.ChaosFault{Probability: 1, Type: tt.faultType},
+			}
+
+			_, err := executor.Execute(&models.Request{}, &models.IsResponse{Body: "ok"},
+				[]models.Behavior{{Chaos: chaos}})
+
+			var chaosFault *ChaosFaultError
+			if !errors.As(err, &chaosFault) {
+				t.Fatalf("expected ChaosFaultError, got %v", err)
+			}
+			if chaosFault.Fault != tt.wantFault {
+				t.Errorf("expected fault %s, got %s", tt.wantFault, chaosFault.Fault)
+			}
+		})
+	}
+}
+
+// TestChaosError tests error response injection
+func TestChaosError(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   *models.IsResponse
+		wantStatus interface{}
+		wantBody   interface{}
+	}{
+		{"default response", nil, 500, nil},
+		{"custom response", &models.IsResponse{StatusCode: 503, Body: "unavailable"}, 503, "unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewBehaviorExecutor(NewJSEngine())
+			behaviors := []models.Behavior{
+				{Chaos: &models.Chaos{Error: &models.ChaosError{Probability: 1, Response: tt.response}}},
+				{Decorate: "function (request, response) { response.body = 'decorated'; }"},
+			}
+
+			result, err := executor.Execute(&models.Request{}, &models.IsResponse{StatusCode: 200, Body: "ok"}, behaviors)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %v, got %v", tt.wantStatus, result.StatusCode)
+			}
+			if result.Body != tt.wantBody {
+				t.Errorf("expected body %v, got %v", tt.wantBody, result.Body)
+			}
+		})
+	}
+}
+
+// TestChaosNeverTriggers tests that zero probabilities leave the response untouched
+func TestChaosNeverTriggers(t *testing.T) {
+	executor := NewBehaviorExecutor(NewJSEngine())
+	chaos := &models.Chaos{
+		Latency: &models.ChaosLatency{Probability: 0, Value: 10000},
+		Fault:   &models.ChaosFault{Probability: 0},
+		Error:   &models.ChaosError{Probability: 0},
+	}
+
+	for i := 0; i < 100; i++ {
+		result, err := executor.Execute(&models.Request{}, &models.IsResponse{StatusCode: 200, Body: "ok"},
+			[]models.Behavior{{Chaos: chaos}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Body != "ok" {
+			t.Fatalf("expected untouched response, got %v", result.Body)
+		}
+	}
+}
+
+// TestChaosSeedReproducible tests that the same seed yields the same outcomes
+func TestChaosSeedReproducible(t *testing.T) {
+	outcomes := func() []bool {
+		executor := NewBehaviorExecutor(NewJSEngine())
+		chaos := &models.Chaos{
+			Seed:  chaosSeed(42),
+			Error: &models.ChaosError{Probability: 0.5},
+		}
+		var got []bool
+		for i := 0; i < 50; i++ {
+			result, err := executor.Execute(&models.Request{}, &models.IsResponse{StatusCode: 200},
+				[]models.Behavior{{Chaos: chaos}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got = append(got, result.StatusCode == 500)
+		}
+		return got
+	}
+
+	first, second := outcomes(), outcomes()
+	failures := 0
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("outcome %d differs between seeded runs", i)
+		}
+		if first[i] {
+			failures++
+		}
+	}
+	if failures == 0 || failures == len(first) {
+		t.Errorf("expected a mix of outcomes at probability 0.5, got %d/%d failures", failures, len(first))
+	}
+}
+
+// TestChaosLatencyDistributions tests the latency draws stay within bounds
+func TestChaosLatencyDistributions(t *testing.T) {
+	tests := []struct {
+		name    string
+		latency models.ChaosLatency
+		min     float64
+		max     float64
+	}{
+		{"fixed", models.ChaosLatency{Value: 25}, 25, 25},
+		{"uniform", models.ChaosLatency{Distribution: "uniform", Min: 10, Max: 20}, 10, 20},
+		{"normal clamped", models.ChaosLatency{Distribution: "normal", Mean: 50, StdDev: 100, Max: 80}, 0, 80},
+		{"p99", models.ChaosLatency{Distribution: "p99", P50: 10, P99: 100, Max: 500}, 0, 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chaos := &models.Chaos{Seed: chaosSeed(7)}
+			for i := 0; i < 1000; i++ {
+				ms, err := chaosLatency(chaos, &tt.latency)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if ms < tt.min || ms > tt.max {
+					t.Fatalf("latency %v outside [%v, %v]", ms, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+// TestChaosLatencyP99Shape tests that the p99 distribution honors its median and tail
+func TestChaosLatencyP99Shape(t *testing.T) {
+	chaos := &models.Chaos{Seed: chaosSeed(3)}
+	latency := &models.ChaosLatency{Distribution: "p99", P50: 20, P99: 200}
+
+	const n = 10000
+	below50, below99 := 0, 0
+	for i := 0; i < n; i++ {
+		ms, err := chaosLatency(chaos, latency)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ms <= 20 {
+			below50++
+		}
+		if ms <= 200 {
+			below99++
+		}
+	}
+
+	if frac := float64(below50) / n; frac < 0.47 || frac > 0.53 {
+		t.Errorf("expected ~50%% at or below p50, got %.3f", frac)
+	}
+	if frac := float64(below99) / n; frac < 0.985 || frac > 0.995 {
+		t.Errorf("expected ~99%% at or below p99, got %.3f", frac)
+	}
+}
+
+// TestChaosLatencyInvalid tests configuration errors
+func TestChaosLatencyInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		latency models.ChaosLatency
+	}{
+		{"unknown distribution", models.ChaosLatency{Distribution: "pareto"}},
+		{"p99 below p50", models.ChaosLatency{Distribution: "p99", P50: 100, P99: 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := chaosLatency(&models.Chaos{}, &tt.latency); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// TestChaosUnmarshal tests the JSON shape of the chaos behavior
+func TestChaosUnmarshal(t *testing.T) {
+	input := `{"chaos": {"seed": 9, "latency": {"probability": 0.2, "distribution": "normal", "mean": 100, "stddev": 20},
+		"fault": {"probability": 0.01}, "error": {"probability": 0.05, "response": {"statusCode": 503}}}}`
+
+	var behavior models.Behavior
+	if err := json.Unmarshal([]byte(input), &behavior); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	chaos := behavior.Chaos
+	if chaos == nil || chaos.Seed == nil || *chaos.Seed != 9 {
+		t.Fatalf("expected chaos with seed 9, got %+v", chaos)
+	}
+	if chaos.Latency.Distribution != "normal" || chaos.Latency.Mean != 100 {
+		t.Errorf("unexpected latency: %+v", chaos.Latency)
+	}
+	if chaos.Fault.Probability != 0.01 {
+		t.Errorf("unexpected fault: %+v", chaos.Fault)
+	}
+	if chaos.Error.Response == nil {
+		t.Error("expected error response")
+	}
+}
@@ -0,0 +1,52 @@
+package imposter
+
+import (
+	"github.com/TetsujinOni/go-tartuffe/internal/events"
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// publishRequest publishes that an imposter received a request
+func publishRequest(bus *events.Bus, imp *models.Imposter, req interface{}) {
+	bus.Publish(events.Event{
+		Type:     events.RequestReceived,
+		Imposter: imp.Port,
+		Protocol: imp.Protocol,
+		Request:  req,
+	})
+}
+
+// publishMatch publishes the stub that matched a request, or that none did
+func publishMatch(bus *events.Bus, imp *models.Imposter, req interface{}, stub *models.Stub, index int) {
+	e := events.Event{
+		Type:     events.NoMatch,
+		Imposter: imp.Port,
+		Protocol: imp.Protocol,
+		Request:  req,
+	}
+	if stub != nil {
+		e.Type = events.StubMatched
+		e.StubIndex = &index
+		e.StubID = stub.ID
+	}
+	bus.Publish(e)
+}
+
+// publishProxyRecorded publishes a stub a proxy recorded
+func publishProxyRecorded(bus *events.Bus, imp *models.Imposter, stub *models.Stub) {
+	bus.Publish(events.Event{
+		Type:     events.ProxyRecorded,
+		Imposter: imp.Port,
+		Protocol: imp.Protocol,
+		StubID:   stub.ID,
+		Stub:     stub,
+	})
+}
+
+// publishLifecycle publishes that an imposter started or stopped
+func publishLifecycle(bus *events.Bus, eventType string, imp *models.Imposter) {
+	bus.Publish(events.Event{
+		Type:     eventType,
+		Imposter: imp.Port,
+		Protocol: imp.Protocol,
+	})
+}
@@ -0,0 +1,52 @@
+package imposter
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/events"
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// TestManagerEventsAreIsolated tests that each manager publishes to its
+// own bus, so servers in one process don't see each other's events
+func TestManagerEventsAreIsolated(t *testing.T) {
+	first, second := NewManager(), NewManager()
+	sub := first.Events().Subscribe(0, nil)
+	defer sub.Close()
+
+	imp := &models.Imposter{Protocol: "http", Port: 0}
+	if err := second.Start(imp); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer second.StopAll()
+
+	resp, err := http.Get("http://localhost:" + strconv.Itoa(imp.Port) + "/")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	select {
+	case msg := <-sub.C:
+		t.Fatalf("expected no events from another manager, got %s", msg.Data)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	own := &models.Imposter{Protocol: "http", Port: 0}
+	if err := first.Start(own); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer first.StopAll()
+
+	select {
+	case msg := <-sub.C:
+		if msg.Type != events.ImposterStarted || msg.Imposter != own.Port {
+			t.Errorf("expected the start of imposter %d, got %s", own.Port, msg.Data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected an event from the manager's own imposter")
+	}
+}
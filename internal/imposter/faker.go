@@ -0,0 +1,254 @@
+package imposter
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand/v2"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// fakeTokenPattern matches generator tokens such as ${fake.email}
+var fakeTokenPattern = regexp.MustCompile(`\$\{fake\.([a-zA-Z0-9]+)\}`)
+
+// Faker produces realistic random values for response bodies.
+// Each imposter owns one; when the imposter has a seed, the sequence of
+// generated values is identical across runs for the same request order.
+type Faker struct {
+	rng *rand.Rand
+	mu  sync.Mutex
+}
+
+// NewFaker creates a faker, seeded when seed is non-nil
+func NewFaker(seed *int64) *Faker {
+	s := uint64(time.Now().UnixNano())
+	if seed != nil {
+		s = uint64(*seed)
+	}
+	return &Faker{rng: rand.New(rand.NewPCG(s, s))}
+}
+
+// fakeGenerators is the generator vocabulary, keyed by token name
+var fakeGenerators = map[string]func(f *Faker) string{
+	"firstName": func(f *Faker) string { return f.pick(fakeFirstNames) },
+	"lastName":  func(f *Faker) string { return f.pick(fakeLastNames) },
+	"name":      func(f *Faker) string { return f.pick(fakeFirstNames) + " " + f.pick(fakeLastNames) },
+	"username": func(f *Faker) string {
+		return strings.ToLower(f.pick(fakeFirstNames)) + fmt.Sprintf("%d", f.intn(1000))
+	},
+	"email": func(f *Faker) string {
+		return strings.ToLower(f.pick(fakeFirstNames)+"."+f.pick(fakeLastNames)) + "@" + f.pick(fakeEmailDomains)
+	},
+	"phone":    func(f *Faker) string { return fmt.Sprintf("+1-555-%03d-%04d", f.intn(1000), f.intn(10000)) },
+	"company":  func(f *Faker) string { return f.pick(fakeLastNames) + " " + f.pick(fakeCompanySuffixes) },
+	"street":   func(f *Faker) string { return fmt.Sprintf("%d %s", 1+f.intn(9999), f.pick(fakeStreets)) },
+	"city":     func(f *Faker) string { return f.pick(fakeCities) },
+	"country":  func(f *Faker) string { return f.pick(fakeCountries) },
+	"postcode": func(f *Faker) string { return fmt.Sprintf("%05d", f.intn(100000)) },
+	"address": func(f *Faker) string {
+		return fmt.Sprintf("%d %s, %s %05d, %s", 1+f.intn(9999), f.pick(fakeStreets), f.pick(fakeCities), f.intn(100000), f.pick(fakeCountries))
+	},
+	"uuid":      func(f *Faker) string { return f.UUID() },
+	"iban":      func(f *Faker) string { return f.iban() },
+	"ipv4":      func(f *Faker) string { return fmt.Sprintf("10.%d.%d.%d", f.intn(256), f.intn(256), 1+f.intn(254)) },
+	"url":       func(f *Faker) string { return "https://" + f.pick(fakeEmailDomains) + "/" + f.pick(fakeWords) },
+	"word":      func(f *Faker) string { return f.pick(fakeWords) },
+	"sentence":  func(f *Faker) string { return f.sentence() },
+	"number":    func(f *Faker) string { return fmt.Sprintf("%d", f.intn(1000000)) },
+	"boolean":   func(f *Faker) string { return fmt.Sprintf("%t", f.intn(2) == 1) },
+	"date":      func(f *Faker) string { return f.moment().Format("2006-01-02") },
+	"datetime":  func(f *Faker) string { return f.moment().Format(time.RFC3339) },
+	"timestamp": func(f *Faker) string { return time.Now().UTC().Format(time.RFC3339) },
+}
+
+// FakeGeneratorNames lists the supported generator names in sorted order
+func FakeGeneratorNames() []string {
+	names := make([]string, 0, len(fakeGenerators))
+	for name := range fakeGenerators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Generate returns a value from the named generator
+func (f *Faker) Generate(name string) (string, error) {
+	gen, ok := fakeGenerators[name]
+	if !ok {
+		return "", fmt.Errorf("unknown fake data generator: %s", name)
+	}
+	return gen(f), nil
+}
+
+// Expand replaces every ${fake.<name>} token in s. Unknown generators are
+// left in place so that unrelated ${...} tokens survive.
+func (f *Faker) Expand(s string) string {
+	if !strings.Contains(s, "${fake.") {
+		return s
+	}
+	return fakeTokenPattern.ReplaceAllStringFunc(s, func(token string) string {
+		name := fakeTokenPattern.FindStringSubmatch(token)[1]
+		if gen, ok := fakeGenerators[name]; ok {
+			return gen(f)
+		}
+		return token
+	})
+}
+
+// ExpandResponse returns a copy of resp with generator tokens expanded in
+// the body, headers, TCP data and gRPC stream messages
+func (f *Faker) ExpandResponse(resp *models.IsResponse) *models.IsResponse {
+	if resp == nil {
+		return nil
+	}
+
+	expanded := *resp
+	expanded.Body = f.expandValue(resp.Body)
+	expanded.Data = f.Expand(resp.Data)
+	if resp.Headers != nil {
+		expanded.Headers = make(map[string]interface{}, len(resp.Headers))
+		for name, value := range resp.Headers {
+			expanded.Headers[name] = f.expandValue(value)
+		}
+	}
+	if resp.Stream != nil {
+		expanded.Stream = make([]interface{}, len(resp.Stream))
+		for i, msg := range resp.Stream {
+			expanded.Stream[i] = f.expandValue(msg)
+		}
+	}
+	return &expanded
+}
+
+func (f *Faker) expandValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return f.Expand(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = f.expandValue(item)
+		}
+		return out
+	case []string:
+		out := make([]string, len(v))
+		for i, item := range v {
+			out[i] = f.Expand(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = f.expandValue(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// UUID returns a version 4 UUID drawn from the faker's random source
+func (f *Faker) UUID() string {
+	f.mu.Lock()
+	hi, lo := f.rng.Uint64(), f.rng.Uint64()
+	f.mu.Unlock()
+
+	var b [16]byte
+	for i := 0; i < 8; i++ {
+		b[i] = byte(hi >> (56 - 8*i))
+		b[8+i] = byte(lo >> (56 - 8*i))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// IntN returns a random integer in [0, n)
+func (f *Faker) IntN(n int) int {
+	return f.intn(n)
+}
+
+func (f *Faker) intn(n int) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rng.IntN(n)
+}
+
+func (f *Faker) pick(values []string) string {
+	return values[f.intn(len(values))]
+}
+
+func (f *Faker) sentence() string {
+	n := 4 + f.intn(6)
+	words := make([]string, n)
+	for i := range words {
+		words[i] = f.pick(fakeWords)
+	}
+	words[0] = strings.ToUpper(words[0][:1]) + words[0][1:]
+	return strings.Join(words, " ") + "."
+}
+
+// moment returns a random instant between 1970 and 2030
+func (f *Faker) moment() time.Time {
+	const span = 60 * 365 * 24 * 60 * 60
+	f.mu.Lock()
+	secs := f.rng.Int64N(span)
+	f.mu.Unlock()
+	return time.Unix(secs, 0).UTC()
+}
+
+// iban returns a German-format IBAN with a valid ISO 13616 check number
+func (f *Faker) iban() string {
+	var bban strings.Builder
+	for i := 0; i < 18; i++ {
+		bban.WriteByte(byte('0' + f.intn(10)))
+	}
+
+	// Check digits: move country code and "00" to the end, letters to numbers
+	// (D=13, E=14), then 98 - (n mod 97)
+	n, _ := new(big.Int).SetString(bban.String()+"131400", 10)
+	check := 98 - new(big.Int).Mod(n, big.NewInt(97)).Int64()
+	return fmt.Sprintf("DE%02d%s", check, bban.String())
+}
+
+var fakeFirstNames = []string{
+	"Ada", "Alan", "Barbara", "Claude", "Dennis", "Donald", "Edsger", "Frances", "Grace", "Guido",
+	"Hedy", "Ivan", "Joan", "John", "Ken", "Linus", "Margaret", "Niklaus", "Radia", "Rob",
+	"Sophie", "Tim", "Vint", "Whitfield", "Yukihiro",
+}
+
+var fakeLastNames = []string{
+	"Allen", "Backus", "Cerf", "Dijkstra", "Engelbart", "Floyd", "Goldberg", "Hamilton", "Hopper", "Kay",
+	"Knuth", "Lamport", "Liskov", "Lovelace", "McCarthy", "Perlman", "Pike", "Ritchie", "Stroustrup", "Thompson",
+	"Torvalds", "Turing", "Wilson", "Wirth",
+}
+
+var fakeEmailDomains = []string{"example.com", "example.org", "example.net"}
+
+var fakeCompanySuffixes = []string{"Inc", "LLC", "Ltd", "GmbH", "Group", "Systems", "Labs"}
+
+var fakeStreets = []string{
+	"Main Street", "High Street", "Oak Avenue", "Maple Drive", "Cedar Lane", "Park Road", "Station Road",
+	"Church Street", "Mill Lane", "River Road", "Elm Street", "Lake View",
+}
+
+var fakeCities = []string{
+	"Springfield", "Riverside", "Fairview", "Franklin", "Greenville", "Bristol", "Clinton", "Salem",
+	"Madison", "Georgetown", "Arlington", "Ashland",
+}
+
+var fakeCountries = []string{
+	"Australia", "Brazil", "Canada", "Denmark", "France", "Germany", "India", "Ireland", "Japan",
+	"Kenya", "Netherlands", "New Zealand", "Norway", "Spain", "Sweden", "United Kingdom", "United States",
+}
+
+var fakeWords = []string{
+	"alpha", "bravo", "cargo", "delta", "echo", "fabric", "gamma", "harbor", "index", "jigsaw",
+	"kernel", "lambda", "matrix", "nexus", "orbit", "packet", "quartz", "relay", "signal", "token",
+	"vector", "widget", "yield", "zenith",
+}
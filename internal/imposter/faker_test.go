@@ -0,0 +1,133 @@
+package imposter
+
+import (
+	"math/big"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// TestFakerSeedIsReproducible tests that two fakers with the same seed agree
+func TestFakerSeedIsReproducible(t *testing.T) {
+	seed := int64(42)
+	a, b := NewFaker(&seed), NewFaker(&seed)
+
+	for _, name := range FakeGeneratorNames() {
+		if name == "timestamp" {
+			continue
+		}
+		x, err := a.Generate(name)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		y, _ := b.Generate(name)
+		if x != y {
+			t.Errorf("%s: expected seeded fakers to agree, got %q and %q", name, x, y)
+		}
+	}
+}
+
+// TestFakerGeneratorFormats tests the shape of selected generators
+func TestFakerGeneratorFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+	}{
+		{"email", `^[a-z]+\.[a-z]+@example\.(com|org|net)$`},
+		{"uuid", `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{"ipv4", `^10\.\d{1,3}\.\d{1,3}\.\d{1,3}$`},
+		{"phone", `^\+1-555-\d{3}-\d{4}$`},
+		{"date", `^\d{4}-\d{2}-\d{2}$`},
+		{"boolean", `^(true|false)$`},
+		{"iban", `^DE\d{20}$`},
+	}
+
+	faker := NewFaker(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := faker.Generate(tt.name)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !regexp.MustCompile(tt.pattern).MatchString(value) {
+				t.Errorf("expected %s to match %s, got %q", tt.name, tt.pattern, value)
+			}
+		})
+	}
+
+	if _, err := faker.Generate("nope"); err == nil {
+		t.Error("expected error for unknown generator")
+	}
+}
+
+// TestFakerIBANChecksum tests that generated IBANs pass the mod-97 check
+func TestFakerIBANChecksum(t *testing.T) {
+	faker := NewFaker(nil)
+	for i := 0; i < 20; i++ {
+		iban, _ := faker.Generate("iban")
+		rearranged := iban[4:] + "1314" + iban[2:4]
+		n, ok := new(big.Int).SetString(rearranged, 10)
+		if !ok || new(big.Int).Mod(n, big.NewInt(97)).Int64() != 1 {
+			t.Fatalf("invalid IBAN checksum: %s", iban)
+		}
+	}
+}
+
+// TestFakerExpandResponse tests token expansion in a response copy
+func TestFakerExpandResponse(t *testing.T) {
+	faker := NewFaker(nil)
+	original := &models.IsResponse{
+		Body: map[string]interface{}{
+			"user":  map[string]interface{}{"email": "${fake.email}"},
+			"tags":  []interface{}{"${fake.word}"},
+			"other": "${NAME} ${fake.unknown}",
+			"count": 3,
+		},
+		Headers: map[string]interface{}{"X-Trace": "${fake.uuid}"},
+		Data:    "hello ${fake.firstName}",
+	}
+
+	resp := faker.ExpandResponse(original)
+
+	body := resp.Body.(map[string]interface{})
+	if email := body["user"].(map[string]interface{})["email"].(string); !strings.Contains(email, "@example.") {
+		t.Errorf("expected nested email to be expanded, got %q", email)
+	}
+	if word := body["tags"].([]interface{})[0].(string); strings.Contains(word, "${") {
+		t.Errorf("expected array item to be expanded, got %q", word)
+	}
+	if body["other"] != "${NAME} ${fake.unknown}" {
+		t.Errorf("expected unrelated tokens to be left alone, got %q", body["other"])
+	}
+	if body["count"] != 3 {
+		t.Errorf("expected non-string values to pass through, got %v", body["count"])
+	}
+	if strings.Contains(resp.Headers["X-Trace"].(string), "${") || strings.Contains(resp.Data, "${") {
+		t.Errorf("expected headers and data to be expanded, got %v / %q", resp.Headers, resp.Data)
+	}
+	if original.Headers["X-Trace"] != "${fake.uuid}" {
+		t.Error("expected the stub response to be left untouched")
+	}
+}
+
+// TestTemplateFakeFunc tests that templates draw from the seeded faker
+func TestTemplateFakeFunc(t *testing.T) {
+	seed := int64(7)
+	render := func() string {
+		engine := NewTemplateEngine(nil, NewFaker(&seed))
+		resp, err := engine.Render(&models.Request{}, &models.IsResponse{
+			Template: true,
+			Body:     `{{ fake "name" }} {{ uuid }} {{ randomInt 1 100 }}`,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp.Body.(string)
+	}
+
+	if first, second := render(), render(); first != second {
+		t.Errorf("expected seeded templates to agree, got %q and %q", first, second)
+	}
+}
@@ -14,6 +14,7 @@ import (
 type GRPCMatcher struct {
 	imposter    *models.Imposter
 	protoLoader *ProtoLoader
+	scenarios   *ScenarioStore
 }
 
 // NewGRPCMatcher creates a new gRPC matcher
@@ -21,9 +22,15 @@ func NewGRPCMatcher(imp *models.Imposter, loader *ProtoLoader) *GRPCMatcher {
 	return &GRPCMatcher{
 		imposter:    imp,
 		protoLoader: loader,
+		scenarios:   NewScenarioStore(),
 	}
 }
 
+// SetScenarios shares the owning server's scenario store with the matcher
+func (m *GRPCMatcher) SetScenarios(scenarios *ScenarioStore) {
+	m.scenarios = scenarios
+}
+
 // GRPCMatchResult contains the result of matching a gRPC request
 type GRPCMatchResult struct {
 	Response  *models.IsResponse
@@ -36,7 +43,10 @@ type GRPCMatchResult struct {
 func (m *GRPCMatcher) Match(req *models.GRPCRequest, method protoreflect.MethodDescriptor) *GRPCMatchResult {
 	for i := range m.imposter.Stubs {
 		stub := &m.imposter.Stubs[i]
-		if m.matchesAllPredicates(stub, req) {
+		if !m.scenarios.Allows(stub) {
+			continue
+		}
+		if m.matchesAllPredicates(stub, req) && m.scenarios.Advance(stub) {
 			return m.getMatchResult(stub, i)
 		}
 	}
@@ -5,12 +5,14 @@ import (
 
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"sync"
 	"time"
 
+	"github.com/TetsujinOni/go-tartuffe/internal/events"
 	"github.com/TetsujinOni/go-tartuffe/internal/models"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -30,8 +32,13 @@ type GRPCServer struct {
 	listener         net.Listener
 	protoLoader      *ProtoLoader
 	matcher          *GRPCMatcher
+	scenarios        *ScenarioStore
 	jsEngine         *JSEngine
 	behaviorExecutor *BehaviorExecutor
+	templateEngine   *TemplateEngine
+	faker            *Faker
+	requestLog       requestLog  // Retention of recorded requests
+	events           *events.Bus // Bus the imposter's events are published to
 	started          bool
 	stopping         bool
 	mu               sync.RWMutex
@@ -51,13 +58,20 @@ func NewGRPCServer(imp *models.Imposter) (*GRPCServer, error) {
 	}
 
 	jsEngine := NewJSEngine()
+	scenarios := NewScenarioStore()
+	matcher := NewGRPCMatcher(imp, loader)
+	matcher.SetScenarios(scenarios)
+	faker := NewFaker(imp.Seed)
 
 	return &GRPCServer{
 		imposter:         imp,
 		protoLoader:      loader,
-		matcher:          NewGRPCMatcher(imp, loader),
+		matcher:          matcher,
+		scenarios:        scenarios,
 		jsEngine:         jsEngine,
 		behaviorExecutor: NewBehaviorExecutor(jsEngine),
+		templateEngine:   NewTemplateEngine(nil, faker),
+		faker:            faker,
 	}, nil
 }
 
@@ -82,6 +96,13 @@ func (s *GRPCServer) Start() error {
 	}
 	s.listener = listener
 
+	// Get the actual port if port=0 was used (auto-assign)
+	if s.imposter.Port == 0 {
+		if tcpAddr, ok := listener.Addr().(*net.TCPAddr); ok {
+			s.imposter.Port = tcpAddr.Port
+		}
+	}
+
 	// Create gRPC server with unknown service handler
 	s.grpcServer = grpc.NewServer(
 		grpc.UnknownServiceHandler(s.handleUnknown),
@@ -179,6 +200,7 @@ func (s *GRPCServer) handleUnary(ctx context.Context, stream grpc.ServerStream,
 	s.recordRequest(ctx, grpcReq)
 
 	match := s.matcher.Match(grpcReq, method)
+	publishMatch(s.events, s.imposter, grpcReq, match.Stub, match.StubIndex)
 
 	return s.sendUnaryResponse(stream, method, match, grpcReq)
 }
@@ -199,6 +221,7 @@ func (s *GRPCServer) handleServerStream(ctx context.Context, stream grpc.ServerS
 	s.recordRequest(ctx, grpcReq)
 
 	match := s.matcher.Match(grpcReq, method)
+	publishMatch(s.events, s.imposter, grpcReq, match.Stub, match.StubIndex)
 
 	return s.sendStreamingResponse(stream, method, match, grpcReq)
 }
@@ -251,12 +274,13 @@ func (s *GRPCServer) handleClientStream(ctx context.Context, stream grpc.ServerS
 		Method:    methodName,
 		Message:   messageMap,
 		Metadata:  metadataMap,
-		Timestamp: time.Now().Format(time.RFC3339),
+		Timestamp: time.Now().Format(time.RFC3339Nano),
 	}
 
 	s.recordRequest(ctx, grpcReq)
 
 	match := s.matcher.Match(grpcReq, method)
+	publishMatch(s.events, s.imposter, grpcReq, match.Stub, match.StubIndex)
 
 	return s.sendUnaryResponse(stream, method, match, grpcReq)
 }
@@ -299,12 +323,13 @@ func (s *GRPCServer) handleBidiStream(ctx context.Context, stream grpc.ServerStr
 			Method:    methodName,
 			Message:   messageMap,
 			Metadata:  metadataMap,
-			Timestamp: time.Now().Format(time.RFC3339),
+			Timestamp: time.Now().Format(time.RFC3339Nano),
 		}
 
 		s.recordRequest(ctx, grpcReq)
 
 		match := s.matcher.Match(grpcReq, method)
+		publishMatch(s.events, s.imposter, grpcReq, match.Stub, match.StubIndex)
 
 		if match.Response == nil {
 			continue
@@ -359,12 +384,14 @@ func (s *GRPCServer) createGRPCRequest(ctx context.Context, inputMsg *dynamicpb.
 		Method:    methodName,
 		Message:   messageMap,
 		Metadata:  metadataMap,
-		Timestamp: time.Now().Format(time.RFC3339),
+		Timestamp: time.Now().Format(time.RFC3339Nano),
 	}, nil
 }
 
 // recordRequest records the request if configured
 func (s *GRPCServer) recordRequest(ctx context.Context, grpcReq *models.GRPCRequest) {
+	defer publishRequest(s.events, s.imposter, grpcReq)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -372,7 +399,8 @@ func (s *GRPCServer) recordRequest(ctx context.Context, grpcReq *models.GRPCRequ
 		if p, ok := getPeerAddress(ctx); ok {
 			grpcReq.RequestFrom = p
 		}
-		s.imposter.GRPCRequests = append(s.imposter.GRPCRequests, *grpcReq)
+		s.imposter.GRPCRequests = appendRetained(&s.requestLog, s.imposter, s.imposter.GRPCRequests, *grpcReq,
+			func(r *models.GRPCRequest) string { return r.Timestamp })
 	}
 	// Increment request counter
 	if s.imposter.NumberOfRequests == nil {
@@ -390,19 +418,27 @@ func (s *GRPCServer) applyBehaviors(match *GRPCMatchResult, grpcReq *models.GRPC
 		return &models.IsResponse{}, nil
 	}
 
+	// Convert gRPC request to HTTP-like request for templates and behaviors
+	httpReq := s.grpcToHTTPRequest(grpcReq)
+
+	resp, err := s.templateEngine.Render(httpReq, resp)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "template error: %v", err)
+	}
+
 	// Apply behaviors if present
 	if len(match.Behaviors) > 0 {
-		// Convert gRPC request to HTTP-like request for behavior executor
-		httpReq := s.grpcToHTTPRequest(grpcReq)
-
-		var err error
 		resp, err = s.behaviorExecutor.Execute(httpReq, resp, match.Behaviors)
 		if err != nil {
+			var chaosFault *ChaosFaultError
+			if errors.As(err, &chaosFault) {
+				return nil, status.Errorf(codes.Unavailable, "%s", chaosFault.Fault)
+			}
 			return nil, status.Errorf(codes.Internal, "behavior error: %v", err)
 		}
 	}
 
-	return resp, nil
+	return s.faker.ExpandResponse(resp), nil
 }
 
 // grpcToHTTPRequest converts a gRPC request to an HTTP-like request for behaviors
@@ -547,6 +583,11 @@ func (s *GRPCServer) UpdateStubs(stubs []models.Stub) {
 	s.imposter.Stubs = stubs
 }
 
+// Scenarios returns the scenario states for this imposter
+func (s *GRPCServer) Scenarios() *ScenarioStore {
+	return s.scenarios
+}
+
 // parseFullMethod parses "/package.Service/Method" into service and method names
 func parseFullMethod(fullMethod string) (string, string) {
 	if len(fullMethod) > 0 && fullMethod[0] == '/' {
@@ -0,0 +1,105 @@
+package imposter
+
+import (
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// RequestQuery selects recorded requests. Predicates use the same operators
+// and fields as the imposter's stubs; From and To bound the request
+// timestamp and are ignored when zero.
+type RequestQuery struct {
+	Predicates []models.Predicate
+	From       time.Time
+	To         time.Time
+}
+
+// QueryRequests returns the requests an imposter recorded that match the
+// query, oldest first, along with the number it recorded in total. Each
+// request is the protocol's own record type.
+func QueryRequests(imp *models.Imposter, query RequestQuery) ([]interface{}, int) {
+	var recorded []interface{}
+	var timestamps []string
+	var matches func(i int, pred *models.Predicate) bool
+
+	switch imp.Protocol {
+	case "tcp":
+		matcher := NewTCPMatcher(imp)
+		requests := imp.TCPRequests
+		for _, req := range requests {
+			recorded = append(recorded, req)
+			timestamps = append(timestamps, req.Timestamp)
+		}
+		matches = func(i int, pred *models.Predicate) bool {
+			return matcher.evaluatePredicate(pred, requests[i].Data)
+		}
+	case "smtp":
+		matcher := NewSMTPMatcher(imp)
+		requests := imp.SMTPRequests
+		for _, req := range requests {
+			recorded = append(recorded, req)
+			timestamps = append(timestamps, req.Timestamp)
+		}
+		matches = func(i int, pred *models.Predicate) bool {
+			return matcher.evaluatePredicate(pred, requests[i].ToMap())
+		}
+	case "grpc":
+		matcher := NewGRPCMatcher(imp, nil)
+		requests := imp.GRPCRequests
+		for _, req := range requests {
+			recorded = append(recorded, req)
+			timestamps = append(timestamps, req.Timestamp)
+		}
+		matches = func(i int, pred *models.Predicate) bool {
+			return matcher.evaluatePredicate(pred, &requests[i])
+		}
+	default:
+		matcher := NewMatcher(imp)
+		requests := imp.Requests
+		for _, req := range requests {
+			recorded = append(recorded, req)
+			timestamps = append(timestamps, req.Timestamp)
+		}
+		matches = func(i int, pred *models.Predicate) bool {
+			return matcher.evaluatePredicate(pred, &requests[i])
+		}
+	}
+
+	result := make([]interface{}, 0, len(recorded))
+	for i, req := range recorded {
+		if !query.inRange(timestamps[i]) {
+			continue
+		}
+		matched := true
+		for j := range query.Predicates {
+			if !matches(i, &query.Predicates[j]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			result = append(result, req)
+		}
+	}
+	return result, len(recorded)
+}
+
+// inRange reports whether a request timestamp is within the query's time
+// range. Requests without a readable timestamp only pass an unbounded range.
+func (q RequestQuery) inRange(timestamp string) bool {
+	if q.From.IsZero() && q.To.IsZero() {
+		return true
+	}
+	at, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return false
+	}
+	if !q.From.IsZero() && at.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && at.After(q.To) {
+		return false
+	}
+	return true
+}
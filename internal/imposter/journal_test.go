@@ -0,0 +1,100 @@
+package imposter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// TestQueryRequests tests filtering recorded requests for each protocol
+func TestQueryRequests(t *testing.T) {
+	earlier := "2026-01-01T10:00:00Z"
+	later := "2026-01-01T12:00:00Z"
+
+	tests := []struct {
+		name      string
+		imposter  *models.Imposter
+		query     RequestQuery
+		wantCount int
+		wantTotal int
+	}{
+		{
+			name: "http contains body",
+			imposter: &models.Imposter{Protocol: "http", Requests: []models.Request{
+				{Method: "POST", Path: "/orders", Body: `{"item":"book"}`, Timestamp: earlier},
+				{Method: "POST", Path: "/orders", Body: `{"item":"pen"}`, Timestamp: later},
+				{Method: "GET", Path: "/orders", Timestamp: later},
+			}},
+			query: RequestQuery{Predicates: []models.Predicate{
+				{Equals: map[string]interface{}{"method": "POST"}},
+				{Contains: map[string]interface{}{"body": "book"}},
+			}},
+			wantCount: 1,
+			wantTotal: 3,
+		},
+		{
+			name: "http time range",
+			imposter: &models.Imposter{Protocol: "http", Requests: []models.Request{
+				{Method: "GET", Path: "/", Timestamp: earlier},
+				{Method: "GET", Path: "/", Timestamp: later},
+				{Method: "GET", Path: "/"},
+			}},
+			query:     RequestQuery{From: mustParse(t, "2026-01-01T11:00:00Z")},
+			wantCount: 1,
+			wantTotal: 3,
+		},
+		{
+			name: "tcp data",
+			imposter: &models.Imposter{Protocol: "tcp", TCPRequests: []models.TCPRequest{
+				{Data: "LOGIN alice"},
+				{Data: "LOGOUT"},
+			}},
+			query:     RequestQuery{Predicates: []models.Predicate{{StartsWith: map[string]interface{}{"data": "LOGIN"}}}},
+			wantCount: 1,
+			wantTotal: 2,
+		},
+		{
+			name: "smtp subject",
+			imposter: &models.Imposter{Protocol: "smtp", SMTPRequests: []models.SMTPRequest{
+				{Subject: "Welcome"},
+				{Subject: "Password reset"},
+				{Subject: "Welcome back"},
+			}},
+			query:     RequestQuery{Predicates: []models.Predicate{{Contains: map[string]interface{}{"subject": "welcome"}}}},
+			wantCount: 2,
+			wantTotal: 3,
+		},
+		{
+			name: "grpc method",
+			imposter: &models.Imposter{Protocol: "grpc", GRPCRequests: []models.GRPCRequest{
+				{Service: "helloworld.Greeter", Method: "SayHello", Message: map[string]interface{}{"name": "a"}},
+				{Service: "helloworld.Greeter", Method: "SayGoodbye", Message: map[string]interface{}{"name": "b"}},
+			}},
+			query:     RequestQuery{Predicates: []models.Predicate{{Equals: map[string]interface{}{"method": "SayHello"}}}},
+			wantCount: 1,
+			wantTotal: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total := QueryRequests(tt.imposter, tt.query)
+			if len(got) != tt.wantCount {
+				t.Errorf("QueryRequests() matched %d, want %d", len(got), tt.wantCount)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("QueryRequests() total = %d, want %d", total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func mustParse(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("time.Parse(%q) error = %v", value, err)
+	}
+	return parsed
+}
@@ -0,0 +1,256 @@
+package imposter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/config"
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// SchemaValidator compiles and caches the schemas used by jsonSchema
+// predicates. Inline schemas are keyed by their JSON encoding and file
+// references by path, so each schema is compiled once per matcher.
+type SchemaValidator struct {
+	root  string   // the server's --bodyDir; schema files must be inside it
+	cache sync.Map // cache key -> *jsonschema.Schema
+}
+
+// NewSchemaValidator creates a schema validator that reads schema files
+// from root, the server's --bodyDir. Without a root, only inline schemas
+// without file $refs compile.
+func NewSchemaValidator(root string) *SchemaValidator {
+	return &SchemaValidator{root: root}
+}
+
+// Compile returns the compiled schema for an inline schema or a file
+// reference such as "schemas/order.json" or
+// "openapi.yaml#/components/schemas/Order". Paths, including relative
+// $refs inside inline schemas, resolve against --bodyDir and may not climb
+// out of it.
+func (v *SchemaValidator) Compile(schema interface{}) (*jsonschema.Schema, error) {
+	var key, location string
+	var doc interface{}
+
+	root, err := filepath.Abs(v.root)
+	if err != nil {
+		return nil, err
+	}
+
+	switch s := schema.(type) {
+	case string:
+		name, fragment, _ := strings.Cut(s, "#")
+		path, err := bodyPath(root, name)
+		if v.root == "" {
+			err = errNoBodyDir
+		}
+		if err != nil {
+			return nil, err
+		}
+		key = "file:" + s
+		location = fileURL(path)
+		if fragment != "" {
+			location += "#" + fragment
+		}
+	case map[string]interface{}, bool:
+		encoded, err := json.Marshal(s)
+		if err != nil {
+			return nil, err
+		}
+		key = "inline:" + string(encoded)
+		location = fileURL(filepath.Join(root, "inline-schema.json"))
+		doc = s
+	default:
+		return nil, fmt.Errorf("schema must be an object or a file path, got %T", schema)
+	}
+
+	if cached, ok := v.cache.Load(key); ok {
+		return cached.(*jsonschema.Schema), nil
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.UseLoader(jsonschema.SchemeURLLoader{"file": schemaFileLoader{root: v.root}})
+	if doc != nil {
+		if err := compiler.AddResource(location, doc); err != nil {
+			return nil, err
+		}
+	}
+	compiled, err := compiler.Compile(location)
+	if err != nil {
+		return nil, err
+	}
+
+	v.cache.Store(key, compiled)
+	return compiled, nil
+}
+
+// ValidateRequest checks the request against a jsonSchema predicate and
+// returns every violation found; an empty result means the predicate matches
+func (v *SchemaValidator) ValidateRequest(pred *models.JSONSchemaPredicate, req *models.Request) []string {
+	var violations []string
+
+	if pred.Body != nil {
+		body, err := jsonschema.UnmarshalJSON(strings.NewReader(req.Body))
+		if err != nil {
+			violations = append(violations, "body: not valid JSON")
+		} else {
+			violations = append(violations, v.validate(pred.Body, "body", body)...)
+		}
+	}
+
+	if pred.Query != nil {
+		query := make(map[string]interface{}, len(req.Query))
+		for k, val := range req.Query {
+			query[k] = val
+		}
+		violations = append(violations, v.validate(pred.Query, "query", query)...)
+	}
+
+	if pred.Headers != nil {
+		headers := make(map[string]interface{}, len(req.Headers))
+		for k, val := range req.Headers {
+			headers[strings.ToLower(k)] = val
+		}
+		violations = append(violations, v.validate(pred.Headers, "headers", headers)...)
+	}
+
+	return violations
+}
+
+// validate runs one schema and flattens its errors into messages of the
+// form "body/order/qty: got string, want number"
+func (v *SchemaValidator) validate(schema interface{}, part string, value interface{}) []string {
+	compiled, err := v.Compile(schema)
+	if err != nil {
+		return []string{part + ": invalid schema: " + err.Error()}
+	}
+
+	err = compiled.Validate(value)
+	if err == nil {
+		return nil
+	}
+
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return []string{part + ": " + err.Error()}
+	}
+
+	var violations []string
+	output := verr.BasicOutput()
+	units := append([]jsonschema.OutputUnit{*output}, output.Errors...)
+	for _, unit := range units {
+		if unit.Error == nil {
+			continue
+		}
+		violations = append(violations, part+unit.InstanceLocation+": "+unit.Error.String())
+	}
+	if len(violations) == 0 {
+		violations = append(violations, part+": "+verr.Error())
+	}
+	return violations
+}
+
+// ValidateStubSchemas compiles every jsonSchema predicate in stubs so that a
+// bad inline schema, or a schema file that is missing or outside root, the
+// server's --bodyDir, is rejected when the stub is added
+func ValidateStubSchemas(root string, stubs []models.Stub) error {
+	validator := NewSchemaValidator(root)
+	for i := range stubs {
+		for _, pred := range stubs[i].Predicates {
+			if err := validatePredicateSchemas(validator, &pred); err != nil {
+				return fmt.Errorf("stubs[%d]: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validatePredicateSchemas(validator *SchemaValidator, pred *models.Predicate) error {
+	for _, p := range pred.And {
+		if err := validatePredicateSchemas(validator, &p); err != nil {
+			return err
+		}
+	}
+	for _, p := range pred.Or {
+		if err := validatePredicateSchemas(validator, &p); err != nil {
+			return err
+		}
+	}
+	if pred.Not != nil {
+		if err := validatePredicateSchemas(validator, pred.Not); err != nil {
+			return err
+		}
+	}
+	if pred.JSONSchema == nil {
+		return nil
+	}
+
+	parts := map[string]interface{}{
+		"body":    pred.JSONSchema.Body,
+		"query":   pred.JSONSchema.Query,
+		"headers": pred.JSONSchema.Headers,
+	}
+	names := make([]string, 0, len(parts))
+	for name, schema := range parts {
+		if schema != nil {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return errors.New("jsonSchema predicate requires a body, query or headers schema")
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := validator.Compile(parts[name]); err != nil {
+			return fmt.Errorf("jsonSchema %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// schemaFileLoader loads schema files from inside root, accepting YAML as
+// well as JSON so OpenAPI documents can be referenced directly
+type schemaFileLoader struct {
+	root string
+}
+
+func (l schemaFileLoader) Load(location string) (interface{}, error) {
+	path, err := jsonschema.FileLoader{}.ToFile(location)
+	if err != nil {
+		return nil, err
+	}
+	if l.root == "" {
+		return nil, errNoBodyDir
+	}
+	root, err := filepath.Abs(l.root)
+	if err != nil {
+		return nil, err
+	}
+	if rel, err := filepath.Rel(root, path); err != nil || !filepath.IsLocal(rel) {
+		return nil, fmt.Errorf("%q is outside --bodyDir", path)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return config.DecodeYAML(data)
+	default:
+		return jsonschema.FileLoader{}.Load(location)
+	}
+}
+
+func fileURL(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
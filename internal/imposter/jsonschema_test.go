@@ -0,0 +1,325 @@
+package imposter
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+var orderSchema = map[string]interface{}{
+	"type":     "object",
+	"required": []interface{}{"id", "qty"},
+	"properties": map[string]interface{}{
+		"id":  map[string]interface{}{"type": "string"},
+		"qty": map[string]interface{}{"type": "integer", "minimum": 1},
+	},
+}
+
+// TestSchemaValidatorRequest tests body, query and header validation
+func TestSchemaValidatorRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		pred     models.JSONSchemaPredicate
+		req      models.Request
+		contains []string
+	}{
+		{
+			name: "valid body",
+			pred: models.JSONSchemaPredicate{Body: orderSchema},
+			req:  models.Request{Body: `{"id": "a1", "qty": 2}`},
+		},
+		{
+			name:     "wrong type",
+			pred:     models.JSONSchemaPredicate{Body: orderSchema},
+			req:      models.Request{Body: `{"id": "a1", "qty": "2"}`},
+			contains: []string{"body/qty:"},
+		},
+		{
+			name:     "missing property",
+			pred:     models.JSONSchemaPredicate{Body: orderSchema},
+			req:      models.Request{Body: `{"qty": 0}`},
+			contains: []string{"body: missing property 'id'", "body/qty:"},
+		},
+		{
+			name:     "not JSON",
+			pred:     models.JSONSchemaPredicate{Body: orderSchema},
+			req:      models.Request{Body: "qty=2"},
+			contains: []string{"body: not valid JSON"},
+		},
+		{
+			name: "query pattern",
+			pred: models.JSONSchemaPredicate{Query: map[string]interface{}{
+				"properties": map[string]interface{}{"page": map[string]interface{}{"pattern": "^[0-9]+$"}},
+			}},
+			req:      models.Request{Query: map[string]string{"page": "two"}},
+			contains: []string{"query/page:"},
+		},
+		{
+			name: "headers are lowercased",
+			pred: models.JSONSchemaPredicate{Headers: map[string]interface{}{
+				"required": []interface{}{"x-api-key"},
+			}},
+			req: models.Request{Headers: map[string]string{"X-Api-Key": "secret"}},
+		},
+	}
+
+	validator := NewSchemaValidator("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := validator.ValidateRequest(&tt.pred, &tt.req)
+			if len(tt.contains) == 0 && len(violations) > 0 {
+				t.Fatalf("expected no violations, got %v", violations)
+			}
+			joined := strings.Join(violations, "\n")
+			for _, want := range tt.contains {
+				if !strings.Contains(joined, want) {
+					t.Errorf("expected violation containing %q, got %v", want, violations)
+				}
+			}
+		})
+	}
+}
+
+// TestSchemaValidatorFileReferences tests JSON and OpenAPI YAML schema files
+// inside --bodyDir
+func TestSchemaValidatorFileReferences(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "schemas")
+	os.Mkdir(dir, 0755)
+	jsonFile := "order.json"
+	yamlFile := "openapi.yaml"
+
+	os.WriteFile(filepath.Join(parent, "outside.json"), []byte(`{"type": "object"}`), 0644)
+	os.WriteFile(filepath.Join(dir, jsonFile), []byte(`{"type": "object", "required": ["id"]}`), 0644)
+	os.WriteFile(filepath.Join(dir, yamlFile), []byte(`openapi: 3.1.0
+paths:
+  /orders:
+    post:
+      responses:
+        201:
+          description: created
+components:
+  schemas:
+    Order:
+      type: object
+      required: [id, lines]
+      properties:
+        lines:
+          type: array
+          items:
+            $ref: '#/components/schemas/Line'
+    Line:
+      type: object
+      required: [sku]
+`), 0644)
+
+	tests := []struct {
+		name   string
+		root   string
+		schema interface{}
+		body   string
+		valid  bool
+	}{
+		{"json file valid", dir, jsonFile, `{"id": 1}`, true},
+		{"json file invalid", dir, jsonFile, `{}`, false},
+		{"openapi component valid", dir, yamlFile + "#/components/schemas/Order", `{"id": 1, "lines": [{"sku": "x"}]}`, true},
+		{"openapi nested ref invalid", dir, yamlFile + "#/components/schemas/Order", `{"id": 1, "lines": [{}]}`, false},
+		{"inline ref inside root", dir, map[string]interface{}{"$ref": jsonFile}, `{"id": 1}`, true},
+		{"no root", "", jsonFile, `{"id": 1}`, false},
+		{"absolute path", dir, filepath.Join(dir, jsonFile), `{"id": 1}`, false},
+		{"climbs out of root", dir, "../outside.json", `{}`, false},
+		{"inline ref climbs out of root", dir, map[string]interface{}{"$ref": "../outside.json"}, `{}`, false},
+		{"inline ref without root", "", map[string]interface{}{"$ref": "order.json"}, `{"id": 1}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewSchemaValidator(tt.root)
+			violations := validator.ValidateRequest(&models.JSONSchemaPredicate{Body: tt.schema}, &models.Request{Body: tt.body})
+			if valid := len(violations) == 0; valid != tt.valid {
+				t.Errorf("expected valid=%v, got violations %v", tt.valid, violations)
+			}
+		})
+	}
+}
+
+// TestValidateStubSchemas tests that bad schemas are rejected up front
+func TestValidateStubSchemas(t *testing.T) {
+	tests := []struct {
+		name     string
+		pred     models.Predicate
+		contains string
+	}{
+		{"valid", models.Predicate{JSONSchema: &models.JSONSchemaPredicate{Body: orderSchema}}, ""},
+		{"empty", models.Predicate{JSONSchema: &models.JSONSchemaPredicate{}}, "requires a body"},
+		{"bad keyword", models.Predicate{JSONSchema: &models.JSONSchemaPredicate{
+			Body: map[string]interface{}{"type": "strnig"},
+		}}, "jsonSchema body"},
+		{"missing file", models.Predicate{Not: &models.Predicate{JSONSchema: &models.JSONSchemaPredicate{
+			Body: "does/not/exist.json",
+		}}}, "jsonSchema body"},
+		{"file outside bodyDir", models.Predicate{JSONSchema: &models.JSONSchemaPredicate{
+			Body: "../order.json",
+		}}, "jsonSchema body"},
+	}
+
+	root := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStubSchemas(root, []models.Stub{{}, {Predicates: []models.Predicate{tt.pred}}})
+			if tt.contains == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.contains) || !strings.HasPrefix(err.Error(), "stubs[1]") {
+				t.Errorf("expected error containing %q, got %v", tt.contains, err)
+			}
+		})
+	}
+}
+
+// TestMatcherJSONSchemaPredicate tests matching and explaining schema predicates
+func TestMatcherJSONSchemaPredicate(t *testing.T) {
+	imp := &models.Imposter{
+		Protocol: "http",
+		Stubs: []models.Stub{
+			{
+				Predicates: []models.Predicate{{JSONSchema: &models.JSONSchemaPredicate{Body: orderSchema}}},
+				Responses:  []models.Response{{Is: &models.IsResponse{StatusCode: 201}}},
+			},
+			{
+				Predicates: []models.Predicate{{
+					JSONPath:   &models.Selector{Selector: "$.order"},
+					JSONSchema: &models.JSONSchemaPredicate{Body: orderSchema},
+				}},
+				Responses: []models.Response{{Is: &models.IsResponse{StatusCode: 202}}},
+			},
+		},
+	}
+	matcher := NewMatcher(imp)
+
+	valid := &models.Request{Method: "POST", Body: `{"id": "a1", "qty": 1}`}
+	if result := matcher.Match(valid); result.StubIndex != 0 {
+		t.Errorf("expected valid body to match stub 0, got %d", result.StubIndex)
+	}
+	if violations := matcher.ExplainSchemas(valid); len(violations) != 1 || !strings.HasPrefix(violations[0], "stub 1: body") {
+		t.Errorf("expected only the selector stub to explain a violation, got %v", violations)
+	}
+
+	nested := &models.Request{Method: "POST", Body: `{"order": {"id": "a1", "qty": 1}}`}
+	if result := matcher.Match(nested); result.StubIndex != 1 {
+		t.Errorf("expected selected body to match stub 1, got %d", result.StubIndex)
+	}
+
+	invalid := &models.Request{Method: "POST", Body: `{"qty": 1}`}
+	if result := matcher.Match(invalid); result.StubIndex != -1 {
+		t.Errorf("expected invalid body to match no stub, got %d", result.StubIndex)
+	}
+	if violations := matcher.ExplainSchemas(invalid); len(violations) == 0 || !strings.Contains(violations[0], "missing property 'id'") {
+		t.Errorf("expected missing property violation, got %v", violations)
+	}
+}
+
+// TestExplainSchemasSkipsUnmatchedStubs tests that only stubs whose other
+// predicates match the request explain their schema violations
+func TestExplainSchemasSkipsUnmatchedStubs(t *testing.T) {
+	imp := &models.Imposter{
+		Protocol: "http",
+		Stubs: []models.Stub{
+			{
+				Predicates: []models.Predicate{
+					{Equals: map[string]interface{}{"path": "/orders"}},
+					{JSONSchema: &models.JSONSchemaPredicate{Body: orderSchema}},
+				},
+				Responses: []models.Response{{Is: &models.IsResponse{StatusCode: 201}}},
+			},
+			{
+				Predicates: []models.Predicate{
+					{Equals: map[string]interface{}{"path": "/refunds"}},
+					{JSONSchema: &models.JSONSchemaPredicate{Body: orderSchema}},
+				},
+				Responses: []models.Response{{Is: &models.IsResponse{StatusCode: 202}}},
+			},
+		},
+	}
+	matcher := NewMatcher(imp)
+
+	req := &models.Request{Method: "POST", Path: "/orders", Body: `{"id": "a1"}`}
+	violations := matcher.ExplainSchemas(req)
+	if len(violations) != 1 || !strings.HasPrefix(violations[0], "stub 0: body") {
+		t.Errorf("expected only stub 0 to explain a violation, got %v", violations)
+	}
+}
+
+// TestSchemaViolationsDebugLog tests that unmatched requests explain their
+// schema violations in the log and the imposter's bounded schemaViolations
+// only in debug mode, and never on the recorded requests
+func TestSchemaViolationsDebugLog(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	for _, debug := range []bool{false, true} {
+		logged.Reset()
+		srv, err := NewServer(&models.Imposter{
+			Protocol:       "http",
+			RecordRequests: true,
+			Stubs: []models.Stub{{
+				Predicates: []models.Predicate{{JSONSchema: &models.JSONSchemaPredicate{Body: orderSchema}}},
+				Responses:  []models.Response{{Is: &models.IsResponse{StatusCode: 201}}},
+			}},
+		}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.debug = debug
+
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest("POST", "/orders", strings.NewReader(`{"qty": 1}`)))
+
+		if got := strings.Contains(logged.String(), "missing property 'id'"); got != debug {
+			t.Errorf("debug=%v: expected violations logged=%v, got log %q", debug, debug, logged.String())
+		}
+		if recorded, _ := json.Marshal(srv.GetImposter().Requests); strings.Contains(string(recorded), "schemaViolations") {
+			t.Errorf("debug=%v: expected no violations on the recorded request, got %s", debug, recorded)
+		}
+
+		data, _ := srv.GetImposter().ToJSON(models.SerializeOptions{})
+		var retrieved struct {
+			SchemaViolations []models.SchemaViolation `json:"schemaViolations"`
+		}
+		json.Unmarshal(data, &retrieved)
+		if !debug {
+			if len(retrieved.SchemaViolations) != 0 {
+				t.Errorf("expected no schemaViolations without debug, got %v", retrieved.SchemaViolations)
+			}
+			continue
+		}
+		if len(retrieved.SchemaViolations) != 1 {
+			t.Fatalf("expected 1 schemaViolation, got %s", data)
+		}
+		got := retrieved.SchemaViolations[0]
+		if got.Method != "POST" || got.Path != "/orders" || !strings.Contains(strings.Join(got.Violations, "\n"), "missing property 'id'") {
+			t.Errorf("unexpected schemaViolation %+v", got)
+		}
+
+		for range maxSchemaViolations {
+			srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/orders", strings.NewReader(`{}`)))
+		}
+		if n := len(srv.GetImposter().SchemaViolations); n != maxSchemaViolations {
+			t.Errorf("expected schemaViolations capped at %d, got %d", maxSchemaViolations, n)
+		}
+		if replayable, _ := srv.GetImposter().ToJSON(models.SerializeOptions{Replayable: true}); strings.Contains(string(replayable), "schemaViolations") {
+			t.Errorf("expected replayable output without schemaViolations, got %s", replayable)
+		}
+	}
+}
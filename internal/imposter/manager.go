@@ -13,23 +13,36 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"math/big"
 	"net"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/TetsujinOni/go-tartuffe/internal/events"
 	"github.com/TetsujinOni/go-tartuffe/internal/metrics"
 	"github.com/TetsujinOni/go-tartuffe/internal/models"
 )
 
+// httpWriteTimeout bounds writing an HTTP imposter's response; streamed
+// proxy responses clear it
+var httpWriteTimeout = 30 * time.Second
+
+// maxSchemaViolations bounds the unmatched requests an imposter explains
+// in --debug mode; older explanations are dropped first
+const maxSchemaViolations = 100
+
 // ImposterServer is the interface that both HTTP and TCP servers implement
 type ImposterServer interface {
 	Start() error
 	Stop(ctx context.Context) error
 	GetImposter() *models.Imposter
 	UpdateStubs(stubs []models.Stub)
+	Scenarios() *ScenarioStore
 }
 
 // Manager manages the lifecycle of imposter servers (HTTP, TCP, SMTP, gRPC)
@@ -38,6 +51,10 @@ type Manager struct {
 	tcpServers  map[int]*TCPServer  // TCP servers
 	smtpServers map[int]*SMTPServer // SMTP servers
 	grpcServers map[int]*GRPCServer // gRPC servers
+	retention   models.RequestRetention
+	debug       bool
+	bodyDir     string
+	events      *events.Bus // Bus the imposters publish to
 	mu          sync.RWMutex
 }
 
@@ -48,9 +65,47 @@ func NewManager() *Manager {
 		tcpServers:  make(map[int]*TCPServer),
 		smtpServers: make(map[int]*SMTPServer),
 		grpcServers: make(map[int]*GRPCServer),
+		events:      events.NewBus(),
 	}
 }
 
+// Events returns the bus the manager's imposters publish their events to
+func (m *Manager) Events() *events.Bus {
+	return m.events
+}
+
+// SetRequestRetention sets the bounds on recorded requests for imposters
+// that don't set their own. It applies to servers started afterwards.
+func (m *Manager) SetRequestRetention(defaults models.RequestRetention) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retention = defaults
+}
+
+// SetDebug sets whether HTTP imposters log why requests matched no stub
+// and keep the explanations as the imposter's schemaViolations. It applies to servers started afterwards.
+func (m *Manager) SetDebug(debug bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.debug = debug
+}
+
+// SetBodyDir sets the directory that bodyFile and proxy bodyDir paths are
+// confined to. Without one, imposters cannot read or write body files. It
+// applies to servers started afterwards.
+func (m *Manager) SetBodyDir(dir string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bodyDir = dir
+}
+
+// BodyDir returns the directory set with SetBodyDir
+func (m *Manager) BodyDir() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.bodyDir
+}
+
 // Start starts a server for the given imposter (HTTP or TCP based on protocol)
 func (m *Manager) Start(imp *models.Imposter) error {
 	m.mu.Lock()
@@ -73,18 +128,23 @@ func (m *Manager) Start(imp *models.Imposter) error {
 	}
 
 	// Start appropriate server based on protocol
+	var err error
 	switch imp.Protocol {
 	case "tcp":
-		return m.startTCPServer(imp)
+		err = m.startTCPServer(imp)
 	case "https":
-		return m.startHTTPSServer(imp)
+		err = m.startHTTPSServer(imp)
 	case "smtp":
-		return m.startSMTPServer(imp)
+		err = m.startSMTPServer(imp)
 	case "grpc":
-		return m.startGRPCServer(imp)
+		err = m.startGRPCServer(imp)
 	default:
-		return m.startHTTPServer(imp)
+		err = m.startHTTPServer(imp)
 	}
+	if err == nil {
+		publishLifecycle(m.events, events.ImposterStarted, imp)
+	}
+	return err
 }
 
 // startHTTPServer starts an HTTP server for the given imposter
@@ -93,6 +153,11 @@ func (m *Manager) startHTTPServer(imp *models.Imposter) error {
 	if err != nil {
 		return err
 	}
+	srv.requestLog.defaults = m.retention
+	srv.events = m.events
+	srv.debug = m.debug
+	srv.bodyDir = m.bodyDir
+	srv.matcher.SetBodyDir(m.bodyDir)
 
 	// Save original port in case it's 0 (auto-assign)
 	originalPort := imp.Port
@@ -118,6 +183,11 @@ func (m *Manager) startHTTPSServer(imp *models.Imposter) error {
 	if err != nil {
 		return err
 	}
+	srv.requestLog.defaults = m.retention
+	srv.events = m.events
+	srv.debug = m.debug
+	srv.bodyDir = m.bodyDir
+	srv.matcher.SetBodyDir(m.bodyDir)
 
 	if err := srv.Start(); err != nil {
 		return err
@@ -134,6 +204,8 @@ func (m *Manager) startTCPServer(imp *models.Imposter) error {
 	if err != nil {
 		return err
 	}
+	srv.requestLog.defaults = m.retention
+	srv.events = m.events
 
 	if err := srv.Start(); err != nil {
 		return err
@@ -149,6 +221,8 @@ func (m *Manager) startSMTPServer(imp *models.Imposter) error {
 	if err != nil {
 		return err
 	}
+	srv.requestLog.defaults = m.retention
+	srv.events = m.events
 
 	if err := srv.Start(); err != nil {
 		return err
@@ -164,6 +238,8 @@ func (m *Manager) startGRPCServer(imp *models.Imposter) error {
 	if err != nil {
 		return err
 	}
+	srv.requestLog.defaults = m.retention
+	srv.events = m.events
 
 	if err := srv.Start(); err != nil {
 		return err
@@ -187,6 +263,7 @@ func (m *Manager) Stop(port int) error {
 			return err
 		}
 		delete(m.servers, port)
+		publishLifecycle(m.events, events.ImposterStopped, srv.GetImposter())
 		return nil
 	}
 
@@ -196,6 +273,7 @@ func (m *Manager) Stop(port int) error {
 			return err
 		}
 		delete(m.tcpServers, port)
+		publishLifecycle(m.events, events.ImposterStopped, srv.GetImposter())
 		return nil
 	}
 
@@ -205,6 +283,7 @@ func (m *Manager) Stop(port int) error {
 			return err
 		}
 		delete(m.smtpServers, port)
+		publishLifecycle(m.events, events.ImposterStopped, srv.GetImposter())
 		return nil
 	}
 
@@ -214,6 +293,7 @@ func (m *Manager) Stop(port int) error {
 			return err
 		}
 		delete(m.grpcServers, port)
+		publishLifecycle(m.events, events.ImposterStopped, srv.GetImposter())
 		return nil
 	}
 
@@ -233,6 +313,8 @@ func (m *Manager) StopAll() error {
 	for port, srv := range m.servers {
 		if err := srv.Stop(ctx); err != nil {
 			lastErr = err
+		} else {
+			publishLifecycle(m.events, events.ImposterStopped, srv.GetImposter())
 		}
 		delete(m.servers, port)
 	}
@@ -241,6 +323,8 @@ func (m *Manager) StopAll() error {
 	for port, srv := range m.tcpServers {
 		if err := srv.Stop(ctx); err != nil {
 			lastErr = err
+		} else {
+			publishLifecycle(m.events, events.ImposterStopped, srv.GetImposter())
 		}
 		delete(m.tcpServers, port)
 	}
@@ -249,6 +333,8 @@ func (m *Manager) StopAll() error {
 	for port, srv := range m.smtpServers {
 		if err := srv.Stop(ctx); err != nil {
 			lastErr = err
+		} else {
+			publishLifecycle(m.events, events.ImposterStopped, srv.GetImposter())
 		}
 		delete(m.smtpServers, port)
 	}
@@ -257,6 +343,8 @@ func (m *Manager) StopAll() error {
 	for port, srv := range m.grpcServers {
 		if err := srv.Stop(ctx); err != nil {
 			lastErr = err
+		} else {
+			publishLifecycle(m.events, events.ImposterStopped, srv.GetImposter())
 		}
 		delete(m.grpcServers, port)
 	}
@@ -264,6 +352,75 @@ func (m *Manager) StopAll() error {
 	return lastErr
 }
 
+// Snapshot returns a copy of the imposter running on port, taken under its
+// server's lock, so its stubs and recorded requests can be read while the
+// server goes on handling traffic
+func (m *Manager) Snapshot(port int) (*models.Imposter, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	mu, reqLog, imp, ok := m.recorder(port)
+	if !ok {
+		return nil, false
+	}
+	pruneLocked(mu, reqLog, imp)
+	return snapshotImposter(mu, imp), true
+}
+
+// PruneExpiredRequests drops the requests recorded by the imposter on port
+// that have outlived its retention maxAge. Reads of recorded requests call
+// it so expired requests don't linger on an imposter nothing reaches.
+func (m *Manager) PruneExpiredRequests(port int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if mu, reqLog, imp, ok := m.recorder(port); ok {
+		pruneLocked(mu, reqLog, imp)
+	}
+}
+
+// recorder returns the lock, request log and imposter of the server on
+// port. Callers hold m.mu.
+func (m *Manager) recorder(port int) (*sync.RWMutex, *requestLog, *models.Imposter, bool) {
+	if srv, exists := m.servers[port]; exists {
+		return &srv.mu, &srv.requestLog, srv.imposter, true
+	}
+	if srv, exists := m.tcpServers[port]; exists {
+		return &srv.mu, &srv.requestLog, srv.imposter, true
+	}
+	if srv, exists := m.smtpServers[port]; exists {
+		return &srv.mu, &srv.requestLog, srv.imposter, true
+	}
+	if srv, exists := m.grpcServers[port]; exists {
+		return &srv.mu, &srv.requestLog, srv.imposter, true
+	}
+	return nil, nil, nil, false
+}
+
+func pruneLocked(mu *sync.RWMutex, reqLog *requestLog, imp *models.Imposter) {
+	mu.Lock()
+	defer mu.Unlock()
+	pruneExpired(reqLog, imp)
+}
+
+func snapshotImposter(mu *sync.RWMutex, imp *models.Imposter) *models.Imposter {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	snapshot := *imp
+	snapshot.Stubs = slices.Clone(imp.Stubs)
+	snapshot.Requests = slices.Clone(imp.Requests)
+	snapshot.TCPRequests = slices.Clone(imp.TCPRequests)
+	snapshot.SMTPRequests = slices.Clone(imp.SMTPRequests)
+	snapshot.GRPCRequests = slices.Clone(imp.GRPCRequests)
+	snapshot.SchemaViolations = slices.Clone(imp.SchemaViolations)
+	if imp.NumberOfRequests != nil {
+		count := *imp.NumberOfRequests
+		snapshot.NumberOfRequests = &count
+	}
+	return &snapshot
+}
+
 // IsRunning checks if a server is running on the given port
 func (m *Manager) IsRunning(port int) bool {
 	m.mu.RLock()
@@ -345,7 +502,14 @@ type Server struct {
 	proxyHandler     *ProxyHandler
 	jsEngine         *JSEngine
 	behaviorExecutor *BehaviorExecutor
+	templateEngine   *TemplateEngine
+	faker            *Faker
 	imposterState    map[string]interface{} // Shared state for JS injection
+	scenarios        *ScenarioStore         // Scenario states shared with matcher
+	requestLog       requestLog             // Retention of recorded requests
+	debug            bool                   // Log jsonSchema violations of unmatched requests
+	bodyDir          string                 // Directory body files are confined to
+	events           *events.Bus            // Bus the imposter's events are published to
 	tlsConfig        *tls.Config
 	useTLS           bool
 	started          bool
@@ -359,9 +523,12 @@ func NewServer(imp *models.Imposter, useTLS bool) (*Server, error) {
 
 	matcher := NewMatcher(imp)
 	matcher.SetState(imposterState) // Share state with matcher
+	scenarios := NewScenarioStore()
+	matcher.SetScenarios(scenarios)
 
 	// Use the matcher's JS engine for consistency
 	jsEngine := matcher.GetJSEngine()
+	faker := NewFaker(imp.Seed)
 
 	srv := &Server{
 		imposter:         imp,
@@ -369,7 +536,10 @@ func NewServer(imp *models.Imposter, useTLS bool) (*Server, error) {
 		proxyHandler:     NewProxyHandler(),
 		jsEngine:         jsEngine,
 		behaviorExecutor: NewBehaviorExecutor(jsEngine),
+		templateEngine:   NewTemplateEngine(imposterState, faker),
+		faker:            faker,
 		imposterState:    imposterState,
+		scenarios:        scenarios,
 		useTLS:           useTLS,
 	}
 
@@ -377,7 +547,7 @@ func NewServer(imp *models.Imposter, useTLS bool) (*Server, error) {
 		Addr:         fmt.Sprintf("%s:%d", imp.Host, imp.Port),
 		Handler:      srv,
 		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		WriteTimeout: httpWriteTimeout,
 		IdleTimeout:  120 * time.Second,
 	}
 
@@ -591,11 +761,45 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Find matching stub and record predicate match duration
+	publishRequest(s.events, s.imposter, req)
+
+	predicateStart := time.Now()
+	match := s.matcher.Match(req)
+	metrics.RecordPredicateMatchDuration(s.imposter.Protocol, portStr, time.Since(predicateStart).Seconds())
+
+	// Record no-match if no stub matched
+	if match.StubIndex < 0 {
+		metrics.RecordNoMatch(s.imposter.Protocol, portStr)
+	}
+	publishMatch(s.events, s.imposter, req, match.Stub, match.StubIndex)
+
+	// In debug mode, explain the jsonSchema predicates that left the request
+	// without a matching stub, in the log and on the imposter
+	if s.debug && match.StubIndex < 0 {
+		if violations := s.matcher.ExplainSchemas(req); len(violations) > 0 {
+			log.Printf("[DEBUG] imposter %d: %s %s matched no stub; schema violations: %s",
+				s.imposter.Port, req.Method, req.Path, strings.Join(violations, "; "))
+			s.mu.Lock()
+			s.imposter.SchemaViolations = append(s.imposter.SchemaViolations, models.SchemaViolation{
+				Timestamp:  time.Now().Format(time.RFC3339Nano),
+				Method:     req.Method,
+				Path:       req.Path,
+				Violations: violations,
+			})
+			if excess := len(s.imposter.SchemaViolations) - maxSchemaViolations; excess > 0 {
+				s.imposter.SchemaViolations = slices.Delete(s.imposter.SchemaViolations, 0, excess)
+			}
+			s.mu.Unlock()
+		}
+	}
+
 	// Record the request if configured
 	s.mu.Lock()
 	if s.imposter.RecordRequests {
-		req.Timestamp = time.Now().Format(time.RFC3339)
-		s.imposter.Requests = append(s.imposter.Requests, *req)
+		req.Timestamp = time.Now().Format(time.RFC3339Nano)
+		s.imposter.Requests = appendRetained(&s.requestLog, s.imposter, s.imposter.Requests, *req,
+			func(r *models.Request) string { return r.Timestamp })
 	}
 	// Increment request counter
 	if s.imposter.NumberOfRequests == nil {
@@ -606,16 +810,6 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	s.mu.Unlock()
 
-	// Find matching stub and record predicate match duration
-	predicateStart := time.Now()
-	match := s.matcher.Match(req)
-	metrics.RecordPredicateMatchDuration(s.imposter.Protocol, portStr, time.Since(predicateStart).Seconds())
-
-	// Record no-match if no stub matched
-	if match.StubIndex < 0 {
-		metrics.RecordNoMatch(s.imposter.Protocol, portStr)
-	}
-
 	// Defer response duration recording
 	defer func() {
 		metrics.RecordResponseDuration(s.imposter.Protocol, portStr, time.Since(startTime).Seconds())
@@ -627,32 +821,25 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Transparent proxies without behaviors to apply stream the target's
+	// response to the client as it arrives rather than buffering it
+	if match.Proxy != nil && match.Proxy.Mode == "proxyTransparent" && len(match.Behaviors) == 0 {
+		if err := s.proxyHandler.Stream(w, req, match.Proxy, r); err != nil {
+			writeProxyError(w, err)
+		}
+		return
+	}
+
 	var resp *models.IsResponse
 
 	// Handle different response types
 	var proxyStubToRecord *models.Stub
+	expandFakes := false
 	if match.Proxy != nil {
 		// Handle proxy response
 		proxyResult, err := s.proxyHandler.Execute(req, match.Proxy, r)
 		if err != nil {
-			// Check for ProxyError with specific error code
-			var proxyErr *ProxyError
-			if errors.As(err, &proxyErr) {
-				// Return mountebank-compatible error format
-				w.Header().Set("Content-Type", "application/json; charset=utf-8")
-				w.WriteHeader(http.StatusInternalServerError)
-				errorResp := map[string]interface{}{
-					"errors": []map[string]interface{}{
-						{
-							"code":    proxyErr.Code,
-							"message": proxyErr.Message,
-						},
-					},
-				}
-				json.NewEncoder(w).Encode(errorResp)
-				return
-			}
-			http.Error(w, fmt.Sprintf("proxy error: %v", err), http.StatusBadGateway)
+			writeProxyError(w, err)
 			return
 		}
 
@@ -678,8 +865,17 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 		resp = injResp
 	} else {
-		// Standard "is" response
-		resp = match.Response
+		// Standard "is" response, rendered first if it is a template
+		var err error
+		resp, err = s.templateEngine.Render(req, match.Response)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("template error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if match.Response != nil && match.Response.Template {
+			resp = s.matcher.normalizeResponse(resp)
+		}
+		expandFakes = true
 	}
 
 	// Apply behaviors if any
@@ -687,6 +883,11 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		var err error
 		resp, err = s.behaviorExecutor.Execute(req, resp, match.Behaviors)
 		if err != nil {
+			var chaosFault *ChaosFaultError
+			if errors.As(err, &chaosFault) {
+				s.handleFault(w, chaosFault.Fault)
+				return
+			}
 			http.Error(w, fmt.Sprintf("behavior error: %v", err), http.StatusInternalServerError)
 			return
 		}
@@ -699,13 +900,15 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Expand ${fake.*} tokens after behaviors so values inserted by copy and
+	// lookup can use generators too
+	if expandFakes {
+		resp = s.faker.ExpandResponse(resp)
+	}
+
 	// Record proxy stub AFTER behaviors are applied (so decorated response is saved)
 	if proxyStubToRecord != nil {
-		// Update stub with the post-behavior response
-		if len(proxyStubToRecord.Responses) > 0 {
-			proxyStubToRecord.Responses[0].Is = resp
-		}
-		s.recordProxyStub(match, proxyStubToRecord)
+		s.recordProxyResponse(match, proxyStubToRecord, resp)
 	}
 
 	// Merge with defaultResponse if configured
@@ -717,6 +920,48 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.writeResponse(w, resp)
 }
 
+// writeProxyError reports a failed proxy request, using mountebank's error
+// format for ProxyErrors
+func writeProxyError(w http.ResponseWriter, err error) {
+	var proxyErr *ProxyError
+	if errors.As(err, &proxyErr) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		errorResp := map[string]interface{}{
+			"errors": []map[string]interface{}{
+				{
+					"code":    proxyErr.Code,
+					"message": proxyErr.Message,
+				},
+			},
+		}
+		json.NewEncoder(w).Encode(errorResp)
+		return
+	}
+	http.Error(w, fmt.Sprintf("proxy error: %v", err), http.StatusBadGateway)
+}
+
+// recordProxyResponse records a proxied response as the generated stub's
+// response, after applying the proxy's recording options
+func (s *Server) recordProxyResponse(match *MatchResult, stub *models.Stub, resp *models.IsResponse) {
+	// Content-Length may be stale once a JSON body is re-marshaled
+	if resp != nil && resp.Headers != nil {
+		delete(resp.Headers, "Content-Length")
+		delete(resp.Headers, "content-length")
+	}
+
+	recorded, err := recordedResponse(resp, match.Proxy, s.bodyDir)
+	if err != nil {
+		log.Printf("[WARN] imposter %d: not recording proxy response: %v", s.imposter.Port, err)
+		return
+	}
+	if len(stub.Responses) > 0 {
+		stub.Responses[0].Is = recorded
+	}
+	s.recordProxyStub(match, stub)
+	publishProxyRecorded(s.events, s.imposter, stub)
+}
+
 // mergeWithDefault merges a stub response with the default response
 // Missing fields in the stub response are filled from defaultResponse
 func (s *Server) mergeWithDefault(resp, defaultResp *models.IsResponse) *models.IsResponse {
@@ -896,6 +1141,9 @@ func (s *Server) recordProxyStub(match *MatchResult, newStub *models.Stub) {
 
 	switch mode {
 	case "proxyOnce":
+		if match.Proxy != nil && match.Proxy.CollapseResponses && collapseInto(s.imposter.Stubs, match.StubIndex, newStub) {
+			break
+		}
 		// Insert new stub before the proxy stub
 		if match.StubIndex >= 0 && match.StubIndex < len(s.imposter.Stubs) {
 			// Insert at the current position
@@ -931,6 +1179,9 @@ func (s *Server) recordProxyStub(match *MatchResult, newStub *models.Stub) {
 
 		// If no matching stub found, create a new one at the END
 		// This maintains insertion order and ensures proxy stub stays first
+		if !foundMatch && match.Proxy.CollapseResponses {
+			foundMatch = collapseInto(s.imposter.Stubs, match.StubIndex, newStub)
+		}
 		if !foundMatch {
 			s.imposter.Stubs = append(s.imposter.Stubs, *newStub)
 		}
@@ -938,6 +1189,8 @@ func (s *Server) recordProxyStub(match *MatchResult, newStub *models.Stub) {
 
 	// Update matcher with new stubs
 	s.matcher = NewMatcher(s.imposter)
+	s.matcher.SetScenarios(s.scenarios)
+	s.matcher.SetBodyDir(s.bodyDir)
 }
 
 // writeResponse writes the response to the HTTP response writer
@@ -1058,6 +1311,8 @@ func (s *Server) ResetRequestCount() {
 	count := 0
 	s.imposter.NumberOfRequests = &count
 	s.imposter.Requests = nil
+	s.imposter.DroppedRequests = 0
+	s.imposter.SchemaViolations = nil
 }
 
 // UpdateStubs updates the stubs for this imposter
@@ -1066,4 +1321,11 @@ func (s *Server) UpdateStubs(stubs []models.Stub) {
 	defer s.mu.Unlock()
 	s.imposter.Stubs = stubs
 	s.matcher = NewMatcher(s.imposter)
+	s.matcher.SetScenarios(s.scenarios)
+	s.matcher.SetBodyDir(s.bodyDir)
+}
+
+// Scenarios returns the scenario states for this imposter
+func (s *Server) Scenarios() *ScenarioStore {
+	return s.scenarios
 }
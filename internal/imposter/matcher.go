@@ -1,9 +1,11 @@
 package imposter
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -43,6 +45,9 @@ type Matcher struct {
 	jsEngine      *JSEngine              // Shared JS engine
 	imposterState map[string]interface{} // Shared state across all requests
 	regexCache    sync.Map               // Cache for compiled regex patterns
+	scenarios     *ScenarioStore         // Scenario states shared with Server
+	schemas       *SchemaValidator       // Compiled jsonSchema predicate schemas
+	bodyDir       string                 // Directory bodyFile paths are resolved against
 }
 
 // NewMatcher creates a new matcher for an imposter
@@ -51,6 +56,8 @@ func NewMatcher(imp *models.Imposter) *Matcher {
 		imposter:      imp,
 		jsEngine:      NewJSEngine(),
 		imposterState: make(map[string]interface{}),
+		scenarios:     NewScenarioStore(),
+		schemas:       NewSchemaValidator(""),
 	}
 }
 
@@ -59,6 +66,18 @@ func (m *Matcher) SetState(state map[string]interface{}) {
 	m.imposterState = state
 }
 
+// SetScenarios shares the owning server's scenario store with the matcher
+func (m *Matcher) SetScenarios(scenarios *ScenarioStore) {
+	m.scenarios = scenarios
+}
+
+// SetBodyDir sets the directory, the server's --bodyDir, that bodyFile
+// and jsonSchema file paths are resolved against
+func (m *Matcher) SetBodyDir(dir string) {
+	m.bodyDir = dir
+	m.schemas = NewSchemaValidator(dir)
+}
+
 // GetState returns the imposter state reference
 func (m *Matcher) GetState() map[string]interface{} {
 	return m.imposterState
@@ -79,7 +98,10 @@ func (m *Matcher) GetResponse(req *models.Request) *models.IsResponse {
 func (m *Matcher) Match(req *models.Request) *MatchResult {
 	for i := range m.imposter.Stubs {
 		stub := &m.imposter.Stubs[i]
-		if m.matchesAllPredicates(stub, req) {
+		if !m.scenarios.Allows(stub) {
+			continue
+		}
+		if m.matchesAllPredicates(stub, req) && m.scenarios.Advance(stub) {
 			return m.getMatchResult(stub, i)
 		}
 	}
@@ -101,6 +123,75 @@ func (m *Matcher) Match(req *models.Request) *MatchResult {
 	return &MatchResult{Response: &models.IsResponse{StatusCode: 200}, StubIndex: -1}
 }
 
+// ExplainSchemas validates the request against the jsonSchema predicates of
+// the stubs whose other predicates match it, and returns the violations
+// prefixed by stub index. Validation has no side effects, so this can run
+// after Match.
+func (m *Matcher) ExplainSchemas(req *models.Request) []string {
+	var violations []string
+	for i := range m.imposter.Stubs {
+		stub := &m.imposter.Stubs[i]
+		if !m.scenarios.Allows(stub) || !m.matchesOtherPredicates(stub, req) {
+			continue
+		}
+		for _, pred := range stub.Predicates {
+			for _, v := range m.explainPredicate(&pred, req) {
+				violations = append(violations, fmt.Sprintf("stub %d: %s", i, v))
+			}
+		}
+	}
+	return violations
+}
+
+// matchesOtherPredicates reports whether the request matches every
+// predicate of the stub that does not use jsonSchema
+func (m *Matcher) matchesOtherPredicates(stub *models.Stub, req *models.Request) bool {
+	for _, pred := range stub.Predicates {
+		if !usesJSONSchema(&pred) && !m.evaluatePredicate(&pred, req) {
+			return false
+		}
+	}
+	return true
+}
+
+func usesJSONSchema(pred *models.Predicate) bool {
+	if pred.JSONSchema != nil || (pred.Not != nil && usesJSONSchema(pred.Not)) {
+		return true
+	}
+	for _, group := range [][]models.Predicate{pred.And, pred.Or} {
+		for i := range group {
+			if usesJSONSchema(&group[i]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m *Matcher) explainPredicate(pred *models.Predicate, req *models.Request) []string {
+	var violations []string
+	for _, p := range pred.And {
+		violations = append(violations, m.explainPredicate(&p, req)...)
+	}
+	for _, p := range pred.Or {
+		violations = append(violations, m.explainPredicate(&p, req)...)
+	}
+	if pred.Not != nil {
+		violations = append(violations, m.explainPredicate(pred.Not, req)...)
+	}
+	if pred.JSONSchema == nil {
+		return violations
+	}
+
+	effectiveReq := req
+	if pred.JSONPath != nil || pred.XPath != nil {
+		if effectiveReq = m.applySelector(req, pred, pred.KeyCaseSensitive || pred.CaseSensitive); effectiveReq == nil {
+			return violations
+		}
+	}
+	return append(violations, m.schemas.ValidateRequest(pred.JSONSchema, effectiveReq)...)
+}
+
 // getCompiledRegex returns a compiled regex from cache or compiles and caches it
 func (m *Matcher) getCompiledRegex(pattern string) (*regexp.Regexp, error) {
 	// Try to get from cache
@@ -165,6 +256,29 @@ func (m *Matcher) normalizeResponse(resp *models.IsResponse) *models.IsResponse
 	// Make a copy to avoid modifying the original
 	normalized := *resp
 
+	// Bodies recorded to files are read on every response, so they can be
+	// edited without reloading the imposter
+	if normalized.Body == nil && normalized.BodyFile != "" {
+		path, err := bodyPath(m.bodyDir, normalized.BodyFile)
+		var data []byte
+		if err == nil {
+			data, err = os.ReadFile(path)
+		}
+		if err != nil {
+			log.Printf("[WARN] failed to read response bodyFile: %v", err)
+		} else if normalized.Mode == "binary" {
+			normalized.Body = base64.StdEncoding.EncodeToString(data)
+		} else {
+			normalized.Body = string(data)
+		}
+	}
+
+	// Templates are rendered against the structured body; the server
+	// normalizes again once rendering is done
+	if normalized.Template {
+		return &normalized
+	}
+
 	// If body is an object (not a string), convert it to JSON
 	if normalized.Body != nil {
 		switch normalized.Body.(type) {
@@ -282,6 +396,10 @@ func (m *Matcher) evaluatePredicate(pred *models.Predicate, req *models.Request)
 		return m.evaluateExists(pred.Exists, effectiveReq, opts)
 	}
 
+	if pred.JSONSchema != nil {
+		return len(m.schemas.ValidateRequest(pred.JSONSchema, effectiveReq)) == 0
+	}
+
 	if pred.Inject != "" {
 		return m.evaluateInject(pred.Inject, req)
 	}
@@ -79,8 +79,13 @@ func (l *ProtoLoader) LoadProtos(baseDir string, protoFiles []string) error {
 		},
 	}
 
-	// Compile proto files
-	files, err := compiler.Compile(context.Background(), resolvedFiles...)
+	// Compile proto files, named relative to an import path so the
+	// resolver can find them
+	names := make([]string, len(resolvedFiles))
+	for i, f := range resolvedFiles {
+		names[i] = importName(baseDir, f)
+	}
+	files, err := compiler.Compile(context.Background(), names...)
 	if err != nil {
 		return fmt.Errorf("failed to compile proto files: %w", err)
 	}
@@ -119,6 +124,18 @@ func (l *ProtoLoader) getImportPaths(baseDir string, files []string) []string {
 	return result
 }
 
+// importName returns the name to compile a proto file under: its path
+// relative to baseDir if it is inside it, otherwise its file name, which
+// resolves against the file's own directory
+func importName(baseDir, file string) string {
+	if baseDir != "" {
+		if rel, err := filepath.Rel(baseDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.Base(file)
+}
+
 // indexFile indexes services and messages from a proto file
 func (l *ProtoLoader) indexFile(file protoreflect.FileDescriptor) {
 	// Index services
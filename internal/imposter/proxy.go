@@ -2,17 +2,22 @@ package imposter
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
@@ -38,13 +43,20 @@ func (e *ProxyError) Unwrap() error {
 type ProxyHandler struct {
 	client   *http.Client
 	jsEngine *JSEngine
+
+	mu      sync.Mutex
+	clients map[string]*http.Client // clients for proxies with connection options
 }
 
+// defaultProxyTimeout bounds a whole proxy request unless the proxy sets
+// both connectTimeout and readTimeout
+var defaultProxyTimeout = 30 * time.Second
+
 // NewProxyHandler creates a new proxy handler
 func NewProxyHandler() *ProxyHandler {
 	return &ProxyHandler{
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: defaultProxyTimeout,
 			Transport: &http.Transport{
 				// Disable automatic decompression so we can preserve Content-Encoding headers
 				// and binary data as-is from the origin server
@@ -61,21 +73,41 @@ func NewProxyHandler() *ProxyHandler {
 			},
 		},
 		jsEngine: NewJSEngine(),
+		clients:  make(map[string]*http.Client),
 	}
 }
 
-// getClient returns the appropriate HTTP client for the proxy request
-// If mTLS options are specified, creates a new client with custom TLS config
-func (h *ProxyHandler) getClient(proxy *models.ProxyResponse) *http.Client {
-	// Use default client if no mTLS options
-	if proxy.Cert == "" && proxy.Key == "" && proxy.SecureProtocol == "" {
-		return h.client
+// getClient returns the appropriate HTTP client for the proxy request.
+// Proxies with TLS, upstream proxy or timeout options get their own client,
+// shared by every proxy configured the same way so connections are reused.
+func (h *ProxyHandler) getClient(proxy *models.ProxyResponse) (*http.Client, error) {
+	// Use default client if no connection options
+	if proxy.Cert == "" && proxy.Key == "" && proxy.SecureProtocol == "" && proxy.CACert == "" &&
+		proxy.UpstreamProxy == "" && proxy.ConnectTimeout == 0 && proxy.ReadTimeout == 0 && !proxy.HTTP2 {
+		return h.client, nil
+	}
+
+	key := strings.Join([]string{proxy.Cert, proxy.Key, proxy.SecureProtocol, proxy.CACert, proxy.UpstreamProxy,
+		strconv.Itoa(proxy.ConnectTimeout), strconv.Itoa(proxy.ReadTimeout), strconv.FormatBool(proxy.HTTP2)}, "\x00")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if client, ok := h.clients[key]; ok {
+		return client, nil
 	}
 
-	// Create custom TLS config - start with InsecureSkipVerify for self-signed certs
+	// Create custom TLS config - skip verification for self-signed certs
+	// unless a CA bundle says what to trust
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: true,
 	}
+	if proxy.CACert != "" {
+		pool, err := loadCABundle(proxy.CACert)
+		if err != nil {
+			return nil, &ProxyError{Code: "invalid proxy", Message: err.Error(), Err: err}
+		}
+		tlsConfig.InsecureSkipVerify = false
+		tlsConfig.RootCAs = pool
+	}
 
 	// Load client certificate if provided
 	if proxy.Cert != "" && proxy.Key != "" {
@@ -105,18 +137,66 @@ func (h *ProxyHandler) getClient(proxy *models.ProxyResponse) *http.Client {
 	}
 
 	// Create transport with custom TLS config
+	dialer := &net.Dialer{
+		Timeout: time.Duration(proxy.ConnectTimeout) * time.Millisecond,
+	}
 	transport := &http.Transport{
-		TLSClientConfig:    tlsConfig,
-		DisableCompression: true, // Preserve Content-Encoding headers
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   time.Duration(proxy.ConnectTimeout) * time.Millisecond,
+		ResponseHeaderTimeout: time.Duration(proxy.ReadTimeout) * time.Millisecond,
+		DisableCompression:    true, // Preserve Content-Encoding headers
+		ForceAttemptHTTP2:     proxy.HTTP2,
+	}
+
+	// Route through an HTTP(S) or SOCKS5 proxy if configured
+	if proxy.UpstreamProxy != "" {
+		upstream, err := url.Parse(proxy.UpstreamProxy)
+		if err != nil || upstream.Host == "" {
+			return nil, &ProxyError{
+				Code:    "invalid proxy",
+				Message: fmt.Sprintf("Invalid upstreamProxy %q", proxy.UpstreamProxy),
+				Err:     err,
+			}
+		}
+		switch upstream.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, &ProxyError{
+				Code:    "invalid proxy",
+				Message: fmt.Sprintf("Unsupported upstreamProxy scheme %q (use http, https or socks5)", upstream.Scheme),
+			}
+		}
+		transport.Proxy = http.ProxyURL(upstream)
 	}
 
-	return &http.Client{
+	// Connect and read timeouts together replace the blanket limit, so slow
+	// downloads that keep arriving aren't cut off; either one alone would
+	// leave a silent target hanging the request
+	timeout := defaultProxyTimeout
+	if proxy.ConnectTimeout > 0 && proxy.ReadTimeout > 0 {
+		timeout = 0
+	}
+
+	client := &http.Client{
 		Transport: transport,
-		Timeout:   30 * time.Second,
+		Timeout:   timeout,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
 	}
+	h.clients[key] = client
+	return client, nil
+}
+
+// loadCABundle reads an inline PEM CA bundle. Paths aren't accepted, so an
+// imposter can't make the server read its files.
+func loadCABundle(bundle string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(bundle)) {
+		return nil, fmt.Errorf("caCert contains no PEM certificates")
+	}
+	return pool, nil
 }
 
 // ProxyResult contains the result of a proxy operation
@@ -126,12 +206,88 @@ type ProxyResult struct {
 	ShouldRecord  bool
 }
 
+// defaultMaxRecordedBodySize caps the body a proxy records when it doesn't
+// set maxRecordedBodySize
+const defaultMaxRecordedBodySize = 10 << 20
+
 // Execute proxies a request and returns the response
 func (h *ProxyHandler) Execute(req *models.Request, proxy *models.ProxyResponse, originalReq *http.Request) (*ProxyResult, error) {
+	resp, elapsed, err := h.send(req, proxy, originalReq, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Read response body
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proxy response: %w", err)
+	}
+
+	result := h.result(req, proxy, responseOf(resp, respBody), elapsed)
+	limit := proxy.MaxRecordedBodySize
+	if limit <= 0 {
+		limit = defaultMaxRecordedBodySize
+	}
+	if result.ShouldRecord && len(respBody) > limit {
+		log.Printf("[WARN] proxy response from %s is larger than %d bytes, not recording it", proxy.To, limit)
+		result.ShouldRecord = false
+		result.GeneratedStub = nil
+	}
+	return result, nil
+}
+
+// Stream proxies a request for a proxyTransparent response, writing the
+// target's response to w as it arrives, so large downloads and event
+// streams pass straight through. The response goes out as the target sent
+// it, without the imposter's defaultResponse merged in. Errors returned
+// before anything is written can still be reported to the client; once
+// streaming has started, failures just end the response.
+func (h *ProxyHandler) Stream(w http.ResponseWriter, req *models.Request, proxy *models.ProxyResponse, originalReq *http.Request) error {
+	resp, _, err := h.send(req, proxy, originalReq, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	for k, v := range resp.Header {
+		if !isHopByHopHeader(k) {
+			w.Header()[k] = v
+		}
+	}
+	// Match writeResponse, which closes connections unless told otherwise
+	w.Header().Set("Connection", "close")
+	w.WriteHeader(resp.StatusCode)
+
+	// A stream may outlast the imposter's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return nil // Client went away
+			}
+			rc.Flush()
+		}
+		if readErr != nil {
+			return nil // Done, or the target went away mid-response
+		}
+	}
+}
+
+// send forwards a request to the proxy's target and returns the response
+// with the time it took to arrive. A streaming send has no overall time
+// limit, only one on waiting for the response to start, and is cancelled
+// if the client goes away. With a readTimeout, the body fails once a read
+// waits longer than it for data.
+func (h *ProxyHandler) send(req *models.Request, proxy *models.ProxyResponse, originalReq *http.Request, streaming bool) (*http.Response, time.Duration, error) {
 	// Build target URL
 	targetURL, err := h.buildTargetURL(proxy.To, req, originalReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build target URL: %w", err)
+		return nil, 0, fmt.Errorf("failed to build target URL: %w", err)
 	}
 
 	// Handle request body - decode if binary mode
@@ -150,7 +306,7 @@ func (h *ProxyHandler) Execute(req *models.Request, proxy *models.ProxyResponse,
 	// Create proxy request
 	proxyReq, err := http.NewRequest(req.Method, targetURL, bodyReader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create proxy request: %w", err)
+		return nil, 0, fmt.Errorf("failed to create proxy request: %w", err)
 	}
 
 	// Copy headers from original request (except Host - it's handled specially below)
@@ -187,17 +343,36 @@ func (h *ProxyHandler) Execute(req *models.Request, proxy *models.ProxyResponse,
 	proxyReq.Header.Del("transfer-encoding")
 	proxyReq.Header.Del("upgrade")
 
-	// Get the appropriate client (default or mTLS-configured)
-	client := h.getClient(proxy)
+	// Get the appropriate client (default or configured by the proxy)
+	client, err := h.getClient(proxy)
+	if err != nil {
+		return nil, 0, err
+	}
+	parent := context.Background()
+	if streaming && originalReq != nil {
+		parent = originalReq.Context()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	proxyReq = proxyReq.WithContext(ctx)
+	if streaming {
+		if client.Timeout > 0 {
+			timer := time.AfterFunc(client.Timeout, cancel)
+			defer timer.Stop()
+			unlimited := *client
+			unlimited.Timeout = 0
+			client = &unlimited
+		}
+	}
 
 	// Execute request
 	startTime := time.Now()
 	resp, err := client.Do(proxyReq)
 	if err != nil {
+		cancel()
 		// Check for DNS resolution errors
 		var dnsErr *net.DNSError
 		if errors.As(err, &dnsErr) {
-			return nil, &ProxyError{
+			return nil, 0, &ProxyError{
 				Code:    "invalid proxy",
 				Message: fmt.Sprintf("Cannot resolve %q", proxy.To),
 				Err:     err,
@@ -208,31 +383,73 @@ func (h *ProxyHandler) Execute(req *models.Request, proxy *models.ProxyResponse,
 		if errors.As(err, &opErr) {
 			if opErr.Op == "dial" {
 				// Could be connection refused or other dial errors
-				return nil, &ProxyError{
+				return nil, 0, &ProxyError{
 					Code:    "invalid proxy",
 					Message: fmt.Sprintf("Cannot connect to %q", proxy.To),
 					Err:     err,
 				}
 			}
 		}
-		return nil, fmt.Errorf("proxy request failed: %w", err)
+		return nil, 0, fmt.Errorf("proxy request failed: %w", err)
 	}
-	defer resp.Body.Close()
-	elapsed := time.Since(startTime)
+	resp.Body = cancelOnClose{resp.Body, cancel}
+	if proxy.ReadTimeout > 0 {
+		resp.Body = newIdleTimeoutBody(resp.Body, time.Duration(proxy.ReadTimeout)*time.Millisecond, cancel)
+	}
+	return resp, time.Since(startTime), nil
+}
 
-	// Read response body
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read proxy response: %w", err)
+// idleTimeoutBody cancels a proxy request when a read of its body waits
+// longer than timeout for data. Time between reads, such as time spent
+// writing to a slow client, doesn't count.
+type idleTimeoutBody struct {
+	io.ReadCloser
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func newIdleTimeoutBody(body io.ReadCloser, timeout time.Duration, cancel context.CancelFunc) *idleTimeoutBody {
+	timer := time.AfterFunc(timeout, cancel)
+	timer.Stop()
+	return &idleTimeoutBody{ReadCloser: body, timer: timer, timeout: timeout}
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	b.timer.Reset(b.timeout)
+	defer b.timer.Stop()
+	return b.ReadCloser.Read(p)
+}
+
+// cancelOnClose releases a proxy request's context with its body
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// isHopByHopHeader reports whether a response header applies only to the
+// connection it arrived on
+func isHopByHopHeader(name string) bool {
+	switch strings.ToLower(name) {
+	case "connection", "keep-alive", "transfer-encoding":
+		return true
 	}
+	return false
+}
 
+// responseOf converts a target response and its body to an is response
+func responseOf(resp *http.Response, respBody []byte) *models.IsResponse {
 	// Convert response headers
 	headers := make(map[string]interface{})
 	for k, v := range resp.Header {
 		if len(v) > 0 {
 			// Skip hop-by-hop headers
-			lk := strings.ToLower(k)
-			if lk == "connection" || lk == "keep-alive" || lk == "transfer-encoding" {
+			if isHopByHopHeader(k) {
 				continue
 			}
 			// Support multi-value headers
@@ -279,6 +496,11 @@ func (h *ProxyHandler) Execute(req *models.Request, proxy *models.ProxyResponse,
 		}
 	}
 
+	return isResp
+}
+
+// result decides what to record for a proxied response based on the mode
+func (h *ProxyHandler) result(req *models.Request, proxy *models.ProxyResponse, isResp *models.IsResponse, elapsed time.Duration) *ProxyResult {
 	result := &ProxyResult{
 		Response: isResp,
 	}
@@ -300,7 +522,7 @@ func (h *ProxyHandler) Execute(req *models.Request, proxy *models.ProxyResponse,
 		result.ShouldRecord = false
 	}
 
-	return result, nil
+	return result
 }
 
 // buildTargetURL constructs the target URL for proxying
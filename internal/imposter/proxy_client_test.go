@@ -0,0 +1,325 @@
+package imposter
+
+import (
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+func proxyRequest() *models.Request {
+	return &models.Request{Method: "GET", Path: "/hello", Headers: map[string]string{}}
+}
+
+// TestProxyCACert tests verifying the target against a CA bundle
+func TestProxyCACert(t *testing.T) {
+	target := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "trusted")
+	}))
+	defer target.Close()
+	trusted := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: target.Certificate().Raw}))
+
+	// httptest servers share one certificate, so generate an unrelated one
+	other, err := generateSelfSignedCert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	untrusted := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: other.Certificate[0]}))
+
+	tests := []struct {
+		name    string
+		caCert  string
+		wantErr string
+	}{
+		{"trusted CA", trusted, ""},
+		{"untrusted CA", untrusted, "certificate"},
+		{"path", "/etc/ssl/certs/ca-certificates.crt", "no PEM certificates"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewProxyHandler()
+			result, err := h.Execute(proxyRequest(), &models.ProxyResponse{To: target.URL, CACert: tt.caCert}, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Response.Body != "trusted" {
+				t.Errorf("expected target body, got %v", result.Response.Body)
+			}
+		})
+	}
+}
+
+// TestProxyUpstreamProxy tests routing proxy requests through an HTTP proxy
+func TestProxyUpstreamProxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "from target")
+	}))
+	defer target.Close()
+
+	var forwarded atomic.Int32
+	forwardProxy := httptest.NewServer(&httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			forwarded.Add(1)
+			r.Out.URL = r.In.URL
+		},
+	})
+	defer forwardProxy.Close()
+
+	h := NewProxyHandler()
+	result, err := h.Execute(proxyRequest(), &models.ProxyResponse{To: target.URL, UpstreamProxy: forwardProxy.URL}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Response.Body != "from target" || forwarded.Load() != 1 {
+		t.Errorf("expected request via upstream proxy, got body %v after %d forwards", result.Response.Body, forwarded.Load())
+	}
+
+	_, err = h.Execute(proxyRequest(), &models.ProxyResponse{To: target.URL, UpstreamProxy: "ftp://proxy:21"}, nil)
+	var proxyErr *ProxyError
+	if !errors.As(err, &proxyErr) || proxyErr.Code != "invalid proxy" {
+		t.Errorf("expected invalid proxy error for unsupported scheme, got %v", err)
+	}
+}
+
+// socks5Proxy starts a SOCKS5 proxy without authentication that counts the
+// connections it relays
+func socks5Proxy(t *testing.T, relayed *atomic.Int32) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				// Greeting: version, methods; choose no authentication
+				head := make([]byte, 2)
+				if _, err := io.ReadFull(conn, head); err != nil {
+					return
+				}
+				io.ReadFull(conn, make([]byte, head[1]))
+				conn.Write([]byte{5, 0})
+
+				// Request: version, CONNECT, reserved, address type, address, port
+				req := make([]byte, 4)
+				if _, err := io.ReadFull(conn, req); err != nil {
+					return
+				}
+				var host string
+				switch req[3] {
+				case 1:
+					ip := make([]byte, 4)
+					io.ReadFull(conn, ip)
+					host = net.IP(ip).String()
+				case 3:
+					n := make([]byte, 1)
+					io.ReadFull(conn, n)
+					name := make([]byte, n[0])
+					io.ReadFull(conn, name)
+					host = string(name)
+				default:
+					return
+				}
+				port := make([]byte, 2)
+				io.ReadFull(conn, port)
+
+				target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port[0])<<8|int(port[1]))))
+				if err != nil {
+					conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer target.Close()
+				relayed.Add(1)
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(target, conn)
+				io.Copy(conn, target)
+			}()
+		}
+	}()
+	return "socks5://" + ln.Addr().String()
+}
+
+// TestProxySOCKS5Upstream tests routing proxy requests through a SOCKS5 proxy
+func TestProxySOCKS5Upstream(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "from target")
+	}))
+	defer target.Close()
+
+	var relayed atomic.Int32
+	upstream := socks5Proxy(t, &relayed)
+
+	h := NewProxyHandler()
+	result, err := h.Execute(proxyRequest(), &models.ProxyResponse{To: target.URL, UpstreamProxy: upstream}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Response.Body != "from target" || relayed.Load() != 1 {
+		t.Errorf("expected request via SOCKS5 proxy, got body %v after %d relays", result.Response.Body, relayed.Load())
+	}
+}
+
+// TestProxyConnectTimeout tests giving up on a target that accepts the
+// connection but never completes the TLS handshake, and that a connect
+// timeout alone leaves the overall limit in place
+func TestProxyConnectTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { defaultProxyTimeout = timeout }(defaultProxyTimeout)
+	defaultProxyTimeout = 300 * time.Millisecond
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close() // Never says anything
+		}
+	}()
+
+	tests := []struct {
+		name  string
+		to    string
+		limit time.Duration
+	}{
+		{"TLS handshake", "https://" + ln.Addr().String(), 250 * time.Millisecond},
+		{"silent target", "http://" + ln.Addr().String(), time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewProxyHandler()
+			start := time.Now()
+			if _, err := h.Execute(proxyRequest(), &models.ProxyResponse{To: tt.to, ConnectTimeout: 50}, nil); err == nil {
+				t.Fatal("expected a timeout error")
+			}
+			if elapsed := time.Since(start); elapsed > tt.limit {
+				t.Errorf("expected to give up within %v, took %v", tt.limit, elapsed)
+			}
+		})
+	}
+}
+
+// TestProxyReadTimeout tests giving up on a target that is slow to respond
+func TestProxyReadTimeout(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		fmt.Fprint(w, "late")
+	}))
+	defer target.Close()
+
+	h := NewProxyHandler()
+	start := time.Now()
+	_, err := h.Execute(proxyRequest(), &models.ProxyResponse{To: target.URL, ReadTimeout: 50}, nil)
+	if err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Errorf("expected to give up after about 50ms, took %v", elapsed)
+	}
+
+	if _, err := h.Execute(proxyRequest(), &models.ProxyResponse{To: target.URL, ReadTimeout: 1000}, nil); err != nil {
+		t.Errorf("expected a generous timeout to succeed, got %v", err)
+	}
+}
+
+// TestProxyHTTP2 tests negotiating HTTP/2 only when asked
+func TestProxyHTTP2(t *testing.T) {
+	target := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Proto)
+	}))
+	target.EnableHTTP2 = true
+	target.StartTLS()
+	defer target.Close()
+
+	h := NewProxyHandler()
+	for _, tt := range []struct {
+		http2 bool
+		want  string
+	}{{false, "HTTP/1.1"}, {true, "HTTP/2.0"}} {
+		result, err := h.Execute(proxyRequest(), &models.ProxyResponse{To: target.URL, HTTP2: tt.http2}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Response.Body != tt.want {
+			t.Errorf("http2=%v: expected %s, got %v", tt.http2, tt.want, result.Response.Body)
+		}
+	}
+}
+
+// TestProxyReadTimeoutIdleBody tests that readTimeout applies to each wait
+// on the body rather than to the whole download
+func TestProxyReadTimeoutIdleBody(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pause, _ := time.ParseDuration(strings.TrimPrefix(r.URL.Path, "/"))
+		for range 4 {
+			fmt.Fprint(w, "chunk ")
+			w.(http.Flusher).Flush()
+			time.Sleep(pause)
+		}
+	}))
+	defer target.Close()
+
+	tests := []struct {
+		name    string
+		pause   string
+		wantErr bool
+	}{
+		{"trickling body", "50ms", false},
+		{"stalled body", "400ms", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := proxyRequest()
+			req.Path = "/" + tt.pause
+			h := NewProxyHandler()
+			start := time.Now()
+			result, err := h.Execute(req, &models.ProxyResponse{To: target.URL, ConnectTimeout: 100, ReadTimeout: 150}, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected the stalled body to time out, got %v", result.Response.Body)
+				}
+				if elapsed := time.Since(start); elapsed > 350*time.Millisecond {
+					t.Errorf("expected to give up after about 150ms, took %v", elapsed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Response.Body != strings.Repeat("chunk ", 4) {
+				t.Errorf("expected the whole body, got %v", result.Response.Body)
+			}
+		})
+	}
+}
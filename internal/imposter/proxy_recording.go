@@ -0,0 +1,290 @@
+package imposter
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// redactedValue replaces redacted header and field values in recordings
+const redactedValue = "[REDACTED]"
+
+// recordedResponse returns the copy of a proxied response to store in the
+// recorded stub, with the proxy's scrub, redact and bodyDir options applied.
+// bodyDir is resolved against root, the server's --bodyDir. The response
+// sent to the client is left as it is.
+func recordedResponse(resp *models.IsResponse, proxy *models.ProxyResponse, root string) (*models.IsResponse, error) {
+	if resp == nil || !hasRecordingOptions(proxy) {
+		return resp, nil
+	}
+
+	recorded := *resp
+	if len(resp.Headers) > 0 {
+		scrub := headerSet(proxy.ScrubHeaders)
+		redact := headerSet(proxy.RedactHeaders)
+		recorded.Headers = make(map[string]interface{}, len(resp.Headers))
+		for name, value := range resp.Headers {
+			switch lower := strings.ToLower(name); {
+			case scrub[lower]:
+			case redact[lower]:
+				recorded.Headers[name] = redactedValue
+			default:
+				recorded.Headers[name] = value
+			}
+		}
+	}
+
+	if recorded.Mode != "binary" && (len(proxy.ScrubFields) > 0 || len(proxy.RedactFields) > 0) {
+		recorded.Body = editJSONBody(recorded.Body, proxy.ScrubFields, proxy.RedactFields)
+	}
+
+	if proxy.BodyDir != "" && recorded.Body != nil {
+		dir, err := bodyPath(root, proxy.BodyDir)
+		if err != nil {
+			return nil, err
+		}
+		name, err := writeBodyFile(dir, &recorded)
+		if err != nil {
+			return nil, err
+		}
+		recorded.Body = nil
+		recorded.BodyFile = filepath.Join(proxy.BodyDir, name)
+	}
+
+	return &recorded, nil
+}
+
+func hasRecordingOptions(proxy *models.ProxyResponse) bool {
+	return len(proxy.ScrubHeaders) > 0 || len(proxy.RedactHeaders) > 0 ||
+		len(proxy.ScrubFields) > 0 || len(proxy.RedactFields) > 0 || proxy.BodyDir != ""
+}
+
+func headerSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[strings.ToLower(name)] = true
+	}
+	return set
+}
+
+// editJSONBody removes and redacts fields in a JSON body, which may be parsed
+// already or still a string. Bodies that aren't JSON are returned unchanged.
+func editJSONBody(body interface{}, scrub, redact []string) interface{} {
+	text, isString := body.(string)
+	if isString {
+		var parsed interface{}
+		if json.Unmarshal([]byte(text), &parsed) != nil {
+			return body
+		}
+		body = parsed
+	} else {
+		// Copy so the client's response isn't edited too
+		data, err := json.Marshal(body)
+		if err != nil {
+			return body
+		}
+		json.Unmarshal(data, &body)
+	}
+
+	for _, path := range scrub {
+		editField(body, fieldPath(path), nil)
+	}
+	for _, path := range redact {
+		editField(body, fieldPath(path), func(interface{}) interface{} { return redactedValue })
+	}
+
+	if isString {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return text
+		}
+		return string(data)
+	}
+	return body
+}
+
+// fieldPath splits a dot path such as "user.token", allowing a JSONPath-style
+// "$." prefix
+func fieldPath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	return strings.Split(path, ".")
+}
+
+// editField walks path through objects, applying it to every element of any
+// array on the way. A nil replace deletes the field.
+func editField(value interface{}, path []string, replace func(interface{}) interface{}) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			editField(item, path, replace)
+		}
+	case map[string]interface{}:
+		field, ok := v[path[0]]
+		if !ok {
+			return
+		}
+		if len(path) > 1 {
+			editField(field, path[1:], replace)
+		} else if replace == nil {
+			delete(v, path[0])
+		} else {
+			v[path[0]] = replace(field)
+		}
+	}
+}
+
+// errNoBodyDir rejects bodyFile, bodyDir and schema files on a server
+// without a directory to keep them in
+var errNoBodyDir = errors.New("bodyFile, bodyDir and jsonSchema files require the server to be started with --bodyDir")
+
+// bodyPath resolves a bodyFile or bodyDir path against root, the server's
+// --bodyDir, refusing absolute paths and paths that climb out of it
+func bodyPath(root, name string) (string, error) {
+	if root == "" {
+		return "", errNoBodyDir
+	}
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("%q must be a relative path inside --bodyDir", name)
+	}
+	return filepath.Join(root, name), nil
+}
+
+// ValidateBodyPaths checks the bodyFile and proxy bodyDir fields of the
+// responses in stubs and defaultResponse against root, the server's
+// --bodyDir, so that an imposter cannot read or write files outside it
+func ValidateBodyPaths(root string, stubs []models.Stub, defaultResponse *models.Response) error {
+	for i := range stubs {
+		for j := range stubs[i].Responses {
+			if err := validateResponseBodyPaths(root, &stubs[i].Responses[j]); err != nil {
+				return fmt.Errorf("stubs[%d].responses[%d]: %w", i, j, err)
+			}
+		}
+	}
+	if defaultResponse != nil {
+		if err := validateResponseBodyPaths(root, defaultResponse); err != nil {
+			return fmt.Errorf("defaultResponse: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateResponseBodyPaths(root string, resp *models.Response) error {
+	if resp.Is != nil && resp.Is.BodyFile != "" {
+		if _, err := bodyPath(root, resp.Is.BodyFile); err != nil {
+			return fmt.Errorf("bodyFile: %w", err)
+		}
+	}
+	if resp.Proxy != nil && resp.Proxy.BodyDir != "" {
+		if _, err := bodyPath(root, resp.Proxy.BodyDir); err != nil {
+			return fmt.Errorf("bodyDir: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeBodyFile stores a response body in dir under the SHA-256 of its
+// content, so identical bodies share a file, and returns the file's name
+func writeBodyFile(dir string, resp *models.IsResponse) (string, error) {
+	var data []byte
+	ext := ".txt"
+	switch body := resp.Body.(type) {
+	case string:
+		data = []byte(body)
+		if resp.Mode == "binary" {
+			decoded, err := base64.StdEncoding.DecodeString(body)
+			if err != nil {
+				return "", fmt.Errorf("invalid binary body: %w", err)
+			}
+			data, ext = decoded, ".bin"
+		} else if json.Valid(data) && strings.Contains(contentTypeOf(resp), "json") {
+			ext = ".json"
+		}
+	default:
+		var err error
+		if data, err = models.MarshalBody(body); err != nil {
+			return "", fmt.Errorf("failed to encode body: %w", err)
+		}
+		ext = ".json"
+	}
+
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:]) + ext
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return name, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create bodyDir: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write body file: %w", err)
+	}
+	return name, nil
+}
+
+func contentTypeOf(resp *models.IsResponse) string {
+	for name, value := range resp.Headers {
+		if strings.EqualFold(name, "Content-Type") {
+			if s, ok := value.(string); ok {
+				return strings.ToLower(s)
+			}
+		}
+	}
+	return ""
+}
+
+// collapseInto merges a newly recorded stub into an existing recorded stub
+// with identical responses by OR-ing their predicates. It reports whether a
+// stub was found; the new stub needs no recording if so.
+func collapseInto(stubs []models.Stub, skip int, newStub *models.Stub) bool {
+	responses, err := json.Marshal(newStub.Responses)
+	if err != nil {
+		return false
+	}
+
+	for i := range stubs {
+		if i == skip || !stubs[i].IsProxyGenerated {
+			continue
+		}
+		existing, err := json.Marshal(stubs[i].Responses)
+		if err != nil || string(existing) != string(responses) {
+			continue
+		}
+
+		alternatives := orAlternatives(stubs[i].Predicates)
+		candidate := combinePredicates(newStub.Predicates)
+		for _, alt := range alternatives {
+			if predicatesEqual([]models.Predicate{alt}, []models.Predicate{candidate}) {
+				return true
+			}
+		}
+		stubs[i].Predicates = []models.Predicate{{Or: append(alternatives, candidate)}}
+		return true
+	}
+	return false
+}
+
+// orAlternatives returns the alternatives of a collapsed stub's predicates,
+// or the predicates as a single alternative if the stub isn't collapsed yet
+func orAlternatives(predicates []models.Predicate) []models.Predicate {
+	if len(predicates) == 1 && len(predicates[0].Or) > 0 {
+		return predicates[0].Or
+	}
+	return []models.Predicate{combinePredicates(predicates)}
+}
+
+// combinePredicates turns a stub's predicate list into one predicate
+func combinePredicates(predicates []models.Predicate) models.Predicate {
+	if len(predicates) == 1 {
+		return predicates[0]
+	}
+	return models.Predicate{And: predicates}
+}
@@ -0,0 +1,171 @@
+package imposter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// TestEditJSONBody tests scrubbing and redacting fields by dot path
+func TestEditJSONBody(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   interface{}
+		scrub  []string
+		redact []string
+		want   string
+	}{
+		{
+			name:   "string body",
+			body:   `{"token": "t", "keep": 1}`,
+			redact: []string{"token"},
+			want:   `{"keep":1,"token":"[REDACTED]"}`,
+		},
+		{
+			name:  "nested path through arrays",
+			body:  map[string]interface{}{"items": []interface{}{map[string]interface{}{"id": 1.0, "etag": "a"}, map[string]interface{}{"id": 2.0}}},
+			scrub: []string{"$.items.etag"},
+			want:  `{"items":[{"id":1},{"id":2}]}`,
+		},
+		{
+			name:   "missing field",
+			body:   `{"a": {"b": 1}}`,
+			redact: []string{"a.c.d", "x"},
+			want:   `{"a":{"b":1}}`,
+		},
+		{
+			name:   "not JSON",
+			body:   "plain text",
+			redact: []string{"token"},
+			want:   `"plain text"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := editJSONBody(tt.body, tt.scrub, tt.redact)
+			var encoded []byte
+			if s, ok := got.(string); ok && json.Valid([]byte(s)) {
+				var v interface{}
+				json.Unmarshal([]byte(s), &v)
+				encoded, _ = json.Marshal(v)
+			} else {
+				encoded, _ = json.Marshal(got)
+			}
+			if string(encoded) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, encoded)
+			}
+		})
+	}
+}
+
+// TestRecordedResponse tests that recording options leave the original response alone
+func TestRecordedResponse(t *testing.T) {
+	root := t.TempDir()
+	original := &models.IsResponse{
+		StatusCode: 200,
+		Headers:    map[string]interface{}{"Set-Cookie": "s=1", "Date": "today", "Content-Type": "application/json"},
+		Body:       map[string]interface{}{"secret": "x"},
+	}
+	proxy := &models.ProxyResponse{
+		ScrubHeaders:  []string{"date"},
+		RedactHeaders: []string{"set-cookie"},
+		RedactFields:  []string{"secret"},
+		BodyDir:       "bodies",
+	}
+
+	recorded, err := recordedResponse(original, proxy, root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := recorded.Headers["Date"]; ok {
+		t.Error("expected Date to be scrubbed")
+	}
+	if recorded.Headers["Set-Cookie"] != redactedValue {
+		t.Errorf("expected Set-Cookie redacted, got %v", recorded.Headers["Set-Cookie"])
+	}
+	if recorded.Body != nil || filepath.Dir(recorded.BodyFile) != "bodies" || filepath.Ext(recorded.BodyFile) != ".json" {
+		t.Errorf("expected body moved to a .json file in bodyDir, got %+v", recorded)
+	}
+	data, _ := os.ReadFile(filepath.Join(root, recorded.BodyFile))
+	if !json.Valid(data) || string(data) == "" {
+		t.Errorf("expected JSON body file, got %s", data)
+	}
+
+	if original.Headers["Date"] != "today" || original.Body.(map[string]interface{})["secret"] != "x" {
+		t.Error("expected the client response to be unchanged")
+	}
+
+	// Identical bodies share a content-addressed file
+	again, _ := recordedResponse(original, proxy, root)
+	if again.BodyFile != recorded.BodyFile {
+		t.Errorf("expected the same body file, got %s and %s", recorded.BodyFile, again.BodyFile)
+	}
+}
+
+// TestBodyPaths tests confining bodyFile and bodyDir paths to --bodyDir
+func TestBodyPaths(t *testing.T) {
+	tests := []struct {
+		name string
+		root string
+		path string
+		err  string
+	}{
+		{"relative", "/srv/bodies", "recorded/a.json", ""},
+		{"clean within root", "/srv/bodies", "recorded/../a.json", ""},
+		{"no root", "", "a.json", "--bodyDir"},
+		{"absolute", "/srv/bodies", "/etc/passwd", "relative path"},
+		{"climbs out", "/srv/bodies", "../../etc/passwd", "relative path"},
+		{"climbs out after cleaning", "/srv/bodies", "a/../../b", "relative path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubs := []models.Stub{{Responses: []models.Response{{Is: &models.IsResponse{BodyFile: tt.path}}}}}
+			err := ValidateBodyPaths(tt.root, stubs, nil)
+			if tt.err == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+
+			proxy := &models.Response{Proxy: &models.ProxyResponse{To: "http://origin", BodyDir: tt.path}}
+			if err := ValidateBodyPaths(tt.root, nil, proxy); (err == nil) != (tt.err == "") {
+				t.Errorf("expected the proxy bodyDir to be treated the same, got %v", err)
+			}
+		})
+	}
+}
+
+// TestCollapseInto tests OR-ing predicates of stubs with identical responses
+func TestCollapseInto(t *testing.T) {
+	response := []models.Response{{Is: &models.IsResponse{Body: "same"}}}
+	pathIs := func(path string) []models.Predicate {
+		return []models.Predicate{{Equals: map[string]interface{}{"path": path}}}
+	}
+	stubs := []models.Stub{
+		{Responses: []models.Response{{Proxy: &models.ProxyResponse{To: "http://origin"}}}},
+		{Predicates: pathIs("/a"), Responses: response, IsProxyGenerated: true},
+	}
+
+	if !collapseInto(stubs, 0, &models.Stub{Predicates: pathIs("/b"), Responses: response}) {
+		t.Fatal("expected /b to collapse into /a")
+	}
+	if !collapseInto(stubs, 0, &models.Stub{Predicates: pathIs("/b"), Responses: response}) {
+		t.Fatal("expected repeated /b to be absorbed")
+	}
+	if len(stubs[1].Predicates) != 1 || len(stubs[1].Predicates[0].Or) != 2 {
+		t.Errorf("expected one OR predicate with 2 alternatives, got %+v", stubs[1].Predicates)
+	}
+
+	other := []models.Response{{Is: &models.IsResponse{Body: "different"}}}
+	if collapseInto(stubs, 0, &models.Stub{Predicates: pathIs("/c"), Responses: other}) {
+		t.Error("expected a different response not to collapse")
+	}
+}
@@ -0,0 +1,155 @@
+package imposter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+func proxyImposter(to string, proxy models.ProxyResponse, behaviors ...models.Behavior) *models.Imposter {
+	proxy.To = to
+	return &models.Imposter{
+		Protocol: "http",
+		Port:     0,
+		Stubs: []models.Stub{{
+			Responses: []models.Response{{Proxy: &proxy, Behaviors: behaviors}},
+		}},
+	}
+}
+
+// TestProxyStreamsTransparentResponses tests that events reach the client
+// before the target finishes responding
+func TestProxyStreamsTransparentResponses(t *testing.T) {
+	release := make(chan struct{})
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-release
+		fmt.Fprint(w, "data: second\n\n")
+	}))
+	defer target.Close()
+	defer close(release)
+
+	srv, err := NewServer(proxyImposter(target.URL, models.ProxyResponse{Mode: "proxyTransparent"}), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	front := httptest.NewServer(srv)
+	defer front.Close()
+
+	resp, err := http.Get(front.URL + "/events")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected target headers, got Content-Type %q", ct)
+	}
+
+	line := make(chan string, 1)
+	go func() {
+		text, _ := bufio.NewReader(resp.Body).ReadString('\n')
+		line <- text
+	}()
+	select {
+	case text := <-line:
+		if text != "data: first\n" {
+			t.Errorf("expected first event, got %q", text)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("first event was held back until the target finished")
+	}
+}
+
+// TestProxyRecordingLimit tests that proxied bodies are recorded only up to
+// maxRecordedBodySize
+func TestProxyRecordingLimit(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Repeat("x", len(r.URL.Path)))
+	}))
+	defer target.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		behaviors  []models.Behavior
+		wantStubs  int
+		wantLength int
+	}{
+		{"small body recorded", "/abc", nil, 2, 4},
+		{"large body passed on but not recorded", "/" + strings.Repeat("a", 40), nil, 1, 41},
+		{"large body with behaviors not recorded", "/" + strings.Repeat("a", 40), []models.Behavior{{Wait: 1}}, 1, 41},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imp := proxyImposter(target.URL, models.ProxyResponse{Mode: "proxyAlways", MaxRecordedBodySize: 16}, tt.behaviors...)
+			srv, err := NewServer(imp, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			front := httptest.NewServer(srv)
+			defer front.Close()
+
+			resp, err := http.Get(front.URL + tt.path)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if len(body) != tt.wantLength {
+				t.Errorf("expected the full %d byte body, got %d", tt.wantLength, len(body))
+			}
+			if n := len(srv.GetImposter().Stubs); n != tt.wantStubs {
+				t.Errorf("expected %d stubs, got %d", tt.wantStubs, n)
+			}
+		})
+	}
+}
+
+// TestProxyStreamOutlastsWriteTimeout tests that a stream through a running
+// imposter isn't cut off by the imposter's write timeout
+func TestProxyStreamOutlastsWriteTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { httpWriteTimeout = timeout }(httpWriteTimeout)
+	httpWriteTimeout = 200 * time.Millisecond
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := range 6 {
+			fmt.Fprintf(w, "data: %d\n\n", i)
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer target.Close()
+
+	manager := NewManager()
+	imp := proxyImposter(target.URL, models.ProxyResponse{Mode: "proxyTransparent"})
+	if err := manager.Start(imp); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer manager.StopAll()
+
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d/events", imp.Port))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("stream cut off after %q: %v", body, err)
+	}
+	if !strings.HasSuffix(string(body), "data: 5\n\n") {
+		t.Errorf("expected every event, got %q", body)
+	}
+}
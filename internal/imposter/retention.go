@@ -0,0 +1,116 @@
+package imposter
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/metrics"
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// requestLog applies an imposter's retention policy to the requests its
+// server records. It remembers the size of each request it keeps so the
+// byte bound doesn't re-measure the whole log on every append.
+type requestLog struct {
+	defaults models.RequestRetention // Bounds for imposters that don't set their own
+	sizes    []int64
+	bytes    int64
+}
+
+// appendRetained appends req to an imposter's recorded requests and then
+// drops the oldest ones until the rest are within the retention policy,
+// counting them in the imposter's DroppedRequests. Callers hold the
+// server's lock.
+func appendRetained[T any](l *requestLog, imp *models.Imposter, requests []T, req T, timestamp func(*T) string) []T {
+	retention := imp.EffectiveRetention(l.defaults)
+	if retention.IsZero() {
+		return append(requests, req)
+	}
+
+	resync(l, retention, requests)
+	requests = append(requests, req)
+	l.push(retention, req)
+	return dropRetained(l, imp, retention, requests, timestamp)
+}
+
+// pruneExpired drops the recorded requests that have outlived the retention
+// policy's maxAge, so they age out of an idle imposter as well as on
+// append. Callers hold the server's lock.
+func pruneExpired(l *requestLog, imp *models.Imposter) {
+	retention := imp.EffectiveRetention(l.defaults)
+	if retention.MaxAge <= 0 {
+		return
+	}
+	switch {
+	case len(imp.Requests) > 0:
+		resync(l, retention, imp.Requests)
+		imp.Requests = dropRetained(l, imp, retention, imp.Requests,
+			func(r *models.Request) string { return r.Timestamp })
+	case len(imp.TCPRequests) > 0:
+		resync(l, retention, imp.TCPRequests)
+		imp.TCPRequests = dropRetained(l, imp, retention, imp.TCPRequests,
+			func(r *models.TCPRequest) string { return r.Timestamp })
+	case len(imp.SMTPRequests) > 0:
+		resync(l, retention, imp.SMTPRequests)
+		imp.SMTPRequests = dropRetained(l, imp, retention, imp.SMTPRequests,
+			func(r *models.SMTPRequest) string { return r.Timestamp })
+	case len(imp.GRPCRequests) > 0:
+		resync(l, retention, imp.GRPCRequests)
+		imp.GRPCRequests = dropRetained(l, imp, retention, imp.GRPCRequests,
+			func(r *models.GRPCRequest) string { return r.Timestamp })
+	}
+}
+
+// resync re-measures the requests when they have been cleared or replaced
+// since the log last saw them
+func resync[T any](l *requestLog, retention models.RequestRetention, requests []T) {
+	if len(l.sizes) == len(requests) {
+		return
+	}
+	l.sizes = l.sizes[:0]
+	l.bytes = 0
+	for i := range requests {
+		l.push(retention, requests[i])
+	}
+}
+
+// dropRetained drops the oldest requests until the rest are within the
+// retention policy, counting them in the imposter's DroppedRequests
+func dropRetained[T any](l *requestLog, imp *models.Imposter, retention models.RequestRetention, requests []T, timestamp func(*T) string) []T {
+	now := time.Now()
+	dropped := 0
+	for dropped < len(requests) {
+		overCount := retention.MaxCount > 0 && len(requests)-dropped > retention.MaxCount
+		overBytes := retention.MaxBytes > 0 && l.bytes > retention.MaxBytes
+		if !overCount && !overBytes && !retention.Expired(timestamp(&requests[dropped]), now) {
+			break
+		}
+		l.bytes -= l.sizes[dropped]
+		dropped++
+	}
+	if dropped == 0 {
+		return requests
+	}
+
+	// Release what the dropped requests refer to; the backing array is
+	// reclaimed when a later append outgrows it
+	clear(requests[:dropped])
+	l.sizes = l.sizes[dropped:]
+	imp.DroppedRequests += dropped
+	metrics.RecordDroppedRequests(strconv.Itoa(imp.Port), imp.Protocol, dropped)
+	return requests[dropped:]
+}
+
+// push tracks the size of a kept request, measured as JSON only when the
+// policy bounds bytes
+func (l *requestLog) push(retention models.RequestRetention, req interface{}) {
+	var size int64
+	if retention.MaxBytes > 0 {
+		if data, err := json.Marshal(req); err == nil {
+			size = int64(len(data))
+		}
+	}
+	l.sizes = append(l.sizes, size)
+	l.bytes += size
+}
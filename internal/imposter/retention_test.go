@@ -0,0 +1,134 @@
+package imposter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// TestAppendRetained tests that recorded requests are trimmed oldest first
+// to the imposter's retention policy
+func TestAppendRetained(t *testing.T) {
+	now := time.Now().Format(time.RFC3339)
+	old := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		name        string
+		retention   *models.RequestRetention
+		defaults    models.RequestRetention
+		timestamps  []string
+		bodies      []string
+		wantBodies  []string
+		wantDropped int
+	}{
+		{
+			name:       "unbounded keeps everything",
+			timestamps: []string{now, now, now},
+			bodies:     []string{"a", "b", "c"},
+			wantBodies: []string{"a", "b", "c"},
+		},
+		{
+			name:        "max count",
+			retention:   &models.RequestRetention{MaxCount: 2},
+			timestamps:  []string{now, now, now, now},
+			bodies:      []string{"a", "b", "c", "d"},
+			wantBodies:  []string{"c", "d"},
+			wantDropped: 2,
+		},
+		{
+			name:        "default max count",
+			defaults:    models.RequestRetention{MaxCount: 1},
+			timestamps:  []string{now, now},
+			bodies:      []string{"a", "b"},
+			wantBodies:  []string{"b"},
+			wantDropped: 1,
+		},
+		{
+			name:        "max bytes",
+			retention:   &models.RequestRetention{MaxBytes: 250},
+			timestamps:  []string{now, now, now},
+			bodies:      []string{strings.Repeat("a", 100), strings.Repeat("b", 100), strings.Repeat("c", 100)},
+			wantBodies:  []string{strings.Repeat("c", 100)},
+			wantDropped: 2,
+		},
+		{
+			name:        "max age",
+			retention:   &models.RequestRetention{MaxAge: 60000},
+			timestamps:  []string{old, old, now},
+			bodies:      []string{"a", "b", "c"},
+			wantBodies:  []string{"c"},
+			wantDropped: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imp := &models.Imposter{Port: 9730, Protocol: "http", RequestRetention: tt.retention}
+			log := &requestLog{defaults: tt.defaults}
+			for i, body := range tt.bodies {
+				req := models.Request{Method: "POST", Path: "/", Body: body, Timestamp: tt.timestamps[i]}
+				imp.Requests = appendRetained(log, imp, imp.Requests, req,
+					func(r *models.Request) string { return r.Timestamp })
+			}
+
+			var bodies []string
+			for _, req := range imp.Requests {
+				bodies = append(bodies, req.Body)
+			}
+			if strings.Join(bodies, ",") != strings.Join(tt.wantBodies, ",") {
+				t.Errorf("kept %v, want %v", bodies, tt.wantBodies)
+			}
+			if imp.DroppedRequests != tt.wantDropped {
+				t.Errorf("DroppedRequests = %d, want %d", imp.DroppedRequests, tt.wantDropped)
+			}
+		})
+	}
+}
+
+// TestAppendRetainedAfterClear tests that the request log follows requests
+// cleared outside the server
+func TestAppendRetainedAfterClear(t *testing.T) {
+	imp := &models.Imposter{Port: 9731, Protocol: "tcp", RequestRetention: &models.RequestRetention{MaxCount: 2}}
+	log := &requestLog{}
+	timestamp := func(r *models.TCPRequest) string { return r.Timestamp }
+
+	for _, data := range []string{"a", "b", "c"} {
+		imp.TCPRequests = appendRetained(log, imp, imp.TCPRequests, models.TCPRequest{Data: data}, timestamp)
+	}
+	imp.TCPRequests = nil
+	imp.TCPRequests = appendRetained(log, imp, imp.TCPRequests, models.TCPRequest{Data: "d"}, timestamp)
+
+	if len(imp.TCPRequests) != 1 || imp.TCPRequests[0].Data != "d" {
+		t.Errorf("expected only the request recorded after clearing, got %v", imp.TCPRequests)
+	}
+	if imp.DroppedRequests != 1 {
+		t.Errorf("DroppedRequests = %d, want 1", imp.DroppedRequests)
+	}
+}
+
+// TestPruneExpired tests that requests past their maxAge are dropped
+// without a new request arriving
+func TestPruneExpired(t *testing.T) {
+	now := time.Now().Format(time.RFC3339Nano)
+	old := time.Now().Add(-time.Hour).Format(time.RFC3339Nano)
+
+	imp := &models.Imposter{Port: 9732, Protocol: "smtp", RequestRetention: &models.RequestRetention{MaxAge: 60000}}
+	imp.SMTPRequests = []models.SMTPRequest{{Subject: "a", Timestamp: old}, {Subject: "b", Timestamp: old}, {Subject: "c", Timestamp: now}}
+	pruneExpired(&requestLog{}, imp)
+
+	if len(imp.SMTPRequests) != 1 || imp.SMTPRequests[0].Subject != "c" {
+		t.Errorf("expected only the unexpired request, got %v", imp.SMTPRequests)
+	}
+	if imp.DroppedRequests != 2 {
+		t.Errorf("DroppedRequests = %d, want 2", imp.DroppedRequests)
+	}
+
+	unbounded := &models.Imposter{Port: 9733, Protocol: "http", RequestRetention: &models.RequestRetention{MaxCount: 1}}
+	unbounded.Requests = []models.Request{{Timestamp: old}, {Timestamp: old}}
+	pruneExpired(&requestLog{}, unbounded)
+	if len(unbounded.Requests) != 2 {
+		t.Errorf("expected pruning to apply only maxAge, got %v", unbounded.Requests)
+	}
+}
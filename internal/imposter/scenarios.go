@@ -0,0 +1,107 @@
+package imposter
+
+import (
+	"sync"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// ScenarioStarted is the state every scenario is in until a stub moves it
+const ScenarioStarted = "Started"
+
+// ScenarioStore tracks the current state of each named scenario for an imposter.
+// Scenarios let stubs form a state machine without injection: a stub with
+// requiredScenarioState only matches while its scenario is in that state, and
+// newScenarioState moves the scenario on when the stub is selected.
+type ScenarioStore struct {
+	states map[string]string
+	mu     sync.Mutex
+}
+
+// NewScenarioStore creates an empty scenario store
+func NewScenarioStore() *ScenarioStore {
+	return &ScenarioStore{
+		states: make(map[string]string),
+	}
+}
+
+// State returns the current state of a scenario
+func (s *ScenarioStore) State(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stateLocked(name)
+}
+
+func (s *ScenarioStore) stateLocked(name string) string {
+	if state, ok := s.states[name]; ok {
+		return state
+	}
+	return ScenarioStarted
+}
+
+// Allows reports whether a stub's scenario is in the state the stub requires
+func (s *ScenarioStore) Allows(stub *models.Stub) bool {
+	if stub.ScenarioName == "" || stub.RequiredScenarioState == "" {
+		return true
+	}
+	return s.State(stub.ScenarioName) == stub.RequiredScenarioState
+}
+
+// Advance re-checks the stub's required state and applies its transition in a
+// single step, so two concurrent requests cannot both take the same transition.
+// It returns false if another request moved the scenario since Allows was checked.
+func (s *ScenarioStore) Advance(stub *models.Stub) bool {
+	if stub.ScenarioName == "" {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stub.RequiredScenarioState != "" && s.stateLocked(stub.ScenarioName) != stub.RequiredScenarioState {
+		return false
+	}
+	if stub.NewScenarioState != "" {
+		s.states[stub.ScenarioName] = stub.NewScenarioState
+	}
+	return true
+}
+
+// Set forces a scenario into the given state
+func (s *ScenarioStore) Set(name, state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[name] = state
+}
+
+// Reset returns a single scenario to ScenarioStarted
+func (s *ScenarioStore) Reset(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, name)
+}
+
+// ResetAll returns every scenario to ScenarioStarted
+func (s *ScenarioStore) ResetAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states = make(map[string]string)
+}
+
+// Snapshot returns the state of every scenario referenced by the stubs,
+// plus any scenario that has been moved explicitly
+func (s *ScenarioStore) Snapshot(stubs []models.Stub) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make(map[string]string)
+	for _, stub := range stubs {
+		if stub.ScenarioName != "" {
+			result[stub.ScenarioName] = s.stateLocked(stub.ScenarioName)
+		}
+	}
+	for name, state := range s.states {
+		result[name] = state
+	}
+	return result
+}
@@ -0,0 +1,152 @@
+package imposter
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+func scenarioStub(body, required, next string) models.Stub {
+	return models.Stub{
+		ScenarioName:          "checkout",
+		RequiredScenarioState: required,
+		NewScenarioState:      next,
+		Responses: []models.Response{
+			{Is: &models.IsResponse{Body: body}},
+		},
+	}
+}
+
+// TestMatcherScenarioTransitions tests that stubs follow the scenario state machine
+func TestMatcherScenarioTransitions(t *testing.T) {
+	imp := &models.Imposter{
+		Protocol: "http",
+		Stubs: []models.Stub{
+			scenarioStub("empty cart", ScenarioStarted, "item added"),
+			scenarioStub("one item", "item added", "checked out"),
+			scenarioStub("order placed", "checked out", ""),
+		},
+	}
+	matcher := NewMatcher(imp)
+
+	expected := []string{"empty cart", "one item", "order placed", "order placed"}
+	for i, want := range expected {
+		result := matcher.Match(&models.Request{Method: "GET", Path: "/cart"})
+		if result.Response == nil || result.Response.Body != want {
+			t.Fatalf("request %d: expected %q, got %+v", i, want, result.Response)
+		}
+	}
+
+	if state := matcher.scenarios.State("checkout"); state != "checked out" {
+		t.Errorf("expected state 'checked out', got %q", state)
+	}
+
+	matcher.scenarios.ResetAll()
+	result := matcher.Match(&models.Request{Method: "GET", Path: "/cart"})
+	if result.Response.Body != "empty cart" {
+		t.Errorf("expected reset to restart the scenario, got %v", result.Response.Body)
+	}
+}
+
+// TestMatcherScenarioFallsThrough tests that inactive scenario stubs are skipped
+func TestMatcherScenarioFallsThrough(t *testing.T) {
+	imp := &models.Imposter{
+		Protocol: "http",
+		Stubs: []models.Stub{
+			scenarioStub("only after login", "logged in", ""),
+			{Responses: []models.Response{{Is: &models.IsResponse{Body: "fallback"}}}},
+		},
+	}
+	matcher := NewMatcher(imp)
+
+	result := matcher.Match(&models.Request{Method: "GET", Path: "/"})
+	if result.Response.Body != "fallback" || result.StubIndex != 1 {
+		t.Fatalf("expected fallback stub, got index %d body %v", result.StubIndex, result.Response.Body)
+	}
+
+	matcher.scenarios.Set("checkout", "logged in")
+	result = matcher.Match(&models.Request{Method: "GET", Path: "/"})
+	if result.Response.Body != "only after login" {
+		t.Errorf("expected scenario stub after Set, got %v", result.Response.Body)
+	}
+}
+
+// TestScenarioStoreAdvanceIsExclusive tests that only one concurrent request takes a transition
+func TestScenarioStoreAdvanceIsExclusive(t *testing.T) {
+	store := NewScenarioStore()
+	stub := scenarioStub("", ScenarioStarted, "done")
+
+	var winners int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if store.Advance(&stub) {
+				atomic.AddInt32(&winners, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if winners != 1 {
+		t.Errorf("expected exactly one transition, got %d", winners)
+	}
+}
+
+// TestScenarioStoreSnapshot tests the reported states include untouched scenarios
+func TestScenarioStoreSnapshot(t *testing.T) {
+	store := NewScenarioStore()
+	store.Set("manual", "custom")
+
+	stubs := []models.Stub{
+		scenarioStub("", ScenarioStarted, "next"),
+		{},
+	}
+	snapshot := store.Snapshot(stubs)
+
+	if len(snapshot) != 2 {
+		t.Fatalf("expected 2 scenarios, got %v", snapshot)
+	}
+	if snapshot["checkout"] != ScenarioStarted {
+		t.Errorf("expected checkout to be %q, got %q", ScenarioStarted, snapshot["checkout"])
+	}
+	if snapshot["manual"] != "custom" {
+		t.Errorf("expected manual to be 'custom', got %q", snapshot["manual"])
+	}
+
+	store.Reset("manual")
+	if state := store.State("manual"); state != ScenarioStarted {
+		t.Errorf("expected reset scenario to be %q, got %q", ScenarioStarted, state)
+	}
+}
+
+// TestTCPMatcherScenario tests that the TCP matcher honors scenarios
+func TestTCPMatcherScenario(t *testing.T) {
+	imp := &models.Imposter{
+		Protocol: "tcp",
+		Stubs: []models.Stub{
+			{
+				ScenarioName:          "handshake",
+				RequiredScenarioState: ScenarioStarted,
+				NewScenarioState:      "connected",
+				Responses:             []models.Response{{Is: &models.IsResponse{Data: "HELLO"}}},
+			},
+			{
+				ScenarioName:          "handshake",
+				RequiredScenarioState: "connected",
+				Responses:             []models.Response{{Is: &models.IsResponse{Data: "DATA"}}},
+			},
+		},
+	}
+	matcher := NewTCPMatcher(imp)
+
+	if got := matcher.Match("x").Response.Data; got != "HELLO" {
+		t.Fatalf("expected HELLO, got %q", got)
+	}
+	if got := matcher.Match("x").Response.Data; got != "DATA" {
+		t.Errorf("expected DATA, got %q", got)
+	}
+}
@@ -12,26 +12,35 @@ import (
 	"sync"
 	"time"
 
+	"github.com/TetsujinOni/go-tartuffe/internal/events"
 	"github.com/TetsujinOni/go-tartuffe/internal/models"
 )
 
 // SMTPServer represents an SMTP imposter server
 type SMTPServer struct {
-	imposter *models.Imposter
-	listener net.Listener
-	matcher  *SMTPMatcher
-	started  bool
-	mu       sync.RWMutex
-	wg       sync.WaitGroup
-	quit     chan struct{}
+	imposter   *models.Imposter
+	listener   net.Listener
+	matcher    *SMTPMatcher
+	scenarios  *ScenarioStore
+	requestLog requestLog  // Retention of recorded requests
+	events     *events.Bus // Bus the imposter's events are published to
+	started    bool
+	mu         sync.RWMutex
+	wg         sync.WaitGroup
+	quit       chan struct{}
 }
 
 // NewSMTPServer creates a new SMTP imposter server
 func NewSMTPServer(imp *models.Imposter) (*SMTPServer, error) {
+	scenarios := NewScenarioStore()
+	matcher := NewSMTPMatcher(imp)
+	matcher.SetScenarios(scenarios)
+
 	return &SMTPServer{
-		imposter: imp,
-		matcher:  NewSMTPMatcher(imp),
-		quit:     make(chan struct{}),
+		imposter:  imp,
+		matcher:   matcher,
+		scenarios: scenarios,
+		quit:      make(chan struct{}),
 	}, nil
 }
 
@@ -110,6 +119,12 @@ func (s *SMTPServer) UpdateStubs(stubs []models.Stub) {
 	defer s.mu.Unlock()
 	s.imposter.Stubs = stubs
 	s.matcher = NewSMTPMatcher(s.imposter)
+	s.matcher.SetScenarios(s.scenarios)
+}
+
+// Scenarios returns the scenario states for this imposter
+func (s *SMTPServer) Scenarios() *ScenarioStore {
+	return s.scenarios
 }
 
 // acceptLoop accepts incoming connections
@@ -185,8 +200,9 @@ func (s *SMTPServer) handleConnection(conn net.Conn) {
 				// Record request if configured
 				s.mu.Lock()
 				if s.imposter.RecordRequests {
-					smtpReq.Timestamp = time.Now().Format(time.RFC3339)
-					s.imposter.SMTPRequests = append(s.imposter.SMTPRequests, *smtpReq)
+					smtpReq.Timestamp = time.Now().Format(time.RFC3339Nano)
+					s.imposter.SMTPRequests = appendRetained(&s.requestLog, s.imposter, s.imposter.SMTPRequests, *smtpReq,
+						func(r *models.SMTPRequest) string { return r.Timestamp })
 				}
 				// Increment request counter
 				if s.imposter.NumberOfRequests == nil {
@@ -197,8 +213,11 @@ func (s *SMTPServer) handleConnection(conn net.Conn) {
 				}
 				s.mu.Unlock()
 
+				publishRequest(s.events, s.imposter, smtpReq)
+
 				// Match against stubs (for logging/tracking purposes)
-				s.matcher.Match(smtpReq)
+				match := s.matcher.Match(smtpReq)
+				publishMatch(s.events, s.imposter, smtpReq, match.Stub, match.StubIndex)
 
 				s.writeLine(writer, "250 OK message queued")
 
@@ -610,19 +629,28 @@ func extractIP(addr string) string {
 
 // SMTPMatcher handles request matching for SMTP
 type SMTPMatcher struct {
-	imposter *models.Imposter
+	imposter  *models.Imposter
+	scenarios *ScenarioStore
 }
 
 // NewSMTPMatcher creates a new SMTP matcher
 func NewSMTPMatcher(imp *models.Imposter) *SMTPMatcher {
-	return &SMTPMatcher{imposter: imp}
+	return &SMTPMatcher{imposter: imp, scenarios: NewScenarioStore()}
+}
+
+// SetScenarios shares the owning server's scenario store with the matcher
+func (m *SMTPMatcher) SetScenarios(scenarios *ScenarioStore) {
+	m.scenarios = scenarios
 }
 
 // Match finds a matching stub for an SMTP request
 func (m *SMTPMatcher) Match(req *models.SMTPRequest) *SMTPMatchResult {
 	for i := range m.imposter.Stubs {
 		stub := &m.imposter.Stubs[i]
-		if m.matchesAllPredicates(stub, req) {
+		if !m.scenarios.Allows(stub) {
+			continue
+		}
+		if m.matchesAllPredicates(stub, req) && m.scenarios.Advance(stub) {
 			return &SMTPMatchResult{
 				Stub:      stub,
 				StubIndex: i,
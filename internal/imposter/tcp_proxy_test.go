@@ -485,3 +485,144 @@ func TestTCPProxyConnectionRefused(t *testing.T) {
 		t.Logf("Connection closed on connection refused (acceptable - error handled gracefully)")
 	}
 }
+
+// tcpExchange sends one request to port and returns the reply
+func tcpExchange(t *testing.T, port int, request []byte) []byte {
+	t.Helper()
+	conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		t.Fatalf("failed to connect to port %d: %v", port, err)
+	}
+	defer conn.Close()
+
+	conn.Write(request)
+	conn.SetReadDeadline(time.Now().Add(1 * time.Second))
+	response := make([]byte, 1024)
+	n, _ := conn.Read(response)
+	return response[:n]
+}
+
+// TestTCPProxyRecording tests that TCP proxies record replayable stubs
+func TestTCPProxyRecording(t *testing.T) {
+	tests := []struct {
+		name       string
+		originPort int
+		proxyPort  int
+		mode       string
+		proxy      models.ProxyResponse
+		request    []byte
+		response   []byte
+		predicate  models.Predicate
+		wantStubs  int
+	}{
+		{
+			name:       "text proxyOnce",
+			originPort: 9720,
+			proxyPort:  9721,
+			mode:       "text",
+			request:    []byte("HELLO\n"),
+			response:   []byte("WORLD"),
+			predicate:  models.Predicate{Equals: map[string]interface{}{"data": "HELLO\n"}},
+			wantStubs:  2,
+		},
+		{
+			name:       "binary proxyOnce",
+			originPort: 9722,
+			proxyPort:  9723,
+			mode:       "binary",
+			request:    []byte{0x00, 0x01, 0xfe, 0xff},
+			response:   []byte{0xca, 0xfe, 0x00, 0xba, 0xbe},
+			predicate: models.Predicate{Equals: map[string]interface{}{
+				"data": base64.StdEncoding.EncodeToString([]byte{0x00, 0x01, 0xfe, 0xff}),
+			}},
+			wantStubs: 2,
+		},
+		{
+			name:       "text regex generator",
+			originPort: 9724,
+			proxyPort:  9725,
+			mode:       "text",
+			proxy: models.ProxyResponse{
+				PredicateGenerators: []models.PredicateGen{{
+					Matches:       map[string]interface{}{"data": "GET [a-z]+"},
+					CaseSensitive: true,
+				}},
+			},
+			request:   []byte("GET users id=42\n"),
+			response:  []byte("ALICE"),
+			predicate: models.Predicate{Contains: map[string]interface{}{"data": "GET users"}, CaseSensitive: true},
+			wantStubs: 2,
+		},
+		{
+			name:       "proxyTransparent",
+			originPort: 9726,
+			proxyPort:  9727,
+			mode:       "text",
+			proxy:      models.ProxyResponse{Mode: "proxyTransparent"},
+			request:    []byte("HELLO\n"),
+			response:   []byte("WORLD"),
+			wantStubs:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			originData := string(tt.response)
+			if tt.mode == "binary" {
+				originData = base64.StdEncoding.EncodeToString(tt.response)
+			}
+			originSrv, err := NewTCPServer(&models.Imposter{
+				Protocol: "tcp",
+				Port:     tt.originPort,
+				Mode:     tt.mode,
+				Stubs:    []models.Stub{{Responses: []models.Response{{Is: &models.IsResponse{Data: originData}}}}},
+			})
+			if err != nil {
+				t.Fatalf("NewTCPServer(origin) error = %v", err)
+			}
+			if err := originSrv.Start(); err != nil {
+				t.Fatalf("origin Start() error = %v", err)
+			}
+
+			proxy := tt.proxy
+			proxy.To = fmt.Sprintf("tcp://localhost:%d", tt.originPort)
+			proxySrv, err := NewTCPServer(&models.Imposter{
+				Protocol: "tcp",
+				Port:     tt.proxyPort,
+				Mode:     tt.mode,
+				Stubs:    []models.Stub{{Responses: []models.Response{{Proxy: &proxy}}}},
+			})
+			if err != nil {
+				t.Fatalf("NewTCPServer(proxy) error = %v", err)
+			}
+			if err := proxySrv.Start(); err != nil {
+				t.Fatalf("proxy Start() error = %v", err)
+			}
+			defer proxySrv.Stop(context.Background())
+			time.Sleep(50 * time.Millisecond)
+
+			if got := tcpExchange(t, tt.proxyPort, tt.request); string(got) != string(tt.response) {
+				t.Fatalf("proxied response = %q, want %q", got, tt.response)
+			}
+
+			stubs := proxySrv.imposter.Stubs
+			if len(stubs) != tt.wantStubs {
+				t.Fatalf("stubs = %d, want %d", len(stubs), tt.wantStubs)
+			}
+			if tt.wantStubs == 1 {
+				originSrv.Stop(context.Background())
+				return
+			}
+			if !predicatesEqual(stubs[0].Predicates, []models.Predicate{tt.predicate}) {
+				t.Errorf("recorded predicates = %+v, want %+v", stubs[0].Predicates, tt.predicate)
+			}
+
+			// The recording answers once the origin is gone
+			originSrv.Stop(context.Background())
+			time.Sleep(50 * time.Millisecond)
+			if got := tcpExchange(t, tt.proxyPort, tt.request); string(got) != string(tt.response) {
+				t.Errorf("replayed response = %q, want %q", got, tt.response)
+			}
+		})
+	}
+}
@@ -13,30 +13,46 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
+	"github.com/TetsujinOni/go-tartuffe/internal/events"
 	"github.com/TetsujinOni/go-tartuffe/internal/models"
 )
 
 // TCPServer represents a TCP imposter server
 type TCPServer struct {
-	imposter *models.Imposter
-	listener net.Listener
-	matcher  *TCPMatcher
-	jsEngine *JSEngine
-	state    map[string]interface{} // Persistent state for injection scripts
-	started  bool
-	stopping bool
-	mu       sync.RWMutex
-	wg       sync.WaitGroup
+	imposter       *models.Imposter
+	listener       net.Listener
+	matcher        *TCPMatcher
+	jsEngine       *JSEngine
+	state          map[string]interface{} // Persistent state for injection scripts
+	scenarios      *ScenarioStore
+	templateEngine *TemplateEngine
+	faker          *Faker
+	requestLog     requestLog  // Retention of recorded requests
+	events         *events.Bus // Bus the imposter's events are published to
+	started        bool
+	stopping       bool
+	mu             sync.RWMutex
+	wg             sync.WaitGroup
 }
 
 // NewTCPServer creates a new TCP imposter server
 func NewTCPServer(imp *models.Imposter) (*TCPServer, error) {
+	scenarios := NewScenarioStore()
+	matcher := NewTCPMatcher(imp)
+	matcher.SetScenarios(scenarios)
+	state := make(map[string]interface{})
+	faker := NewFaker(imp.Seed)
+
 	return &TCPServer{
-		imposter: imp,
-		matcher:  NewTCPMatcher(imp),
-		jsEngine: NewJSEngine(),
-		state:    make(map[string]interface{}),
+		imposter:       imp,
+		matcher:        matcher,
+		jsEngine:       NewJSEngine(),
+		state:          state,
+		scenarios:      scenarios,
+		templateEngine: NewTemplateEngine(state, faker),
+		faker:          faker,
 	}, nil
 }
 
@@ -159,9 +175,10 @@ func (s *TCPServer) handleConnection(conn net.Conn) {
 			tcpReq := models.TCPRequest{
 				RequestFrom: remoteAddr,
 				Data:        pktStr,
-				Timestamp:   time.Now().Format(time.RFC3339),
+				Timestamp:   time.Now().Format(time.RFC3339Nano),
 			}
-			s.imposter.TCPRequests = append(s.imposter.TCPRequests, tcpReq)
+			s.imposter.TCPRequests = appendRetained(&s.requestLog, s.imposter, s.imposter.TCPRequests, tcpReq,
+				func(r *models.TCPRequest) string { return r.Timestamp })
 		}
 	}
 	// Increment request counter (once per connection, not per packet)
@@ -176,17 +193,22 @@ func (s *TCPServer) handleConnection(conn net.Conn) {
 	// Use allData for proxy requests
 	data := allData
 
+	received := models.TCPRequest{RequestFrom: conn.RemoteAddr().String(), Data: dataStr}
+	publishRequest(s.events, s.imposter, received)
+
 	// Find matching stub
 	match := s.matcher.Match(dataStr)
+	publishMatch(s.events, s.imposter, received, match.Stub, match.StubIndex)
 
 	// Check for proxy response first
 	if match.RawResponse != nil && match.RawResponse.Proxy != nil {
-		s.handleProxyRequest(conn, data, match.RawResponse)
+		s.handleProxyRequest(conn, data, dataStr, match)
 		return
 	}
 
 	// Determine response data
 	var responseData string
+	expandFakes := false
 
 	// Check for injection response
 	if match.RawResponse != nil && match.RawResponse.Inject != "" {
@@ -205,12 +227,27 @@ func (s *TCPServer) handleConnection(conn net.Conn) {
 			responseData = injectedData
 		}
 	} else if match.Response != nil {
-		responseData = match.Response.Data
+		rendered, err := s.templateEngine.Render(&models.Request{Body: dataStr}, match.Response)
+		if err != nil {
+			log.Printf("[ERROR] TCP response template error: %v", err)
+			return
+		}
+		responseData = rendered.Data
+		expandFakes = true
 	}
 
 	// Apply behaviors if present
 	if match.RawResponse != nil && len(match.RawResponse.Behaviors) > 0 {
-		responseData = s.applyTCPBehaviors(dataStr, responseData, match.RawResponse.Behaviors)
+		var chaosFault *ChaosFaultError
+		responseData, chaosFault = s.applyTCPBehaviors(dataStr, responseData, match.RawResponse.Behaviors)
+		if chaosFault != nil {
+			s.handleFault(conn, chaosFault.Fault)
+			return
+		}
+	}
+
+	if expandFakes {
+		responseData = s.faker.Expand(responseData)
 	}
 
 	// Write response if we have data
@@ -228,8 +265,9 @@ func (s *TCPServer) handleConnection(conn net.Conn) {
 	}
 }
 
-// applyTCPBehaviors applies behaviors to TCP response data
-func (s *TCPServer) applyTCPBehaviors(requestData, responseData string, behaviors []models.Behavior) string {
+// applyTCPBehaviors applies behaviors to TCP response data.
+// A non-nil fault means a chaos behavior asked for the connection to be broken.
+func (s *TCPServer) applyTCPBehaviors(requestData, responseData string, behaviors []models.Behavior) (string, *ChaosFaultError) {
 	result := responseData
 
 	// Create a simple request/response structure for behaviors
@@ -245,8 +283,12 @@ func (s *TCPServer) applyTCPBehaviors(requestData, responseData string, behavior
 	behaviorExecutor := NewBehaviorExecutor(s.jsEngine)
 	processedResp, err := behaviorExecutor.Execute(req, resp, behaviors)
 	if err != nil {
+		var chaosFault *ChaosFaultError
+		if errors.As(err, &chaosFault) {
+			return "", chaosFault
+		}
 		log.Printf("[ERROR] TCP behavior execution error: %v", err)
-		return result
+		return result, nil
 	}
 
 	// Extract data from processed response
@@ -256,7 +298,25 @@ func (s *TCPServer) applyTCPBehaviors(requestData, responseData string, behavior
 		result = bodyStr
 	}
 
-	return result
+	return result, nil
+}
+
+// handleFault breaks the connection the same way HTTP faults do
+func (s *TCPServer) handleFault(conn net.Conn, fault string) {
+	switch fault {
+	case models.FaultConnectionResetByPeer:
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			tcpConn.SetLinger(0) // Send RST instead of FIN
+		}
+		conn.Close()
+	case models.FaultRandomDataThenClose:
+		garbage := make([]byte, 32)
+		for i := range garbage {
+			garbage[i] = byte(i * 17 % 256)
+		}
+		conn.Write(garbage)
+		conn.Close()
+	}
 }
 
 // executeTCPDecorate executes a decorate behavior for TCP
@@ -310,8 +370,11 @@ func (s *TCPServer) executeTCPDecorate(requestData, responseData, script string)
 	return responseData
 }
 
-// handleProxyRequest proxies the TCP request to the origin server
-func (s *TCPServer) handleProxyRequest(clientConn net.Conn, requestData []byte, resp *models.Response) {
+// handleProxyRequest proxies the TCP request to the origin server, recording
+// the exchange as a stub unless the proxy is in proxyTransparent mode
+func (s *TCPServer) handleProxyRequest(clientConn net.Conn, requestData []byte, dataStr string, match *TCPMatchResult) {
+	resp := match.RawResponse
+
 	// Parse the target URL
 	targetURL := resp.Proxy.To
 
@@ -372,6 +435,7 @@ func (s *TCPServer) handleProxyRequest(clientConn net.Conn, requestData []byte,
 	}
 
 	// Read response from origin - use endOfRequestResolver if available
+	startTime := time.Now()
 	var response []byte
 	resolver := s.imposter.EndOfRequestResolver
 	if resolver != nil && resolver.Inject != "" {
@@ -406,6 +470,121 @@ func (s *TCPServer) handleProxyRequest(clientConn net.Conn, requestData []byte,
 	if len(responseData) > 0 {
 		clientConn.Write(responseData)
 	}
+
+	if resp.Proxy.Mode != "proxyTransparent" && len(responseData) > 0 {
+		stub := s.generateProxyStub(dataStr, responseData, resp.Proxy, time.Since(startTime))
+		s.recordProxyStub(match, stub)
+		publishProxyRecorded(s.events, s.imposter, stub)
+	}
+}
+
+// generateProxyStub creates a stub replaying an origin response. Binary
+// imposters record base64 data; text imposters record text, falling back to
+// a binary response for output that isn't valid UTF-8.
+func (s *TCPServer) generateProxyStub(dataStr string, responseData []byte, proxy *models.ProxyResponse, elapsed time.Duration) *models.Stub {
+	is := &models.IsResponse{}
+	if s.imposter.Mode == "binary" || !utf8.Valid(responseData) {
+		is.Data = base64.StdEncoding.EncodeToString(responseData)
+		if s.imposter.Mode != "binary" {
+			is.Mode = "binary"
+		}
+	} else {
+		is.Data = string(responseData)
+	}
+
+	stub := &models.Stub{Responses: []models.Response{{Is: is}}}
+	if proxy.AddWaitBehavior {
+		stub.Responses[0].Behaviors = []models.Behavior{{Wait: int(elapsed.Milliseconds())}}
+	}
+
+	generators := proxy.PredicateGenerators
+	if len(generators) == 0 {
+		generators = []models.PredicateGen{{Matches: map[string]interface{}{"data": true}}}
+	}
+	for _, gen := range generators {
+		if pred := s.generateDataPredicate(dataStr, gen); pred != nil {
+			stub.Predicates = append(stub.Predicates, *pred)
+		}
+	}
+	return stub
+}
+
+// generateDataPredicate turns a predicate generator into a predicate on the
+// request data. data: true matches the whole request; a regex pattern
+// matches requests containing the same text as the pattern matched, which
+// only makes sense for text imposters.
+func (s *TCPServer) generateDataPredicate(dataStr string, gen models.PredicateGen) *models.Predicate {
+	if gen.Inject != "" {
+		log.Printf("[WARN] TCP proxy: inject predicateGenerators are not supported, ignoring")
+		return nil
+	}
+	matches, ok := gen.Matches.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	switch pattern := matches["data"].(type) {
+	case bool:
+		if pattern {
+			return &models.Predicate{
+				Equals:        map[string]interface{}{"data": dataStr},
+				CaseSensitive: gen.CaseSensitive,
+			}
+		}
+	case string:
+		if s.imposter.Mode == "binary" {
+			log.Printf("[WARN] TCP proxy: regex predicateGenerators need a text imposter, matching the whole request")
+			return &models.Predicate{Equals: map[string]interface{}{"data": dataStr}}
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Printf("[WARN] TCP proxy: invalid predicateGenerator pattern %q: %v", pattern, err)
+			return nil
+		}
+		if found := re.FindString(dataStr); found != "" {
+			return &models.Predicate{
+				Contains:      map[string]interface{}{"data": found},
+				CaseSensitive: gen.CaseSensitive,
+			}
+		}
+	}
+	return nil
+}
+
+// recordProxyStub adds a recorded stub the way HTTP proxies do: proxyOnce
+// puts it ahead of the proxy so it answers from then on, while proxyAlways
+// keeps proxying and collects responses for equal predicates in one stub
+func (s *TCPServer) recordProxyStub(match *TCPMatchResult, newStub *models.Stub) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	newStub.IsProxyGenerated = true
+	stubs := s.imposter.Stubs
+
+	if match.RawResponse.Proxy.Mode == "proxyAlways" {
+		found := false
+		for i := range stubs {
+			if i != match.StubIndex && stubs[i].IsProxyGenerated && predicatesEqual(stubs[i].Predicates, newStub.Predicates) {
+				stubs[i].Responses = append(stubs[i].Responses, newStub.Responses...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			stubs = append(stubs, *newStub)
+		}
+	} else if match.StubIndex >= 0 && match.StubIndex < len(stubs) {
+		updated := make([]models.Stub, 0, len(stubs)+1)
+		updated = append(updated, stubs[:match.StubIndex]...)
+		updated = append(updated, *newStub)
+		stubs = append(updated, stubs[match.StubIndex:]...)
+	} else {
+		stubs = append([]models.Stub{*newStub}, stubs...)
+	}
+
+	s.imposter.Stubs = stubs
+	s.matcher = NewTCPMatcher(s.imposter)
+	s.matcher.SetScenarios(s.scenarios)
 }
 
 // readFullResponse reads the complete response from origin without a resolver
@@ -596,22 +775,35 @@ func (s *TCPServer) UpdateStubs(stubs []models.Stub) {
 	defer s.mu.Unlock()
 	s.imposter.Stubs = stubs
 	s.matcher = NewTCPMatcher(s.imposter)
+	s.matcher.SetScenarios(s.scenarios)
+}
+
+// Scenarios returns the scenario states for this imposter
+func (s *TCPServer) Scenarios() *ScenarioStore {
+	return s.scenarios
 }
 
 // TCPMatcher handles request matching for TCP protocol
 type TCPMatcher struct {
-	imposter *models.Imposter
-	jsEngine *JSEngine
+	imposter  *models.Imposter
+	jsEngine  *JSEngine
+	scenarios *ScenarioStore
 }
 
 // NewTCPMatcher creates a new TCP matcher
 func NewTCPMatcher(imp *models.Imposter) *TCPMatcher {
 	return &TCPMatcher{
-		imposter: imp,
-		jsEngine: NewJSEngine(),
+		imposter:  imp,
+		jsEngine:  NewJSEngine(),
+		scenarios: NewScenarioStore(),
 	}
 }
 
+// SetScenarios shares the owning server's scenario store with the matcher
+func (m *TCPMatcher) SetScenarios(scenarios *ScenarioStore) {
+	m.scenarios = scenarios
+}
+
 // TCPMatchResult contains the result of matching a TCP request
 type TCPMatchResult struct {
 	Response    *models.IsResponse
@@ -624,7 +816,10 @@ type TCPMatchResult struct {
 func (m *TCPMatcher) Match(data string) *TCPMatchResult {
 	for i := range m.imposter.Stubs {
 		stub := &m.imposter.Stubs[i]
-		if m.matchesAllPredicates(stub, data) {
+		if !m.scenarios.Allows(stub) {
+			continue
+		}
+		if m.matchesAllPredicates(stub, data) && m.scenarios.Advance(stub) {
 			return m.getMatchResult(stub, i)
 		}
 	}
@@ -0,0 +1,436 @@
+package imposter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// maxTemplateOutput bounds the size of a single rendered template so a
+// runaway range cannot exhaust memory
+const maxTemplateOutput = 1 << 20
+
+// maxTemplateSteps and maxTemplateDuration bound the work a single template
+// may do, so a range that writes nothing cannot spin forever. A step is a
+// range iteration or a call of a template.
+const (
+	maxTemplateSteps    = 100000
+	maxTemplateDuration = time.Second
+)
+
+var (
+	errTemplateOutputTooLarge = errors.New("template output exceeds 1MB limit")
+	errTemplateTooManySteps   = fmt.Errorf("template exceeds %d range iterations or template calls", maxTemplateSteps)
+	errTemplateTimeout        = fmt.Errorf("template took longer than %s", maxTemplateDuration)
+)
+
+// TemplateEngine renders `is` responses marked with `_template: true`.
+// Templates use Go text/template syntax and only have access to the request,
+// the imposter state and the helper functions below, so unlike injection they
+// are safe to allow without --allowInjection. Each render is bounded in
+// output size, steps and time.
+type TemplateEngine struct {
+	cache     sync.Map // template source -> *template.Template
+	selectors *SelectorEvaluator
+	faker     *Faker
+	state     map[string]interface{}
+	stateMu   sync.Mutex
+}
+
+// NewTemplateEngine creates a template engine sharing the given imposter
+// state and fake data generator
+func NewTemplateEngine(state map[string]interface{}, faker *Faker) *TemplateEngine {
+	if state == nil {
+		state = make(map[string]interface{})
+	}
+	if faker == nil {
+		faker = NewFaker(nil)
+	}
+	return &TemplateEngine{
+		selectors: NewSelectorEvaluator(),
+		faker:     faker,
+		state:     state,
+	}
+}
+
+// templateData is the dot value inside a template. Helpers that need the
+// request are methods so parsed templates can be cached and shared.
+type templateData struct {
+	Request *models.Request
+	engine  *TemplateEngine
+}
+
+// Query returns a query parameter from the request
+func (d *templateData) Query(name string) string {
+	return d.Request.Query[name]
+}
+
+// Header returns a request header, matching the name case-insensitively
+func (d *templateData) Header(name string) string {
+	if v, ok := d.Request.Headers[name]; ok {
+		return v
+	}
+	for k, v := range d.Request.Headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// JSONPath evaluates a JSONPath selector against the request body
+func (d *templateData) JSONPath(selector string) (string, error) {
+	return d.engine.selectors.ApplySelector(d.Request.Body, &models.Selector{Selector: selector}, "jsonpath")
+}
+
+// XPath evaluates an XPath selector against the request body
+func (d *templateData) XPath(selector string) (string, error) {
+	return d.engine.selectors.ApplySelector(d.Request.Body, &models.Selector{Selector: selector}, "xpath")
+}
+
+// State reads a value from the imposter state shared with injection scripts.
+// Missing keys render as an empty string rather than "<no value>".
+func (d *templateData) State(key string) interface{} {
+	d.engine.stateMu.Lock()
+	defer d.engine.stateMu.Unlock()
+	if v, ok := d.engine.state[key]; ok {
+		return v
+	}
+	return ""
+}
+
+// SetState stores a value in the imposter state and renders nothing
+func (d *templateData) SetState(key string, value interface{}) string {
+	d.engine.stateMu.Lock()
+	defer d.engine.stateMu.Unlock()
+	d.engine.state[key] = value
+	return ""
+}
+
+// Render returns a copy of resp with body, headers and data rendered.
+// Responses without `_template` are returned unchanged.
+func (t *TemplateEngine) Render(req *models.Request, resp *models.IsResponse) (*models.IsResponse, error) {
+	if resp == nil || !resp.Template {
+		return resp, nil
+	}
+
+	data := &templateData{Request: req, engine: t}
+	if data.Request == nil {
+		data.Request = &models.Request{}
+	}
+
+	rendered := *resp
+	rendered.Template = false
+
+	body, err := t.renderValue(resp.Body, data)
+	if err != nil {
+		return nil, fmt.Errorf("body: %w", err)
+	}
+	rendered.Body = body
+
+	if resp.Stream != nil {
+		rendered.Stream = make([]interface{}, len(resp.Stream))
+		for i, msg := range resp.Stream {
+			if rendered.Stream[i], err = t.renderValue(msg, data); err != nil {
+				return nil, fmt.Errorf("stream[%d]: %w", i, err)
+			}
+		}
+	}
+
+	if resp.Data != "" {
+		if rendered.Data, err = t.renderString(resp.Data, data); err != nil {
+			return nil, fmt.Errorf("data: %w", err)
+		}
+	}
+
+	if code, ok := resp.StatusCode.(string); ok {
+		if rendered.StatusCode, err = t.renderString(code, data); err != nil {
+			return nil, fmt.Errorf("statusCode: %w", err)
+		}
+	}
+
+	if resp.Headers != nil {
+		rendered.Headers = make(map[string]interface{}, len(resp.Headers))
+		for name, value := range resp.Headers {
+			if rendered.Headers[name], err = t.renderValue(value, data); err != nil {
+				return nil, fmt.Errorf("header %s: %w", name, err)
+			}
+		}
+	}
+
+	return &rendered, nil
+}
+
+// renderValue renders every string inside a JSON-like value
+func (t *TemplateEngine) renderValue(value interface{}, data *templateData) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return t.renderString(v, data)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			rendered, err := t.renderValue(item, data)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = rendered
+		}
+		return out, nil
+	case []string:
+		out := make([]string, len(v))
+		for i, item := range v {
+			rendered, err := t.renderString(item, data)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = rendered
+		}
+		return out, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			rendered, err := t.renderValue(item, data)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = rendered
+		}
+		return out, nil
+	default:
+		return value, nil
+	}
+}
+
+// renderString parses (or reuses) and executes a single template
+func (t *TemplateEngine) renderString(source string, data *templateData) (string, error) {
+	if !strings.Contains(source, "{{") {
+		return source, nil
+	}
+
+	tmpl, err := t.parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	// The clone shares the parsed trees; only its step function is its own
+	tmpl, err = tmpl.Clone()
+	if err != nil {
+		return "", err
+	}
+	steps := 0
+	deadline := time.Now().Add(maxTemplateDuration)
+	tmpl.Funcs(template.FuncMap{stepFunc: func() (string, error) {
+		steps++
+		if steps > maxTemplateSteps {
+			return "", errTemplateTooManySteps
+		}
+		if steps%1000 == 0 && time.Now().After(deadline) {
+			return "", errTemplateTimeout
+		}
+		return "", nil
+	}})
+
+	var out limitedBuilder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func (t *TemplateEngine) parse(source string) (*template.Template, error) {
+	if cached, ok := t.cache.Load(source); ok {
+		return cached.(*template.Template), nil
+	}
+
+	tmpl, err := template.New("response").Option("missingkey=zero").Funcs(t.funcMap()).Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	for _, tree := range tmpl.Templates() {
+		if tree.Tree != nil {
+			countSteps(tree.Tree.Root)
+		}
+	}
+
+	t.cache.Store(source, tmpl)
+	return tmpl, nil
+}
+
+// stepFunc is called at the start of every range iteration and template
+// call; renderString replaces it with one that counts steps
+const stepFunc = "_step"
+
+// stepNode is a parsed {{_step}} action, shared by every template
+var stepNode = func() parse.Node {
+	tmpl := template.Must(template.New("response").Funcs(template.FuncMap{stepFunc: noStep}).Parse("{{" + stepFunc + "}}"))
+	return tmpl.Tree.Root.Nodes[0]
+}()
+
+func noStep() string { return "" }
+
+// countSteps inserts stepNode at the start of list and of every range body
+// inside it
+func countSteps(list *parse.ListNode) {
+	list.Nodes = append([]parse.Node{stepNode}, list.Nodes...)
+	var walk func(nodes []parse.Node)
+	walk = func(nodes []parse.Node) {
+		for _, node := range nodes {
+			switch n := node.(type) {
+			case *parse.RangeNode:
+				n.List.Nodes = append([]parse.Node{stepNode}, n.List.Nodes...)
+				walk(n.List.Nodes[1:])
+				if n.ElseList != nil {
+					walk(n.ElseList.Nodes)
+				}
+			case *parse.IfNode:
+				walk(n.List.Nodes)
+				if n.ElseList != nil {
+					walk(n.ElseList.Nodes)
+				}
+			case *parse.WithNode:
+				walk(n.List.Nodes)
+				if n.ElseList != nil {
+					walk(n.ElseList.Nodes)
+				}
+			}
+		}
+	}
+	walk(list.Nodes[1:])
+}
+
+// limitedBuilder is a strings.Builder that refuses to grow past maxTemplateOutput
+type limitedBuilder struct {
+	strings.Builder
+}
+
+func (b *limitedBuilder) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > maxTemplateOutput {
+		return 0, errTemplateOutputTooLarge
+	}
+	return b.Builder.Write(p)
+}
+
+// templateFuncs are the stateless helpers available to every template;
+// see funcMap for the random and fake data helpers
+var templateFuncs = template.FuncMap{
+	// Dates
+	"now":        func() time.Time { return time.Now().UTC() },
+	"dateAdd":    templateDateAdd,
+	"formatDate": func(layout string, t time.Time) string { return t.Format(layout) },
+	"parseDate":  func(layout, value string) (time.Time, error) { return time.Parse(layout, value) },
+
+	// Math
+	"add": func(a, b interface{}) (float64, error) {
+		return templateMath(a, b, func(x, y float64) float64 { return x + y })
+	},
+	"sub": func(a, b interface{}) (float64, error) {
+		return templateMath(a, b, func(x, y float64) float64 { return x - y })
+	},
+	"mul": func(a, b interface{}) (float64, error) {
+		return templateMath(a, b, func(x, y float64) float64 { return x * y })
+	},
+	"div": func(a, b interface{}) (float64, error) {
+		return templateMath(a, b, func(x, y float64) float64 { return x / y })
+	},
+	"mod": func(a, b interface{}) (float64, error) { return templateMath(a, b, math.Mod) },
+
+	// Strings
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"trim":    strings.TrimSpace,
+	"replace": func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"default": templateDefault,
+	"toJson":  templateToJSON,
+}
+
+func templateDateAdd(duration string, t time.Time) (time.Time, error) {
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return t, err
+	}
+	return t.Add(d), nil
+}
+
+// funcMap returns templateFuncs plus the random helpers, which draw from the
+// imposter's faker so a seeded imposter renders the same values every run
+func (t *TemplateEngine) funcMap() template.FuncMap {
+	funcs := make(template.FuncMap, len(templateFuncs)+5)
+	for name, fn := range templateFuncs {
+		funcs[name] = fn
+	}
+	funcs["fake"] = t.faker.Generate
+	funcs["uuid"] = t.faker.UUID
+	funcs["randomInt"] = func(min, max int) (int, error) {
+		if max < min {
+			return 0, fmt.Errorf("randomInt: max %d is less than min %d", max, min)
+		}
+		return min + t.faker.IntN(max-min+1), nil
+	}
+	funcs["randomId"] = func(length int) (string, error) {
+		if length < 0 || length > maxTemplateOutput {
+			return "", fmt.Errorf("randomId: length %d is out of range", length)
+		}
+		b := make([]byte, length)
+		for i := range b {
+			b[i] = randomIDAlphabet[t.faker.IntN(len(randomIDAlphabet))]
+		}
+		return string(b), nil
+	}
+	funcs[stepFunc] = noStep
+	return funcs
+}
+
+const randomIDAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// templateMath coerces both operands to numbers, accepting strings so that
+// values pulled out of the request with JSONPath can be used directly
+func templateMath(a, b interface{}, op func(x, y float64) float64) (float64, error) {
+	x, err := templateNumber(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := templateNumber(b)
+	if err != nil {
+		return 0, err
+	}
+	return op(x, y), nil
+}
+
+func templateNumber(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(n), 64)
+	case nil:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("not a number: %v", v)
+	}
+}
+
+// templateDefault returns fallback when value is empty: {{ .Query "page" | default "1" }}
+func templateDefault(fallback, value interface{}) interface{} {
+	if value == nil || value == "" {
+		return fallback
+	}
+	return value
+}
+
+func templateToJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
@@ -0,0 +1,212 @@
+package imposter
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// TestTemplateRenderHelpers tests the request helpers and stateless functions
+func TestTemplateRenderHelpers(t *testing.T) {
+	req := &models.Request{
+		Method:  "POST",
+		Path:    "/orders",
+		Query:   map[string]string{"page": "2"},
+		Headers: map[string]string{"X-Request-Id": "abc123"},
+		Body:    `{"order": {"id": 42, "qty": "3"}, "customer": "ada"}`,
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"request fields", "{{ .Request.Method }} {{ .Request.Path }}", "POST /orders"},
+		{"query helper", `{{ .Query "page" }}`, "2"},
+		{"query default", `{{ .Query "size" | default "10" }}`, "10"},
+		{"header case-insensitive", `{{ .Header "x-request-id" }}`, "abc123"},
+		{"jsonpath", `{{ .JSONPath "$.order.id" }}`, "42"},
+		{"math on jsonpath", `{{ mul (.JSONPath "$.order.qty") 2.5 }}`, "7.5"},
+		{"integer math", `{{ add 1 2 }}`, "3"},
+		{"string helpers", `{{ .JSONPath "$.customer" | upper }}`, "ADA"},
+		{"toJson", `{{ toJson .Request.Query }}`, `{"page":"2"}`},
+		{"no template markers", "plain text", "plain text"},
+	}
+
+	engine := NewTemplateEngine(nil, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := engine.Render(req, &models.IsResponse{Body: tt.template, Template: true})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Body != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, resp.Body)
+			}
+		})
+	}
+}
+
+// TestTemplateXPath tests XPath lookups against XML request bodies
+func TestTemplateXPath(t *testing.T) {
+	engine := NewTemplateEngine(nil, nil)
+	req := &models.Request{Body: "<order><id>7</id></order>"}
+
+	resp, err := engine.Render(req, &models.IsResponse{Body: `<ack>{{ .XPath "//id" }}</ack>`, Template: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Body != "<ack>7</ack>" {
+		t.Errorf("expected '<ack>7</ack>', got %q", resp.Body)
+	}
+}
+
+// TestTemplateGenerators tests dates, UUIDs and random IDs
+func TestTemplateGenerators(t *testing.T) {
+	engine := NewTemplateEngine(nil, nil)
+	resp, err := engine.Render(&models.Request{}, &models.IsResponse{
+		Template: true,
+		Body: map[string]interface{}{
+			"id":       "{{ uuid }}",
+			"token":    "{{ randomId 12 }}",
+			"roll":     "{{ randomInt 1 6 }}",
+			"tomorrow": `{{ now | dateAdd "24h" | formatDate "2006-01-02" }}`,
+			"count":    5,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body := resp.Body.(map[string]interface{})
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(body["id"].(string)) {
+		t.Errorf("expected a v4 UUID, got %q", body["id"])
+	}
+	if len(body["token"].(string)) != 12 {
+		t.Errorf("expected 12 character id, got %q", body["token"])
+	}
+	if roll := body["roll"].(string); roll < "1" || roll > "6" || len(roll) != 1 {
+		t.Errorf("expected roll in 1..6, got %q", roll)
+	}
+	if want := time.Now().UTC().Add(24 * time.Hour).Format("2006-01-02"); body["tomorrow"] != want {
+		t.Errorf("expected %s, got %v", want, body["tomorrow"])
+	}
+	if body["count"] != 5 {
+		t.Errorf("expected non-string values to pass through, got %v", body["count"])
+	}
+}
+
+// TestTemplateState tests that state persists between renders and is shared
+func TestTemplateState(t *testing.T) {
+	state := map[string]interface{}{"seeded": "from inject"}
+	engine := NewTemplateEngine(state, nil)
+
+	for i, expected := range []string{"from inject:", "from inject:first"} {
+		resp, err := engine.Render(&models.Request{Body: "first"}, &models.IsResponse{
+			Template: true,
+			Body:     `{{ .State "seeded" }}:{{ .State "last" }}{{ .SetState "last" .Request.Body }}`,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Body != expected {
+			t.Errorf("render %d: expected %q, got %q", i, expected, resp.Body)
+		}
+	}
+	if state["last"] != "first" {
+		t.Errorf("expected state to be shared, got %v", state["last"])
+	}
+}
+
+// TestTemplateHeadersAndStatus tests rendering of headers and string status codes
+func TestTemplateHeadersAndStatus(t *testing.T) {
+	engine := NewTemplateEngine(nil, nil)
+	original := &models.IsResponse{
+		Template:   true,
+		StatusCode: `{{ .Query "status" }}`,
+		Headers:    map[string]interface{}{"Location": "/orders/{{ .Query \"id\" }}"},
+	}
+	req := &models.Request{Query: map[string]string{"status": "201", "id": "9"}}
+
+	resp, err := engine.Render(req, original)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != "201" {
+		t.Errorf("expected status '201', got %v", resp.StatusCode)
+	}
+	if resp.Headers["Location"] != "/orders/9" {
+		t.Errorf("expected Location '/orders/9', got %v", resp.Headers["Location"])
+	}
+	if original.Headers["Location"] != "/orders/{{ .Query \"id\" }}" {
+		t.Error("expected the stub response to be left untouched")
+	}
+}
+
+// TestTemplateNotOptedIn tests that responses without _template are not rendered
+func TestTemplateNotOptedIn(t *testing.T) {
+	engine := NewTemplateEngine(nil, nil)
+	original := &models.IsResponse{Body: "{{ uuid }}"}
+
+	resp, err := engine.Render(&models.Request{}, original)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Body != "{{ uuid }}" {
+		t.Errorf("expected body untouched, got %v", resp.Body)
+	}
+}
+
+// TestTemplateErrors tests parse errors and the output size limit
+func TestTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		contains string
+	}{
+		{"parse error", "{{ .Query ", "body"},
+		{"unknown function", "{{ exec \"ls\" }}", "not defined"},
+		{"bad randomInt", "{{ randomInt 5 1 }}", "less than min"},
+		{"output limit", "{{ range 50000 }}xxxxxxxxxxxxxxxxxxxxxxxxx{{ end }}", "1MB"},
+		{"huge range", "{{ range 3000000000 }}{{ end }}", "error calling _step"},
+		{"nested ranges", "{{ range 1000 }}{{ range 1000 }}{{ end }}{{ end }}", "error calling _step"},
+		{"recursive templates", `{{ define "a" }}{{ template "b" }}{{ template "b" }}{{ end }}{{ define "b" }}{{ template "c" }}{{ template "c" }}{{ end }}{{ define "c" }}{{ range 40000 }}{{ end }}{{ end }}{{ template "a" }}`, "error calling _step"},
+		{"huge randomId", "{{ randomId 2000000000 }}", "out of range"},
+	}
+
+	engine := NewTemplateEngine(nil, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			_, err := engine.Render(&models.Request{}, &models.IsResponse{Body: tt.template, Template: true})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("expected error containing %q, got %v", tt.contains, err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*maxTemplateDuration {
+				t.Errorf("expected the template to fail fast, took %s", elapsed)
+			}
+		})
+	}
+}
+
+// TestTemplateStepsPerRender tests that the step limit applies to each
+// render rather than to the cached template
+func TestTemplateStepsPerRender(t *testing.T) {
+	engine := NewTemplateEngine(nil, nil)
+	resp := &models.IsResponse{Body: "{{ range 60000 }}{{ end }}ok", Template: true}
+	for i := 0; i < 3; i++ {
+		rendered, err := engine.Render(&models.Request{}, resp)
+		if err != nil {
+			t.Fatalf("render %d: unexpected error: %v", i, err)
+		}
+		if rendered.Body != "ok" {
+			t.Errorf("expected ok, got %v", rendered.Body)
+		}
+	}
+}
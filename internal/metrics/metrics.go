@@ -15,6 +15,15 @@ var (
 		[]string{"imposter", "protocol"},
 	)
 
+	// RequestsDroppedTotal tracks recorded requests dropped by retention policies
+	RequestsDroppedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mb_requests_dropped_total",
+			Help: "Total number of recorded requests dropped by request retention policies",
+		},
+		[]string{"imposter", "protocol"},
+	)
+
 	// PredicateMatchDuration tracks predicate matching duration
 	// This is the metric that mountebank tests expect
 	// Note: We add an "endpoint" label (e < i) so there's content between { and imposter
@@ -120,6 +129,11 @@ func RecordRequest(port, protocol string) {
 	RequestsTotal.WithLabelValues(port, protocol).Inc()
 }
 
+// RecordDroppedRequests records recorded requests dropped from an imposter
+func RecordDroppedRequests(port, protocol string, count int) {
+	RequestsDroppedTotal.WithLabelValues(port, protocol).Add(float64(count))
+}
+
 // RecordPredicateMatchDuration records the time taken to match predicates
 func RecordPredicateMatchDuration(endpoint, port string, duration float64) {
 	PredicateMatchDuration.WithLabelValues(endpoint, port).Observe(duration)
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Latency distribution names accepted by ChaosLatency.Distribution
+const (
+	DistributionFixed   = "fixed"
+	DistributionUniform = "uniform"
+	DistributionNormal  = "normal"
+	DistributionP99     = "p99"
+)
+
+// Chaos injects probabilistic failures into a response.
+// Each request rolls independently for latency, fault and error; a fixed
+// seed makes the sequence of outcomes reproducible across runs.
+type Chaos struct {
+	Seed    *int64        `json:"seed,omitempty"`
+	Latency *ChaosLatency `json:"latency,omitempty"`
+	Fault   *ChaosFault   `json:"fault,omitempty"`
+	Error   *ChaosError   `json:"error,omitempty"`
+
+	// Random source shared by every request hitting this behavior.
+	// Lazily created so a seed set after unmarshaling is honored.
+	mu  sync.Mutex `json:"-"`
+	rng *rand.Rand `json:"-"`
+}
+
+// ChaosLatency adds jittered latency drawn from a distribution (milliseconds)
+type ChaosLatency struct {
+	Probability  float64 `json:"probability"`
+	Distribution string  `json:"distribution,omitempty"` // fixed (default), uniform, normal, p99
+	Value        float64 `json:"value,omitempty"`        // fixed
+	Min          float64 `json:"min,omitempty"`          // uniform lower bound; clamp for all distributions
+	Max          float64 `json:"max,omitempty"`          // uniform upper bound; clamp for all distributions
+	Mean         float64 `json:"mean,omitempty"`         // normal
+	StdDev       float64 `json:"stddev,omitempty"`       // normal
+	P50          float64 `json:"p50,omitempty"`          // p99 (log-normal median)
+	P99          float64 `json:"p99,omitempty"`          // p99 (log-normal 99th percentile)
+}
+
+// ChaosFault replaces the response with a connection fault
+type ChaosFault struct {
+	Probability float64 `json:"probability"`
+	Type        string  `json:"type,omitempty"` // defaults to CONNECTION_RESET_BY_PEER
+}
+
+// ChaosError replaces the response with an error response
+type ChaosError struct {
+	Probability float64     `json:"probability"`
+	Response    *IsResponse `json:"response,omitempty"` // defaults to a bare 500
+}
+
+// Float64 returns the next value in [0.0, 1.0) from the chaos random source
+func (c *Chaos) Float64() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.source().Float64()
+}
+
+// NormFloat64 returns the next standard normal value from the chaos random source
+func (c *Chaos) NormFloat64() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.source().NormFloat64()
+}
+
+// source returns the random source, creating it on first use. Caller holds mu.
+func (c *Chaos) source() *rand.Rand {
+	if c.rng == nil {
+		seed := uint64(time.Now().UnixNano())
+		if c.Seed != nil {
+			seed = uint64(*c.Seed)
+		}
+		c.rng = rand.New(rand.NewPCG(seed, seed))
+	}
+	return c.rng
+}
@@ -41,8 +41,10 @@ type Imposter struct {
 	Host                 string                `json:"host,omitempty"` // Hostname/IP to bind to (empty = all interfaces)
 	Mode                 string                `json:"mode,omitempty"` // For TCP: "text" or "binary"
 	RecordRequests       bool                  `json:"recordRequests"`
+	RequestRetention     *RequestRetention     `json:"requestRetention,omitempty"`     // Bounds on recorded requests
 	AllowCORS            bool                  `json:"allowCORS,omitempty"`            // Enable CORS preflight support
 	EndOfRequestResolver *EndOfRequestResolver `json:"endOfRequestResolver,omitempty"` // For TCP: custom request boundary detection
+	Seed                 *int64                `json:"seed,omitempty"`                 // Seed for fake data generators (nil = random)
 	Stubs                []Stub                `json:"stubs,omitempty"`
 	DefaultResponse      *Response             `json:"defaultResponse,omitempty"`
 	Requests             []Request             `json:"requests,omitempty"`
@@ -73,6 +75,19 @@ type Imposter struct {
 
 	// Internal fields (conditionally serialized)
 	NumberOfRequests *int `json:"numberOfRequests,omitempty"`
+	DroppedRequests  int  `json:"droppedRequests,omitempty"` // Recorded requests dropped by the retention policy
+
+	// With --debug, why the latest unmatched requests failed jsonSchema predicates
+	SchemaViolations []SchemaViolation `json:"schemaViolations,omitempty"`
+}
+
+// SchemaViolation explains a request that matched no stub because it
+// failed the jsonSchema predicates of the stubs it would otherwise have matched
+type SchemaViolation struct {
+	Timestamp  string   `json:"timestamp"`
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Violations []string `json:"violations"`
 }
 
 // TCPRequest represents a recorded TCP request
@@ -150,8 +165,10 @@ func (imp *Imposter) MarshalJSON() ([]byte, error) {
 		Host                   string                `json:"host,omitempty"`
 		Mode                   string                `json:"mode,omitempty"`
 		RecordRequests         bool                  `json:"recordRequests"`
+		RequestRetention       *RequestRetention     `json:"requestRetention,omitempty"`
 		AllowCORS              bool                  `json:"allowCORS,omitempty"`
 		EndOfRequestResolver   *EndOfRequestResolver `json:"endOfRequestResolver,omitempty"`
+		Seed                   *int64                `json:"seed,omitempty"`
 		Stubs                  []Stub                `json:"stubs"`
 		DefaultResponse        *Response             `json:"defaultResponse,omitempty"`
 		Requests               interface{}           `json:"requests,omitempty"`
@@ -171,6 +188,8 @@ func (imp *Imposter) MarshalJSON() ([]byte, error) {
 		ValidFrom              string                `json:"validFrom,omitempty"`
 		ValidTo                string                `json:"validTo,omitempty"`
 		NumberOfRequests       *int                  `json:"numberOfRequests,omitempty"`
+		DroppedRequests        int                   `json:"droppedRequests,omitempty"`
+		SchemaViolations       []SchemaViolation     `json:"schemaViolations,omitempty"`
 	}
 
 	result := ImposterJSON{
@@ -180,8 +199,10 @@ func (imp *Imposter) MarshalJSON() ([]byte, error) {
 		Host:                   imp.Host,
 		Mode:                   imp.Mode,
 		RecordRequests:         imp.RecordRequests,
+		RequestRetention:       imp.RequestRetention,
 		AllowCORS:              imp.AllowCORS,
 		EndOfRequestResolver:   imp.EndOfRequestResolver,
+		Seed:                   imp.Seed,
 		DefaultResponse:        imp.DefaultResponse,
 		Links:                  imp.Links,
 		ProtoFiles:             imp.ProtoFiles,
@@ -199,6 +220,8 @@ func (imp *Imposter) MarshalJSON() ([]byte, error) {
 		ValidFrom:              imp.ValidFrom,
 		ValidTo:                imp.ValidTo,
 		NumberOfRequests:       imp.NumberOfRequests,
+		DroppedRequests:        imp.DroppedRequests,
+		SchemaViolations:       imp.SchemaViolations,
 	}
 
 	// Ensure stubs is never nil (required for mountebank compatibility)
@@ -266,6 +289,8 @@ func (imp *Imposter) ToJSON(options SerializeOptions) ([]byte, error) {
 		out.TCPRequests = nil
 		out.SMTPRequests = nil
 		out.GRPCRequests = nil
+		out.DroppedRequests = 0
+		out.SchemaViolations = nil
 	}
 
 	// Remove proxy stubs if requested
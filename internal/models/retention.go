@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// RequestRetention bounds the requests an imposter keeps while recording.
+// A zero field leaves that dimension unbounded; once any bound is exceeded
+// the oldest requests are dropped first.
+type RequestRetention struct {
+	MaxCount int   `json:"maxCount,omitempty"` // Requests kept
+	MaxBytes int64 `json:"maxBytes,omitempty"` // Total JSON size of the requests kept
+	MaxAge   int   `json:"maxAge,omitempty"`   // Milliseconds a request is kept
+}
+
+// IsZero reports whether the policy keeps every request
+func (r RequestRetention) IsZero() bool {
+	return r.MaxCount <= 0 && r.MaxBytes <= 0 && r.MaxAge <= 0
+}
+
+// WithDefaults returns the policy with unset bounds taken from defaults
+func (r RequestRetention) WithDefaults(defaults RequestRetention) RequestRetention {
+	if r.MaxCount <= 0 {
+		r.MaxCount = defaults.MaxCount
+	}
+	if r.MaxBytes <= 0 {
+		r.MaxBytes = defaults.MaxBytes
+	}
+	if r.MaxAge <= 0 {
+		r.MaxAge = defaults.MaxAge
+	}
+	return r
+}
+
+// Expired reports whether a request recorded at timestamp is older than
+// the policy's maximum age. Unreadable timestamps never expire.
+func (r RequestRetention) Expired(timestamp string, now time.Time) bool {
+	if r.MaxAge <= 0 {
+		return false
+	}
+	at, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return false
+	}
+	return now.Sub(at) > time.Duration(r.MaxAge)*time.Millisecond
+}
+
+// EffectiveRetention returns the imposter's retention policy with unset
+// bounds taken from defaults
+func (imp *Imposter) EffectiveRetention(defaults RequestRetention) RequestRetention {
+	if imp.RequestRetention == nil {
+		return defaults
+	}
+	return imp.RequestRetention.WithDefaults(defaults)
+}
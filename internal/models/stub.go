@@ -8,10 +8,19 @@ import (
 
 // Stub defines matching rules and responses
 type Stub struct {
+	// ID optionally names the stub so it can be addressed through
+	// /imposters/:id/stubs/by-id/:stubId while other stubs come and go
+	ID         string      `json:"id,omitempty"`
 	Predicates []Predicate `json:"predicates,omitempty"`
 	Responses  []Response  `json:"responses"`
 	Links      *StubLinks  `json:"_links,omitempty"`
 
+	// Scenario state machine: the stub only matches while ScenarioName is in
+	// RequiredScenarioState, and moves it to NewScenarioState when selected
+	ScenarioName          string `json:"scenarioName,omitempty"`
+	RequiredScenarioState string `json:"requiredScenarioState,omitempty"`
+	NewScenarioState      string `json:"newScenarioState,omitempty"`
+
 	// Internal state for response cycling (use atomic for thread safety)
 	// These are plain int64 so Stub can be copied; use atomic functions to access
 	responseIndex int64 `json:"-"`
@@ -30,17 +39,18 @@ type StubLinks struct {
 // Predicate defines match conditions
 type Predicate struct {
 	// Operators - only one should be set
-	Equals     interface{} `json:"equals,omitempty"`
-	DeepEquals interface{} `json:"deepEquals,omitempty"`
-	Contains   interface{} `json:"contains,omitempty"`
-	StartsWith interface{} `json:"startsWith,omitempty"`
-	EndsWith   interface{} `json:"endsWith,omitempty"`
-	Matches    interface{} `json:"matches,omitempty"`
-	Exists     interface{} `json:"exists,omitempty"`
-	Not        *Predicate  `json:"not,omitempty"`
-	And        []Predicate `json:"and,omitempty"`
-	Or         []Predicate `json:"or,omitempty"`
-	Inject     string      `json:"inject,omitempty"`
+	Equals     interface{}          `json:"equals,omitempty"`
+	DeepEquals interface{}          `json:"deepEquals,omitempty"`
+	Contains   interface{}          `json:"contains,omitempty"`
+	StartsWith interface{}          `json:"startsWith,omitempty"`
+	EndsWith   interface{}          `json:"endsWith,omitempty"`
+	Matches    interface{}          `json:"matches,omitempty"`
+	Exists     interface{}          `json:"exists,omitempty"`
+	Not        *Predicate           `json:"not,omitempty"`
+	And        []Predicate          `json:"and,omitempty"`
+	Or         []Predicate          `json:"or,omitempty"`
+	Inject     string               `json:"inject,omitempty"`
+	JSONSchema *JSONSchemaPredicate `json:"jsonSchema,omitempty"`
 
 	// Options
 	CaseSensitive    bool      `json:"caseSensitive,omitempty"`
@@ -50,6 +60,17 @@ type Predicate struct {
 	JSONPath         *Selector `json:"jsonpath,omitempty"`
 }
 
+// JSONSchemaPredicate validates parts of the request against JSON Schemas.
+// Each field is either an inline schema or a path, relative to --bodyDir, to
+// a JSON or YAML schema file, optionally with a JSON pointer fragment such as
+// "openapi.yaml#/components/schemas/Order". Query and header values are
+// validated as strings; header names are lowercased.
+type JSONSchemaPredicate struct {
+	Body    interface{} `json:"body,omitempty"`
+	Query   interface{} `json:"query,omitempty"`
+	Headers interface{} `json:"headers,omitempty"`
+}
+
 // Selector for XPath or JSONPath expressions
 type Selector struct {
 	Selector   string            `json:"selector"`
@@ -197,6 +218,8 @@ type IsResponse struct {
 	Body          interface{}            `json:"body,omitempty"`
 	Data          string                 `json:"data,omitempty"` // For TCP protocol
 	Mode          string                 `json:"_mode,omitempty"`
+	Template      bool                   `json:"_template,omitempty"` // Render body, headers and data as Go templates
+	BodyFile      string                 `json:"bodyFile,omitempty"`  // File to read the body from when body is unset
 
 	// Proxy response time tracking (used with addWaitBehavior)
 	ProxyResponseTime int `json:"_proxyResponseTime,omitempty"`
@@ -219,6 +242,24 @@ type ProxyResponse struct {
 	Key            string `json:"key,omitempty"`            // Private key PEM
 	Ciphers        string `json:"ciphers,omitempty"`        // TLS cipher suites
 	SecureProtocol string `json:"secureProtocol,omitempty"` // TLS version (TLSv1, TLSv1.1, TLSv1.2, TLSv1.3)
+
+	// Connection options for reaching the target
+	UpstreamProxy  string `json:"upstreamProxy,omitempty"`  // http://, https:// or socks5:// proxy to route through
+	CACert         string `json:"caCert,omitempty"`         // Inline PEM CA bundle to verify the target
+	ConnectTimeout int    `json:"connectTimeout,omitempty"` // Milliseconds allowed to connect, including the TLS handshake
+	ReadTimeout    int    `json:"readTimeout,omitempty"`    // Milliseconds allowed for the target to start responding, and for each read of its body
+	HTTP2          bool   `json:"http2,omitempty"`          // Negotiate HTTP/2 with HTTPS targets
+
+	// Recording options: these change the stubs the proxy records, never the
+	// response returned to the client
+	ScrubHeaders      []string `json:"scrubHeaders,omitempty"`      // Headers left out of recorded responses
+	RedactHeaders     []string `json:"redactHeaders,omitempty"`     // Headers recorded as [REDACTED]
+	ScrubFields       []string `json:"scrubFields,omitempty"`       // JSON body fields (dot paths) left out
+	RedactFields      []string `json:"redactFields,omitempty"`      // JSON body fields (dot paths) recorded as [REDACTED]
+	CollapseResponses bool     `json:"collapseResponses,omitempty"` // Merge recorded stubs with identical responses
+	BodyDir           string   `json:"bodyDir,omitempty"`           // Record bodies as content-addressed files in this directory
+	// Responses with larger bodies are passed on but not recorded
+	MaxRecordedBodySize int `json:"maxRecordedBodySize,omitempty"` // Bytes, default 10 MiB
 }
 
 // PredicateGen defines how to generate predicates from proxied requests
@@ -238,6 +279,7 @@ type Behavior struct {
 	Lookup         []Lookup    `json:"lookup,omitempty"`
 	Decorate       string      `json:"decorate,omitempty"`
 	ShellTransform interface{} `json:"shellTransform,omitempty"` // Can be string or []string
+	Chaos          *Chaos      `json:"chaos,omitempty"`
 }
 
 // UnmarshalJSON handles both array and object formats for copy and lookup
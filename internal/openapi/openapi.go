@@ -0,0 +1,476 @@
+// Package openapi converts OpenAPI 3.x documents into HTTP imposters.
+//
+// Each operation becomes a stub matching its method and (templated) path,
+// answering with the operation's success response built from the documented
+// examples or, failing that, a sample generated from the response schema.
+// Requests for a known path with an undocumented method get a 405, and
+// anything else falls through to a final 404 stub.
+package openapi
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/config"
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// Options controls how a document is converted
+type Options struct {
+	Port int    // Imposter port; 0 lets the server assign one
+	Name string // Imposter name; defaults to info.title
+}
+
+// httpMethods lists the OpenAPI operation keys in the order stubs are generated
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// maxSampleDepth stops sample generation for recursive schemas
+const maxSampleDepth = 8
+
+var pathParamPattern = regexp.MustCompile(`\{[^/{}]+\}`)
+
+// converter holds the parsed document so $refs can be resolved
+type converter struct {
+	doc map[string]interface{}
+}
+
+// Convert builds an HTTP imposter from an OpenAPI 3.x document in JSON or YAML
+func Convert(data []byte, opts Options) (*models.Imposter, error) {
+	parsed, err := config.DecodeYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse OpenAPI document: %w", err)
+	}
+	doc, ok := parsed.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("OpenAPI document must be an object")
+	}
+
+	version, _ := doc["openapi"].(string)
+	if !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q: only 3.x documents can be imported", version)
+	}
+	paths, ok := doc["paths"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("OpenAPI document has no paths")
+	}
+
+	c := &converter{doc: doc}
+	basePath := c.basePath()
+
+	name := opts.Name
+	if name == "" {
+		if info, ok := doc["info"].(map[string]interface{}); ok {
+			name, _ = info["title"].(string)
+		}
+	}
+
+	imp := &models.Imposter{
+		Port:     opts.Port,
+		Protocol: "http",
+		Name:     name,
+		Stubs:    []models.Stub{},
+	}
+
+	for _, path := range sortedPaths(paths) {
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		item = c.resolve(item)
+		pathPredicate := pathPredicate(basePath + path)
+
+		var allowed []string
+		for _, method := range httpMethods {
+			op, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			upper := strings.ToUpper(method)
+			allowed = append(allowed, upper)
+
+			imp.Stubs = append(imp.Stubs, models.Stub{
+				Predicates: []models.Predicate{{Equals: map[string]interface{}{"method": upper}}, pathPredicate},
+				Responses:  []models.Response{{Is: c.operationResponse(op)}},
+			})
+		}
+
+		if len(allowed) > 0 {
+			imp.Stubs = append(imp.Stubs, models.Stub{
+				Predicates: []models.Predicate{pathPredicate},
+				Responses: []models.Response{{Is: &models.IsResponse{
+					StatusCode: 405,
+					Headers: map[string]interface{}{
+						"Allow":        strings.Join(allowed, ", "),
+						"Content-Type": "application/json",
+					},
+					Body: map[string]interface{}{"error": "method not allowed"},
+				}}},
+			})
+		}
+	}
+
+	// A trailing catch-all rather than defaultResponse, since the default
+	// response would also fill in fields the operation stubs leave empty
+	imp.Stubs = append(imp.Stubs, models.Stub{
+		Responses: []models.Response{{Is: &models.IsResponse{
+			StatusCode: 404,
+			Headers:    map[string]interface{}{"Content-Type": "application/json"},
+			Body:       map[string]interface{}{"error": "no operation matches this request"},
+		}}},
+	})
+
+	return imp, nil
+}
+
+// sortedPaths orders paths so literal segments win over templated ones,
+// e.g. /pets/mine is tried before /pets/{id}
+func sortedPaths(paths map[string]interface{}) []string {
+	keys := make([]string, 0, len(paths))
+	for path := range paths {
+		keys = append(keys, path)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		pi := len(pathParamPattern.FindAllString(keys[i], -1))
+		pj := len(pathParamPattern.FindAllString(keys[j], -1))
+		if pi != pj {
+			return pi < pj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// pathPredicate matches a path exactly, or by regex when it has templated
+// parameters
+func pathPredicate(path string) models.Predicate {
+	if !pathParamPattern.MatchString(path) {
+		return models.Predicate{Equals: map[string]interface{}{"path": path}}
+	}
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range pathParamPattern.FindAllStringIndex(path, -1) {
+		pattern.WriteString(regexp.QuoteMeta(path[last:loc[0]]))
+		pattern.WriteString("[^/]+")
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(path[last:]))
+	pattern.WriteString("$")
+	return models.Predicate{Matches: map[string]interface{}{"path": pattern.String()}}
+}
+
+// basePath returns the path component of the first server URL, with server
+// variables replaced by their defaults
+func (c *converter) basePath() string {
+	servers, _ := c.doc["servers"].([]interface{})
+	if len(servers) == 0 {
+		return ""
+	}
+	server, _ := servers[0].(map[string]interface{})
+	raw, _ := server["url"].(string)
+
+	if vars, ok := server["variables"].(map[string]interface{}); ok {
+		for name, v := range vars {
+			variable, _ := v.(map[string]interface{})
+			if def, ok := variable["default"]; ok {
+				raw = strings.ReplaceAll(raw, "{"+name+"}", fmt.Sprint(def))
+			}
+		}
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(u.Path, "/")
+}
+
+// operationResponse builds the `is` response for an operation's success case
+func (c *converter) operationResponse(op map[string]interface{}) *models.IsResponse {
+	responses, _ := op["responses"].(map[string]interface{})
+	code, spec := successResponse(responses)
+	resp := &models.IsResponse{StatusCode: code}
+	if spec == nil {
+		return resp
+	}
+	spec = c.resolve(spec)
+
+	if headers, ok := spec["headers"].(map[string]interface{}); ok {
+		for name, h := range headers {
+			header, ok := h.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			header = c.resolve(header)
+			value := header["example"]
+			if value == nil {
+				if schema, ok := header["schema"].(map[string]interface{}); ok {
+					value = c.sample(schema, 0)
+				}
+			}
+			if value != nil {
+				if resp.Headers == nil {
+					resp.Headers = make(map[string]interface{})
+				}
+				resp.Headers[name] = fmt.Sprint(value)
+			}
+		}
+	}
+
+	content, _ := spec["content"].(map[string]interface{})
+	mediaType := preferredMediaType(content)
+	if mediaType == "" {
+		return resp
+	}
+	if resp.Headers == nil {
+		resp.Headers = make(map[string]interface{})
+	}
+	resp.Headers["Content-Type"] = mediaType
+
+	media, _ := content[mediaType].(map[string]interface{})
+	body := c.mediaExample(media)
+	if body == nil {
+		return resp
+	}
+	if isJSON(mediaType) {
+		resp.Body = body
+	} else if s, ok := body.(string); ok {
+		resp.Body = s
+	} else {
+		resp.Body = fmt.Sprint(body)
+	}
+	return resp
+}
+
+// successResponse picks the lowest documented 2xx response, then 2XX, then
+// default, so the stub mirrors the API's happy path
+func successResponse(responses map[string]interface{}) (int, map[string]interface{}) {
+	best := 0
+	for key := range responses {
+		if code, err := strconv.Atoi(key); err == nil && code >= 200 && code < 300 && (best == 0 || code < best) {
+			best = code
+		}
+	}
+	if best != 0 {
+		spec, _ := responses[strconv.Itoa(best)].(map[string]interface{})
+		return best, spec
+	}
+	for _, key := range []string{"2XX", "2xx", "default"} {
+		if spec, ok := responses[key].(map[string]interface{}); ok {
+			return 200, spec
+		}
+	}
+	return 200, nil
+}
+
+// preferredMediaType favours JSON responses
+func preferredMediaType(content map[string]interface{}) string {
+	if len(content) == 0 {
+		return ""
+	}
+	if _, ok := content["application/json"]; ok {
+		return "application/json"
+	}
+	types := make([]string, 0, len(content))
+	for mt := range content {
+		types = append(types, mt)
+	}
+	sort.Strings(types)
+	for _, mt := range types {
+		if isJSON(mt) {
+			return mt
+		}
+	}
+	return types[0]
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// mediaExample returns the media type's example, its first named example,
+// or a sample generated from its schema
+func (c *converter) mediaExample(media map[string]interface{}) interface{} {
+	if media == nil {
+		return nil
+	}
+	if example, ok := media["example"]; ok {
+		return example
+	}
+	if examples, ok := media["examples"].(map[string]interface{}); ok && len(examples) > 0 {
+		names := make([]string, 0, len(examples))
+		for name := range examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if example, ok := examples[names[0]].(map[string]interface{}); ok {
+			if value, ok := c.resolve(example)["value"]; ok {
+				return value
+			}
+		}
+	}
+	if schema, ok := media["schema"].(map[string]interface{}); ok {
+		return c.sample(schema, 0)
+	}
+	return nil
+}
+
+// sample generates a representative value for a schema
+func (c *converter) sample(schema map[string]interface{}, depth int) interface{} {
+	if depth > maxSampleDepth {
+		return nil
+	}
+	schema = c.resolve(schema)
+
+	for _, key := range []string{"example", "default", "const"} {
+		if v, ok := schema[key]; ok {
+			return v
+		}
+	}
+	if examples, ok := schema["examples"].([]interface{}); ok && len(examples) > 0 {
+		return examples[0]
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		merged := make(map[string]interface{})
+		for _, s := range allOf {
+			if sub, ok := s.(map[string]interface{}); ok {
+				if obj, ok := c.sample(sub, depth+1).(map[string]interface{}); ok {
+					for k, v := range obj {
+						merged[k] = v
+					}
+				}
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if options, ok := schema[key].([]interface{}); ok && len(options) > 0 {
+			if sub, ok := options[0].(map[string]interface{}); ok {
+				return c.sample(sub, depth+1)
+			}
+		}
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		obj := make(map[string]interface{})
+		if props, ok := schema["properties"].(map[string]interface{}); ok {
+			for name, p := range props {
+				if prop, ok := p.(map[string]interface{}); ok {
+					if v := c.sample(prop, depth+1); v != nil {
+						obj[name] = v
+					}
+				}
+			}
+		}
+		return obj
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		if items == nil {
+			return []interface{}{}
+		}
+		if v := c.sample(items, depth+1); v != nil {
+			return []interface{}{v}
+		}
+		return []interface{}{}
+	case "integer":
+		if min, ok := schema["minimum"]; ok {
+			return min
+		}
+		return 0
+	case "number":
+		if min, ok := schema["minimum"]; ok {
+			return min
+		}
+		return 0.0
+	case "boolean":
+		return true
+	case "string":
+		return sampleString(schema)
+	default:
+		return nil
+	}
+}
+
+// schemaType returns the schema's type, inferring object and array from
+// their keywords and taking the first non-null entry of an OpenAPI 3.1 type list
+func schemaType(schema map[string]interface{}) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	if _, ok := schema["items"]; ok {
+		return "array"
+	}
+	return ""
+}
+
+func sampleString(schema map[string]interface{}) string {
+	switch schema["format"] {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "time":
+		return "00:00:00"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "00000000-0000-4000-8000-000000000000"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	case "ipv6":
+		return "2001:db8::1"
+	case "byte":
+		return "c3RyaW5n"
+	}
+	return "string"
+}
+
+// resolve follows a local $ref ("#/components/...") until it reaches a
+// concrete object; unresolvable refs are returned as-is
+func (c *converter) resolve(obj map[string]interface{}) map[string]interface{} {
+	for i := 0; i < maxSampleDepth; i++ {
+		ref, ok := obj["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return obj
+		}
+		var current interface{} = c.doc
+		for _, token := range strings.Split(ref[2:], "/") {
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			m, ok := current.(map[string]interface{})
+			if !ok {
+				return obj
+			}
+			current = m[token]
+		}
+		next, ok := current.(map[string]interface{})
+		if !ok {
+			return obj
+		}
+		obj = next
+	}
+	return obj
+}
@@ -0,0 +1,192 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+const petstore = `
+openapi: 3.1.0
+info:
+  title: Petstore
+servers:
+  - url: https://api.example.com/{version}/
+    variables:
+      version:
+        default: v2
+paths:
+  /pets/{petId}/photos/{photoId}:
+    get:
+      responses:
+        200:
+          description: photo
+          content:
+            image/png:
+              example: not-really-a-png
+  /pets/{petId}:
+    get:
+      responses:
+        404:
+          description: missing
+        200:
+          $ref: '#/components/responses/Pet'
+    delete:
+      responses:
+        204:
+          description: deleted
+  /pets/mine:
+    get:
+      responses:
+        default:
+          description: mine
+          content:
+            application/json:
+              examples:
+                b: {value: {id: 2}}
+                a: {value: {id: 1}}
+components:
+  responses:
+    Pet:
+      description: one pet
+      headers:
+        X-Rate-Limit:
+          example: 100
+      content:
+        application/hal+json:
+          schema:
+            $ref: '#/components/schemas/Pet'
+  schemas:
+    Pet:
+      allOf:
+        - $ref: '#/components/schemas/Named'
+        - type: object
+          properties:
+            id: {type: [integer, "null"], minimum: 1}
+            status: {type: string, enum: [available, sold]}
+            owner: {type: string, format: email}
+            children:
+              type: array
+              items: {$ref: '#/components/schemas/Pet'}
+    Named:
+      properties:
+        name: {type: string, default: Rex}
+`
+
+func responseOf(stub models.Stub) *models.IsResponse {
+	return stub.Responses[0].Is
+}
+
+// TestConvertStubOrderAndPredicates tests path ordering, base paths and path templating
+func TestConvertStubOrderAndPredicates(t *testing.T) {
+	imp, err := Convert([]byte(petstore), Options{Port: 4545})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if imp.Name != "Petstore" || imp.Port != 4545 || imp.Protocol != "http" {
+		t.Errorf("unexpected imposter header: %+v", imp)
+	}
+
+	var got []string
+	for _, stub := range imp.Stubs {
+		var parts []string
+		for _, pred := range stub.Predicates {
+			if pred.Equals != nil {
+				for k, v := range pred.Equals.(map[string]interface{}) {
+					parts = append(parts, k+"="+v.(string))
+				}
+			}
+			if pred.Matches != nil {
+				parts = append(parts, "path~"+pred.Matches.(map[string]interface{})["path"].(string))
+			}
+		}
+		got = append(got, strings.Join(parts, " "))
+	}
+
+	expected := []string{
+		"method=GET path=/v2/pets/mine",
+		"path=/v2/pets/mine",
+		"method=GET path~^/v2/pets/[^/]+$",
+		"method=DELETE path~^/v2/pets/[^/]+$",
+		"path~^/v2/pets/[^/]+$",
+		"method=GET path~^/v2/pets/[^/]+/photos/[^/]+$",
+		"path~^/v2/pets/[^/]+/photos/[^/]+$",
+		"",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected stubs:\n got %q\nwant %q", got, expected)
+	}
+
+	if allow := responseOf(imp.Stubs[4]).Headers["Allow"]; allow != "GET, DELETE" {
+		t.Errorf("expected Allow 'GET, DELETE', got %v", allow)
+	}
+	if status := responseOf(imp.Stubs[len(imp.Stubs)-1]).StatusCode; status != 404 {
+		t.Errorf("expected trailing 404 stub, got %v", status)
+	}
+}
+
+// TestConvertResponses tests status selection, examples and schema samples
+func TestConvertResponses(t *testing.T) {
+	imp, err := Convert([]byte(petstore), Options{Name: "override"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if imp.Name != "override" {
+		t.Errorf("expected name override, got %q", imp.Name)
+	}
+
+	mine := responseOf(imp.Stubs[0])
+	if mine.StatusCode != 200 || !reflect.DeepEqual(mine.Body, map[string]interface{}{"id": 1}) {
+		t.Errorf("expected default response with first named example, got %v %v", mine.StatusCode, mine.Body)
+	}
+
+	pet := responseOf(imp.Stubs[2])
+	if pet.StatusCode != 200 {
+		t.Errorf("expected 200 over 404, got %v", pet.StatusCode)
+	}
+	if pet.Headers["Content-Type"] != "application/hal+json" || pet.Headers["X-Rate-Limit"] != "100" {
+		t.Errorf("unexpected headers: %v", pet.Headers)
+	}
+	body := pet.Body.(map[string]interface{})
+	if body["name"] != "Rex" || body["id"] != 1 || body["status"] != "available" || body["owner"] != "user@example.com" {
+		t.Errorf("unexpected sample body: %v", body)
+	}
+	if _, ok := body["children"].([]interface{}); !ok {
+		t.Errorf("expected recursive children to stop at an array, got %v", body["children"])
+	}
+
+	deleted := responseOf(imp.Stubs[3])
+	if deleted.StatusCode != 204 || deleted.Body != nil {
+		t.Errorf("expected empty 204, got %v %v", deleted.StatusCode, deleted.Body)
+	}
+
+	photo := responseOf(imp.Stubs[5])
+	if photo.Body != "not-really-a-png" || photo.Headers["Content-Type"] != "image/png" {
+		t.Errorf("expected non-JSON example as string body, got %v %v", photo.Body, photo.Headers)
+	}
+}
+
+// TestConvertErrors tests documents that cannot be imported
+func TestConvertErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		contains string
+	}{
+		{"swagger 2", `{"swagger": "2.0", "paths": {}}`, "unsupported OpenAPI version"},
+		{"no paths", `{"openapi": "3.0.0"}`, "no paths"},
+		{"not an object", `[1, 2]`, "must be an object"},
+		{"invalid", "openapi: [", "unable to parse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Convert([]byte(tt.doc), Options{})
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("expected error containing %q, got %v", tt.contains, err)
+			}
+		})
+	}
+}
@@ -14,11 +14,13 @@ type FilesystemRepositoryPlugin struct {
 }
 
 // NewFilesystemRepositoryPlugin creates a new filesystem repository plugin
-func NewFilesystemRepositoryPlugin(dataDir string) (*FilesystemRepositoryPlugin, error) {
+// that applies retention to imposters without their own requestRetention
+func NewFilesystemRepositoryPlugin(dataDir string, retention models.RequestRetention) (*FilesystemRepositoryPlugin, error) {
 	repo, err := repository.NewFilesystem(dataDir)
 	if err != nil {
 		return nil, err
 	}
+	repo.SetRequestRetention(retention)
 	return &FilesystemRepositoryPlugin{
 		repo: repo,
 	}, nil
@@ -86,6 +88,22 @@ func (f *FilesystemRepositoryPlugin) DeleteStub(port int, index int) error {
 	return f.repo.DeleteStub(port, index)
 }
 
+func (f *FilesystemRepositoryPlugin) GetStubByID(port int, id string) (models.Stub, error) {
+	return f.repo.GetStubByID(port, id)
+}
+
+func (f *FilesystemRepositoryPlugin) ReplaceStubByID(port int, id string, stub models.Stub) error {
+	return f.repo.ReplaceStubByID(port, id, stub)
+}
+
+func (f *FilesystemRepositoryPlugin) DeleteStubByID(port int, id string) error {
+	return f.repo.DeleteStubByID(port, id)
+}
+
+func (f *FilesystemRepositoryPlugin) InsertStubByID(port int, stub models.Stub, id string, after bool) error {
+	return f.repo.InsertStubByID(port, stub, id, after)
+}
+
 func (f *FilesystemRepositoryPlugin) ClearRequests(port int) error {
 	return f.repo.ClearRequests(port)
 }
@@ -111,5 +129,5 @@ func FilesystemRepositoryFactory(config pluginrepo.Config) (pluginrepo.Repositor
 		return nil, fmt.Errorf("filesystem repository requires a data directory")
 	}
 
-	return NewFilesystemRepositoryPlugin(dataDir)
+	return NewFilesystemRepositoryPlugin(dataDir, config.RequestRetention)
 }
@@ -0,0 +1,41 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+	pluginrepo "github.com/TetsujinOni/go-tartuffe/internal/plugin/repository"
+)
+
+// TestFilesystemRepositoryFactoryRetention tests that a file:// repository
+// applies the server's request retention defaults
+func TestFilesystemRepositoryFactoryRetention(t *testing.T) {
+	repo, err := FilesystemRepositoryFactory(pluginrepo.Config{
+		Scheme:           "file",
+		ConnectionString: t.TempDir(),
+		RequestRetention: models.RequestRetention{MaxCount: 2},
+	})
+	if err != nil {
+		t.Fatalf("FilesystemRepositoryFactory() error = %v", err)
+	}
+
+	if err := repo.Add(&models.Imposter{Port: 4547, Protocol: "http", RecordRequests: true}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	for _, path := range []string{"/a", "/b", "/c"} {
+		if err := repo.AddRequest(4547, models.Request{Method: "GET", Path: path}); err != nil {
+			t.Fatalf("AddRequest(%s) error = %v", path, err)
+		}
+	}
+
+	got, err := repo.Get(4547)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(got.Requests) != 2 || got.Requests[0].Path != "/b" {
+		t.Errorf("expected requests /b and /c, got %v", got.Requests)
+	}
+	if got.DroppedRequests != 1 {
+		t.Errorf("DroppedRequests = %d, want 1", got.DroppedRequests)
+	}
+}
@@ -79,6 +79,22 @@ func (m *MemoryRepositoryPlugin) DeleteStub(port int, index int) error {
 	return m.repo.DeleteStub(port, index)
 }
 
+func (m *MemoryRepositoryPlugin) GetStubByID(port int, id string) (models.Stub, error) {
+	return m.repo.GetStubByID(port, id)
+}
+
+func (m *MemoryRepositoryPlugin) ReplaceStubByID(port int, id string, stub models.Stub) error {
+	return m.repo.ReplaceStubByID(port, id, stub)
+}
+
+func (m *MemoryRepositoryPlugin) DeleteStubByID(port int, id string) error {
+	return m.repo.DeleteStubByID(port, id)
+}
+
+func (m *MemoryRepositoryPlugin) InsertStubByID(port int, stub models.Stub, id string, after bool) error {
+	return m.repo.InsertStubByID(port, stub, id, after)
+}
+
 func (m *MemoryRepositoryPlugin) ClearRequests(port int) error {
 	return m.repo.ClearRequests(port)
 }
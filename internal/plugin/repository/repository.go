@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
 	repo "github.com/TetsujinOni/go-tartuffe/internal/repository"
 )
 
@@ -40,6 +41,10 @@ type Config struct {
 
 	// Options contains plugin-specific options
 	Options map[string]interface{}
+
+	// RequestRetention holds the server's --maxRequests, --maxRequestBytes
+	// and --maxRequestAge defaults, for repositories that store requests
+	RequestRetention models.RequestRetention
 }
 
 // Factory creates repository instances.
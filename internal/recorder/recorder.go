@@ -0,0 +1,216 @@
+// Package recorder captures HTTP traffic to a dependency as a replayable
+// imposter.
+//
+// Recording stands up a proxyAlways imposter in front of the target, so every
+// request is forwarded and its response is appended to a stub keyed by the
+// generated predicates. Replayable then drops the proxy, strips volatile
+// headers and collapses duplicate stubs and responses, leaving a config that
+// serves the recorded traffic without the target.
+package recorder
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// DefaultIgnoreHeaders are response headers that differ on every call and
+// would make a recording noisy without helping replay
+var DefaultIgnoreHeaders = []string{
+	"Date",
+	"Age",
+	"Connection",
+	"Keep-Alive",
+	"Transfer-Encoding",
+	"Via",
+	"Server-Timing",
+	"X-Request-Id",
+	"X-Correlation-Id",
+	"X-Amzn-Trace-Id",
+	"X-B3-TraceId",
+	"X-B3-SpanId",
+	"Traceparent",
+	"Tracestate",
+	"Cf-Ray",
+}
+
+// DefaultMatches is the predicate generator used when none is given: one
+// stub per distinct method, path and query string
+var DefaultMatches = map[string]interface{}{
+	"method": true,
+	"path":   true,
+	"query":  true,
+}
+
+// Options configures a recording
+type Options struct {
+	Target              string
+	Port                int
+	Name                string
+	PredicateGenerators []models.PredicateGen
+	RedactHeaders       []string
+	RedactFields        []string
+	BodyDir             string // relative to the manager's body directory
+}
+
+// NewProxyImposter returns a proxyAlways imposter forwarding to the target
+func NewProxyImposter(options Options) (*models.Imposter, error) {
+	target, err := url.Parse(options.Target)
+	if err != nil || target.Host == "" || (target.Scheme != "http" && target.Scheme != "https") {
+		return nil, fmt.Errorf("target must be an http or https URL, got %q", options.Target)
+	}
+
+	generators := options.PredicateGenerators
+	if len(generators) == 0 {
+		generators = []models.PredicateGen{{Matches: DefaultMatches}}
+	}
+
+	name := options.Name
+	if name == "" {
+		name = "recording of " + target.Host
+	}
+
+	return &models.Imposter{
+		Protocol: "http",
+		Port:     options.Port,
+		Name:     name,
+		Stubs: []models.Stub{{
+			Responses: []models.Response{{
+				Proxy: &models.ProxyResponse{
+					To:                  strings.TrimRight(options.Target, "/"),
+					Mode:                "proxyAlways",
+					PredicateGenerators: generators,
+					RedactHeaders:       options.RedactHeaders,
+					RedactFields:        options.RedactFields,
+					BodyDir:             options.BodyDir,
+				},
+			}},
+		}},
+	}, nil
+}
+
+// Replayable returns a copy of a recording imposter without its proxy stubs.
+// Headers named in ignoreHeaders (case-insensitively) are removed from
+// recorded responses and header predicates, then stubs whose predicates are
+// now equal are merged and repeated responses within a stub are dropped.
+func Replayable(imp *models.Imposter, ignoreHeaders []string) *models.Imposter {
+	ignored := make(map[string]bool, len(ignoreHeaders))
+	for _, name := range ignoreHeaders {
+		ignored[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+
+	out := &models.Imposter{
+		Protocol: imp.Protocol,
+		Port:     imp.Port,
+		Name:     imp.Name,
+		Stubs:    []models.Stub{},
+	}
+
+	index := make(map[string]int)
+	for _, stub := range imp.Stubs {
+		if stub.IsProxyStub() {
+			continue
+		}
+
+		predicates := make([]models.Predicate, len(stub.Predicates))
+		for i, pred := range stub.Predicates {
+			predicates[i] = withoutHeaderPredicates(pred, ignored)
+		}
+		responses := make([]models.Response, 0, len(stub.Responses))
+		for _, resp := range stub.Responses {
+			responses = append(responses, withoutHeaders(resp, ignored))
+		}
+
+		key := jsonKey(predicates)
+		if i, ok := index[key]; ok {
+			out.Stubs[i].Responses = appendUnique(out.Stubs[i].Responses, responses...)
+			continue
+		}
+		index[key] = len(out.Stubs)
+		out.Stubs = append(out.Stubs, models.Stub{
+			Predicates: predicates,
+			Responses:  appendUnique(nil, responses...),
+		})
+	}
+
+	return out
+}
+
+// withoutHeaders copies a response, dropping ignored headers
+func withoutHeaders(resp models.Response, ignored map[string]bool) models.Response {
+	if resp.Is == nil || len(resp.Is.Headers) == 0 {
+		return resp
+	}
+	is := *resp.Is
+	is.Headers = make(map[string]interface{}, len(resp.Is.Headers))
+	for name, value := range resp.Is.Headers {
+		if !ignored[strings.ToLower(name)] {
+			is.Headers[name] = value
+		}
+	}
+	resp.Is = &is
+	return resp
+}
+
+// withoutHeaderPredicates copies a predicate, dropping ignored headers from
+// its equals and deepEquals header maps
+func withoutHeaderPredicates(pred models.Predicate, ignored map[string]bool) models.Predicate {
+	pred.Equals = withoutHeaderFields(pred.Equals, ignored)
+	pred.DeepEquals = withoutHeaderFields(pred.DeepEquals, ignored)
+	return pred
+}
+
+func withoutHeaderFields(fields interface{}, ignored map[string]bool) interface{} {
+	m, ok := fields.(map[string]interface{})
+	if !ok {
+		return fields
+	}
+	headers, ok := m["headers"].(map[string]interface{})
+	if !ok {
+		return fields
+	}
+
+	kept := make(map[string]interface{}, len(headers))
+	for name, value := range headers {
+		if !ignored[strings.ToLower(name)] {
+			kept[name] = value
+		}
+	}
+	copied := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	if len(kept) > 0 {
+		copied["headers"] = kept
+	} else {
+		delete(copied, "headers")
+	}
+	return copied
+}
+
+// appendUnique appends the responses not already in list
+func appendUnique(list []models.Response, responses ...models.Response) []models.Response {
+	for _, resp := range responses {
+		key := jsonKey(resp)
+		duplicate := false
+		for _, existing := range list {
+			if jsonKey(existing) == key {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			list = append(list, resp)
+		}
+	}
+	return list
+}
+
+// jsonKey compares values by their JSON form, which sorts map keys
+func jsonKey(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
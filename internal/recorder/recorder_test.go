@@ -0,0 +1,95 @@
+package recorder
+
+import (
+	"testing"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// TestNewProxyImposter tests target validation and the default generators
+func TestNewProxyImposter(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		wantErr bool
+	}{
+		{"http target", "http://localhost:8080/", false},
+		{"https target", "https://api.example.com", false},
+		{"missing scheme", "api.example.com", true},
+		{"unsupported scheme", "ftp://example.com", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imp, err := NewProxyImposter(Options{Target: tt.target, Port: 4545})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			proxy := imp.Stubs[0].Responses[0].Proxy
+			if proxy.Mode != "proxyAlways" || len(proxy.PredicateGenerators) != 1 {
+				t.Errorf("unexpected proxy: %+v", proxy)
+			}
+			if proxy.To[len(proxy.To)-1] == '/' {
+				t.Errorf("expected trailing slash trimmed, got %q", proxy.To)
+			}
+		})
+	}
+}
+
+// TestReplayable tests proxy removal, header filtering and de-duplication
+func TestReplayable(t *testing.T) {
+	is := func(body, requestID string) models.Response {
+		return models.Response{Is: &models.IsResponse{
+			StatusCode: 200,
+			Headers:    map[string]interface{}{"Content-Type": "text/plain", "x-request-id": requestID},
+			Body:       body,
+		}}
+	}
+	pred := func(path, traceID string) []models.Predicate {
+		return []models.Predicate{{Equals: map[string]interface{}{
+			"path":    path,
+			"headers": map[string]interface{}{"traceparent": traceID},
+		}}}
+	}
+
+	imp := &models.Imposter{
+		Protocol: "http",
+		Port:     4545,
+		Stubs: []models.Stub{
+			{Responses: []models.Response{{Proxy: &models.ProxyResponse{To: "http://target", Mode: "proxyAlways"}}}},
+			{Predicates: pred("/a", "t1"), Responses: []models.Response{is("one", "r1"), is("one", "r2"), is("two", "r3")}},
+			{Predicates: pred("/a", "t2"), Responses: []models.Response{is("one", "r4"), is("three", "r5")}},
+			{Predicates: pred("/b", "t3"), Responses: []models.Response{is("b", "r6")}},
+		},
+	}
+
+	out := Replayable(imp, []string{"X-Request-Id", "Traceparent"})
+
+	if len(out.Stubs) != 2 {
+		t.Fatalf("expected 2 stubs, got %d", len(out.Stubs))
+	}
+	var bodies []interface{}
+	for _, resp := range out.Stubs[0].Responses {
+		bodies = append(bodies, resp.Is.Body)
+		if _, ok := resp.Is.Headers["x-request-id"]; ok {
+			t.Error("expected ignored header to be removed")
+		}
+		if resp.Is.Headers["Content-Type"] != "text/plain" {
+			t.Error("expected other headers to be kept")
+		}
+	}
+	if len(bodies) != 3 || bodies[0] != "one" || bodies[1] != "two" || bodies[2] != "three" {
+		t.Errorf("expected distinct responses in recorded order, got %v", bodies)
+	}
+	if _, ok := out.Stubs[0].Predicates[0].Equals.(map[string]interface{})["headers"]; ok {
+		t.Error("expected emptied header predicate to be removed")
+	}
+
+	// The source imposter is left untouched
+	if _, ok := imp.Stubs[1].Responses[0].Is.Headers["x-request-id"]; !ok {
+		t.Error("expected original response headers to be unchanged")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -30,9 +31,10 @@ import (
 //	    /requests/
 //	      /{timestamp}.json
 type FilesystemRepository struct {
-	datadir string
-	counter int64
-	mu      sync.RWMutex
+	datadir   string
+	counter   int64
+	retention models.RequestRetention // Default bounds on recorded requests
+	mu        sync.RWMutex
 }
 
 // NewFilesystem creates a new filesystem-backed repository
@@ -48,6 +50,14 @@ func NewFilesystem(datadir string) (*FilesystemRepository, error) {
 	}, nil
 }
 
+// SetRequestRetention sets the bounds on recorded requests for imposters
+// that don't set their own
+func (r *FilesystemRepository) SetRequestRetention(defaults models.RequestRetention) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retention = defaults
+}
+
 // filenameFor generates a unique filename based on timestamp, pid, and counter
 func (r *FilesystemRepository) filenameFor() string {
 	epoch := time.Now().UnixMilli()
@@ -55,6 +65,27 @@ func (r *FilesystemRepository) filenameFor() string {
 	return fmt.Sprintf("%d-%d-%d", epoch, os.Getpid(), counter)
 }
 
+// filenameLess orders names made by filenameFor by creation. Their numbers
+// are not padded, so they are compared as numbers: "-10" sorts after "-9".
+func filenameLess(a, b string) bool {
+	aParts := strings.Split(strings.TrimSuffix(a, filepath.Ext(a)), "-")
+	bParts := strings.Split(strings.TrimSuffix(b, filepath.Ext(b)), "-")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		x, errX := strconv.ParseInt(aParts[i], 10, 64)
+		y, errY := strconv.ParseInt(bParts[i], 10, 64)
+		if errX != nil || errY != nil {
+			if aParts[i] != bParts[i] {
+				return aParts[i] < bParts[i]
+			}
+			continue
+		}
+		if x != y {
+			return x < y
+		}
+	}
+	return len(aParts) < len(bParts)
+}
+
 // imposterDir returns the directory for an imposter
 func (r *FilesystemRepository) imposterDir(port int) string {
 	return filepath.Join(r.datadir, strconv.Itoa(port))
@@ -115,17 +146,23 @@ type stubMeta struct {
 
 // imposterHeader holds the imposter header stored in imposter.json
 type imposterHeader struct {
-	Protocol       string               `json:"protocol"`
-	Port           int                  `json:"port"`
-	Name           string               `json:"name,omitempty"`
-	RecordRequests bool                 `json:"recordRequests,omitempty"`
-	Stubs          []imposterStubHeader `json:"stubs"`
+	Protocol         string                   `json:"protocol"`
+	Port             int                      `json:"port"`
+	Name             string                   `json:"name,omitempty"`
+	RecordRequests   bool                     `json:"recordRequests,omitempty"`
+	RequestRetention *models.RequestRetention `json:"requestRetention,omitempty"`
+	DroppedRequests  int                      `json:"droppedRequests,omitempty"`
+	Stubs            []imposterStubHeader     `json:"stubs"`
 }
 
-// imposterStubHeader holds stub header info (predicates + meta dir reference)
+// imposterStubHeader holds stub header info (predicates, scenario + meta dir reference)
 type imposterStubHeader struct {
-	Predicates []models.Predicate `json:"predicates,omitempty"`
-	Meta       struct {
+	ID                    string             `json:"id,omitempty"`
+	Predicates            []models.Predicate `json:"predicates,omitempty"`
+	ScenarioName          string             `json:"scenarioName,omitempty"`
+	RequiredScenarioState string             `json:"requiredScenarioState,omitempty"`
+	NewScenarioState      string             `json:"newScenarioState,omitempty"`
+	Meta                  struct {
 		Dir string `json:"dir"`
 	} `json:"meta"`
 }
@@ -149,11 +186,13 @@ func (r *FilesystemRepository) Add(imp *models.Imposter) error {
 
 	// Create the header with stub references
 	header := imposterHeader{
-		Protocol:       imp.Protocol,
-		Port:           imp.Port,
-		Name:           imp.Name,
-		RecordRequests: imp.RecordRequests,
-		Stubs:          make([]imposterStubHeader, 0),
+		Protocol:         imp.Protocol,
+		Port:             imp.Port,
+		Name:             imp.Name,
+		RecordRequests:   imp.RecordRequests,
+		RequestRetention: imp.RequestRetention,
+		DroppedRequests:  imp.DroppedRequests,
+		Stubs:            make([]imposterStubHeader, 0),
 	}
 
 	// Save each stub to disk
@@ -214,7 +253,11 @@ func (r *FilesystemRepository) saveStub(port int, stub models.Stub) (imposterStu
 	}
 
 	return imposterStubHeader{
-		Predicates: stub.Predicates,
+		ID:                    stub.ID,
+		Predicates:            stub.Predicates,
+		ScenarioName:          stub.ScenarioName,
+		RequiredScenarioState: stub.RequiredScenarioState,
+		NewScenarioState:      stub.NewScenarioState,
 		Meta: struct {
 			Dir string `json:"dir"`
 		}{Dir: "stubs/" + stubDirName},
@@ -242,12 +285,14 @@ func (r *FilesystemRepository) getImposter(port int) (*models.Imposter, error) {
 	}
 
 	imp := &models.Imposter{
-		Protocol:       header.Protocol,
-		Port:           header.Port,
-		Name:           header.Name,
-		RecordRequests: header.RecordRequests,
-		Stubs:          make([]models.Stub, 0),
-		Requests:       make([]models.Request, 0),
+		Protocol:         header.Protocol,
+		Port:             header.Port,
+		Name:             header.Name,
+		RecordRequests:   header.RecordRequests,
+		RequestRetention: header.RequestRetention,
+		DroppedRequests:  header.DroppedRequests,
+		Stubs:            make([]models.Stub, 0),
+		Requests:         make([]models.Request, 0),
 	}
 
 	// Load stubs
@@ -279,8 +324,12 @@ func (r *FilesystemRepository) loadStub(port int, header imposterStubHeader) (mo
 	}
 
 	stub := models.Stub{
-		Predicates: header.Predicates,
-		Responses:  make([]models.Response, 0),
+		ID:                    header.ID,
+		Predicates:            header.Predicates,
+		ScenarioName:          header.ScenarioName,
+		RequiredScenarioState: header.RequiredScenarioState,
+		NewScenarioState:      header.NewScenarioState,
+		Responses:             make([]models.Response, 0),
 	}
 
 	// Load responses
@@ -308,7 +357,7 @@ func (r *FilesystemRepository) loadRequests(port int) ([]models.Request, error)
 
 	// Sort by filename (which is timestamp-based)
 	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].Name() < entries[j].Name()
+		return filenameLess(entries[i].Name(), entries[j].Name())
 	})
 
 	requests := make([]models.Request, 0)
@@ -445,6 +494,10 @@ func (r *FilesystemRepository) UpdateStubs(port int, stubs []models.Stub) error
 		return err
 	}
 
+	if err := checkStubIDs(stubIDs(stubs)); err != nil {
+		return err
+	}
+
 	// Remove old stubs directory
 	os.RemoveAll(r.stubsDir(port))
 
@@ -476,6 +529,10 @@ func (r *FilesystemRepository) AddStub(port int, stub models.Stub, index int) er
 		return err
 	}
 
+	if err := checkStubIDs(append(headerStubIDs(header), stub.ID)); err != nil {
+		return err
+	}
+
 	// Save the new stub
 	stubHeader, err := r.saveStub(port, stub)
 	if err != nil {
@@ -529,16 +586,155 @@ func (r *FilesystemRepository) DeleteStub(port int, index int) error {
 	return nil
 }
 
+// readHeader reads an imposter's header (must be called with lock held)
+func (r *FilesystemRepository) readHeader(port int) (imposterHeader, error) {
+	var header imposterHeader
+	if err := readJSON(r.imposterFile(port), &header); err != nil {
+		if os.IsNotExist(err) {
+			return header, ErrNotFound{Port: port}
+		}
+		return header, err
+	}
+	return header, nil
+}
+
+// headerStubIDs lists the ids of the stubs in an imposter header
+func headerStubIDs(header imposterHeader) []string {
+	ids := make([]string, len(header.Stubs))
+	for i, stub := range header.Stubs {
+		ids[i] = stub.ID
+	}
+	return ids
+}
+
+// GetStubByID retrieves the stub with the given id
+func (r *FilesystemRepository) GetStubByID(port int, id string) (models.Stub, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	header, err := r.readHeader(port)
+	if err != nil {
+		return models.Stub{}, err
+	}
+
+	index := indexOfStubID(headerStubIDs(header), id)
+	if index < 0 {
+		return models.Stub{}, ErrStubNotFound{ID: id}
+	}
+	return r.loadStub(port, header.Stubs[index])
+}
+
+// ReplaceStubByID replaces the stub with the given id, keeping its position
+func (r *FilesystemRepository) ReplaceStubByID(port int, id string, stub models.Stub) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	header, err := r.readHeader(port)
+	if err != nil {
+		return err
+	}
+
+	ids := headerStubIDs(header)
+	index := indexOfStubID(ids, id)
+	if index < 0 {
+		return ErrStubNotFound{ID: id}
+	}
+	ids[index] = stub.ID
+	if err := checkStubIDs(ids); err != nil {
+		return err
+	}
+
+	stubHeader, err := r.saveStub(port, stub)
+	if err != nil {
+		return err
+	}
+	oldDir := filepath.Join(r.imposterDir(port), header.Stubs[index].Meta.Dir)
+	header.Stubs[index] = stubHeader
+
+	if err := writeJSON(r.imposterFile(port), header); err != nil {
+		return err
+	}
+	os.RemoveAll(oldDir)
+	return nil
+}
+
+// DeleteStubByID removes the stub with the given id
+func (r *FilesystemRepository) DeleteStubByID(port int, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	header, err := r.readHeader(port)
+	if err != nil {
+		return err
+	}
+
+	index := indexOfStubID(headerStubIDs(header), id)
+	if index < 0 {
+		return ErrStubNotFound{ID: id}
+	}
+	stubDir := filepath.Join(r.imposterDir(port), header.Stubs[index].Meta.Dir)
+	header.Stubs = append(header.Stubs[:index], header.Stubs[index+1:]...)
+
+	if err := writeJSON(r.imposterFile(port), header); err != nil {
+		return err
+	}
+	os.RemoveAll(stubDir)
+	return nil
+}
+
+// InsertStubByID adds a stub before the stub with the given id, or after it
+// if after is set
+func (r *FilesystemRepository) InsertStubByID(port int, stub models.Stub, id string, after bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	header, err := r.readHeader(port)
+	if err != nil {
+		return err
+	}
+
+	ids := headerStubIDs(header)
+	index := indexOfStubID(ids, id)
+	if index < 0 {
+		return ErrStubNotFound{ID: id}
+	}
+	if err := checkStubIDs(append(ids, stub.ID)); err != nil {
+		return err
+	}
+	if after {
+		index++
+	}
+
+	stubHeader, err := r.saveStub(port, stub)
+	if err != nil {
+		return err
+	}
+	stubs := make([]imposterStubHeader, 0, len(header.Stubs)+1)
+	stubs = append(stubs, header.Stubs[:index]...)
+	stubs = append(stubs, stubHeader)
+	header.Stubs = append(stubs, header.Stubs[index:]...)
+
+	return writeJSON(r.imposterFile(port), header)
+}
+
 // ClearRequests clears recorded requests for an imposter
 func (r *FilesystemRepository) ClearRequests(port int) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, err := os.Stat(r.imposterFile(port)); os.IsNotExist(err) {
-		return ErrNotFound{Port: port}
+	header, err := r.readHeader(port)
+	if err != nil {
+		return err
 	}
 
-	return os.RemoveAll(r.requestsDir(port))
+	if err := os.RemoveAll(r.requestsDir(port)); err != nil {
+		return err
+	}
+	if header.DroppedRequests == 0 {
+		return nil
+	}
+	header.DroppedRequests = 0
+	return writeJSON(r.imposterFile(port), header)
 }
 
 // ClearRequestsAndProxyStubs clears requests and removes proxy-generated stubs
@@ -572,6 +768,7 @@ func (r *FilesystemRepository) ClearRequestsAndProxyStubs(port int) error {
 	// Reset request count
 	count := 0
 	imp.NumberOfRequests = &count
+	imp.DroppedRequests = 0
 
 	// Save the updated imposter
 	return writeJSON(impFile, imp)
@@ -582,8 +779,9 @@ func (r *FilesystemRepository) AddRequest(port int, req models.Request) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, err := os.Stat(r.imposterFile(port)); os.IsNotExist(err) {
-		return ErrNotFound{Port: port}
+	header, err := r.readHeader(port)
+	if err != nil {
+		return err
 	}
 
 	reqDir := r.requestsDir(port)
@@ -597,7 +795,73 @@ func (r *FilesystemRepository) AddRequest(port int, req models.Request) error {
 	}
 
 	reqFile := filepath.Join(reqDir, r.filenameFor()+".json")
-	return writeJSON(reqFile, req)
+	if err := writeJSON(reqFile, req); err != nil {
+		return err
+	}
+
+	retention := r.retention
+	if header.RequestRetention != nil {
+		retention = header.RequestRetention.WithDefaults(r.retention)
+	}
+	if retention.IsZero() {
+		return nil
+	}
+
+	dropped, err := pruneRequests(reqDir, retention, time.Now())
+	if err != nil || dropped == 0 {
+		return err
+	}
+	header.DroppedRequests += dropped
+	return writeJSON(r.imposterFile(port), header)
+}
+
+// pruneRequests deletes the oldest request files in dir until the rest
+// are within the retention policy, returning how many it deleted. Age is
+// taken from each file's modification time and size from its length.
+func pruneRequests(dir string, retention models.RequestRetention, now time.Time) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return filenameLess(entries[i].Name(), entries[j].Name())
+	})
+
+	type requestFile struct {
+		name    string
+		size    int64
+		modTime time.Time
+	}
+	files := make([]requestFile, 0, len(entries))
+	var total int64
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, requestFile{entry.Name(), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+
+	maxAge := time.Duration(retention.MaxAge) * time.Millisecond
+	dropped := 0
+	for _, file := range files {
+		overCount := retention.MaxCount > 0 && len(files)-dropped > retention.MaxCount
+		overBytes := retention.MaxBytes > 0 && total > retention.MaxBytes
+		expired := retention.MaxAge > 0 && now.Sub(file.modTime) > maxAge
+		if !overCount && !overBytes && !expired {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, file.name)); err != nil && !os.IsNotExist(err) {
+			return dropped, err
+		}
+		total -= file.size
+		dropped++
+	}
+	return dropped, nil
 }
 
 // LoadAll loads all existing imposters from the datadir
@@ -105,6 +105,10 @@ func (r *InMemory) UpdateStubs(port int, stubs []models.Stub) error {
 		return ErrNotFound{Port: port}
 	}
 
+	if err := checkStubIDs(stubIDs(stubs)); err != nil {
+		return err
+	}
+
 	imp.Stubs = stubs
 	return nil
 }
@@ -119,6 +123,10 @@ func (r *InMemory) AddStub(port int, stub models.Stub, index int) error {
 		return ErrNotFound{Port: port}
 	}
 
+	if err := checkStubIDs(append(stubIDs(imp.Stubs), stub.ID)); err != nil {
+		return err
+	}
+
 	if index < 0 || index >= len(imp.Stubs) {
 		// Append to end
 		imp.Stubs = append(imp.Stubs, stub)
@@ -148,6 +156,101 @@ func (r *InMemory) DeleteStub(port int, index int) error {
 	return nil
 }
 
+// GetStubByID retrieves the stub with the given id
+func (r *InMemory) GetStubByID(port int, id string) (models.Stub, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	imp, ok := r.imposters[port]
+	if !ok {
+		return models.Stub{}, ErrNotFound{Port: port}
+	}
+
+	index := indexOfStubID(stubIDs(imp.Stubs), id)
+	if index < 0 {
+		return models.Stub{}, ErrStubNotFound{ID: id}
+	}
+	return imp.Stubs[index], nil
+}
+
+// ReplaceStubByID replaces the stub with the given id, keeping its position
+func (r *InMemory) ReplaceStubByID(port int, id string, stub models.Stub) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	imp, ok := r.imposters[port]
+	if !ok {
+		return ErrNotFound{Port: port}
+	}
+
+	ids := stubIDs(imp.Stubs)
+	index := indexOfStubID(ids, id)
+	if index < 0 {
+		return ErrStubNotFound{ID: id}
+	}
+	ids[index] = stub.ID
+	if err := checkStubIDs(ids); err != nil {
+		return err
+	}
+
+	stubs := make([]models.Stub, len(imp.Stubs))
+	copy(stubs, imp.Stubs)
+	stubs[index] = stub
+	imp.Stubs = stubs
+	return nil
+}
+
+// DeleteStubByID removes the stub with the given id
+func (r *InMemory) DeleteStubByID(port int, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	imp, ok := r.imposters[port]
+	if !ok {
+		return ErrNotFound{Port: port}
+	}
+
+	index := indexOfStubID(stubIDs(imp.Stubs), id)
+	if index < 0 {
+		return ErrStubNotFound{ID: id}
+	}
+
+	stubs := make([]models.Stub, 0, len(imp.Stubs)-1)
+	stubs = append(stubs, imp.Stubs[:index]...)
+	imp.Stubs = append(stubs, imp.Stubs[index+1:]...)
+	return nil
+}
+
+// InsertStubByID adds a stub before the stub with the given id, or after it
+// if after is set
+func (r *InMemory) InsertStubByID(port int, stub models.Stub, id string, after bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	imp, ok := r.imposters[port]
+	if !ok {
+		return ErrNotFound{Port: port}
+	}
+
+	ids := stubIDs(imp.Stubs)
+	index := indexOfStubID(ids, id)
+	if index < 0 {
+		return ErrStubNotFound{ID: id}
+	}
+	if err := checkStubIDs(append(ids, stub.ID)); err != nil {
+		return err
+	}
+	if after {
+		index++
+	}
+
+	stubs := make([]models.Stub, 0, len(imp.Stubs)+1)
+	stubs = append(stubs, imp.Stubs[:index]...)
+	stubs = append(stubs, stub)
+	imp.Stubs = append(stubs, imp.Stubs[index:]...)
+	return nil
+}
+
 // ClearRequests clears recorded requests for an imposter (HTTP and TCP)
 func (r *InMemory) ClearRequests(port int) error {
 	r.mu.Lock()
@@ -164,6 +267,8 @@ func (r *InMemory) ClearRequests(port int) error {
 	imp.GRPCRequests = nil
 	count := 0
 	imp.NumberOfRequests = &count
+	imp.DroppedRequests = 0
+	imp.SchemaViolations = nil
 	return nil
 }
 
@@ -184,6 +289,8 @@ func (r *InMemory) ClearRequestsAndProxyStubs(port int) error {
 	imp.GRPCRequests = nil
 	count := 0
 	imp.NumberOfRequests = &count
+	imp.DroppedRequests = 0
+	imp.SchemaViolations = nil
 
 	// Remove proxy-generated stubs
 	filteredStubs := make([]models.Stub, 0, len(imp.Stubs))
@@ -31,6 +31,19 @@ type Repository interface {
 	// DeleteStub removes a stub at the given index
 	DeleteStub(port int, index int) error
 
+	// GetStubByID retrieves the stub with the given id
+	GetStubByID(port int, id string) (models.Stub, error)
+
+	// ReplaceStubByID replaces the stub with the given id, keeping its position
+	ReplaceStubByID(port int, id string, stub models.Stub) error
+
+	// DeleteStubByID removes the stub with the given id
+	DeleteStubByID(port int, id string) error
+
+	// InsertStubByID adds a stub before the stub with the given id, or after
+	// it if after is set
+	InsertStubByID(port int, stub models.Stub, id string, after bool) error
+
 	// ClearRequests clears recorded requests for an imposter
 	ClearRequests(port int) error
 
@@ -68,3 +81,56 @@ type ErrInvalidIndex struct {
 func (e ErrInvalidIndex) Error() string {
 	return "bad data"
 }
+
+// ErrStubNotFound is returned when no stub has the given id
+type ErrStubNotFound struct {
+	ID string
+}
+
+func (e ErrStubNotFound) Error() string {
+	return "no such resource"
+}
+
+// ErrDuplicateStubID is returned when a stub id is already used by another
+// stub of the same imposter
+type ErrDuplicateStubID struct {
+	ID string
+}
+
+func (e ErrDuplicateStubID) Error() string {
+	return "resource conflict"
+}
+
+// indexOfStubID returns the position of id in ids, or -1
+func indexOfStubID(ids []string, id string) int {
+	for i, existing := range ids {
+		if existing == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkStubIDs returns ErrDuplicateStubID if any non-empty id appears twice
+func checkStubIDs(ids []string) error {
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		if seen[id] {
+			return ErrDuplicateStubID{ID: id}
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// stubIDs lists the ids of stubs
+func stubIDs(stubs []models.Stub) []string {
+	ids := make([]string, len(stubs))
+	for i, stub := range stubs {
+		ids[i] = stub.ID
+	}
+	return ids
+}
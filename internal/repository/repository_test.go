@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// TestStubsByID tests that every repository addresses stubs by id
+func TestStubsByID(t *testing.T) {
+	stub := func(id string) models.Stub {
+		return models.Stub{
+			ID:        id,
+			Responses: []models.Response{{Is: &models.IsResponse{Body: id}}},
+		}
+	}
+
+	filesystem, err := NewFilesystem(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFilesystem() error = %v", err)
+	}
+	repos := map[string]Repository{
+		"memory":     NewInMemory(),
+		"filesystem": filesystem,
+	}
+
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			imp := &models.Imposter{Port: 4545, Protocol: "http", Stubs: []models.Stub{stub("a"), stub("c")}}
+			if err := repo.Add(imp); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+
+			if err := repo.InsertStubByID(4545, stub("b"), "c", false); err != nil {
+				t.Fatalf("InsertStubByID(before) error = %v", err)
+			}
+			if err := repo.InsertStubByID(4545, stub("d"), "c", true); err != nil {
+				t.Fatalf("InsertStubByID(after) error = %v", err)
+			}
+			if err := repo.AddStub(4545, stub("a"), -1); err == nil {
+				t.Error("AddStub() with a duplicate id should fail")
+			} else if _, ok := err.(ErrDuplicateStubID); !ok {
+				t.Errorf("AddStub() error = %T, want ErrDuplicateStubID", err)
+			}
+
+			replacement := stub("b")
+			replacement.Responses[0].Is.Body = "replaced"
+			if err := repo.ReplaceStubByID(4545, "b", replacement); err != nil {
+				t.Fatalf("ReplaceStubByID() error = %v", err)
+			}
+			if err := repo.ReplaceStubByID(4545, "b", stub("c")); err == nil {
+				t.Error("ReplaceStubByID() taking another stub's id should fail")
+			}
+
+			got, err := repo.GetStubByID(4545, "b")
+			if err != nil {
+				t.Fatalf("GetStubByID() error = %v", err)
+			}
+			if body := got.Responses[0].Is.Body; body != "replaced" {
+				t.Errorf("GetStubByID() body = %v, want replaced", body)
+			}
+
+			if err := repo.DeleteStubByID(4545, "a"); err != nil {
+				t.Fatalf("DeleteStubByID() error = %v", err)
+			}
+			if _, err := repo.GetStubByID(4545, "a"); err == nil {
+				t.Error("GetStubByID() after delete should fail")
+			} else if _, ok := err.(ErrStubNotFound); !ok {
+				t.Errorf("GetStubByID() error = %T, want ErrStubNotFound", err)
+			}
+
+			stored, err := repo.Get(4545)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			var ids []string
+			for _, s := range stored.Stubs {
+				ids = append(ids, s.ID)
+			}
+			want := []string{"b", "c", "d"}
+			if len(ids) != len(want) {
+				t.Fatalf("stub ids = %v, want %v", ids, want)
+			}
+			for i := range want {
+				if ids[i] != want[i] {
+					t.Errorf("stub ids = %v, want %v", ids, want)
+					break
+				}
+			}
+		})
+	}
+}
+
+// TestFilesystemRequestRetention tests that the filesystem repository
+// prunes the oldest request files to the retention policy
+func TestFilesystemRequestRetention(t *testing.T) {
+	repo, err := NewFilesystem(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFilesystem() error = %v", err)
+	}
+	repo.SetRequestRetention(models.RequestRetention{MaxCount: 5})
+
+	imp := &models.Imposter{
+		Port:             4546,
+		Protocol:         "http",
+		RecordRequests:   true,
+		RequestRetention: &models.RequestRetention{MaxCount: 2},
+	}
+	if err := repo.Add(imp); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	for _, path := range []string{"/a", "/b", "/c"} {
+		if err := repo.AddRequest(4546, models.Request{Method: "GET", Path: path}); err != nil {
+			t.Fatalf("AddRequest(%s) error = %v", path, err)
+		}
+	}
+
+	got, err := repo.Get(4546)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(got.Requests) != 2 || got.Requests[0].Path != "/b" || got.Requests[1].Path != "/c" {
+		t.Errorf("expected requests /b and /c, got %v", got.Requests)
+	}
+	if got.DroppedRequests != 1 {
+		t.Errorf("DroppedRequests = %d, want 1", got.DroppedRequests)
+	}
+
+	if err := repo.ClearRequests(4546); err != nil {
+		t.Fatalf("ClearRequests() error = %v", err)
+	}
+	if got, _ = repo.Get(4546); len(got.Requests) != 0 || got.DroppedRequests != 0 {
+		t.Errorf("expected cleared requests and drop count, got %d requests and %d dropped",
+			len(got.Requests), got.DroppedRequests)
+	}
+}
+
+// TestFilesystemRequestOrder tests that request files written in the same
+// millisecond keep their order past the ninth, in reads and when pruning
+func TestFilesystemRequestOrder(t *testing.T) {
+	repo, err := NewFilesystem(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFilesystem() error = %v", err)
+	}
+	if err := repo.Add(&models.Imposter{Port: 4547, Protocol: "http", RecordRequests: true}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	dir := repo.requestsDir(4547)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 12; i++ {
+		name := fmt.Sprintf("1700000000000-4242-%d.json", i)
+		if err := writeJSON(filepath.Join(dir, name), models.Request{Path: fmt.Sprintf("/%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := repo.Get(4547)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	for i, req := range got.Requests {
+		if want := fmt.Sprintf("/%d", i+1); req.Path != want {
+			t.Fatalf("request %d: got %s, want %s", i, req.Path, want)
+		}
+	}
+
+	dropped, err := pruneRequests(dir, models.RequestRetention{MaxCount: 3}, time.Now())
+	if err != nil || dropped != 9 {
+		t.Fatalf("pruneRequests() = %d, %v; want 9 dropped", dropped, err)
+	}
+	got, _ = repo.Get(4547)
+	var paths []string
+	for _, req := range got.Requests {
+		paths = append(paths, req.Path)
+	}
+	if strings.Join(paths, ",") != "/10,/11,/12" {
+		t.Errorf("expected the newest requests to be kept, got %v", paths)
+	}
+}
@@ -3,6 +3,8 @@ package response
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/config"
 )
 
 // ErrorResponse is the standard error format
@@ -15,6 +17,7 @@ type Error struct {
 	Code    string  `json:"code"`
 	Message string  `json:"message"`
 	Source  *string `json:"source,omitempty"`
+	Pointer string  `json:"pointer,omitempty"` // JSON pointer to the offending value in the request body
 }
 
 // Error codes matching mountebank
@@ -24,6 +27,8 @@ const (
 	ErrCodeNoSuchResource   = "no such resource"
 	ErrCodeInvalidJSON      = "invalid JSON"
 	ErrCodeInvalidInjection = "invalid injection"
+	ErrCodeUnauthorized     = "unauthorized"
+	ErrCodeForbidden        = "forbidden"
 )
 
 // WriteError writes an error response
@@ -52,3 +57,16 @@ func WriteJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 		json.NewEncoder(w).Encode(data)
 	}
 }
+
+// WriteYAML writes a YAML response with the same fields as WriteJSON
+func WriteYAML(w http.ResponseWriter, statusCode int, data interface{}) {
+	body, err := config.EncodeYAML(data)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, ErrCodeBadData, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
@@ -0,0 +1,212 @@
+// Package tenant scopes the admin API to tenants on a shared server. A
+// tenants file maps each API key to a role and, unless the key is an admin
+// key, to a tenant; each tenant owns a range of imposter ports, and its
+// keys only see and change the imposters on those ports.
+package tenant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/config"
+)
+
+// Role is what a key may do
+type Role string
+
+const (
+	RoleAdmin     Role = "admin"     // every imposter, and the server-wide routes
+	RoleReadWrite Role = "readWrite" // create, change and delete the tenant's imposters
+	RoleReadOnly  Role = "readOnly"  // read the tenant's imposters
+)
+
+// PortRange is an inclusive range of imposter ports
+type PortRange struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// Contains reports whether port is in the range
+func (r PortRange) Contains(port int) bool {
+	return port >= r.From && port <= r.To
+}
+
+func (r PortRange) overlaps(other PortRange) bool {
+	return r.From <= other.To && other.From <= r.To
+}
+
+// Tenant is a namespace of imposters
+type Tenant struct {
+	Name  string    `json:"name"`
+	Ports PortRange `json:"ports"`
+}
+
+// Key maps an API key to a tenant and role. Role defaults to readWrite;
+// admin keys have no tenant.
+type Key struct {
+	Key    string `json:"key"`
+	Tenant string `json:"tenant,omitempty"`
+	Role   Role   `json:"role,omitempty"`
+}
+
+// File is the tenants file
+type File struct {
+	Tenants []Tenant `json:"tenants"`
+	Keys    []Key    `json:"keys"`
+}
+
+// Caller is the tenant and role behind an admin API request. A nil Caller,
+// on a server without tenants, may do anything.
+type Caller struct {
+	Tenant string // empty for admin keys
+	Role   Role
+	Ports  PortRange
+}
+
+// IsAdmin reports whether the caller may act on every imposter
+func (c *Caller) IsAdmin() bool {
+	return c == nil || c.Role == RoleAdmin
+}
+
+// CanWrite reports whether the caller may change imposters
+func (c *Caller) CanWrite() bool {
+	return c == nil || c.Role != RoleReadOnly
+}
+
+// Allows reports whether the caller may act on the imposter on port
+func (c *Caller) Allows(port int) bool {
+	return c.IsAdmin() || c.Ports.Contains(port)
+}
+
+// CheckPort returns an error if the caller may not act on the imposter
+// on port
+func (c *Caller) CheckPort(port int) error {
+	if c.Allows(port) {
+		return nil
+	}
+	return fmt.Errorf("port %d is outside the ports of tenant %q (%d-%d)", port, c.Tenant, c.Ports.From, c.Ports.To)
+}
+
+// Registry resolves API keys to callers
+type Registry struct {
+	callers map[string]*Caller
+}
+
+// Load reads a tenants file, JSON or, for .yaml and .yml files, YAML
+func Load(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenants file: %w", err)
+	}
+
+	if config.IsYAMLFile(path) {
+		doc, err := config.DecodeYAML(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tenants YAML: %w", err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	var file File
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse tenants file: %w", err)
+	}
+
+	registry, err := New(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tenants file: %w", err)
+	}
+	return registry, nil
+}
+
+// New checks a tenants file and builds the registry from it. Tenant port
+// ranges may not overlap, since a port's range is what places an imposter
+// in a tenant.
+func New(file File) (*Registry, error) {
+	tenants := make(map[string]Tenant, len(file.Tenants))
+	for i, t := range file.Tenants {
+		if t.Name == "" {
+			return nil, fmt.Errorf("tenant %d: 'name' is required", i)
+		}
+		if _, ok := tenants[t.Name]; ok {
+			return nil, fmt.Errorf("tenant %q is defined more than once", t.Name)
+		}
+		if t.Ports.From < 1 || t.Ports.To > 65535 || t.Ports.From > t.Ports.To {
+			return nil, fmt.Errorf("tenant %q: 'ports' must be a range of port numbers", t.Name)
+		}
+		for _, other := range file.Tenants[:i] {
+			if t.Ports.overlaps(other.Ports) {
+				return nil, fmt.Errorf("tenant %q: ports overlap those of tenant %q", t.Name, other.Name)
+			}
+		}
+		tenants[t.Name] = t
+	}
+
+	r := &Registry{callers: make(map[string]*Caller, len(file.Keys))}
+	for i, k := range file.Keys {
+		if k.Key == "" {
+			return nil, fmt.Errorf("key %d: 'key' is required", i)
+		}
+		if _, ok := r.callers[k.Key]; ok {
+			return nil, fmt.Errorf("key %d is defined more than once", i)
+		}
+
+		role := k.Role
+		if role == "" {
+			role = RoleReadWrite
+		}
+		switch role {
+		case RoleAdmin:
+			if k.Tenant != "" {
+				return nil, fmt.Errorf("key %d: admin keys do not belong to a tenant", i)
+			}
+			r.callers[k.Key] = &Caller{Role: role}
+			continue
+		case RoleReadWrite, RoleReadOnly:
+		default:
+			return nil, fmt.Errorf("key %d: unknown role %q; expected one of %s, %s, %s", i, role, RoleAdmin, RoleReadWrite, RoleReadOnly)
+		}
+
+		t, ok := tenants[k.Tenant]
+		if !ok {
+			return nil, fmt.Errorf("key %d: unknown tenant %q", i, k.Tenant)
+		}
+		r.callers[k.Key] = &Caller{Tenant: t.Name, Role: role, Ports: t.Ports}
+	}
+	return r, nil
+}
+
+// AddAdminKey adds an admin key, such as the server's --apikey
+func (r *Registry) AddAdminKey(key string) {
+	r.callers[key] = &Caller{Role: RoleAdmin}
+}
+
+// Lookup returns the caller for an API key
+func (r *Registry) Lookup(key string) (*Caller, bool) {
+	if key == "" {
+		return nil, false
+	}
+	c, ok := r.callers[key]
+	return c, ok
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the caller
+func NewContext(ctx context.Context, c *Caller) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext returns the caller of a request, or nil when the server has
+// no tenants and every caller may act on every imposter
+func FromContext(ctx context.Context) *Caller {
+	c, _ := ctx.Value(contextKey{}).(*Caller)
+	return c
+}
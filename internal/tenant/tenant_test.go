@@ -0,0 +1,140 @@
+package tenant
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestNewInvalid tests the tenants files New rejects
+func TestNewInvalid(t *testing.T) {
+	payments := Tenant{Name: "payments", Ports: PortRange{From: 5000, To: 5099}}
+
+	tests := []struct {
+		name string
+		file File
+		err  string
+	}{
+		{"unnamed tenant", File{Tenants: []Tenant{{Ports: PortRange{From: 1, To: 2}}}}, "'name' is required"},
+		{"duplicate tenant", File{Tenants: []Tenant{payments, payments}}, "defined more than once"},
+		{"empty range", File{Tenants: []Tenant{{Name: "a"}}}, "range of port numbers"},
+		{"reversed range", File{Tenants: []Tenant{{Name: "a", Ports: PortRange{From: 10, To: 5}}}}, "range of port numbers"},
+		{"overlapping ranges", File{Tenants: []Tenant{payments, {Name: "search", Ports: PortRange{From: 5099, To: 5199}}}}, "overlap"},
+		{"empty key", File{Keys: []Key{{Role: RoleAdmin}}}, "'key' is required"},
+		{"duplicate key", File{Keys: []Key{{Key: "k", Role: RoleAdmin}, {Key: "k", Role: RoleAdmin}}}, "defined more than once"},
+		{"unknown role", File{Tenants: []Tenant{payments}, Keys: []Key{{Key: "k", Tenant: "payments", Role: "owner"}}}, "unknown role"},
+		{"unknown tenant", File{Tenants: []Tenant{payments}, Keys: []Key{{Key: "k", Tenant: "search"}}}, "unknown tenant"},
+		{"admin with tenant", File{Tenants: []Tenant{payments}, Keys: []Key{{Key: "k", Tenant: "payments", Role: RoleAdmin}}}, "admin keys"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.file)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+// TestLookup tests resolving keys to callers and what each role allows
+func TestLookup(t *testing.T) {
+	r, err := New(File{
+		Tenants: []Tenant{{Name: "payments", Ports: PortRange{From: 5000, To: 5099}}},
+		Keys: []Key{
+			{Key: "pay", Tenant: "payments"},
+			{Key: "pay-view", Tenant: "payments", Role: RoleReadOnly},
+			{Key: "ops", Role: RoleAdmin},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r.AddAdminKey("global")
+
+	tests := []struct {
+		key      string
+		admin    bool
+		canWrite bool
+		allows   []int
+		denies   []int
+	}{
+		{"pay", false, true, []int{5000, 5099}, []int{4999, 5100}},
+		{"pay-view", false, false, []int{5050}, []int{6000}},
+		{"ops", true, true, []int{1, 5050, 65535}, nil},
+		{"global", true, true, []int{5050, 6000}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			c, ok := r.Lookup(tt.key)
+			if !ok {
+				t.Fatalf("key %q not found", tt.key)
+			}
+			if c.IsAdmin() != tt.admin || c.CanWrite() != tt.canWrite {
+				t.Errorf("expected admin=%v canWrite=%v, got %+v", tt.admin, tt.canWrite, c)
+			}
+			for _, port := range tt.allows {
+				if err := c.CheckPort(port); err != nil {
+					t.Errorf("expected port %d to be allowed: %v", port, err)
+				}
+			}
+			for _, port := range tt.denies {
+				if c.Allows(port) {
+					t.Errorf("expected port %d to be denied", port)
+				}
+			}
+		})
+	}
+
+	for _, key := range []string{"", "unknown"} {
+		if _, ok := r.Lookup(key); ok {
+			t.Errorf("expected key %q not to be found", key)
+		}
+	}
+}
+
+// TestNilCaller tests that a server without tenants allows everything
+func TestNilCaller(t *testing.T) {
+	c := FromContext(context.Background())
+	if c != nil {
+		t.Fatalf("expected no caller, got %+v", c)
+	}
+	if !c.IsAdmin() || !c.CanWrite() || c.CheckPort(4545) != nil {
+		t.Error("expected a nil caller to be allowed everything")
+	}
+
+	caller := &Caller{Tenant: "payments", Role: RoleReadOnly}
+	if FromContext(NewContext(context.Background(), caller)) != caller {
+		t.Error("expected the caller back from the context")
+	}
+}
+
+// TestLoad tests reading JSON and YAML tenants files
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"tenants.json": `{"tenants": [{"name": "payments", "ports": {"from": 5000, "to": 5099}}], "keys": [{"key": "pay", "tenant": "payments"}]}`,
+		"tenants.yaml": "tenants:\n  - name: payments\n    ports: {from: 5000, to: 5099}\nkeys:\n  - key: pay\n    tenant: payments\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0644)
+
+		r, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if c, ok := r.Lookup("pay"); !ok || c.Tenant != "payments" || c.Ports.To != 5099 {
+			t.Errorf("%s: unexpected caller %+v", name, c)
+		}
+	}
+
+	path := filepath.Join(dir, "typo.json")
+	os.WriteFile(path, []byte(`{"tenant": []}`), 0644)
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Errorf("expected an unknown field error, got %v", err)
+	}
+}
@@ -0,0 +1,521 @@
+package validate
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// predicateOperators are the keys that make an object a predicate; the
+// other predicate keys are options
+var predicateOperators = []string{
+	"equals", "deepEquals", "contains", "startsWith", "endsWith", "matches",
+	"exists", "not", "and", "or", "inject", "jsonSchema",
+}
+
+// responseTypes are the keys that choose what a response does. A response
+// with none of them is the shorthand for an is response.
+var responseTypes = []string{"is", "proxy", "inject", "fault"}
+
+var faults = []string{models.FaultConnectionResetByPeer, models.FaultRandomDataThenClose}
+
+func imposter(c *checker, ptr string, v interface{}) {
+	c.object(ptr, v, "imposter", imposterFields())
+}
+
+func imposterFields() fields {
+	return fields{
+		"port":                 port,
+		"protocol":             str,
+		"name":                 str,
+		"host":                 str,
+		"mode":                 enum("text", "binary"),
+		"recordRequests":       boolean,
+		"requestRetention":     objectOf("requestRetention", requestRetentionFields()),
+		"allowCORS":            boolean,
+		"endOfRequestResolver": objectOf("endOfRequestResolver", endOfRequestResolverFields()),
+		"seed":                 integer,
+		"stubs":                arrayOf(stub),
+		"defaultResponse":      response,
+
+		// gRPC
+		"protoFiles":       arrayOf(str),
+		"protoDirectory":   str,
+		"services":         arrayOf(service),
+		"enableReflection": boolean,
+
+		// HTTPS
+		"key":                str,
+		"cert":               str,
+		"mutualAuth":         boolean,
+		"rejectUnauthorized": boolean,
+		"ca":                 arrayOf(str),
+		"ciphers":            str,
+
+		// Read-only fields the API returns, accepted so that an imposter
+		// read from the API can be sent back
+		"requests":               arrayOf(anything),
+		"tcpRequests":            arrayOf(anything),
+		"smtpRequests":           arrayOf(anything),
+		"grpcRequests":           arrayOf(anything),
+		"numberOfRequests":       nonNegativeInteger,
+		"droppedRequests":        nonNegativeInteger,
+		"schemaViolations":       arrayOf(anything),
+		"_links":                 anyObject,
+		"certificateFingerprint": str,
+		"commonName":             str,
+		"validFrom":              str,
+		"validTo":                str,
+	}
+}
+
+func requestRetentionFields() fields {
+	return fields{"maxCount": nonNegativeInteger, "maxBytes": nonNegativeInteger, "maxAge": nonNegativeInteger}
+}
+
+func endOfRequestResolverFields() fields {
+	return fields{"inject": str}
+}
+
+func port(c *checker, ptr string, v interface{}) {
+	if n, ok := toInteger(v); !ok || n < 0 || n > 65535 {
+		c.add(ptr, "must be a port number from 0 to 65535")
+	}
+}
+
+func service(c *checker, ptr string, v interface{}) {
+	if obj := c.object(ptr, v, "service", serviceFields()); obj != nil {
+		c.required(ptr, obj, "name")
+	}
+}
+
+func serviceFields() fields {
+	return fields{"name": str, "methods": arrayOf(str)}
+}
+
+func stub(c *checker, ptr string, v interface{}) {
+	c.object(ptr, v, "stub", stubFields())
+}
+
+func stubFields() fields {
+	return fields{
+		"id":                    str,
+		"predicates":            arrayOf(predicate),
+		"responses":             arrayOf(response),
+		"scenarioName":          str,
+		"requiredScenarioState": str,
+		"newScenarioState":      str,
+		"_links":                anyObject,
+	}
+}
+
+func predicate(c *checker, ptr string, v interface{}) {
+	obj := c.object(ptr, v, "predicate", predicateFields())
+	if obj == nil {
+		return
+	}
+
+	var given []string
+	for _, op := range predicateOperators {
+		if _, ok := obj[op]; ok {
+			given = append(given, op)
+		}
+	}
+	switch {
+	case len(given) == 0:
+		c.add(ptr, "predicate has no operator; expected one of %s", strings.Join(predicateOperators, ", "))
+	case len(given) > 1:
+		c.add(ptr, "predicate has more than one operator: %s", strings.Join(given, ", "))
+	}
+}
+
+func predicateFields() fields {
+	return fields{
+		"equals":     anyObject,
+		"deepEquals": anyObject,
+		"contains":   anyObject,
+		"startsWith": anyObject,
+		"endsWith":   anyObject,
+		"matches":    anyObject,
+		"exists":     anyObject,
+		"not":        predicate,
+		"and":        arrayOf(predicate),
+		"or":         arrayOf(predicate),
+		"inject":     str,
+		"jsonSchema": objectOf("jsonSchema", jsonSchemaFields()),
+
+		"caseSensitive":    boolean,
+		"keyCaseSensitive": boolean,
+		"except":           str,
+		"xpath":            selector,
+		"jsonpath":         selector,
+	}
+}
+
+func jsonSchemaFields() fields {
+	return fields{"body": anything, "query": anything, "headers": anything}
+}
+
+func selector(c *checker, ptr string, v interface{}) {
+	if obj := c.object(ptr, v, "selector", selectorFields()); obj != nil {
+		c.required(ptr, obj, "selector")
+	}
+}
+
+func selectorFields() fields {
+	return fields{"selector": str, "ns": stringMap}
+}
+
+func response(c *checker, ptr string, v interface{}) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		c.add(ptr, "response must be an object")
+		return
+	}
+
+	var given []string
+	for _, t := range responseTypes {
+		if _, ok := obj[t]; ok {
+			given = append(given, t)
+		}
+	}
+	if len(given) > 1 {
+		c.add(ptr, "only one of %s may be given", strings.Join(responseTypes, ", "))
+	}
+
+	allowed := responseFields()
+	if len(given) == 0 {
+		// Shorthand: the is response's fields sit on the response itself
+		for key, check := range isFields() {
+			allowed[key] = check
+		}
+	}
+	c.object(ptr, obj, "response", allowed)
+}
+
+func responseFields() fields {
+	return fields{
+		"is":         isResponse,
+		"proxy":      proxy,
+		"inject":     str,
+		"fault":      enum(faults...),
+		"repeat":     nonNegativeInteger,
+		"behaviors":  behaviors,
+		"_behaviors": behaviors,
+	}
+}
+
+func isFields() fields {
+	return fields{
+		"statusCode":         statusCode,
+		"statusMessage":      str,
+		"headers":            headers,
+		"body":               anything,
+		"data":               str,
+		"_mode":              enum("text", "binary"),
+		"_template":          boolean,
+		"bodyFile":           bodyPath,
+		"_proxyResponseTime": nonNegativeInteger,
+		"stream":             arrayOf(anything),
+	}
+}
+
+// bodyPath is a path relative to the server's --bodyDir that may not climb
+// out of it
+func bodyPath(c *checker, ptr string, v interface{}) {
+	s, ok := v.(string)
+	if !ok {
+		c.add(ptr, "must be a string")
+		return
+	}
+	if !filepath.IsLocal(s) {
+		c.add(ptr, "must be a relative path inside --bodyDir")
+	}
+}
+
+func isResponse(c *checker, ptr string, v interface{}) {
+	c.object(ptr, v, "is response", isFields())
+}
+
+func statusCode(c *checker, ptr string, v interface{}) {
+	if _, ok := v.(string); ok {
+		return
+	}
+	if n, ok := toInteger(v); !ok || n < 0 {
+		c.add(ptr, "must be a status code or a string")
+	}
+}
+
+// headers accepts a value, or an array of values for a repeated header
+func headers(c *checker, ptr string, v interface{}) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		c.add(ptr, "must be an object")
+		return
+	}
+	for _, key := range sortedKeys(obj) {
+		if values, ok := obj[key].([]interface{}); ok {
+			arrayOf(headerValue)(c, child(ptr, key), values)
+		} else if obj[key] != nil {
+			headerValue(c, child(ptr, key), obj[key])
+		}
+	}
+}
+
+func headerValue(c *checker, ptr string, v interface{}) {
+	switch v.(type) {
+	case string, bool:
+		return
+	}
+	if _, ok := toFloat(v); !ok {
+		c.add(ptr, "header values must be strings")
+	}
+}
+
+func proxy(c *checker, ptr string, v interface{}) {
+	if obj := c.object(ptr, v, "proxy", proxyFields()); obj != nil {
+		c.required(ptr, obj, "to")
+	}
+}
+
+func proxyFields() fields {
+	return fields{
+		"to":                  str,
+		"mode":                enum("proxyOnce", "proxyAlways", "proxyTransparent"),
+		"predicateGenerators": arrayOf(predicateGenerator),
+		"addWaitBehavior":     boolean,
+		"addDecorateBehavior": str,
+		"injectHeaders":       stringMap,
+		"cert":                str,
+		"key":                 str,
+		"ciphers":             str,
+		"secureProtocol":      enum("TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"),
+		"upstreamProxy":       str,
+		"caCert":              pemBundle,
+		"connectTimeout":      nonNegativeInteger,
+		"readTimeout":         nonNegativeInteger,
+		"http2":               boolean,
+		"scrubHeaders":        arrayOf(str),
+		"redactHeaders":       arrayOf(str),
+		"scrubFields":         arrayOf(str),
+		"redactFields":        arrayOf(str),
+		"collapseResponses":   boolean,
+		"bodyDir":             bodyPath,
+		"maxRecordedBodySize": nonNegativeInteger,
+
+		// mountebank's TCP proxy option; accepted, though it has no effect
+		"keepalive": boolean,
+	}
+}
+
+// pemBundle is inline PEM; a path would have the server read its files
+func pemBundle(c *checker, ptr string, v interface{}) {
+	s, ok := v.(string)
+	if !ok {
+		c.add(ptr, "must be a string")
+		return
+	}
+	if !strings.Contains(s, "-----BEGIN") {
+		c.add(ptr, "must be PEM certificates, not a path")
+	}
+}
+
+func predicateGenerator(c *checker, ptr string, v interface{}) {
+	c.object(ptr, v, "predicate generator", predicateGeneratorFields())
+}
+
+func predicateGeneratorFields() fields {
+	return fields{
+		"matches":       anyObject,
+		"inject":        str,
+		"caseSensitive": boolean,
+		"xpath":         selector,
+		"jsonpath":      selector,
+	}
+}
+
+// behaviors accepts an array of behaviors, or the older single object
+// holding one key per behavior
+func behaviors(c *checker, ptr string, v interface{}) {
+	switch v.(type) {
+	case []interface{}:
+		arrayOf(behavior)(c, ptr, v)
+	case map[string]interface{}:
+		behavior(c, ptr, v)
+	default:
+		c.add(ptr, "behaviors must be an object or an array")
+	}
+}
+
+func behavior(c *checker, ptr string, v interface{}) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		c.add(ptr, "behavior must be an object")
+		return
+	}
+	allowed := behaviorFields()
+	for _, key := range sortedKeys(obj) {
+		if key == "shellTransform" {
+			c.add(child(ptr, key), "the shellTransform behavior is not supported")
+			continue
+		}
+		if _, ok := allowed[key]; !ok {
+			c.add(child(ptr, key), "unknown behavior %q", key)
+			continue
+		}
+		if obj[key] != nil {
+			allowed[key](c, child(ptr, key), obj[key])
+		}
+	}
+}
+
+func behaviorFields() fields {
+	return fields{
+		"wait":     wait,
+		"repeat":   nonNegativeInteger,
+		"copy":     oneOrMany(copyBehavior),
+		"lookup":   oneOrMany(lookup),
+		"decorate": str,
+		"chaos":    chaos,
+	}
+}
+
+// wait is a delay in milliseconds, or a JavaScript function returning one
+func wait(c *checker, ptr string, v interface{}) {
+	if _, ok := v.(string); ok {
+		return
+	}
+	nonNegativeInteger(c, ptr, v)
+}
+
+func oneOrMany(item check) check {
+	return func(c *checker, ptr string, v interface{}) {
+		if _, ok := v.([]interface{}); ok {
+			arrayOf(item)(c, ptr, v)
+			return
+		}
+		item(c, ptr, v)
+	}
+}
+
+func copyBehavior(c *checker, ptr string, v interface{}) {
+	if obj := c.object(ptr, v, "copy", copyFields()); obj != nil {
+		c.required(ptr, obj, "from", "into")
+	}
+}
+
+func copyFields() fields {
+	return fields{
+		"from":  stringOrObject,
+		"into":  str,
+		"using": using,
+	}
+}
+
+func lookup(c *checker, ptr string, v interface{}) {
+	if obj := c.object(ptr, v, "lookup", lookupFields()); obj != nil {
+		c.required(ptr, obj, "key", "fromDataSource", "into")
+	}
+}
+
+func lookupFields() fields {
+	return fields{
+		"key":            anyObject,
+		"fromDataSource": dataSource,
+		"into":           str,
+		"using":          using,
+	}
+}
+
+func dataSource(c *checker, ptr string, v interface{}) {
+	c.object(ptr, v, "fromDataSource", dataSourceFields())
+}
+
+func dataSourceFields() fields {
+	return fields{"csv": csv}
+}
+
+func csv(c *checker, ptr string, v interface{}) {
+	if obj := c.object(ptr, v, "csv", csvFields()); obj != nil {
+		c.required(ptr, obj, "path", "keyColumn")
+	}
+}
+
+func csvFields() fields {
+	return fields{"path": str, "keyColumn": str, "delimiter": str}
+}
+
+func using(c *checker, ptr string, v interface{}) {
+	if obj := c.object(ptr, v, "using", usingFields()); obj != nil {
+		c.required(ptr, obj, "method")
+	}
+}
+
+func usingFields() fields {
+	return fields{
+		"method":   enum("regex", "xpath", "jsonpath"),
+		"selector": str,
+		"ns":       stringMap,
+		"options":  objectOf("using options", usingOptionsFields()),
+	}
+}
+
+func usingOptionsFields() fields {
+	return fields{"ignoreCase": boolean, "multiline": boolean}
+}
+
+func stringOrObject(c *checker, ptr string, v interface{}) {
+	switch v.(type) {
+	case string, map[string]interface{}:
+		return
+	}
+	c.add(ptr, "must be a string or an object")
+}
+
+func chaos(c *checker, ptr string, v interface{}) {
+	c.object(ptr, v, "chaos", chaosFields())
+}
+
+func chaosFields() fields {
+	return fields{
+		"seed":    integer,
+		"latency": objectOf("latency", chaosLatencyFields()),
+		"fault":   objectOf("fault", chaosFaultFields()),
+		"error":   objectOf("error", chaosErrorFields()),
+	}
+}
+
+func chaosLatencyFields() fields {
+	return fields{
+		"probability":  probability,
+		"distribution": enum("fixed", "uniform", "normal", "p99"),
+		"value":        number,
+		"min":          number,
+		"max":          number,
+		"mean":         number,
+		"stddev":       number,
+		"p50":          number,
+		"p99":          number,
+	}
+}
+
+func chaosFaultFields() fields {
+	return fields{
+		"probability": probability,
+		"type":        enum(faults...),
+	}
+}
+
+func chaosErrorFields() fields {
+	return fields{
+		"probability": probability,
+		"response":    isResponse,
+	}
+}
+
+func probability(c *checker, ptr string, v interface{}) {
+	if f, ok := toFloat(v); !ok || f < 0 || f > 1 {
+		c.add(ptr, "must be a probability from 0 to 1")
+	}
+}
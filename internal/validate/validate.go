@@ -0,0 +1,281 @@
+// Package validate checks imposter definitions against the imposter schema
+// before they are decoded. Decoding into the models ignores unknown fields
+// and quietly drops values of the wrong type; validation instead reports
+// each problem with a JSON pointer to the offending value.
+package validate
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Problem is one way a definition breaks the schema
+type Problem struct {
+	Pointer string // JSON pointer to the offending value; empty for the whole document
+	Message string
+}
+
+func (p Problem) Error() string {
+	if p.Pointer == "" {
+		return p.Message
+	}
+	return p.Pointer + ": " + p.Message
+}
+
+// Problems is every problem found in a definition
+type Problems []Problem
+
+func (ps Problems) Error() string {
+	messages := make([]string, len(ps))
+	for i, p := range ps {
+		messages[i] = p.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Err returns the problems as an error, or nil if there are none
+func (ps Problems) Err() error {
+	if len(ps) == 0 {
+		return nil
+	}
+	return ps
+}
+
+// Config validates a decoded config file, an object with an imposters array
+func Config(doc interface{}) Problems {
+	c := &checker{}
+	c.object("", doc, "config", fields{
+		"imposters": arrayOf(imposter),
+	})
+	return c.problems
+}
+
+// Imposters validates the decoded body of PUT /imposters
+func Imposters(doc interface{}) Problems {
+	c := &checker{}
+	c.object("", doc, "request", fields{
+		"imposters": arrayOf(imposter),
+	})
+	return c.problems
+}
+
+// Imposter validates a decoded imposter definition
+func Imposter(doc interface{}) Problems {
+	c := &checker{}
+	imposter(c, "", doc)
+	return c.problems
+}
+
+// Stubs validates the decoded body of PUT /imposters/{id}/stubs
+func Stubs(doc interface{}) Problems {
+	c := &checker{}
+	c.object("", doc, "request", fields{
+		"stubs": arrayOf(stub),
+	})
+	return c.problems
+}
+
+// AddStub validates the decoded body of POST /imposters/{id}/stubs
+func AddStub(doc interface{}) Problems {
+	c := &checker{}
+	c.object("", doc, "request", fields{
+		"stub":   stub,
+		"index":  nonNegativeInteger,
+		"before": str,
+		"after":  str,
+	})
+	return c.problems
+}
+
+// Stub validates a decoded stub
+func Stub(doc interface{}) Problems {
+	c := &checker{}
+	stub(c, "", doc)
+	return c.problems
+}
+
+// check validates the value at ptr
+type check func(c *checker, ptr string, v interface{})
+
+// fields maps the keys an object may have to the checks for their values
+type fields map[string]check
+
+type checker struct {
+	problems Problems
+}
+
+func (c *checker) add(ptr, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{Pointer: ptr, Message: fmt.Sprintf(format, args...)})
+}
+
+// object checks that v is an object whose keys all have checks, and runs
+// them in key order. It returns the object, or nil if v is not one.
+func (c *checker) object(ptr string, v interface{}, what string, allowed fields) map[string]interface{} {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		c.add(ptr, "%s must be an object", what)
+		return nil
+	}
+	for _, key := range sortedKeys(obj) {
+		check, ok := allowed[key]
+		if !ok {
+			c.add(child(ptr, key), "unknown %s field %q", what, key)
+			continue
+		}
+		if obj[key] != nil && check != nil {
+			check(c, child(ptr, key), obj[key])
+		}
+	}
+	return obj
+}
+
+// child returns the pointer to key within ptr
+func child(ptr, key string) string {
+	key = strings.ReplaceAll(key, "~", "~0")
+	key = strings.ReplaceAll(key, "/", "~1")
+	return ptr + "/" + key
+}
+
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func str(c *checker, ptr string, v interface{}) {
+	if _, ok := v.(string); !ok {
+		c.add(ptr, "must be a string")
+	}
+}
+
+func boolean(c *checker, ptr string, v interface{}) {
+	if _, ok := v.(bool); !ok {
+		c.add(ptr, "must be a boolean")
+	}
+}
+
+func number(c *checker, ptr string, v interface{}) {
+	if _, ok := toFloat(v); !ok {
+		c.add(ptr, "must be a number")
+	}
+}
+
+// anything accepts any value
+func anything(c *checker, ptr string, v interface{}) {}
+
+func integer(c *checker, ptr string, v interface{}) {
+	if _, ok := toInteger(v); !ok {
+		c.add(ptr, "must be an integer")
+	}
+}
+
+func nonNegativeInteger(c *checker, ptr string, v interface{}) {
+	if n, ok := toInteger(v); !ok || n < 0 {
+		c.add(ptr, "must be a non-negative integer")
+	}
+}
+
+func anyObject(c *checker, ptr string, v interface{}) {
+	if _, ok := v.(map[string]interface{}); !ok {
+		c.add(ptr, "must be an object")
+	}
+}
+
+func stringMap(c *checker, ptr string, v interface{}) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		c.add(ptr, "must be an object of strings")
+		return
+	}
+	for _, key := range sortedKeys(obj) {
+		str(c, child(ptr, key), obj[key])
+	}
+}
+
+func stringOrStrings(c *checker, ptr string, v interface{}) {
+	if _, ok := v.(string); ok {
+		return
+	}
+	if _, ok := v.([]interface{}); ok {
+		arrayOf(str)(c, ptr, v)
+		return
+	}
+	c.add(ptr, "must be a string or an array of strings")
+}
+
+func arrayOf(item check) check {
+	return func(c *checker, ptr string, v interface{}) {
+		items, ok := v.([]interface{})
+		if !ok {
+			c.add(ptr, "must be an array")
+			return
+		}
+		for i, value := range items {
+			if value != nil {
+				item(c, fmt.Sprintf("%s/%d", ptr, i), value)
+			}
+		}
+	}
+}
+
+func enum(values ...string) check {
+	return func(c *checker, ptr string, v interface{}) {
+		s, ok := v.(string)
+		if !ok {
+			c.add(ptr, "must be one of %s", strings.Join(values, ", "))
+			return
+		}
+		for _, value := range values {
+			if s == value {
+				return
+			}
+		}
+		c.add(ptr, "unknown value %q; expected one of %s", s, strings.Join(values, ", "))
+	}
+}
+
+func objectOf(what string, allowed fields) check {
+	return func(c *checker, ptr string, v interface{}) {
+		c.object(ptr, v, what, allowed)
+	}
+}
+
+// required reports each of keys that obj lacks
+func (c *checker) required(ptr string, obj map[string]interface{}, keys ...string) {
+	for _, key := range keys {
+		if obj[key] == nil {
+			c.add(ptr, "'%s' is a required field", key)
+		}
+	}
+}
+
+// toFloat converts a decoded number; JSON decodes to float64 and YAML to
+// the integer types
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func toInteger(v interface{}) (int64, bool) {
+	f, ok := toFloat(v)
+	if !ok || f != math.Trunc(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return int64(f), true
+}
@@ -0,0 +1,226 @@
+package validate
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var doc interface{}
+	if err := json.Unmarshal([]byte(s), &doc); err != nil {
+		t.Fatalf("invalid test JSON %s: %v", s, err)
+	}
+	return doc
+}
+
+// TestImposterValid tests that definitions the models accept pass validation
+func TestImposterValid(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{"minimal", `{"protocol": "http", "port": 4545}`},
+		{"no port", `{"protocol": "http"}`},
+		{"is response", `{"protocol": "http", "stubs": [{"responses": [{"is": {"statusCode": 201, "headers": {"X-A": "1", "X-B": ["2", "3"]}, "body": {"a": 1}}}]}]}`},
+		{"shorthand response", `{"protocol": "http", "stubs": [{"responses": [{"statusCode": 404, "body": "missing"}]}]}`},
+		{"templated status code", `{"protocol": "http", "stubs": [{"responses": [{"is": {"statusCode": "${code}"}}]}]}`},
+		{"proxy", `{"protocol": "http", "stubs": [{"responses": [{"proxy": {"to": "http://localhost:8080", "mode": "proxyAlways", "predicateGenerators": [{"matches": {"path": true}}]}}]}]}`},
+		{"fault", `{"protocol": "http", "stubs": [{"responses": [{"fault": "CONNECTION_RESET_BY_PEER"}]}]}`},
+		{"behaviors array", `{"protocol": "http", "stubs": [{"responses": [{"is": {}, "behaviors": [{"wait": 10}, {"copy": {"from": "path", "into": "${p}", "using": {"method": "regex", "selector": ".*"}}}]}]}]}`},
+		{"old behaviors object", `{"protocol": "http", "stubs": [{"responses": [{"is": {}, "_behaviors": {"wait": "function () { return 1; }", "repeat": 2}}]}]}`},
+		{"nested predicates", `{"protocol": "http", "stubs": [{"predicates": [{"and": [{"equals": {"method": "GET"}}, {"not": {"contains": {"path": "x"}}}]}], "responses": [{"is": {}}]}]}`},
+		{"predicate options", `{"protocol": "http", "stubs": [{"predicates": [{"equals": {"body": "x"}, "caseSensitive": true, "jsonpath": {"selector": "$.a"}}]}]}`},
+		{"read-only fields", `{"protocol": "http", "port": 4545, "numberOfRequests": 0, "requests": [], "_links": {"self": {"href": "x"}}, "stubs": [{"responses": [], "_links": {"self": {"href": "y"}}}]}`},
+		{"null values", `{"protocol": "http", "name": null, "stubs": [{"predicates": null, "responses": [null]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if problems := Imposter(decode(t, tt.doc)); len(problems) > 0 {
+				t.Errorf("unexpected problems: %v", problems)
+			}
+		})
+	}
+}
+
+// TestImposterProblems tests the problems reported for invalid definitions
+func TestImposterProblems(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		pointers []string
+	}{
+		{"unknown field", `{"protocol": "http", "prot": 4545}`, []string{"/prot"}},
+		{"wrong type", `{"protocol": "http", "recordRequests": "yes"}`, []string{"/recordRequests"}},
+		{"port out of range", `{"protocol": "http", "port": 70000}`, []string{"/port"}},
+		{"fractional port", `{"protocol": "http", "port": 45.5}`, []string{"/port"}},
+		{"unknown fault", `{"protocol": "http", "stubs": [{"responses": [{"fault": "NOPE"}]}]}`, []string{"/stubs/0/responses/0/fault"}},
+		{"unknown predicate key", `{"protocol": "http", "stubs": [{"predicates": [{"equals": {}, "caseSensitve": true}]}]}`, []string{"/stubs/0/predicates/0/caseSensitve"}},
+		{"misspelt operator", `{"protocol": "http", "stubs": [{"predicates": [{"equal": {}}]}]}`, []string{"/stubs/0/predicates/0/equal", "/stubs/0/predicates/0"}},
+		{"two operators", `{"protocol": "http", "stubs": [{"predicates": [{"equals": {}, "contains": {}}]}]}`, []string{"/stubs/0/predicates/0"}},
+		{"shellTransform", `{"protocol": "http", "stubs": [{"responses": [{"is": {}, "behaviors": [{"shellTransform": "cat"}]}]}]}`, []string{"/stubs/0/responses/0/behaviors/0/shellTransform"}},
+		{"unknown behavior", `{"protocol": "http", "stubs": [{"responses": [{"is": {}, "_behaviors": {"sleep": 1}}]}]}`, []string{"/stubs/0/responses/0/_behaviors/sleep"}},
+		{"two response types", `{"protocol": "http", "stubs": [{"responses": [{"is": {}, "fault": "CONNECTION_RESET_BY_PEER"}]}]}`, []string{"/stubs/0/responses/0"}},
+		{"proxy without to", `{"protocol": "http", "stubs": [{"responses": [{"proxy": {"mode": "proxyOnce"}}]}]}`, []string{"/stubs/0/responses/0/proxy"}},
+		{"copy without into", `{"protocol": "http", "stubs": [{"responses": [{"is": {}, "behaviors": [{"copy": [{"from": "path"}]}]}]}]}`, []string{"/stubs/0/responses/0/behaviors/0/copy/0"}},
+		{"pointer escaping", `{"protocol": "http", "stubs": [{"responses": [{"is": {"headers": {"a/b~c": 1.5, "x": {}}}}]}]}`, []string{"/stubs/0/responses/0/is/headers/x"}},
+		{"absolute bodyFile", `{"protocol": "http", "stubs": [{"responses": [{"is": {"bodyFile": "/etc/passwd"}}]}]}`, []string{"/stubs/0/responses/0/is/bodyFile"}},
+		{"caCert path", `{"protocol": "http", "stubs": [{"responses": [{"proxy": {"to": "https://origin", "caCert": "/etc/ssl/private/key.pem"}}]}]}`, []string{"/stubs/0/responses/0/proxy/caCert"}},
+		{"bodyDir outside", `{"protocol": "http", "stubs": [{"responses": [{"proxy": {"to": "http://origin", "bodyDir": "a/../../b"}}]}]}`, []string{"/stubs/0/responses/0/proxy/bodyDir"}},
+		{"not an object", `[]`, []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := Imposter(decode(t, tt.doc))
+			var pointers []string
+			for _, p := range problems {
+				pointers = append(pointers, p.Pointer)
+			}
+			if !reflect.DeepEqual(pointers, tt.pointers) {
+				t.Errorf("expected problems at %v, got %v", tt.pointers, problems)
+			}
+		})
+	}
+}
+
+// TestChildEscapesPointer tests escaping keys into JSON pointer tokens
+func TestChildEscapesPointer(t *testing.T) {
+	if got := child("/headers", "a/b~c"); got != "/headers/a~1b~0c" {
+		t.Errorf("expected /headers/a~1b~0c, got %s", got)
+	}
+}
+
+// TestYAMLIntegers tests that the integer types YAML decodes to are numbers
+func TestYAMLIntegers(t *testing.T) {
+	doc := map[string]interface{}{
+		"imposters": []interface{}{
+			map[string]interface{}{
+				"protocol":         "http",
+				"port":             4545,
+				"requestRetention": map[string]interface{}{"maxCount": int64(10), "maxBytes": uint64(1024)},
+			},
+		},
+	}
+	if problems := Config(doc); len(problems) > 0 {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+}
+
+// TestRequestBodies tests the entry points for the stub endpoints
+func TestRequestBodies(t *testing.T) {
+	tests := []struct {
+		name     string
+		check    func(interface{}) Problems
+		doc      string
+		problems int
+	}{
+		{"stubs", Stubs, `{"stubs": [{"responses": [{"is": {}}]}]}`, 0},
+		{"stubs with unknown field", Stubs, `{"stubs": [], "stub": {}}`, 1},
+		{"add stub", AddStub, `{"index": 0, "stub": {"responses": [{"is": {}}]}}`, 0},
+		{"add stub with negative index", AddStub, `{"index": -1, "stub": {}}`, 1},
+		{"stub", Stub, `{"id": "a", "responses": [{"inject": "function () {}"}]}`, 0},
+		{"stub with bad scenario", Stub, `{"scenarioName": 3}`, 1},
+		{"imposters", Imposters, `{"imposters": [{"protocol": "tcp", "mode": "binary"}]}`, 0},
+		{"imposters with bad mode", Imposters, `{"imposters": [{"protocol": "tcp", "mode": "hex"}]}`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if problems := tt.check(decode(t, tt.doc)); len(problems) != tt.problems {
+				t.Errorf("expected %d problems, got %v", tt.problems, problems)
+			}
+		})
+	}
+}
+
+// TestProblemsErr tests reporting problems as an error
+func TestProblemsErr(t *testing.T) {
+	if err := Problems(nil).Err(); err != nil {
+		t.Errorf("expected nil error, got %v", err)
+	}
+
+	err := Problems{{Pointer: "/port", Message: "bad"}, {Message: "worse"}}.Err()
+	if err == nil || err.Error() != "/port: bad; worse" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+// openAPIObject is an object schema in the admin API's OpenAPI document
+type openAPIObject struct {
+	Properties map[string]openAPIObject `json:"properties"`
+}
+
+// TestFieldsMatchOpenAPI tests that the fields the validator accepts on
+// each object are the properties the OpenAPI document gives it
+func TestFieldsMatchOpenAPI(t *testing.T) {
+	data, err := os.ReadFile("../api/handlers/openapi.json")
+	if err != nil {
+		t.Fatalf("failed to read openapi.json: %v", err)
+	}
+	var doc struct {
+		Components struct {
+			Schemas map[string]openAPIObject `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("failed to parse openapi.json: %v", err)
+	}
+
+	tests := []struct {
+		schema string // component, then any nested properties, dot-separated
+		fields fields
+	}{
+		{"Imposter", imposterFields()},
+		{"RequestRetention", requestRetentionFields()},
+		{"EndOfRequestResolver", endOfRequestResolverFields()},
+		{"ServiceConfig", serviceFields()},
+		{"Stub", stubFields()},
+		{"Predicate", predicateFields()},
+		{"JSONSchemaPredicate", jsonSchemaFields()},
+		{"Selector", selectorFields()},
+		{"Response", responseFields()},
+		{"IsResponse", isFields()},
+		{"ProxyResponse", proxyFields()},
+		{"PredicateGenerator", predicateGeneratorFields()},
+		{"Behavior", behaviorFields()},
+		{"Copy", copyFields()},
+		{"Lookup", lookupFields()},
+		{"DataSource", dataSourceFields()},
+		{"DataSource.csv", csvFields()},
+		{"Using", usingFields()},
+		{"Using.options", usingOptionsFields()},
+		{"Chaos", chaosFields()},
+		{"Chaos.latency", chaosLatencyFields()},
+		{"Chaos.fault", chaosFaultFields()},
+		{"Chaos.error", chaosErrorFields()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schema, func(t *testing.T) {
+			names := strings.Split(tt.schema, ".")
+			schema, ok := doc.Components.Schemas[names[0]]
+			for _, name := range names[1:] {
+				schema, ok = schema.Properties[name]
+			}
+			if !ok {
+				t.Fatalf("openapi.json has no schema %s", tt.schema)
+			}
+
+			for key := range tt.fields {
+				if _, ok := schema.Properties[key]; !ok {
+					t.Errorf("the validator accepts %q, which openapi.json does not describe", key)
+				}
+			}
+			for key := range schema.Properties {
+				if _, ok := tt.fields[key]; !ok {
+					t.Errorf("openapi.json describes %q, which the validator rejects", key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,361 @@
+package client
+
+import "github.com/TetsujinOni/go-tartuffe/pkg/models"
+
+// ImposterBuilder builds an imposter definition
+type ImposterBuilder struct {
+	imp models.Imposter
+}
+
+// NewImposter starts an imposter definition for protocol, such as "http",
+// "https", "tcp", "smtp" or "grpc"
+func NewImposter(protocol string) *ImposterBuilder {
+	return &ImposterBuilder{imp: models.Imposter{Protocol: protocol}}
+}
+
+// Port sets the port to listen on; without one the server picks a free port
+func (b *ImposterBuilder) Port(port int) *ImposterBuilder {
+	b.imp.Port = port
+	return b
+}
+
+// Name sets the imposter's name
+func (b *ImposterBuilder) Name(name string) *ImposterBuilder {
+	b.imp.Name = name
+	return b
+}
+
+// Host sets the interface to listen on
+func (b *ImposterBuilder) Host(host string) *ImposterBuilder {
+	b.imp.Host = host
+	return b
+}
+
+// Mode sets the TCP mode, "text" or "binary"
+func (b *ImposterBuilder) Mode(mode string) *ImposterBuilder {
+	b.imp.Mode = mode
+	return b
+}
+
+// RecordRequests has the imposter record the requests it receives
+func (b *ImposterBuilder) RecordRequests() *ImposterBuilder {
+	b.imp.RecordRequests = true
+	return b
+}
+
+// Retention bounds the requests the imposter records
+func (b *ImposterBuilder) Retention(retention models.RequestRetention) *ImposterBuilder {
+	b.imp.RequestRetention = &retention
+	return b
+}
+
+// AllowCORS has the imposter answer CORS preflight requests
+func (b *ImposterBuilder) AllowCORS() *ImposterBuilder {
+	b.imp.AllowCORS = true
+	return b
+}
+
+// DefaultResponse sets the response sent when no stub matches
+func (b *ImposterBuilder) DefaultResponse(response *ResponseBuilder) *ImposterBuilder {
+	resp := response.Build()
+	b.imp.DefaultResponse = &resp
+	return b
+}
+
+// Stubs appends stubs, which are tried in order
+func (b *ImposterBuilder) Stubs(stubs ...*StubBuilder) *ImposterBuilder {
+	for _, stub := range stubs {
+		b.imp.Stubs = append(b.imp.Stubs, stub.Build())
+	}
+	return b
+}
+
+// Build returns the imposter definition
+func (b *ImposterBuilder) Build() *models.Imposter {
+	imp := b.imp
+	return &imp
+}
+
+// StubBuilder builds a stub
+type StubBuilder struct {
+	stub models.Stub
+}
+
+// NewStub starts a stub definition
+func NewStub() *StubBuilder {
+	return &StubBuilder{}
+}
+
+// ID sets the stub's id, used by the by-id stub routes
+func (b *StubBuilder) ID(id string) *StubBuilder {
+	b.stub.ID = id
+	return b
+}
+
+// Predicates appends predicates, all of which must match
+func (b *StubBuilder) Predicates(predicates ...*PredicateBuilder) *StubBuilder {
+	for _, pred := range predicates {
+		b.stub.Predicates = append(b.stub.Predicates, pred.Build())
+	}
+	return b
+}
+
+// Responses appends responses, which are sent in turn
+func (b *StubBuilder) Responses(responses ...*ResponseBuilder) *StubBuilder {
+	for _, resp := range responses {
+		b.stub.Responses = append(b.stub.Responses, resp.Build())
+	}
+	return b
+}
+
+// Scenario makes the stub match only while the named scenario is in
+// requiredState, moving it to newState when it does. Either state may be
+// empty.
+func (b *StubBuilder) Scenario(name, requiredState, newState string) *StubBuilder {
+	b.stub.ScenarioName = name
+	b.stub.RequiredScenarioState = requiredState
+	b.stub.NewScenarioState = newState
+	return b
+}
+
+// Build returns the stub
+func (b *StubBuilder) Build() models.Stub {
+	stub := b.stub
+	if stub.Responses == nil {
+		stub.Responses = []models.Response{}
+	}
+	return stub
+}
+
+// PredicateBuilder builds a predicate
+type PredicateBuilder struct {
+	pred models.Predicate
+}
+
+// Equals matches when each field equals the given value
+func Equals(fields map[string]interface{}) *PredicateBuilder {
+	return &PredicateBuilder{pred: models.Predicate{Equals: fields}}
+}
+
+// DeepEquals matches when the fields equal the given values exactly,
+// with no extra keys
+func DeepEquals(fields map[string]interface{}) *PredicateBuilder {
+	return &PredicateBuilder{pred: models.Predicate{DeepEquals: fields}}
+}
+
+// Contains matches when each field contains the given value
+func Contains(fields map[string]interface{}) *PredicateBuilder {
+	return &PredicateBuilder{pred: models.Predicate{Contains: fields}}
+}
+
+// StartsWith matches when each field starts with the given value
+func StartsWith(fields map[string]interface{}) *PredicateBuilder {
+	return &PredicateBuilder{pred: models.Predicate{StartsWith: fields}}
+}
+
+// EndsWith matches when each field ends with the given value
+func EndsWith(fields map[string]interface{}) *PredicateBuilder {
+	return &PredicateBuilder{pred: models.Predicate{EndsWith: fields}}
+}
+
+// Matches matches when each field matches the given regular expression
+func Matches(fields map[string]interface{}) *PredicateBuilder {
+	return &PredicateBuilder{pred: models.Predicate{Matches: fields}}
+}
+
+// Exists matches when each field is present (true) or absent (false)
+func Exists(fields map[string]interface{}) *PredicateBuilder {
+	return &PredicateBuilder{pred: models.Predicate{Exists: fields}}
+}
+
+// Not matches when pred does not
+func Not(pred *PredicateBuilder) *PredicateBuilder {
+	inner := pred.Build()
+	return &PredicateBuilder{pred: models.Predicate{Not: &inner}}
+}
+
+// And matches when every one of preds does
+func And(preds ...*PredicateBuilder) *PredicateBuilder {
+	return &PredicateBuilder{pred: models.Predicate{And: buildPredicates(preds)}}
+}
+
+// Or matches when any of preds does
+func Or(preds ...*PredicateBuilder) *PredicateBuilder {
+	return &PredicateBuilder{pred: models.Predicate{Or: buildPredicates(preds)}}
+}
+
+// InjectPredicate matches when the JavaScript function fn returns true;
+// the server must allow injection
+func InjectPredicate(fn string) *PredicateBuilder {
+	return &PredicateBuilder{pred: models.Predicate{Inject: fn}}
+}
+
+// CaseSensitive compares values case-sensitively
+func (b *PredicateBuilder) CaseSensitive() *PredicateBuilder {
+	b.pred.CaseSensitive = true
+	return b
+}
+
+// KeyCaseSensitive compares object keys case-sensitively
+func (b *PredicateBuilder) KeyCaseSensitive() *PredicateBuilder {
+	b.pred.KeyCaseSensitive = true
+	return b
+}
+
+// Except removes text matching the regular expression before comparing
+func (b *PredicateBuilder) Except(pattern string) *PredicateBuilder {
+	b.pred.Except = pattern
+	return b
+}
+
+// JSONPath compares the part of the field the JSONPath selector picks
+func (b *PredicateBuilder) JSONPath(selector string) *PredicateBuilder {
+	b.pred.JSONPath = &models.Selector{Selector: selector}
+	return b
+}
+
+// XPath compares the part of the field the XPath selector picks, with
+// namespace prefixes from ns
+func (b *PredicateBuilder) XPath(selector string, ns map[string]string) *PredicateBuilder {
+	b.pred.XPath = &models.Selector{Selector: selector, Namespaces: ns}
+	return b
+}
+
+// Build returns the predicate
+func (b *PredicateBuilder) Build() models.Predicate {
+	return b.pred
+}
+
+func buildPredicates(builders []*PredicateBuilder) []models.Predicate {
+	preds := make([]models.Predicate, 0, len(builders))
+	for _, b := range builders {
+		preds = append(preds, b.Build())
+	}
+	return preds
+}
+
+// ResponseBuilder builds a stub response and its behaviors
+type ResponseBuilder struct {
+	resp models.Response
+}
+
+// Is starts a canned response
+func Is() *ResponseBuilder {
+	return &ResponseBuilder{resp: models.Response{Is: &models.IsResponse{}}}
+}
+
+// Proxy starts a response that forwards to the origin at to, recording
+// what it returns according to mode ("proxyOnce", "proxyAlways" or
+// "proxyTransparent")
+func Proxy(to, mode string) *ResponseBuilder {
+	return &ResponseBuilder{resp: models.Response{Proxy: &models.ProxyResponse{To: to, Mode: mode}}}
+}
+
+// Inject starts a response built by the JavaScript function fn; the server
+// must allow injection
+func Inject(fn string) *ResponseBuilder {
+	return &ResponseBuilder{resp: models.Response{Inject: fn}}
+}
+
+// Fault starts a response that fails the connection, such as
+// "CONNECTION_RESET_BY_PEER" or "RANDOM_DATA_THEN_CLOSE"
+func Fault(fault string) *ResponseBuilder {
+	return &ResponseBuilder{resp: models.Response{Fault: fault}}
+}
+
+// Status sets the status code of a canned response
+func (b *ResponseBuilder) Status(code int) *ResponseBuilder {
+	b.is().StatusCode = code
+	return b
+}
+
+// Header sets a header of a canned response
+func (b *ResponseBuilder) Header(name string, value interface{}) *ResponseBuilder {
+	is := b.is()
+	if is.Headers == nil {
+		is.Headers = map[string]interface{}{}
+	}
+	is.Headers[name] = value
+	return b
+}
+
+// Body sets the body of a canned response; values other than strings are
+// sent as JSON
+func (b *ResponseBuilder) Body(body interface{}) *ResponseBuilder {
+	b.is().Body = body
+	return b
+}
+
+// Data sets the payload of a canned TCP response
+func (b *ResponseBuilder) Data(data string) *ResponseBuilder {
+	b.is().Data = data
+	return b
+}
+
+// PredicateGenerators sets how a proxy turns the requests it forwards
+// into the predicates of the stubs it records
+func (b *ResponseBuilder) PredicateGenerators(gens ...models.PredicateGen) *ResponseBuilder {
+	if b.resp.Proxy != nil {
+		b.resp.Proxy.PredicateGenerators = append(b.resp.Proxy.PredicateGenerators, gens...)
+	}
+	return b
+}
+
+// AddWaitBehavior has a proxy record how long the origin took to respond
+func (b *ResponseBuilder) AddWaitBehavior() *ResponseBuilder {
+	if b.resp.Proxy != nil {
+		b.resp.Proxy.AddWaitBehavior = true
+	}
+	return b
+}
+
+// Repeat sends the response count times before moving to the next one
+func (b *ResponseBuilder) Repeat(count int) *ResponseBuilder {
+	b.resp.Repeat = count
+	return b
+}
+
+// Wait delays the response by ms milliseconds
+func (b *ResponseBuilder) Wait(ms int) *ResponseBuilder {
+	return b.behavior(models.Behavior{Wait: ms})
+}
+
+// Decorate passes the response through the JavaScript function fn
+func (b *ResponseBuilder) Decorate(fn string) *ResponseBuilder {
+	return b.behavior(models.Behavior{Decorate: fn})
+}
+
+// ShellTransform passes the response through command
+func (b *ResponseBuilder) ShellTransform(command string) *ResponseBuilder {
+	return b.behavior(models.Behavior{ShellTransform: command})
+}
+
+// Copy copies parts of the request into the response
+func (b *ResponseBuilder) Copy(copies ...models.Copy) *ResponseBuilder {
+	return b.behavior(models.Behavior{Copy: copies})
+}
+
+// Lookup fills the response from an external data source keyed by parts
+// of the request
+func (b *ResponseBuilder) Lookup(lookups ...models.Lookup) *ResponseBuilder {
+	return b.behavior(models.Behavior{Lookup: lookups})
+}
+
+// Build returns the response
+func (b *ResponseBuilder) Build() models.Response {
+	return b.resp
+}
+
+func (b *ResponseBuilder) behavior(behavior models.Behavior) *ResponseBuilder {
+	b.resp.Behaviors = append(b.resp.Behaviors, behavior)
+	return b
+}
+
+// is returns the canned response, creating it for builders that were not
+// started with Is
+func (b *ResponseBuilder) is() *models.IsResponse {
+	if b.resp.Is == nil {
+		b.resp.Is = &models.IsResponse{}
+	}
+	return b.resp.Is
+}
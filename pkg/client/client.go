@@ -0,0 +1,183 @@
+// Package client is a typed Go client for the go-tartuffe admin API. It
+// works against any running server, in-process or not, and speaks the
+// same JSON as mountebank, so imposters built with the builders in this
+// package can be sent to either. Imposters, stubs and recorded requests
+// are the types in pkg/models.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/response"
+)
+
+// ErrorCode is a mountebank error code, as found in the errors array of a
+// failed admin API call
+type ErrorCode string
+
+// Error codes returned by the admin API
+const (
+	ErrBadData          ErrorCode = response.ErrCodeBadData
+	ErrResourceConflict ErrorCode = response.ErrCodeResourceConflict
+	ErrNoSuchResource   ErrorCode = response.ErrCodeNoSuchResource
+	ErrInvalidJSON      ErrorCode = response.ErrCodeInvalidJSON
+	ErrInvalidInjection ErrorCode = response.ErrCodeInvalidInjection
+	ErrUnauthorized     ErrorCode = response.ErrCodeUnauthorized
+	ErrForbidden        ErrorCode = response.ErrCodeForbidden
+)
+
+// Error is a failed admin API call. Code and Message come from the first
+// entry of the errors array; responses without one only have StatusCode.
+type Error struct {
+	StatusCode int
+	Code       ErrorCode
+	Message    string
+	Source     string
+	Pointer    string // JSON pointer to the invalid value, for schema errors
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("tartuffe: unexpected status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("tartuffe: %s: %s", e.Code, e.Message)
+}
+
+// IsCode reports whether err is an admin API error with the given code
+func IsCode(err error, code ErrorCode) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// Client calls the admin API of one server
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithAPIKey sends key in the X-Api-Key header of every call
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithHTTPClient makes calls with hc instead of http.DefaultClient
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// New creates a client for the server at baseURL, such as
+// "http://localhost:2525"
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// BaseURL returns the admin API URL the client calls
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// do sends a request and decodes a 2xx response body into out, which may
+// be nil. Other statuses become an *Error.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body interface{}, out interface{}) error {
+	resp, err := c.send(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send issues a request, returning the response only when its status is
+// 2xx; the caller closes the body
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	contentType := "application/json"
+	switch b := body.(type) {
+	case nil:
+	case rawBody:
+		reader = bytes.NewReader(b.data)
+		contentType = b.contentType
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if reader != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-Api-Key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+	return resp, nil
+}
+
+// rawBody is a request body sent as is
+type rawBody struct {
+	data        []byte
+	contentType string
+}
+
+func decodeError(resp *http.Response) error {
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	var errs response.ErrorResponse
+	if json.NewDecoder(resp.Body).Decode(&errs) == nil && len(errs.Errors) > 0 {
+		first := errs.Errors[0]
+		apiErr.Code = ErrorCode(first.Code)
+		apiErr.Message = first.Message
+		apiErr.Pointer = first.Pointer
+		if first.Source != nil {
+			apiErr.Source = *first.Source
+		}
+	}
+	return apiErr
+}
+
+func imposterPath(port int) string {
+	return "/imposters/" + strconv.Itoa(port)
+}
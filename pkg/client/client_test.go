@@ -0,0 +1,347 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/pkg/client"
+	"github.com/TetsujinOni/go-tartuffe/pkg/models"
+	"github.com/TetsujinOni/go-tartuffe/pkg/tartuffe"
+	"github.com/TetsujinOni/go-tartuffe/pkg/tartuffe/tartuffetest"
+)
+
+func newClient(t *testing.T) (*client.Client, *tartuffe.Server) {
+	t.Helper()
+	srv := tartuffetest.NewServer(t, tartuffe.Options{})
+	return client.New(srv.URL()), srv
+}
+
+func getBody(t *testing.T, url string) string {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+// TestClientImposters tests creating, reading and querying an imposter
+// built with the builders
+func TestClientImposters(t *testing.T) {
+	c, srv := newClient(t)
+	ctx := context.Background()
+
+	imp, err := c.CreateImposter(ctx, client.NewImposter("http").
+		Name("greeter").
+		RecordRequests().
+		Stubs(client.NewStub().
+			ID("hello").
+			Predicates(client.Equals(map[string]interface{}{"path": "/hello"})).
+			Responses(client.Is().Status(200).Body("hi"))).
+		Build())
+	if err != nil {
+		t.Fatalf("CreateImposter() error = %v", err)
+	}
+	if imp.Port == 0 || imp.Name != "greeter" {
+		t.Fatalf("unexpected imposter %+v", imp)
+	}
+
+	if body := getBody(t, srv.ImposterURL(imp.Port)+"/hello"); body != "hi" {
+		t.Errorf("expected the stub response, got %q", body)
+	}
+	getBody(t, srv.ImposterURL(imp.Port)+"/other")
+
+	got, err := c.Imposter(ctx, imp.Port, client.GetOptions{})
+	if err != nil {
+		t.Fatalf("Imposter() error = %v", err)
+	}
+	if len(got.Requests) != 2 || got.Requests[0].Path != "/hello" {
+		t.Errorf("expected two recorded requests, got %+v", got.Requests)
+	}
+
+	page, err := c.Requests(ctx, imp.Port, client.RequestsQuery{
+		Predicates: []models.Predicate{client.Equals(map[string]interface{}{"path": "/other"}).Build()},
+	})
+	if err != nil {
+		t.Fatalf("Requests() error = %v", err)
+	}
+	var requests []models.Request
+	if err := page.Decode(&requests); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if page.Total != 2 || page.Matched != 1 || len(requests) != 1 || requests[0].Path != "/other" {
+		t.Errorf("unexpected page %+v with requests %+v", page, requests)
+	}
+
+	two := 2
+	result, err := c.VerifyRequests(ctx, imp.Port, client.Verification{Count: &two})
+	if err != nil {
+		t.Fatalf("VerifyRequests() error = %v", err)
+	}
+	if !result.Verified || result.Expected != "exactly 2" {
+		t.Errorf("unexpected verification %+v", result)
+	}
+
+	if _, err := c.DeleteRequests(ctx, imp.Port); err != nil {
+		t.Fatalf("DeleteRequests() error = %v", err)
+	}
+	if page, err = c.Requests(ctx, imp.Port, client.RequestsQuery{}); err != nil || page.Total != 0 {
+		t.Errorf("expected the requests to be cleared, got %+v (%v)", page, err)
+	}
+
+	deleted, err := c.DeleteImposter(ctx, imp.Port, client.GetOptions{})
+	if err != nil || deleted == nil || deleted.Port != imp.Port {
+		t.Fatalf("DeleteImposter() = %+v, %v", deleted, err)
+	}
+	if deleted, err = c.DeleteImposter(ctx, imp.Port, client.GetOptions{}); err != nil || deleted != nil {
+		t.Errorf("expected nil for a missing imposter, got %+v, %v", deleted, err)
+	}
+}
+
+// TestClientStubsAndScenarios tests the stub and scenario routes
+func TestClientStubsAndScenarios(t *testing.T) {
+	c, srv := newClient(t)
+	ctx := context.Background()
+
+	imp, err := c.CreateImposter(ctx, client.NewImposter("http").Build())
+	if err != nil {
+		t.Fatalf("CreateImposter() error = %v", err)
+	}
+
+	first := client.NewStub().ID("first").Responses(client.Is().Body("one")).Build()
+	if _, err := c.AddStub(ctx, imp.Port, first); err != nil {
+		t.Fatalf("AddStub() error = %v", err)
+	}
+	before := client.NewStub().ID("before").
+		Predicates(client.Equals(map[string]interface{}{"path": "/before"})).
+		Responses(client.Is().Body("zero")).Build()
+	updated, err := c.AddStubBefore(ctx, imp.Port, "first", before)
+	if err != nil {
+		t.Fatalf("AddStubBefore() error = %v", err)
+	}
+	if len(updated.Stubs) != 2 || updated.Stubs[0].ID != "before" {
+		t.Errorf("expected the stub to be inserted first, got %+v", updated.Stubs)
+	}
+
+	stub, err := c.PatchStubByID(ctx, imp.Port, "first", map[string]interface{}{
+		"responses": []interface{}{map[string]interface{}{"is": map[string]interface{}{"body": "patched"}}},
+	})
+	if err != nil {
+		t.Fatalf("PatchStubByID() error = %v", err)
+	}
+	if stub.Links == nil || !strings.HasSuffix(stub.Links.Self.Href, "/stubs/by-id/first") {
+		t.Errorf("expected the stub's link, got %+v", stub.Links)
+	}
+	if body := getBody(t, srv.ImposterURL(imp.Port)+"/"); body != "patched" {
+		t.Errorf("expected the patched response, got %q", body)
+	}
+
+	if _, err := c.DeleteStubByID(ctx, imp.Port, "before"); err != nil {
+		t.Fatalf("DeleteStubByID() error = %v", err)
+	}
+	if _, err := c.StubByID(ctx, imp.Port, "before"); !client.IsCode(err, client.ErrNoSuchResource) {
+		t.Errorf("expected %q for a deleted stub, got %v", client.ErrNoSuchResource, err)
+	}
+
+	scenario := client.NewStub().Scenario("login", "loggedIn", "").Responses(client.Is().Body("welcome")).Build()
+	if _, err := c.ReplaceStubs(ctx, imp.Port, []models.Stub{scenario}); err != nil {
+		t.Fatalf("ReplaceStubs() error = %v", err)
+	}
+	states, err := c.SetScenario(ctx, imp.Port, "login", "loggedIn")
+	if err != nil {
+		t.Fatalf("SetScenario() error = %v", err)
+	}
+	if len(states) != 1 || states[0] != (client.ScenarioState{Name: "login", State: "loggedIn"}) {
+		t.Errorf("unexpected scenarios %+v", states)
+	}
+	if body := getBody(t, srv.ImposterURL(imp.Port)+"/"); body != "welcome" {
+		t.Errorf("expected the scenario stub to match, got %q", body)
+	}
+}
+
+// TestClientSaveAndReplay tests that replay swaps a proxy for the stubs
+// it recorded
+func TestClientSaveAndReplay(t *testing.T) {
+	c, srv := newClient(t)
+	ctx := context.Background()
+
+	origin, err := c.CreateImposter(ctx, client.NewImposter("http").
+		Stubs(client.NewStub().Responses(client.Is().Body("from origin"))).
+		Build())
+	if err != nil {
+		t.Fatalf("CreateImposter() error = %v", err)
+	}
+	proxy, err := c.CreateImposter(ctx, client.NewImposter("http").
+		Stubs(client.NewStub().Responses(client.Proxy(srv.ImposterURL(origin.Port), "proxyOnce"))).
+		Build())
+	if err != nil {
+		t.Fatalf("CreateImposter() error = %v", err)
+	}
+	getBody(t, srv.ImposterURL(proxy.Port)+"/")
+
+	saved, err := c.Save(ctx, false)
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if len(saved) != 2 {
+		t.Fatalf("expected two saved imposters, got %d", len(saved))
+	}
+
+	if _, err := c.Replay(ctx); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	replayed, err := c.Imposter(ctx, proxy.Port, client.GetOptions{})
+	if err != nil {
+		t.Fatalf("Imposter() error = %v", err)
+	}
+	for _, stub := range replayed.Stubs {
+		for _, resp := range stub.Responses {
+			if resp.Proxy != nil {
+				t.Errorf("expected the proxy to be removed, got %+v", replayed.Stubs)
+			}
+		}
+	}
+	if body := getBody(t, srv.ImposterURL(proxy.Port)+"/"); body != "from origin" {
+		t.Errorf("expected the recorded response, got %q", body)
+	}
+}
+
+// TestClientErrors tests that failed calls return the API's error codes
+func TestClientErrors(t *testing.T) {
+	c, _ := newClient(t)
+	ctx := context.Background()
+
+	imp, err := c.CreateImposter(ctx, client.NewImposter("http").Build())
+	if err != nil {
+		t.Fatalf("CreateImposter() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		call   func() error
+		status int
+		code   client.ErrorCode
+	}{
+		{
+			name: "missing imposter",
+			call: func() error {
+				_, err := c.Imposter(ctx, 1, client.GetOptions{})
+				return err
+			},
+			status: http.StatusNotFound,
+			code:   client.ErrNoSuchResource,
+		},
+		{
+			name: "invalid protocol",
+			call: func() error {
+				_, err := c.CreateImposter(ctx, client.NewImposter("gopher").Build())
+				return err
+			},
+			status: http.StatusBadRequest,
+			code:   client.ErrBadData,
+		},
+		{
+			name: "port in use",
+			call: func() error {
+				_, err := c.CreateImposter(ctx, client.NewImposter("http").Port(imp.Port).Build())
+				return err
+			},
+			status: http.StatusBadRequest,
+			code:   client.ErrResourceConflict,
+		},
+		{
+			name: "invalid JSON",
+			call: func() error {
+				_, err := c.Callback(ctx, imp.Port, json.RawMessage(`{"request":`))
+				return err
+			},
+			status: http.StatusBadRequest,
+			code:   client.ErrInvalidJSON,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			apiErr, ok := err.(*client.Error)
+			if !ok {
+				t.Fatalf("expected *client.Error, got %T: %v", err, err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Code != tt.code || apiErr.Message == "" {
+				t.Errorf("unexpected error %+v", apiErr)
+			}
+			if !client.IsCode(err, tt.code) {
+				t.Errorf("IsCode(%q) = false", tt.code)
+			}
+		})
+	}
+}
+
+// TestClientEvents tests streaming an imposter's events
+func TestClientEvents(t *testing.T) {
+	c, srv := newClient(t)
+	ctx := context.Background()
+
+	imp, err := c.CreateImposter(ctx, client.NewImposter("http").Build())
+	if err != nil {
+		t.Fatalf("CreateImposter() error = %v", err)
+	}
+
+	stream, err := c.Events(ctx, imp.Port, client.EventNoMatch)
+	if err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+	defer stream.Close()
+
+	getBody(t, srv.ImposterURL(imp.Port)+"/nothing")
+
+	select {
+	case event := <-stream.C:
+		if event.Type != client.EventNoMatch || event.Imposter != imp.Port {
+			t.Errorf("unexpected event %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+
+	stream.Close()
+	if _, ok := <-stream.C; ok {
+		t.Error("expected the stream to be closed")
+	}
+	if err := stream.Err(); err != nil {
+		t.Errorf("Err() = %v after Close", err)
+	}
+}
+
+// TestClientServerRoutes tests the home, OpenAPI, config, logs and metrics
+// routes
+func TestClientServerRoutes(t *testing.T) {
+	c, _ := newClient(t)
+	ctx := context.Background()
+
+	home, err := c.Home(ctx)
+	if err != nil || home.Links.Imposters.Href == "" {
+		t.Errorf("Home() = %+v, %v", home, err)
+	}
+	if doc, err := c.OpenAPI(ctx); err != nil || !strings.Contains(string(doc), `"openapi"`) {
+		t.Errorf("OpenAPI() = %.80s, %v", doc, err)
+	}
+	config, err := c.Config(ctx)
+	if err != nil || config.Version == "" {
+		t.Errorf("Config() = %+v, %v", config, err)
+	}
+	if _, err := c.Logs(ctx, 0, 0); err != nil {
+		t.Errorf("Logs() error = %v", err)
+	}
+	metrics, err := c.Metrics(ctx)
+	if err != nil || !strings.Contains(metrics, "# TYPE") {
+		t.Errorf("Metrics() = %.80q, %v", metrics, err)
+	}
+}
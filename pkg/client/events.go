@@ -0,0 +1,130 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/events"
+)
+
+// Event is one imposter event from an event stream
+type Event = events.Event
+
+// Event types, for filtering event streams
+const (
+	EventRequestReceived = events.RequestReceived
+	EventStubMatched     = events.StubMatched
+	EventNoMatch         = events.NoMatch
+	EventProxyRecorded   = events.ProxyRecorded
+	EventImposterStarted = events.ImposterStarted
+	EventImposterStopped = events.ImposterStopped
+)
+
+// EventStream is an open server-sent event stream. C is closed when the
+// stream ends, after which Err reports why.
+type EventStream struct {
+	C <-chan Event
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	mu     sync.Mutex
+	err    error
+}
+
+// Events streams the events of the imposter on port, or of every imposter
+// when port is 0 (GET /imposters/{id}/events, GET /events). With no types
+// every event is sent. The stream stays open until ctx is done or Close is
+// called.
+func (c *Client) Events(ctx context.Context, port int, types ...string) (*EventStream, error) {
+	path := "/events"
+	if port != 0 {
+		path = imposterPath(port) + "/events"
+	}
+	q := url.Values{}
+	if len(types) > 0 {
+		q.Set("types", strings.Join(types, ","))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	resp, err := c.send(ctx, http.MethodGet, path, q, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	ch := make(chan Event)
+	stream := &EventStream{C: ch, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(stream.done)
+		defer close(ch)
+		defer resp.Body.Close()
+		stream.setErr(readEvents(ctx, bufio.NewScanner(resp.Body), ch))
+	}()
+	return stream, nil
+}
+
+// Close ends the stream and waits for C to be closed
+func (s *EventStream) Close() error {
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+// Err returns the error that ended the stream, or nil if it was closed or
+// its context done
+func (s *EventStream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *EventStream) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// readEvents sends the data of each event in an SSE stream to ch until the
+// stream or ctx ends. Comments, ids and event names are skipped; the type
+// is also in the data.
+func readEvents(ctx context.Context, scanner *bufio.Scanner, ch chan<- Event) error {
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			if value, ok := strings.CutPrefix(line, "data:"); ok {
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.WriteString(strings.TrimPrefix(value, " "))
+			}
+			continue
+		}
+		if data.Len() == 0 {
+			continue
+		}
+
+		var event Event
+		err := json.Unmarshal([]byte(data.String()), &event)
+		data.Reset()
+		if err != nil {
+			return err
+		}
+		select {
+		case ch <- event:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
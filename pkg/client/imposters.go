@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/TetsujinOni/go-tartuffe/pkg/models"
+)
+
+// GetOptions are the query options accepted by the routes that return
+// imposters
+type GetOptions struct {
+	Replayable    bool // Leave out requests and links, for saving and replaying
+	RemoveProxies bool // Leave out proxy responses, keeping the stubs they recorded
+}
+
+func (o GetOptions) query() url.Values {
+	q := url.Values{}
+	if o.Replayable {
+		q.Set("replayable", "true")
+	}
+	if o.RemoveProxies {
+		q.Set("removeProxies", "true")
+	}
+	return q
+}
+
+// Imposters lists every imposter (GET /imposters)
+func (c *Client) Imposters(ctx context.Context, opts GetOptions) ([]*models.Imposter, error) {
+	return c.imposters(ctx, http.MethodGet, opts.query(), nil)
+}
+
+// CreateImposter starts an imposter (POST /imposters). The result has the
+// port the server chose when imp didn't give one.
+func (c *Client) CreateImposter(ctx context.Context, imp *models.Imposter) (*models.Imposter, error) {
+	return c.imposter(ctx, http.MethodPost, "/imposters", nil, imp)
+}
+
+// CreateImposterFromOpenAPI starts an imposter generated from an OpenAPI 3
+// document in JSON or YAML (POST /imposters/_fromOpenAPI). A zero port or
+// empty name keeps the generated one.
+func (c *Client) CreateImposterFromOpenAPI(ctx context.Context, spec []byte, port int, name string) (*models.Imposter, error) {
+	q := url.Values{}
+	if port != 0 {
+		q.Set("port", strconv.Itoa(port))
+	}
+	if name != "" {
+		q.Set("name", name)
+	}
+	body := rawBody{data: spec, contentType: "application/yaml"}
+	return c.imposter(ctx, http.MethodPost, "/imposters/_fromOpenAPI", q, body)
+}
+
+// ReplaceImposters replaces every imposter with imps (PUT /imposters)
+func (c *Client) ReplaceImposters(ctx context.Context, imps []*models.Imposter) ([]*models.Imposter, error) {
+	if imps == nil {
+		imps = []*models.Imposter{}
+	}
+	body := map[string]interface{}{"imposters": imps}
+	return c.imposters(ctx, http.MethodPut, nil, body)
+}
+
+// DeleteImposters stops every imposter (DELETE /imposters), returning
+// them as they were
+func (c *Client) DeleteImposters(ctx context.Context, opts GetOptions) ([]*models.Imposter, error) {
+	return c.imposters(ctx, http.MethodDelete, opts.query(), nil)
+}
+
+// Imposter returns the imposter on port (GET /imposters/{id})
+func (c *Client) Imposter(ctx context.Context, port int, opts GetOptions) (*models.Imposter, error) {
+	return c.imposter(ctx, http.MethodGet, imposterPath(port), opts.query(), nil)
+}
+
+// DeleteImposter stops the imposter on port (DELETE /imposters/{id}),
+// returning it as it was, or nil if there was none. The server defaults to
+// the replayable form here, so opts only needs RemoveProxies.
+func (c *Client) DeleteImposter(ctx context.Context, port int, opts GetOptions) (*models.Imposter, error) {
+	imp, err := c.imposter(ctx, http.MethodDelete, imposterPath(port), opts.query(), nil)
+	if err != nil || imp.Protocol == "" {
+		return nil, err
+	}
+	return imp, nil
+}
+
+// Save returns every imposter in the replayable form used by the save
+// command, optionally without proxy responses
+func (c *Client) Save(ctx context.Context, removeProxies bool) ([]*models.Imposter, error) {
+	return c.Imposters(ctx, GetOptions{Replayable: true, RemoveProxies: removeProxies})
+}
+
+// Replay swaps the server's proxies for the responses they recorded, as
+// the replay command does
+func (c *Client) Replay(ctx context.Context) ([]*models.Imposter, error) {
+	imps, err := c.Save(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	return c.ReplaceImposters(ctx, imps)
+}
+
+func (c *Client) imposters(ctx context.Context, method string, query url.Values, body interface{}) ([]*models.Imposter, error) {
+	var result struct {
+		Imposters []json.RawMessage `json:"imposters"`
+	}
+	if err := c.do(ctx, method, "/imposters", query, body, &result); err != nil {
+		return nil, err
+	}
+
+	imps := make([]*models.Imposter, 0, len(result.Imposters))
+	for _, raw := range result.Imposters {
+		imp, err := decodeImposter(raw)
+		if err != nil {
+			return nil, err
+		}
+		imps = append(imps, imp)
+	}
+	return imps, nil
+}
+
+func (c *Client) imposter(ctx context.Context, method, path string, query url.Values, body interface{}) (*models.Imposter, error) {
+	var raw json.RawMessage
+	if err := c.do(ctx, method, path, query, body, &raw); err != nil {
+		return nil, err
+	}
+	return decodeImposter(raw)
+}
+
+// decodeImposter decodes an imposter from the API. Every protocol's
+// recorded requests come back under "requests", so they are moved to the
+// field for the imposter's protocol.
+func decodeImposter(data []byte) (*models.Imposter, error) {
+	imp := &models.Imposter{}
+	wire := struct {
+		*models.Imposter
+		Requests json.RawMessage `json:"requests"`
+	}{Imposter: imp}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return nil, err
+	}
+	if len(wire.Requests) == 0 {
+		return imp, nil
+	}
+
+	var target interface{}
+	switch imp.Protocol {
+	case "tcp":
+		target = &imp.TCPRequests
+	case "smtp":
+		target = &imp.SMTPRequests
+	case "grpc":
+		target = &imp.GRPCRequests
+	default:
+		target = &imp.Requests
+	}
+	if err := json.Unmarshal(wire.Requests, target); err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/pkg/models"
+)
+
+// RequestsQuery filters and pages an imposter's recorded requests. Zero
+// times leave the range open; a zero Limit uses the server's page size.
+type RequestsQuery struct {
+	Predicates []models.Predicate
+	From       time.Time
+	To         time.Time
+	Offset     int
+	Limit      int
+}
+
+// RequestsPage is one page of recorded requests
+type RequestsPage struct {
+	Requests []json.RawMessage `json:"requests"` // The protocol's own request records
+	Total    int               `json:"total"`    // Requests recorded
+	Matched  int               `json:"matched"`  // Requests matching the query
+	Offset   int               `json:"offset"`
+	Limit    int               `json:"limit"`
+}
+
+// Decode unmarshals the page's requests into v, which is a pointer to a
+// slice of the imposter protocol's request type, such as *[]Request or
+// *[]TCPRequest
+func (p *RequestsPage) Decode(v interface{}) error {
+	data, err := json.Marshal(p.Requests)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Verification is an expectation about an imposter's recorded requests. At
+// most one of Count, AtLeast and AtMost may be set; with none, at least
+// one matching request is expected.
+type Verification struct {
+	Predicates []models.Predicate
+	From       time.Time
+	To         time.Time
+	Count      *int
+	AtLeast    *int
+	AtMost     *int
+}
+
+// VerifyResult is the outcome of a verification
+type VerifyResult struct {
+	Verified bool   `json:"verified"`
+	Count    int    `json:"count"`    // Matching requests
+	Expected string `json:"expected"` // The expectation, such as "at least 1"
+}
+
+// Requests returns a page of the requests the imposter on port recorded
+// (GET /imposters/{id}/requests)
+func (c *Client) Requests(ctx context.Context, port int, query RequestsQuery) (*RequestsPage, error) {
+	q := url.Values{}
+	if len(query.Predicates) > 0 {
+		predicates, err := json.Marshal(query.Predicates)
+		if err != nil {
+			return nil, err
+		}
+		q.Set("predicates", string(predicates))
+	}
+	if !query.From.IsZero() {
+		q.Set("from", query.From.Format(time.RFC3339Nano))
+	}
+	if !query.To.IsZero() {
+		q.Set("to", query.To.Format(time.RFC3339Nano))
+	}
+	if query.Offset != 0 {
+		q.Set("offset", strconv.Itoa(query.Offset))
+	}
+	if query.Limit != 0 {
+		q.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	var page RequestsPage
+	if err := c.do(ctx, http.MethodGet, imposterPath(port)+"/requests", q, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// VerifyRequests checks the requests the imposter on port recorded against
+// v (POST /imposters/{id}/requests/verify)
+func (c *Client) VerifyRequests(ctx context.Context, port int, v Verification) (*VerifyResult, error) {
+	body := struct {
+		Predicates []models.Predicate `json:"predicates"`
+		From       string             `json:"from,omitempty"`
+		To         string             `json:"to,omitempty"`
+		Count      *int               `json:"count,omitempty"`
+		AtLeast    *int               `json:"atLeast,omitempty"`
+		AtMost     *int               `json:"atMost,omitempty"`
+	}{
+		Predicates: v.Predicates,
+		Count:      v.Count,
+		AtLeast:    v.AtLeast,
+		AtMost:     v.AtMost,
+	}
+	if body.Predicates == nil {
+		body.Predicates = []models.Predicate{}
+	}
+	if !v.From.IsZero() {
+		body.From = v.From.Format(time.RFC3339Nano)
+	}
+	if !v.To.IsZero() {
+		body.To = v.To.Format(time.RFC3339Nano)
+	}
+
+	var result VerifyResult
+	if err := c.do(ctx, http.MethodPost, imposterPath(port)+"/requests/verify", nil, body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DeleteRequests clears the imposter's recorded requests and removes the
+// stubs its proxies recorded (DELETE /imposters/{id}/requests)
+func (c *Client) DeleteRequests(ctx context.Context, port int) (*models.Imposter, error) {
+	return c.imposter(ctx, http.MethodDelete, imposterPath(port)+"/requests", nil, nil)
+}
+
+// DeleteSavedRequests clears the imposter's recorded requests (DELETE
+// /imposters/{id}/savedRequests)
+func (c *Client) DeleteSavedRequests(ctx context.Context, port int) (*models.Imposter, error) {
+	return c.imposter(ctx, http.MethodDelete, imposterPath(port)+"/savedRequests", nil, nil)
+}
+
+// DeleteSavedProxyResponses is the mountebank name for
+// DeleteSavedRequests (DELETE /imposters/{id}/savedProxyResponses)
+func (c *Client) DeleteSavedProxyResponses(ctx context.Context, port int) (*models.Imposter, error) {
+	return c.imposter(ctx, http.MethodDelete, imposterPath(port)+"/savedProxyResponses", nil, nil)
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Home is the hypermedia index of the admin API
+type Home struct {
+	Links struct {
+		Imposters Link `json:"imposters"`
+		Config    Link `json:"config"`
+		Logs      Link `json:"logs"`
+	} `json:"_links"`
+}
+
+// Link is a hypermedia link
+type Link struct {
+	Href string `json:"href"`
+}
+
+// Config is the server's version, startup options and process details
+type Config struct {
+	Version string `json:"version"`
+	Options struct {
+		Port           int      `json:"port"`
+		Host           string   `json:"host,omitempty"`
+		AllowInjection bool     `json:"allowInjection"`
+		LocalOnly      bool     `json:"localOnly"`
+		IPWhitelist    []string `json:"ipWhitelist,omitempty"`
+		Debug          bool     `json:"debug"`
+		Origin         string   `json:"origin,omitempty"`
+	} `json:"options"`
+	Process struct {
+		GoVersion    string `json:"goVersion"`
+		Architecture string `json:"architecture"`
+		Platform     string `json:"platform"`
+		RSS          uint64 `json:"rss"`
+		HeapAlloc    uint64 `json:"heapAlloc"`
+		HeapTotal    uint64 `json:"heapTotal"`
+		Uptime       int64  `json:"uptime"`
+		Cwd          string `json:"cwd"`
+	} `json:"process"`
+}
+
+// LogEntry is one line of the server's log
+type LogEntry struct {
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+	Timestamp string `json:"timestamp"`
+}
+
+// Home returns the admin API's links (GET /)
+func (c *Client) Home(ctx context.Context) (*Home, error) {
+	var home Home
+	if err := c.do(ctx, http.MethodGet, "/", nil, nil, &home); err != nil {
+		return nil, err
+	}
+	return &home, nil
+}
+
+// OpenAPI returns the OpenAPI 3 description of the admin API (GET
+// /openapi.json)
+func (c *Client) OpenAPI(ctx context.Context) (json.RawMessage, error) {
+	var doc json.RawMessage
+	if err := c.do(ctx, http.MethodGet, "/openapi.json", nil, nil, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Config returns the server's configuration (GET /config)
+func (c *Client) Config(ctx context.Context) (*Config, error) {
+	var config Config
+	if err := c.do(ctx, http.MethodGet, "/config", nil, nil, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Logs returns the server's log entries from startIndex up to endIndex
+// (GET /logs). An endIndex no greater than startIndex reads to the end.
+func (c *Client) Logs(ctx context.Context, startIndex, endIndex int) ([]LogEntry, error) {
+	q := url.Values{}
+	if startIndex > 0 {
+		q.Set("startIndex", strconv.Itoa(startIndex))
+	}
+	if endIndex > startIndex {
+		q.Set("endIndex", strconv.Itoa(endIndex))
+	}
+
+	var result struct {
+		Logs []LogEntry `json:"logs"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/logs", q, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Logs, nil
+}
+
+// Metrics returns the server's metrics in the Prometheus text format (GET
+// /metrics)
+func (c *Client) Metrics(ctx context.Context) (string, error) {
+	resp, err := c.send(ctx, http.MethodGet, "/metrics", nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	return string(data), err
+}
+
+// Callback forwards a request to the imposter on port on behalf of an
+// out-of-process protocol plugin, returning the response it resolved
+// (POST /imposters/{id}/_requests)
+func (c *Client) Callback(ctx context.Context, port int, request json.RawMessage) (json.RawMessage, error) {
+	var result json.RawMessage
+	body := rawBody{data: request, contentType: "application/json"}
+	if err := c.do(ctx, http.MethodPost, imposterPath(port)+"/_requests", nil, body, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/TetsujinOni/go-tartuffe/pkg/models"
+)
+
+// ScenarioState is the current state of one of an imposter's scenarios
+type ScenarioState struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// AddStub appends stub to the imposter's stubs (POST /imposters/{id}/stubs)
+func (c *Client) AddStub(ctx context.Context, port int, stub models.Stub) (*models.Imposter, error) {
+	return c.addStub(ctx, port, map[string]interface{}{"stub": stub})
+}
+
+// AddStubAt inserts stub at index in the imposter's stubs
+func (c *Client) AddStubAt(ctx context.Context, port, index int, stub models.Stub) (*models.Imposter, error) {
+	return c.addStub(ctx, port, map[string]interface{}{"stub": stub, "index": index})
+}
+
+// AddStubBefore inserts stub ahead of the stub with the given id
+func (c *Client) AddStubBefore(ctx context.Context, port int, id string, stub models.Stub) (*models.Imposter, error) {
+	return c.addStub(ctx, port, map[string]interface{}{"stub": stub, "before": id})
+}
+
+// AddStubAfter inserts stub behind the stub with the given id
+func (c *Client) AddStubAfter(ctx context.Context, port int, id string, stub models.Stub) (*models.Imposter, error) {
+	return c.addStub(ctx, port, map[string]interface{}{"stub": stub, "after": id})
+}
+
+func (c *Client) addStub(ctx context.Context, port int, body map[string]interface{}) (*models.Imposter, error) {
+	return c.imposter(ctx, http.MethodPost, imposterPath(port)+"/stubs", nil, body)
+}
+
+// ReplaceStubs replaces all of the imposter's stubs (PUT /imposters/{id}/stubs)
+func (c *Client) ReplaceStubs(ctx context.Context, port int, stubs []models.Stub) (*models.Imposter, error) {
+	if stubs == nil {
+		stubs = []models.Stub{}
+	}
+	body := map[string]interface{}{"stubs": stubs}
+	return c.imposter(ctx, http.MethodPut, imposterPath(port)+"/stubs", nil, body)
+}
+
+// ReplaceStub replaces the stub at index (PUT /imposters/{id}/stubs/{stubIndex})
+func (c *Client) ReplaceStub(ctx context.Context, port, index int, stub models.Stub) (*models.Imposter, error) {
+	return c.imposter(ctx, http.MethodPut, stubIndexPath(port, index), nil, stub)
+}
+
+// DeleteStub removes the stub at index (DELETE /imposters/{id}/stubs/{stubIndex})
+func (c *Client) DeleteStub(ctx context.Context, port, index int) (*models.Imposter, error) {
+	return c.imposter(ctx, http.MethodDelete, stubIndexPath(port, index), nil, nil)
+}
+
+// StubByID returns the stub with the given id (GET
+// /imposters/{id}/stubs/by-id/{stubId})
+func (c *Client) StubByID(ctx context.Context, port int, id string) (*models.Stub, error) {
+	return c.stub(ctx, http.MethodGet, port, id, nil)
+}
+
+// ReplaceStubByID replaces the stub with the given id, keeping its place
+// (PUT /imposters/{id}/stubs/by-id/{stubId})
+func (c *Client) ReplaceStubByID(ctx context.Context, port int, id string, stub models.Stub) (*models.Stub, error) {
+	return c.stub(ctx, http.MethodPut, port, id, stub)
+}
+
+// PatchStubByID applies a JSON merge patch to the stub with the given id
+// (PATCH /imposters/{id}/stubs/by-id/{stubId})
+func (c *Client) PatchStubByID(ctx context.Context, port int, id string, patch map[string]interface{}) (*models.Stub, error) {
+	return c.stub(ctx, http.MethodPatch, port, id, patch)
+}
+
+// DeleteStubByID removes the stub with the given id, returning it (DELETE
+// /imposters/{id}/stubs/by-id/{stubId})
+func (c *Client) DeleteStubByID(ctx context.Context, port int, id string) (*models.Stub, error) {
+	return c.stub(ctx, http.MethodDelete, port, id, nil)
+}
+
+func (c *Client) stub(ctx context.Context, method string, port int, id string, body interface{}) (*models.Stub, error) {
+	var stub models.Stub
+	path := imposterPath(port) + "/stubs/by-id/" + url.PathEscape(id)
+	if err := c.do(ctx, method, path, nil, body, &stub); err != nil {
+		return nil, err
+	}
+	return &stub, nil
+}
+
+func stubIndexPath(port, index int) string {
+	return imposterPath(port) + "/stubs/" + strconv.Itoa(index)
+}
+
+// Scenarios returns the state of the imposter's scenarios (GET
+// /imposters/{id}/scenarios)
+func (c *Client) Scenarios(ctx context.Context, port int) ([]ScenarioState, error) {
+	return c.scenarios(ctx, http.MethodGet, imposterPath(port)+"/scenarios", nil)
+}
+
+// SetScenario moves a scenario to state (PUT /imposters/{id}/scenarios/{name})
+func (c *Client) SetScenario(ctx context.Context, port int, name, state string) ([]ScenarioState, error) {
+	body := map[string]string{"state": state}
+	return c.scenarios(ctx, http.MethodPut, scenarioPath(port, name), body)
+}
+
+// ResetScenarios returns every scenario to its starting state (DELETE
+// /imposters/{id}/scenarios)
+func (c *Client) ResetScenarios(ctx context.Context, port int) ([]ScenarioState, error) {
+	return c.scenarios(ctx, http.MethodDelete, imposterPath(port)+"/scenarios", nil)
+}
+
+// ResetScenario returns one scenario to its starting state (DELETE
+// /imposters/{id}/scenarios/{name})
+func (c *Client) ResetScenario(ctx context.Context, port int, name string) ([]ScenarioState, error) {
+	return c.scenarios(ctx, http.MethodDelete, scenarioPath(port, name), nil)
+}
+
+func (c *Client) scenarios(ctx context.Context, method, path string, body interface{}) ([]ScenarioState, error) {
+	var result struct {
+		Scenarios []ScenarioState `json:"scenarios"`
+	}
+	if err := c.do(ctx, method, path, nil, body, &result); err != nil {
+		return nil, err
+	}
+	return result.Scenarios, nil
+}
+
+func scenarioPath(port int, name string) string {
+	return imposterPath(port) + "/scenarios/" + url.PathEscape(name)
+}
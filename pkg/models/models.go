@@ -0,0 +1,62 @@
+// Package models is the public view of the types behind imposter
+// definitions and recorded requests. They are the types the admin API
+// uses, so they marshal to the same JSON; the in-process server in
+// pkg/tartuffe and the admin API client in pkg/client both speak them.
+package models
+
+import (
+	internal "github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// Imposters and what configures them
+type (
+	Imposter             = internal.Imposter
+	EndOfRequestResolver = internal.EndOfRequestResolver
+	ServiceConfig        = internal.ServiceConfig
+	RequestRetention     = internal.RequestRetention
+	SchemaViolation      = internal.SchemaViolation
+	Links                = internal.Links
+	Link                 = internal.Link
+)
+
+// Stubs, their predicates and their responses
+type (
+	Stub                = internal.Stub
+	StubLinks           = internal.StubLinks
+	Predicate           = internal.Predicate
+	Selector            = internal.Selector
+	JSONSchemaPredicate = internal.JSONSchemaPredicate
+	Response            = internal.Response
+	IsResponse          = internal.IsResponse
+	ProxyResponse       = internal.ProxyResponse
+	PredicateGen        = internal.PredicateGen
+	Behavior            = internal.Behavior
+	Copy                = internal.Copy
+	Lookup              = internal.Lookup
+	DataSource          = internal.DataSource
+	CSVSource           = internal.CSVSource
+	Using               = internal.Using
+	UsingOptions        = internal.UsingOptions
+	Chaos               = internal.Chaos
+	ChaosLatency        = internal.ChaosLatency
+	ChaosFault          = internal.ChaosFault
+	ChaosError          = internal.ChaosError
+)
+
+// Latency distribution names accepted by ChaosLatency.Distribution
+const (
+	DistributionFixed   = internal.DistributionFixed
+	DistributionUniform = internal.DistributionUniform
+	DistributionNormal  = internal.DistributionNormal
+	DistributionP99     = internal.DistributionP99
+)
+
+// Recorded requests
+type (
+	Request        = internal.Request
+	TCPRequest     = internal.TCPRequest
+	SMTPRequest    = internal.SMTPRequest
+	EmailAddress   = internal.EmailAddress
+	SMTPAttachment = internal.SMTPAttachment
+	GRPCRequest    = internal.GRPCRequest
+)
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+// reachable adds the named types of the internal models package that t
+// refers to, through fields, pointers, slices and maps
+func reachable(t reflect.Type, seen map[string]bool) {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		reachable(t.Elem(), seen)
+		return
+	case reflect.Map:
+		reachable(t.Key(), seen)
+		reachable(t.Elem(), seen)
+		return
+	}
+	if t.PkgPath() != reflect.TypeFor[Imposter]().PkgPath() || seen[t.Name()] {
+		return
+	}
+	seen[t.Name()] = true
+	if t.Kind() == reflect.Struct {
+		for i := range t.NumField() {
+			if f := t.Field(i); f.IsExported() {
+				reachable(f.Type, seen)
+			}
+		}
+	}
+}
+
+// TestAliasesComplete tests that every type an imposter definition or
+// recorded request is built from has an alias here
+func TestAliasesComplete(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, root := range []reflect.Type{
+		reflect.TypeFor[Imposter](),
+		reflect.TypeFor[Stub](),
+		reflect.TypeFor[Request](),
+		reflect.TypeFor[TCPRequest](),
+		reflect.TypeFor[SMTPRequest](),
+		reflect.TypeFor[GRPCRequest](),
+	} {
+		reachable(root, seen)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "models.go", nil, 0)
+	if err != nil {
+		t.Fatalf("failed to parse models.go: %v", err)
+	}
+	aliases := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if spec, ok := n.(*ast.TypeSpec); ok && spec.Assign.IsValid() {
+			aliases[spec.Name.Name] = true
+		}
+		return true
+	})
+
+	for name := range seen {
+		if !aliases[name] {
+			t.Errorf("expected an alias for %s", name)
+		}
+	}
+}
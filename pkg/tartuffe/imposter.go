@@ -0,0 +1,109 @@
+package tartuffe
+
+import (
+	"fmt"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/imposter"
+	"github.com/TetsujinOni/go-tartuffe/pkg/models"
+)
+
+// RunningImposter is an imposter started on its own, without an admin
+// server
+type RunningImposter struct {
+	manager  *imposter.Manager
+	host     string
+	port     int
+	protocol string
+}
+
+// StartImposter starts an imposter in-process. The protocol defaults to
+// http, and an imposter without a port is given a free one. The imposter
+// must not be modified while it runs; read it back with Imposter.
+func StartImposter(imp *models.Imposter) (*RunningImposter, error) {
+	if imp.Protocol == "" {
+		imp.Protocol = "http"
+	}
+	if imp.Stubs == nil {
+		imp.Stubs = []models.Stub{}
+	}
+	// Without an admin server there is no --bodyDir to confine body and
+	// schema files to
+	if err := imposter.ValidateStubSchemas("", imp.Stubs); err != nil {
+		return nil, err
+	}
+	if err := imposter.ValidateBodyPaths("", imp.Stubs, imp.DefaultResponse); err != nil {
+		return nil, err
+	}
+	if imp.NumberOfRequests == nil {
+		count := 0
+		imp.NumberOfRequests = &count
+	}
+	switch imp.Protocol {
+	case "tcp":
+		if imp.Mode == "" {
+			imp.Mode = "text"
+		}
+	case "https":
+		imp.ExtractCertMetadata()
+	}
+
+	manager := imposter.NewManager()
+	if err := manager.Start(imp); err != nil {
+		return nil, fmt.Errorf("failed to start %s imposter: %w", imp.Protocol, err)
+	}
+	return &RunningImposter{manager: manager, host: imp.Host, port: imp.Port, protocol: imp.Protocol}, nil
+}
+
+// Port returns the imposter's port
+func (r *RunningImposter) Port() int {
+	return r.port
+}
+
+// URL returns the imposter's base URL, such as http://127.0.0.1:4545 or
+// tcp://127.0.0.1:4545
+func (r *RunningImposter) URL() string {
+	return imposterURL(r.host, r.port, r.protocol)
+}
+
+// Imposter returns a copy of the imposter, including its recorded requests
+func (r *RunningImposter) Imposter() *models.Imposter {
+	imp, _ := r.manager.Snapshot(r.port)
+	return imp
+}
+
+// Requests returns the requests an HTTP or HTTPS imposter has recorded
+func (r *RunningImposter) Requests() []models.Request {
+	if imp := r.Imposter(); imp != nil {
+		return imp.Requests
+	}
+	return nil
+}
+
+// TCPRequests returns the requests a TCP imposter has recorded
+func (r *RunningImposter) TCPRequests() []models.TCPRequest {
+	if imp := r.Imposter(); imp != nil {
+		return imp.TCPRequests
+	}
+	return nil
+}
+
+// SMTPRequests returns the emails an SMTP imposter has recorded
+func (r *RunningImposter) SMTPRequests() []models.SMTPRequest {
+	if imp := r.Imposter(); imp != nil {
+		return imp.SMTPRequests
+	}
+	return nil
+}
+
+// GRPCRequests returns the calls a gRPC imposter has recorded
+func (r *RunningImposter) GRPCRequests() []models.GRPCRequest {
+	if imp := r.Imposter(); imp != nil {
+		return imp.GRPCRequests
+	}
+	return nil
+}
+
+// Stop stops the imposter
+func (r *RunningImposter) Stop() error {
+	return r.manager.Stop(r.port)
+}
@@ -0,0 +1,193 @@
+package tartuffe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/api"
+	"github.com/TetsujinOni/go-tartuffe/internal/response"
+	"github.com/TetsujinOni/go-tartuffe/pkg/models"
+)
+
+// Options configures an in-process admin server. The zero value serves on
+// a free port on 127.0.0.1 with injection disabled.
+type Options struct {
+	Host             string // Interface to bind to (default 127.0.0.1)
+	Port             int    // Admin API port (0 = pick a free port)
+	AllowInjection   bool   // Allow JavaScript injection
+	Debug            bool   // Include stub match information in imposter retrievals and log why requests matched no stub
+	APIKey           string // Require this key on admin API requests
+	Origin           string // Safe origin for CORS requests
+	DataDir          string // Persist imposters to this directory
+	BodyDir          string // Confine response bodyFile and proxy bodyDir paths to this directory
+	RequestRetention models.RequestRetention
+}
+
+// Server is an admin API server running in-process
+type Server struct {
+	api    *api.Server
+	host   string
+	port   int
+	apiKey string
+	client *http.Client
+	served chan error
+}
+
+// NewServer starts an admin server. It returns once the server is
+// listening; Close stops it along with its imposters.
+func NewServer(opts Options) (*Server, error) {
+	host := opts.Host
+	if host == "" {
+		host = defaultHost
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(opts.Port)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the admin API: %w", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	srv := &Server{
+		api: api.NewServer(api.ServerConfig{
+			Port:             port,
+			Host:             host,
+			AllowInjection:   opts.AllowInjection,
+			Debug:            opts.Debug,
+			IPWhitelist:      "*",
+			Origin:           opts.Origin,
+			APIKey:           opts.APIKey,
+			DataDir:          opts.DataDir,
+			BodyDir:          opts.BodyDir,
+			RequestRetention: opts.RequestRetention,
+		}),
+		host:   host,
+		port:   port,
+		apiKey: opts.APIKey,
+		client: &http.Client{Timeout: 30 * time.Second},
+		served: make(chan error, 1),
+	}
+	go func() {
+		srv.served <- srv.api.Serve(listener)
+	}()
+	return srv, nil
+}
+
+// Port returns the admin API's port
+func (s *Server) Port() int {
+	return s.port
+}
+
+// URL returns the admin API's base URL
+func (s *Server) URL() string {
+	return "http://" + net.JoinHostPort(s.host, strconv.Itoa(s.port))
+}
+
+// ImposterURL returns the base URL of the imposter on port
+func (s *Server) ImposterURL(port int) string {
+	protocol := "http"
+	if imp, ok := s.Imposter(port); ok {
+		protocol = imp.Protocol
+	}
+	return imposterURL(s.host, port, protocol)
+}
+
+// CreateImposter creates an imposter through the admin API, applying the
+// same validation and defaults as POST /imposters. An imposter without a
+// port is given a free one. The created imposter is returned.
+func (s *Server) CreateImposter(imp *models.Imposter) (*models.Imposter, error) {
+	body, err := json.Marshal(imp)
+	if err != nil {
+		return nil, err
+	}
+
+	var created models.Imposter
+	if err := s.do(http.MethodPost, "/imposters", body, http.StatusCreated, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Imposter returns a copy of the running imposter on port, including the
+// requests it has recorded
+func (s *Server) Imposter(port int) (*models.Imposter, bool) {
+	return s.api.GetImposterManager().Snapshot(port)
+}
+
+// Requests returns the requests recorded by the HTTP imposter on port
+func (s *Server) Requests(port int) []models.Request {
+	imp, ok := s.Imposter(port)
+	if !ok {
+		return nil
+	}
+	return imp.Requests
+}
+
+// DeleteImposter stops and removes the imposter on port
+func (s *Server) DeleteImposter(port int) error {
+	return s.do(http.MethodDelete, "/imposters/"+strconv.Itoa(port), nil, http.StatusOK, nil)
+}
+
+// DeleteImposters stops and removes every imposter
+func (s *Server) DeleteImposters() error {
+	return s.do(http.MethodDelete, "/imposters", nil, http.StatusOK, nil)
+}
+
+// Close stops the admin server and all of its imposters
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.api.Shutdown(ctx); err != nil {
+		return err
+	}
+	if err := <-s.served; err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// do calls the admin API, decoding the response into out when it has the
+// expected status and returning the API's error otherwise
+func (s *Server) do(method, path string, body []byte, status int, out interface{}) error {
+	req, err := http.NewRequest(method, s.URL()+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("X-Api-Key", s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != status {
+		var errs response.ErrorResponse
+		if json.NewDecoder(resp.Body).Decode(&errs) == nil && len(errs.Errors) > 0 {
+			return fmt.Errorf("%s %s: %s", method, path, errs.Errors[0].Message)
+		}
+		return fmt.Errorf("%s %s: unexpected status %d", method, path, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// imposterURL returns the base URL for an imposter; protocols without a
+// URL scheme of their own use the protocol name
+func imposterURL(host string, port int, protocol string) string {
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = defaultHost
+	}
+	return protocol + "://" + net.JoinHostPort(host, strconv.Itoa(port))
+}
@@ -0,0 +1,9 @@
+// Package tartuffe runs go-tartuffe in-process, for Go programs and test
+// suites that want imposters without shelling out to the binary. A Server
+// is the full mountebank-compatible admin API; StartImposter runs a single
+// imposter on its own. Both bind to a free port unless given one, and take
+// imposter definitions as the types in pkg/models.
+package tartuffe
+
+// defaultHost is the interface servers bind to when no host is given
+const defaultHost = "127.0.0.1"
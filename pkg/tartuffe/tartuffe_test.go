@@ -0,0 +1,120 @@
+package tartuffe_test
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/pkg/models"
+	"github.com/TetsujinOni/go-tartuffe/pkg/tartuffe"
+	"github.com/TetsujinOni/go-tartuffe/pkg/tartuffe/tartuffetest"
+)
+
+func helloStub() models.Stub {
+	return models.Stub{
+		Predicates: []models.Predicate{{Equals: map[string]interface{}{"path": "/hello"}}},
+		Responses:  []models.Response{{Is: &models.IsResponse{StatusCode: 200, Body: "hi"}}},
+	}
+}
+
+func getBody(t *testing.T, url string) string {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+// TestServer tests creating imposters through an in-process admin server
+// and reading back what they recorded
+func TestServer(t *testing.T) {
+	srv := tartuffetest.NewServer(t, tartuffe.Options{})
+
+	if body := getBody(t, srv.URL()+"/imposters"); !strings.Contains(body, `"imposters"`) {
+		t.Errorf("expected the admin API at %s, got %s", srv.URL(), body)
+	}
+
+	imp, err := srv.CreateImposter(&models.Imposter{
+		Protocol:       "http",
+		RecordRequests: true,
+		Stubs:          []models.Stub{helloStub()},
+	})
+	if err != nil {
+		t.Fatalf("CreateImposter() error = %v", err)
+	}
+	if imp.Port == 0 {
+		t.Fatal("expected the imposter to be given a port")
+	}
+
+	if body := getBody(t, srv.ImposterURL(imp.Port)+"/hello"); body != "hi" {
+		t.Errorf("expected the stub response, got %q", body)
+	}
+	requests := srv.Requests(imp.Port)
+	if len(requests) != 1 || requests[0].Path != "/hello" {
+		t.Errorf("expected one recorded request to /hello, got %v", requests)
+	}
+
+	if _, err := srv.CreateImposter(&models.Imposter{Protocol: "gopher"}); err == nil ||
+		!strings.Contains(err.Error(), "unsupported protocol") {
+		t.Errorf("expected the API's validation error, got %v", err)
+	}
+
+	if err := srv.DeleteImposter(imp.Port); err != nil {
+		t.Fatalf("DeleteImposter() error = %v", err)
+	}
+	if _, ok := srv.Imposter(imp.Port); ok {
+		t.Error("expected the imposter to be stopped")
+	}
+}
+
+// TestStartImposter tests running imposters without an admin server
+func TestStartImposter(t *testing.T) {
+	t.Run("http", func(t *testing.T) {
+		imp := tartuffetest.StartImposter(t, &models.Imposter{
+			RecordRequests: true,
+			Stubs:          []models.Stub{helloStub()},
+		})
+		if !strings.HasPrefix(imp.URL(), "http://127.0.0.1:") {
+			t.Errorf("unexpected URL %s", imp.URL())
+		}
+
+		if body := getBody(t, imp.URL()+"/hello"); body != "hi" {
+			t.Errorf("expected the stub response, got %q", body)
+		}
+		if requests := imp.Requests(); len(requests) != 1 || requests[0].Path != "/hello" {
+			t.Errorf("expected one recorded request to /hello, got %v", requests)
+		}
+	})
+
+	t.Run("tcp", func(t *testing.T) {
+		imp := tartuffetest.StartImposter(t, &models.Imposter{
+			Protocol:       "tcp",
+			RecordRequests: true,
+			Stubs: []models.Stub{{
+				Responses: []models.Response{{Is: &models.IsResponse{Data: "pong"}}},
+			}},
+		})
+
+		conn, err := net.Dial("tcp", strings.TrimPrefix(imp.URL(), "tcp://"))
+		if err != nil {
+			t.Fatalf("failed to connect to %s: %v", imp.URL(), err)
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(3 * time.Second))
+		conn.Write([]byte("ping"))
+		reply := make([]byte, 4)
+		if _, err := io.ReadFull(conn, reply); err != nil || string(reply) != "pong" {
+			t.Fatalf("expected pong, got %q (%v)", reply, err)
+		}
+
+		if requests := imp.TCPRequests(); len(requests) != 1 || requests[0].Data != "ping" {
+			t.Errorf("expected one recorded ping, got %v", requests)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+// Package tartuffetest starts in-process tartuffe servers and imposters
+// for tests, stopping them when the test finishes.
+package tartuffetest
+
+import (
+	"testing"
+
+	"github.com/TetsujinOni/go-tartuffe/pkg/models"
+	"github.com/TetsujinOni/go-tartuffe/pkg/tartuffe"
+)
+
+// NewServer starts an admin server for the test and closes it, along with
+// its imposters, in the test's cleanup
+func NewServer(tb testing.TB, opts tartuffe.Options) *tartuffe.Server {
+	tb.Helper()
+
+	srv, err := tartuffe.NewServer(opts)
+	if err != nil {
+		tb.Fatalf("failed to start tartuffe server: %v", err)
+	}
+	tb.Cleanup(func() {
+		if err := srv.Close(); err != nil {
+			tb.Errorf("failed to close tartuffe server: %v", err)
+		}
+	})
+	return srv
+}
+
+// StartImposter starts an imposter for the test and stops it in the
+// test's cleanup
+func StartImposter(tb testing.TB, imp *models.Imposter) *tartuffe.RunningImposter {
+	tb.Helper()
+
+	running, err := tartuffe.StartImposter(imp)
+	if err != nil {
+		tb.Fatalf("failed to start imposter: %v", err)
+	}
+	tb.Cleanup(func() {
+		if err := running.Stop(); err != nil {
+			tb.Errorf("failed to stop imposter on port %d: %v", running.Port(), err)
+		}
+	})
+	return running
+}
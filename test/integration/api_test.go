@@ -16,20 +16,28 @@ import (
 )
 
 var (
-	baseURL    = "http://localhost:2525"
-	client     = &http.Client{Timeout: 5 * time.Second}
-	testServer *api.Server
+	baseURL     = "http://localhost:2525"
+	client      = &http.Client{Timeout: 5 * time.Second}
+	testServer  *api.Server
+	testBodyDir string // the test server's --bodyDir
 )
 
 // TestMain sets up a single server for all tests
 func TestMain(m *testing.M) {
 	fmt.Println("Starting integration test server...")
 
+	var err error
+	if testBodyDir, err = os.MkdirTemp("", "tartuffe-bodies"); err != nil {
+		fmt.Printf("failed to create body directory: %v\n", err)
+		os.Exit(1)
+	}
+
 	testServer = api.NewServer(api.ServerConfig{
 		Port:           2525,
 		AllowInjection: true,
 		LocalOnly:      false,
 		IPWhitelist:    "*",
+		BodyDir:        testBodyDir,
 	})
 
 	go func() {
@@ -48,6 +56,7 @@ func TestMain(m *testing.M) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	testServer.Shutdown(ctx)
+	os.RemoveAll(testBodyDir)
 
 	os.Exit(code)
 }
@@ -543,3 +543,114 @@ func TestBehavior_Copy_FromBody(t *testing.T) {
 		t.Errorf("expected 'Received: HelloWorld', got '%s'", string(body))
 	}
 }
+
+func TestBehavior_Chaos_ErrorAndFault(t *testing.T) {
+	defer cleanup(t)
+
+	resp, _, err := post("/imposters", map[string]interface{}{
+		"protocol": "http",
+		"port":     10000,
+		"stubs": []map[string]interface{}{
+			{
+				"predicates": []map[string]interface{}{
+					{"equals": map[string]interface{}{"path": "/error"}},
+				},
+				"responses": []map[string]interface{}{
+					{
+						"is": map[string]interface{}{"body": "ok"},
+						"_behaviors": map[string]interface{}{
+							"chaos": map[string]interface{}{
+								"seed":  1,
+								"error": map[string]interface{}{"probability": 1, "response": map[string]interface{}{"statusCode": 503, "body": "chaos"}},
+							},
+						},
+					},
+				},
+			},
+			{
+				"responses": []map[string]interface{}{
+					{
+						"is": map[string]interface{}{"body": "ok"},
+						"_behaviors": map[string]interface{}{
+							"chaos": map[string]interface{}{
+								"fault": map[string]interface{}{"probability": 1},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create imposter: %v", err)
+	}
+	if resp.StatusCode != 201 {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	impResp, err := http.Get("http://localhost:10000/error")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(impResp.Body)
+	impResp.Body.Close()
+
+	if impResp.StatusCode != 503 || string(body) != "chaos" {
+		t.Errorf("expected 503 'chaos', got %d '%s'", impResp.StatusCode, string(body))
+	}
+
+	if _, err := http.Get("http://localhost:10000/fault"); err == nil {
+		t.Error("expected connection error from chaos fault, got none")
+	}
+}
+
+func TestBehavior_Chaos_SeededLatency(t *testing.T) {
+	defer cleanup(t)
+
+	resp, _, err := post("/imposters", map[string]interface{}{
+		"protocol": "http",
+		"port":     10001,
+		"stubs": []map[string]interface{}{
+			{
+				"responses": []map[string]interface{}{
+					{
+						"is": map[string]interface{}{"body": "ok"},
+						"_behaviors": map[string]interface{}{
+							"chaos": map[string]interface{}{
+								"seed": 7,
+								"latency": map[string]interface{}{
+									"probability":  1,
+									"distribution": "uniform",
+									"min":          100,
+									"max":          150,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create imposter: %v", err)
+	}
+	if resp.StatusCode != 201 {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	impResp, err := http.Get("http://localhost:10001/")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	impResp.Body.Close()
+	elapsed := time.Since(start)
+
+	if elapsed < 100*time.Millisecond {
+		t.Errorf("expected at least 100ms of chaos latency, got %v", elapsed)
+	}
+}
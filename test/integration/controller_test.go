@@ -7,6 +7,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -317,6 +318,176 @@ func TestPUTImpostersOverwrites(t *testing.T) {
 	}
 }
 
+// TestPUTImpostersKeepsUnchanged tests PUT /imposters leaves imposters with
+// an unchanged definition running with their recorded requests
+func TestPUTImpostersKeepsUnchanged(t *testing.T) {
+	defer cleanup(t)
+
+	kept := map[string]interface{}{"protocol": "http", "port": 10390, "recordRequests": true}
+	put("/imposters", map[string]interface{}{
+		"imposters": []interface{}{kept, map[string]interface{}{"protocol": "http", "port": 10391}},
+	})
+
+	httpResp, err := http.Get("http://localhost:10390/recorded")
+	if err != nil {
+		t.Fatalf("failed to call imposter: %v", err)
+	}
+	httpResp.Body.Close()
+
+	resp, body, err := put("/imposters", map[string]interface{}{
+		"imposters": []interface{}{kept, map[string]interface{}{"protocol": "http", "port": 10392}},
+	})
+	if err != nil {
+		t.Fatalf("PUT failed: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d: %v", resp.StatusCode, body)
+	}
+
+	_, body, _ = get("/imposters/10390")
+	if requests, _ := body["requests"].([]interface{}); len(requests) != 1 {
+		t.Errorf("expected the unchanged imposter to keep 1 recorded request, got %v", body["requests"])
+	}
+	if resp, _, _ := get("/imposters/10391"); resp.StatusCode != 404 {
+		t.Errorf("expected removed imposter to be gone, got %d", resp.StatusCode)
+	}
+	if _, err := http.Get("http://localhost:10392/"); err != nil {
+		t.Errorf("expected new imposter to be running: %v", err)
+	}
+}
+
+// TestPUTImpostersRollsBack tests PUT /imposters changes nothing when one of
+// the imposters cannot start
+func TestPUTImpostersRollsBack(t *testing.T) {
+	defer cleanup(t)
+
+	put("/imposters", map[string]interface{}{
+		"imposters": []interface{}{
+			map[string]interface{}{"protocol": "http", "port": 10390, "name": "original"},
+		},
+	})
+
+	// Another process holds the port of one of the new imposters
+	blocker, err := net.Listen("tcp", ":10393")
+	if err != nil {
+		t.Fatalf("failed to hold port: %v", err)
+	}
+	defer blocker.Close()
+
+	resp, _, err := put("/imposters", map[string]interface{}{
+		"imposters": []interface{}{
+			map[string]interface{}{"protocol": "http", "port": 10390, "name": "changed"},
+			map[string]interface{}{"protocol": "http", "port": 10392},
+			map[string]interface{}{"protocol": "http", "port": 10393},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PUT failed: %v", err)
+	}
+	if resp.StatusCode != 400 {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+
+	_, body, _ := get("/imposters/10390")
+	if body["name"] != "original" {
+		t.Errorf("expected the original imposter to be kept, got name %v", body["name"])
+	}
+	if resp, _, _ := get("/imposters/10392"); resp.StatusCode != 404 {
+		t.Errorf("expected no imposter on 10392, got %d", resp.StatusCode)
+	}
+	if _, err := http.Get("http://localhost:10392/"); err == nil {
+		t.Error("expected nothing listening on 10392")
+	}
+}
+
+// TestPUTImpostersRestoresStopped tests that when a new imposter fails to
+// start after the imposters it replaces were stopped, those are started
+// again with the requests they had recorded
+func TestPUTImpostersRestoresStopped(t *testing.T) {
+	defer cleanup(t)
+
+	put("/imposters", map[string]interface{}{
+		"imposters": []interface{}{
+			map[string]interface{}{"protocol": "http", "port": 10390, "name": "original", "recordRequests": true},
+		},
+	})
+	for _, path := range []string{"/first", "/second"} {
+		resp, err := http.Get("http://localhost:10390" + path)
+		if err != nil {
+			t.Fatalf("failed to call imposter: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	// The gRPC imposter passes validation but fails in Start, after the
+	// original imposter has been stopped and its replacement started
+	resp, body, err := put("/imposters", map[string]interface{}{
+		"imposters": []interface{}{
+			map[string]interface{}{"protocol": "http", "port": 10390, "name": "changed"},
+			map[string]interface{}{"protocol": "grpc", "port": 10391, "protoFiles": []string{"missing.proto"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PUT failed: %v", err)
+	}
+	if resp.StatusCode != 400 {
+		t.Fatalf("expected 400, got %d: %v", resp.StatusCode, body)
+	}
+
+	_, body, _ = get("/imposters/10390")
+	if body["name"] != "original" {
+		t.Errorf("expected the original imposter to be restored, got name %v", body["name"])
+	}
+	requests, _ := body["requests"].([]interface{})
+	if len(requests) != 2 || requests[0].(map[string]interface{})["path"] != "/first" {
+		t.Errorf("expected the original's 2 recorded requests, got %v", requests)
+	}
+
+	resp, err = http.Get("http://localhost:10390/third")
+	if err != nil {
+		t.Fatalf("expected the original imposter to be listening again: %v", err)
+	}
+	resp.Body.Close()
+	if _, body, _ = get("/imposters/10390"); len(body["requests"].([]interface{})) != 3 {
+		t.Errorf("expected the restored imposter to keep recording, got %v", body["requests"])
+	}
+	if resp, _, _ := get("/imposters/10391"); resp.StatusCode != 404 {
+		t.Errorf("expected no imposter on 10391, got %d", resp.StatusCode)
+	}
+}
+
+// TestPUTImpostersStartsGRPC tests PUT /imposters starts gRPC imposters
+func TestPUTImpostersStartsGRPC(t *testing.T) {
+	defer cleanup(t)
+
+	protoFile, err := filepath.Abs("../testdata/proto/greeter.proto")
+	if err != nil {
+		t.Fatalf("failed to resolve proto file: %v", err)
+	}
+
+	resp, body, err := put("/imposters", map[string]interface{}{
+		"imposters": []interface{}{
+			map[string]interface{}{
+				"protocol":   "grpc",
+				"port":       10394,
+				"protoFiles": []string{protoFile},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PUT failed: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d: %v", resp.StatusCode, body)
+	}
+
+	conn, err := net.Dial("tcp", "localhost:10394")
+	if err != nil {
+		t.Fatalf("expected gRPC imposter to be listening: %v", err)
+	}
+	conn.Close()
+}
+
 // TestGETHomeHypermedia tests GET / returns correct hypermedia
 func TestGETHomeHypermedia(t *testing.T) {
 	defer cleanup(t)
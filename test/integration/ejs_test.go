@@ -0,0 +1,94 @@
+package integration
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/config"
+)
+
+// EJS evaluation tests: scriptlets, output tags, include locals and --configdata
+
+func TestEJS_ConfigDataLoopsAndIncludes(t *testing.T) {
+	defer cleanup(t)
+
+	wd, _ := os.Getwd()
+	dir := filepath.Join(wd, "..", "..", "test", "fixtures", "ejs")
+	loader := config.NewLoader(config.LoadOptions{
+		ConfigFile: filepath.Join(dir, "imposters.ejs"),
+		DataFile:   filepath.Join(dir, "data.yaml"),
+	})
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if len(cfg.Imposters) != 2 {
+		t.Fatalf("expected one imposter per service, got %d", len(cfg.Imposters))
+	}
+	if got := len(loader.Files()); got != 3 {
+		t.Errorf("expected config, data and include files to be watched, got %v", loader.Files())
+	}
+	if err := testServer.LoadImposters(cfg.Imposters); err != nil {
+		t.Fatalf("failed to load imposters: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	tests := []struct {
+		url    string
+		status int
+		body   string
+	}{
+		{"http://localhost:10360/users", 200, "Tom &amp; Jerry"},
+		{"http://localhost:10360/missing", 404, "gone"},
+		{"http://localhost:10361/orders", 200, "&lt;none&gt;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			resp, err := http.Get(tt.url)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.status || string(body) != tt.body {
+				t.Errorf("expected %d %q, got %d %q", tt.status, tt.body, resp.StatusCode, body)
+			}
+		})
+	}
+}
+
+func TestEJS_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		data     string
+		wantErr  string
+	}{
+		{"unclosed tag", `{"imposters": [<% if (true) { ]}`, "", "close tag"},
+		{"undefined variable", `{"imposters": [<%= missing.port %>]}`, "", "missing"},
+		{"missing include", `{"imposters": [<%- include('nope.json') %>]}`, "", "nope.json"},
+		{"data not an object", `{"imposters": []}<%# %>`, "- 1\n- 2\n", "must contain an object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			options := config.LoadOptions{ConfigFile: filepath.Join(dir, "imposters.ejs")}
+			os.WriteFile(options.ConfigFile, []byte(tt.template), 0644)
+			if tt.data != "" {
+				options.DataFile = filepath.Join(dir, "data.yaml")
+				os.WriteFile(options.DataFile, []byte(tt.data), 0644)
+			}
+
+			_, err := config.NewLoader(options).Load()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
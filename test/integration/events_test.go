@@ -0,0 +1,154 @@
+package integration
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseEvent is one server-sent event read from a stream
+type sseEvent struct {
+	Type string
+	Data map[string]interface{}
+}
+
+// openEvents connects to an event stream and returns its events as they arrive
+func openEvents(t *testing.T, path string) (<-chan sseEvent, func()) {
+	t.Helper()
+	resp, err := http.Get(baseURL + path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+		t.Fatalf("expected 200 from %s, got %d", path, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected text/event-stream, got %q", ct)
+	}
+
+	out := make(chan sseEvent, 16)
+	go func() {
+		defer close(out)
+		scanner := bufio.NewScanner(resp.Body)
+		var event sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event.Type = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.Data)
+			case line == "" && event.Type != "":
+				out <- event
+				event = sseEvent{}
+			}
+		}
+	}()
+	return out, func() { resp.Body.Close() }
+}
+
+// nextEvent waits for the next event on a stream
+func nextEvent(t *testing.T, stream <-chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case event, ok := <-stream:
+		if !ok {
+			t.Fatal("event stream closed")
+		}
+		return event
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return sseEvent{}
+}
+
+// TestEvents_ImposterStream tests that an imposter's stream reports each
+// request and the stub it matched
+func TestEvents_ImposterStream(t *testing.T) {
+	defer cleanup(t)
+
+	post("/imposters", map[string]interface{}{
+		"protocol": "http",
+		"port":     10400,
+		"stubs": []map[string]interface{}{{
+			"id":         "hello",
+			"predicates": []map[string]interface{}{{"equals": map[string]interface{}{"path": "/hello"}}},
+			"responses":  []map[string]interface{}{{"is": map[string]interface{}{"body": "hi"}}},
+		}},
+	})
+
+	stream, closeStream := openEvents(t, "/imposters/10400/events")
+	defer closeStream()
+
+	for _, path := range []string{"/hello", "/missing"} {
+		resp, err := http.Get("http://localhost:10400" + path)
+		if err != nil {
+			t.Fatalf("failed to call imposter: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	expected := []struct {
+		eventType string
+		path      string
+	}{
+		{"request-received", "/hello"},
+		{"stub-matched", "/hello"},
+		{"request-received", "/missing"},
+		{"no-match", "/missing"},
+	}
+	for _, want := range expected {
+		event := nextEvent(t, stream)
+		if event.Type != want.eventType {
+			t.Fatalf("expected %s event, got %s", want.eventType, event.Type)
+		}
+		if event.Data["imposter"] != float64(10400) {
+			t.Errorf("expected imposter 10400, got %v", event.Data["imposter"])
+		}
+		request, _ := event.Data["request"].(map[string]interface{})
+		if request["path"] != want.path {
+			t.Errorf("expected %s for %s, got %v", want.path, want.eventType, request["path"])
+		}
+		if want.eventType == "stub-matched" && (event.Data["stubId"] != "hello" || event.Data["stubIndex"] != float64(0)) {
+			t.Errorf("expected stub hello at index 0, got %v at %v", event.Data["stubId"], event.Data["stubIndex"])
+		}
+	}
+}
+
+// TestEvents_GlobalStream tests that the global stream reports imposter
+// lifecycle events filtered by type
+func TestEvents_GlobalStream(t *testing.T) {
+	defer cleanup(t)
+
+	stream, closeStream := openEvents(t, "/events?types=imposter-started,imposter-stopped")
+	defer closeStream()
+
+	post("/imposters", map[string]interface{}{"protocol": "tcp", "port": 10401})
+	del("/imposters/10401")
+
+	for _, want := range []string{"imposter-started", "imposter-stopped"} {
+		event := nextEvent(t, stream)
+		if event.Type != want || event.Data["imposter"] != float64(10401) || event.Data["protocol"] != "tcp" {
+			t.Errorf("expected %s for tcp imposter 10401, got %s %v", want, event.Type, event.Data)
+		}
+	}
+}
+
+// TestEvents_Errors tests the event stream's error responses
+func TestEvents_Errors(t *testing.T) {
+	defer cleanup(t)
+
+	resp, body, _ := get("/events?types=nope")
+	if resp.StatusCode != 400 {
+		t.Errorf("expected 400 for an unknown event type, got %d: %v", resp.StatusCode, body)
+	}
+
+	resp, body, _ = get("/imposters/10402/events")
+	if resp.StatusCode != 404 {
+		t.Errorf("expected 404 for a missing imposter, got %d: %v", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,149 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Fake data generator tests
+
+func fakerHTTPImposter(port int, seed interface{}) map[string]interface{} {
+	imp := map[string]interface{}{
+		"protocol": "http",
+		"port":     port,
+		"stubs": []map[string]interface{}{
+			{
+				"responses": []map[string]interface{}{
+					{
+						"is": map[string]interface{}{
+							"headers": map[string]interface{}{"X-Trace-Id": "${fake.uuid}"},
+							"body": map[string]interface{}{
+								"greeting": "Hello ${NAME}, meet ${fake.name}",
+								"email":    "${fake.email}",
+								"iban":     "${fake.iban}",
+							},
+						},
+						"_behaviors": map[string]interface{}{
+							"copy": map[string]interface{}{
+								"from": map[string]interface{}{"query": "name"},
+								"into": "${NAME}",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if seed != nil {
+		imp["seed"] = seed
+	}
+	return imp
+}
+
+func fakerFetch(t *testing.T, url string) (map[string]interface{}, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		t.Fatalf("invalid JSON body %q: %v", raw, err)
+	}
+	return body, resp.Header.Get("X-Trace-Id")
+}
+
+func TestFaker_HTTPSeededIsReproducible(t *testing.T) {
+	defer cleanup(t)
+
+	var runs [2]string
+	for i := range runs {
+		resp, _, err := post("/imposters", fakerHTTPImposter(10300, 1234))
+		if err != nil {
+			t.Fatalf("failed to create imposter: %v", err)
+		}
+		if resp.StatusCode != 201 {
+			t.Fatalf("expected status 201, got %d", resp.StatusCode)
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		body, traceID := fakerFetch(t, "http://localhost:10300/?name=ada")
+		greeting, _ := body["greeting"].(string)
+		if !strings.HasPrefix(greeting, "Hello ada, meet ") || strings.Contains(greeting, "${") {
+			t.Errorf("run %d: expected copy and fake tokens to be replaced, got %q", i, greeting)
+		}
+		if !regexp.MustCompile(`^DE\d{20}$`).MatchString(body["iban"].(string)) {
+			t.Errorf("run %d: expected an IBAN, got %v", i, body["iban"])
+		}
+		runs[i] = greeting + "|" + body["email"].(string) + "|" + traceID
+
+		del("/imposters/10300")
+	}
+
+	if runs[0] != runs[1] {
+		t.Errorf("expected seeded imposters to produce identical values, got %q and %q", runs[0], runs[1])
+	}
+}
+
+func TestFaker_HTTPUnseededVaries(t *testing.T) {
+	defer cleanup(t)
+
+	post("/imposters", fakerHTTPImposter(10301, nil))
+	time.Sleep(100 * time.Millisecond)
+
+	_, first := fakerFetch(t, "http://localhost:10301/")
+	_, second := fakerFetch(t, "http://localhost:10301/")
+	if first == "" || first == second {
+		t.Errorf("expected a fresh UUID per request, got %q and %q", first, second)
+	}
+}
+
+func TestFaker_TCPData(t *testing.T) {
+	defer cleanup(t)
+
+	resp, _, err := post("/imposters", map[string]interface{}{
+		"protocol": "tcp",
+		"port":     10302,
+		"seed":     99,
+		"stubs": []map[string]interface{}{
+			{
+				"responses": []map[string]interface{}{
+					{"is": map[string]interface{}{"data": "USER ${fake.username} ${fake.ipv4}"}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create imposter: %v", err)
+	}
+	if resp.StatusCode != 201 {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+
+	conn, err := net.DialTimeout("tcp", "localhost:10302", 2*time.Second)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("request"))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+
+	if got := string(buf[:n]); !regexp.MustCompile(`^USER [a-z]+\d+ 10\.\d+\.\d+\.\d+$`).MatchString(got) {
+		t.Errorf("expected generated TCP data, got %q", got)
+	}
+}
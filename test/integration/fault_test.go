@@ -82,10 +82,10 @@ func TestFault_RandomDataThenClose(t *testing.T) {
 	t.Logf("Got expected error: %v", err)
 }
 
-func TestFault_UnknownFaultReturnsEmpty(t *testing.T) {
+func TestFault_UnknownFaultRejected(t *testing.T) {
 	defer cleanup(t)
 
-	resp, _, err := post("/imposters", map[string]interface{}{
+	resp, body, err := post("/imposters", map[string]interface{}{
 		"protocol": "http",
 		"port":     5402,
 		"stubs": []map[string]interface{}{
@@ -99,17 +99,18 @@ func TestFault_UnknownFaultReturnsEmpty(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create imposter: %v", err)
 	}
-	if resp.StatusCode != 201 {
-		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	if resp.StatusCode != 400 {
+		t.Fatalf("expected status 400, got %d", resp.StatusCode)
 	}
 
-	time.Sleep(100 * time.Millisecond)
-
-	// Make request - unknown fault should just close connection gracefully
-	_, err = http.Get("http://localhost:5402/test")
-	// This might succeed with empty response or fail with EOF
-	// Either is acceptable for unknown fault type
-	t.Logf("Unknown fault result: err=%v", err)
+	errs, _ := body["errors"].([]interface{})
+	if len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", body["errors"])
+	}
+	first := errs[0].(map[string]interface{})
+	if first["pointer"] != "/stubs/0/responses/0/fault" {
+		t.Errorf("expected pointer to the fault, got %v", first["pointer"])
+	}
 }
 
 func TestFault_WithPredicate(t *testing.T) {
@@ -0,0 +1,99 @@
+package integration
+
+import (
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/formatter"
+)
+
+// Formatter tests (WireMock, HAR and Postman conversion)
+
+func TestFormatter_WireMockMappingsServe(t *testing.T) {
+	defer cleanup(t)
+
+	wd, _ := os.Getwd()
+	mappings := filepath.Join(wd, "..", "..", "test", "fixtures", "wiremock", "mappings.json")
+
+	cfg, err := formatter.NewWireMockFormatter().Load(formatter.Options{ConfigFile: mappings, Port: 10330})
+	if err != nil {
+		t.Fatalf("failed to load mappings: %v", err)
+	}
+	if err := testServer.LoadImposters(cfg.Imposters); err != nil {
+		t.Fatalf("failed to load imposters: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	do := func(method, path string) int {
+		req, _ := http.NewRequest(method, "http://localhost:10330"+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := do("GET", "/cart/42"); status != 200 {
+		t.Errorf("expected path template to match, got %d", status)
+	}
+	if status := do("POST", "/cart/checkout"); status != 202 {
+		t.Errorf("expected first checkout to get 202, got %d", status)
+	}
+	if status := do("POST", "/cart/checkout"); status != 409 {
+		t.Errorf("expected scenario to move on to 409, got %d", status)
+	}
+}
+
+func TestCLI_SaveWithFormatter(t *testing.T) {
+	defer cleanup(t)
+
+	resp, _, err := post("/imposters", map[string]interface{}{
+		"protocol": "http",
+		"port":     10331,
+		"stubs": []map[string]interface{}{
+			{
+				"predicates": []map[string]interface{}{
+					{"equals": map[string]interface{}{"method": "GET", "path": "/status"}},
+				},
+				"responses": []map[string]interface{}{
+					{"is": map[string]interface{}{"statusCode": 200, "body": "up"}},
+					{"is": map[string]interface{}{"statusCode": 503, "body": "down"}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create imposter: %v", err)
+	}
+	if resp.StatusCode != 201 {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+
+	wd, _ := os.Getwd()
+	projectRoot := filepath.Join(wd, "..", "..")
+	saveFile := filepath.Join(t.TempDir(), "capture.har")
+
+	cmd := exec.Command("go", "run", "./cmd/tartuffe", "save", "--formatter", "har", "--savefile", saveFile)
+	cmd.Dir = projectRoot
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("save failed: %v\n%s", err, output)
+	}
+
+	cfg, err := formatter.NewHARFormatter().Load(formatter.Options{ConfigFile: saveFile})
+	if err != nil {
+		t.Fatalf("expected a loadable HAR file: %v", err)
+	}
+	if len(cfg.Imposters) != 1 || cfg.Imposters[0].Port != 10331 {
+		t.Fatalf("unexpected imposters: %+v", cfg.Imposters)
+	}
+	stubs := cfg.Imposters[0].Stubs
+	if len(stubs) != 1 || len(stubs[0].Responses) != 2 || stubs[0].Responses[1].Is.Body != "down" {
+		t.Errorf("expected one stub cycling through both responses, got %+v", stubs)
+	}
+}
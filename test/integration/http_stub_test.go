@@ -1036,3 +1036,98 @@ func TestHttpStub_ValidationErrors(t *testing.T) {
 func contains(s, substr string) bool {
 	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
 }
+
+// TestHttpStub_ByID tests addressing stubs by their stable id
+func TestHttpStub_ByID(t *testing.T) {
+	defer cleanup(t)
+
+	stubFor := func(id, path, body string) map[string]interface{} {
+		return map[string]interface{}{
+			"id":         id,
+			"predicates": []interface{}{map[string]interface{}{"equals": map[string]interface{}{"path": path}}},
+			"responses":  []interface{}{map[string]interface{}{"is": map[string]interface{}{"body": body}}},
+		}
+	}
+	bodyAt := func(path string) string {
+		resp, err := http.Get("http://localhost:10395" + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+
+	resp, body, err := post("/imposters", map[string]interface{}{
+		"protocol": "http",
+		"port":     10395,
+		"stubs":    []interface{}{stubFor("first", "/first", "one"), stubFor("last", "/last", "two")},
+	})
+	if err != nil || resp.StatusCode != 201 {
+		t.Fatalf("failed to create imposter: %v %v", err, body)
+	}
+	stubs := body["stubs"].([]interface{})
+	links := stubs[0].(map[string]interface{})["_links"].(map[string]interface{})
+	if href := links["self"].(map[string]interface{})["href"].(string); !strings.HasSuffix(href, "/imposters/10395/stubs/by-id/first") {
+		t.Errorf("expected by-id self link, got %s", href)
+	}
+
+	// Insert before another stub by id
+	resp, body, _ = post("/imposters/10395/stubs", map[string]interface{}{
+		"stub":   stubFor("middle", "/middle", "between"),
+		"before": "last",
+	})
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200 adding stub, got %d: %v", resp.StatusCode, body)
+	}
+	stubs = body["stubs"].([]interface{})
+	if id := stubs[1].(map[string]interface{})["id"]; id != "middle" {
+		t.Errorf("expected inserted stub at index 1, got %v", id)
+	}
+
+	// Duplicate ids are rejected
+	resp, _, _ = post("/imposters/10395/stubs", map[string]interface{}{"stub": stubFor("first", "/x", "x")})
+	if resp.StatusCode != 400 {
+		t.Errorf("expected 400 for duplicate id, got %d", resp.StatusCode)
+	}
+
+	resp, body, _ = get("/imposters/10395/stubs/by-id/middle")
+	if resp.StatusCode != 200 || body["id"] != "middle" {
+		t.Errorf("expected to get stub by id, got %d: %v", resp.StatusCode, body)
+	}
+
+	resp, _, _ = put("/imposters/10395/stubs/by-id/middle", stubFor("", "/middle", "replaced"))
+	if resp.StatusCode != 200 {
+		t.Errorf("expected 200 replacing stub, got %d", resp.StatusCode)
+	}
+	if got := bodyAt("/middle"); got != "replaced" {
+		t.Errorf("expected replaced response, got %q", got)
+	}
+
+	resp, body, _ = doRequest("PATCH", "/imposters/10395/stubs/by-id/middle", map[string]interface{}{
+		"responses": []interface{}{map[string]interface{}{"is": map[string]interface{}{"body": "patched"}}},
+	})
+	if resp.StatusCode != 200 {
+		t.Errorf("expected 200 patching stub, got %d: %v", resp.StatusCode, body)
+	}
+	if got := bodyAt("/middle"); got != "patched" {
+		t.Errorf("expected patched response, got %q", got)
+	}
+
+	resp, _, _ = doRequest("PATCH", "/imposters/10395/stubs/by-id/middle", map[string]interface{}{"id": "renamed"})
+	if resp.StatusCode != 400 {
+		t.Errorf("expected 400 changing stub id, got %d", resp.StatusCode)
+	}
+
+	resp, _, _ = del("/imposters/10395/stubs/by-id/first")
+	if resp.StatusCode != 200 {
+		t.Errorf("expected 200 deleting stub, got %d", resp.StatusCode)
+	}
+	if got := bodyAt("/first"); got != "" {
+		t.Errorf("expected deleted stub not to match, got %q", got)
+	}
+	resp, _, _ = get("/imposters/10395/stubs/by-id/first")
+	if resp.StatusCode != 404 {
+		t.Errorf("expected 404 for deleted stub, got %d", resp.StatusCode)
+	}
+}
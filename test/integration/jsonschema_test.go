@@ -0,0 +1,121 @@
+package integration
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jsonSchema predicate tests
+
+func TestJSONSchema_ContractStubs(t *testing.T) {
+	defer cleanup(t)
+
+	orderSchema := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"id", "qty"},
+		"properties": map[string]interface{}{
+			"id":  map[string]interface{}{"type": "string"},
+			"qty": map[string]interface{}{"type": "integer", "minimum": 1},
+		},
+	}
+
+	resp, _, err := post("/imposters", map[string]interface{}{
+		"protocol":       "http",
+		"port":           10310,
+		"recordRequests": true,
+		"stubs": []map[string]interface{}{
+			{
+				"predicates": []map[string]interface{}{
+					{"equals": map[string]interface{}{"method": "POST", "path": "/orders"}},
+					{"jsonSchema": map[string]interface{}{
+						"body":    orderSchema,
+						"headers": map[string]interface{}{"required": []interface{}{"x-api-key"}},
+					}},
+				},
+				"responses": []map[string]interface{}{
+					{"is": map[string]interface{}{"statusCode": 201}},
+				},
+			},
+			{
+				"predicates": []map[string]interface{}{
+					{"equals": map[string]interface{}{"path": "/orders"}},
+				},
+				"responses": []map[string]interface{}{
+					{"is": map[string]interface{}{"statusCode": 400}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create imposter: %v", err)
+	}
+	if resp.StatusCode != 201 {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	send := func(body string, apiKey bool) int {
+		req, _ := http.NewRequest("POST", "http://localhost:10310/orders", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if apiKey {
+			req.Header.Set("X-Api-Key", "secret")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := send(`{"id": "a1", "qty": 2}`, true); status != 201 {
+		t.Errorf("expected valid payload to get 201, got %d", status)
+	}
+	if status := send(`{"id": "a1", "qty": "2"}`, true); status != 400 {
+		t.Errorf("expected malformed payload to get 400, got %d", status)
+	}
+	if status := send(`{"id": "a1", "qty": 2}`, false); status != 400 {
+		t.Errorf("expected missing header to get 400, got %d", status)
+	}
+
+	_, body, err := get("/imposters/10310")
+	if err != nil {
+		t.Fatalf("failed to get imposter: %v", err)
+	}
+	requests := body["requests"].([]interface{})
+	if len(requests) != 3 {
+		t.Fatalf("expected 3 recorded requests, got %d", len(requests))
+	}
+	if _, ok := requests[1].(map[string]interface{})["schemaViolations"]; ok {
+		t.Errorf("expected violations not to be recorded, got %v", requests[1])
+	}
+}
+
+func TestJSONSchema_InvalidSchemaRejected(t *testing.T) {
+	defer cleanup(t)
+
+	resp, body, err := post("/imposters", map[string]interface{}{
+		"protocol": "http",
+		"port":     10311,
+		"stubs": []map[string]interface{}{
+			{
+				"predicates": []map[string]interface{}{
+					{"jsonSchema": map[string]interface{}{"body": "missing-schema.json"}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != 400 {
+		t.Fatalf("expected status 400, got %d", resp.StatusCode)
+	}
+	errors := body["errors"].([]interface{})
+	if msg := errors[0].(map[string]interface{})["message"].(string); !strings.Contains(msg, "jsonSchema body") {
+		t.Errorf("expected schema error message, got %q", msg)
+	}
+}
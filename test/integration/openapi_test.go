@@ -0,0 +1,131 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/config"
+)
+
+// OpenAPI import tests
+
+func petstoreFixture(t *testing.T) string {
+	t.Helper()
+	wd, _ := os.Getwd()
+	return filepath.Join(wd, "..", "..", "test", "fixtures", "openapi", "petstore.yaml")
+}
+
+func TestOpenAPI_ImportEndpoint(t *testing.T) {
+	defer cleanup(t)
+
+	spec, err := os.ReadFile(petstoreFixture(t))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	resp, err := http.Post(baseURL+"/imposters/_fromOpenAPI?port=10320", "application/yaml", bytes.NewReader(spec))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 201 {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+	if loc := resp.Header.Get("Location"); !strings.HasSuffix(loc, "/imposters/10320") {
+		t.Errorf("expected Location for port 10320, got %q", loc)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		check  func(t *testing.T, resp *http.Response, body []byte)
+	}{
+		{"GET", "/v1/pets/3", 200, func(t *testing.T, _ *http.Response, body []byte) {
+			var pet map[string]interface{}
+			json.Unmarshal(body, &pet)
+			if pet["name"] != "Fido" {
+				t.Errorf("expected example pet, got %s", body)
+			}
+		}},
+		{"GET", "/v1/pets", 200, func(t *testing.T, resp *http.Response, body []byte) {
+			if resp.Header.Get("X-Total") != "2" {
+				t.Errorf("expected X-Total header, got %v", resp.Header)
+			}
+			var pets []map[string]interface{}
+			if err := json.Unmarshal(body, &pets); err != nil || len(pets) != 1 || pets[0]["name"] != "string" {
+				t.Errorf("expected sample pet list, got %s", body)
+			}
+		}},
+		{"POST", "/v1/pets", 201, nil},
+		{"DELETE", "/v1/pets", 405, func(t *testing.T, resp *http.Response, _ []byte) {
+			if allow := resp.Header.Get("Allow"); allow != "GET, POST" {
+				t.Errorf("expected Allow 'GET, POST', got %q", allow)
+			}
+		}},
+		{"GET", "/v1/owners", 404, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, "http://localhost:10320"+tt.path, nil)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("expected status %d, got %d (%s)", tt.status, resp.StatusCode, body)
+			}
+			if tt.check != nil {
+				tt.check(t, resp, body)
+			}
+		})
+	}
+}
+
+func TestOpenAPI_ImportRejectsInvalidDocument(t *testing.T) {
+	resp, err := http.Post(baseURL+"/imposters/_fromOpenAPI", "application/json", strings.NewReader(`{"swagger": "2.0"}`))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 400 {
+		t.Errorf("expected status 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestCLI_ImportOpenAPI(t *testing.T) {
+	wd, _ := os.Getwd()
+	projectRoot := filepath.Join(wd, "..", "..")
+	outFile := filepath.Join(t.TempDir(), "petstore.json")
+
+	cmd := exec.Command("go", "run", "./cmd/tartuffe", "import", "openapi", "--port", "10321", "--savefile", outFile, petstoreFixture(t))
+	cmd.Dir = projectRoot
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("import failed: %v\n%s", err, output)
+	}
+
+	cfg, err := config.LoadFile(outFile, true)
+	if err != nil {
+		t.Fatalf("expected a loadable config file: %v", err)
+	}
+	if len(cfg.Imposters) != 1 || cfg.Imposters[0].Port != 10321 || cfg.Imposters[0].Name != "Petstore" {
+		t.Fatalf("unexpected imposters: %+v", cfg.Imposters)
+	}
+	if len(cfg.Imposters[0].Stubs) != 6 {
+		t.Errorf("expected 6 stubs, got %d", len(cfg.Imposters[0].Stubs))
+	}
+}
@@ -0,0 +1,204 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/pkg/tartuffe"
+)
+
+// Proxy recording option tests: scrubbing, redaction, collapsing and body files
+
+func TestProxyRecording_ScrubRedactCollapseAndBodyFiles(t *testing.T) {
+	defer cleanup(t)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-Trace-Id", r.URL.Path)
+		if r.URL.Path == "/other" {
+			fmt.Fprint(w, `{"status": "other"}`)
+			return
+		}
+		fmt.Fprint(w, `{"status": "ok", "user": {"token": "abc123", "name": "ada"}, "items": [{"id": 1, "etag": "x"}]}`)
+	}))
+	defer upstream.Close()
+
+	resp, _, err := post("/imposters", map[string]interface{}{
+		"protocol": "http",
+		"port":     10380,
+		"stubs": []map[string]interface{}{{
+			"responses": []map[string]interface{}{{
+				"proxy": map[string]interface{}{
+					"to":                  upstream.URL,
+					"mode":                "proxyAlways",
+					"predicateGenerators": []map[string]interface{}{{"matches": map[string]interface{}{"path": true}}},
+					"scrubHeaders":        []string{"x-trace-id"},
+					"redactHeaders":       []string{"Set-Cookie"},
+					"scrubFields":         []string{"items.etag"},
+					"redactFields":        []string{"$.user.token"},
+					"collapseResponses":   true,
+					"bodyDir":             "proxy-recording",
+				},
+			}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create proxy imposter: %v", err)
+	}
+	resp.Body.Close()
+
+	time.Sleep(100 * time.Millisecond)
+
+	for _, path := range []string{"/a", "/b", "/other", "/a"} {
+		resp, err := http.Get("http://localhost:10380" + path)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if path == "/a" && !strings.Contains(string(body), "abc123") {
+			t.Errorf("expected the client to get the unredacted body, got %s", body)
+		}
+	}
+
+	_, imp, err := get("/imposters/10380?removeProxies=true")
+	if err != nil {
+		t.Fatalf("failed to get imposter: %v", err)
+	}
+	stubs := imp["stubs"].([]interface{})
+	if len(stubs) != 2 {
+		t.Fatalf("expected /a and /b to collapse into one stub beside /other, got %d stubs", len(stubs))
+	}
+
+	collapsed := stubs[0].(map[string]interface{})
+	predicates, _ := json.Marshal(collapsed["predicates"])
+	if !strings.Contains(string(predicates), `"or"`) || !strings.Contains(string(predicates), `"/b"`) {
+		t.Errorf("expected OR-ed predicates for /a and /b, got %s", predicates)
+	}
+	if n := len(collapsed["responses"].([]interface{})); n != 1 {
+		t.Errorf("expected the identical repeat of /a to be absorbed, got %d responses", n)
+	}
+
+	is := collapsed["responses"].([]interface{})[0].(map[string]interface{})["is"].(map[string]interface{})
+	headers := is["headers"].(map[string]interface{})
+	if headers["Set-Cookie"] != "[REDACTED]" {
+		t.Errorf("expected Set-Cookie redacted, got %v", headers["Set-Cookie"])
+	}
+	if _, ok := headers["X-Trace-Id"]; ok {
+		t.Error("expected X-Trace-Id scrubbed")
+	}
+	if _, ok := is["body"]; ok {
+		t.Error("expected the body to be stored in a file")
+	}
+	bodyFile, _ := is["bodyFile"].(string)
+	if filepath.Dir(bodyFile) != "proxy-recording" {
+		t.Errorf("expected a bodyFile relative to --bodyDir, got %q", bodyFile)
+	}
+	data, err := os.ReadFile(filepath.Join(testBodyDir, bodyFile))
+	if err != nil {
+		t.Fatalf("expected a body file: %v", err)
+	}
+	if !strings.Contains(string(data), "[REDACTED]") || strings.Contains(string(data), "abc123") || strings.Contains(string(data), "etag") {
+		t.Errorf("expected scrubbed and redacted fields in the body file, got %s", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(testBodyDir, "proxy-recording")); len(entries) != 2 {
+		t.Errorf("expected identical bodies to share a file, got %d files", len(entries))
+	}
+
+	// The recorded stubs replay with their bodies read back from the files
+	resp, _, err = post("/imposters", map[string]interface{}{
+		"protocol": "http",
+		"port":     10381,
+		"stubs":    stubs,
+	})
+	if err != nil {
+		t.Fatalf("failed to create replay imposter: %v", err)
+	}
+	resp.Body.Close()
+
+	time.Sleep(100 * time.Millisecond)
+	body, err := getBody(t, "http://localhost:10381/b")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if !strings.Contains(body, `"name": "ada"`) || !strings.Contains(body, "[REDACTED]") {
+		t.Errorf("expected the recorded body on replay, got %s", body)
+	}
+}
+
+func TestProxyRecording_BodyPathsConfinedToBodyDir(t *testing.T) {
+	defer cleanup(t)
+
+	tests := []struct {
+		name     string
+		response map[string]interface{}
+		pointer  string
+	}{
+		{"absolute bodyFile", map[string]interface{}{"is": map[string]interface{}{"bodyFile": "/etc/passwd"}}, "/stubs/0/responses/0/is/bodyFile"},
+		{"bodyFile outside", map[string]interface{}{"is": map[string]interface{}{"bodyFile": "../../etc/passwd"}}, "/stubs/0/responses/0/is/bodyFile"},
+		{"absolute bodyDir", map[string]interface{}{"proxy": map[string]interface{}{"to": "http://localhost:1", "bodyDir": "/tmp"}}, "/stubs/0/responses/0/proxy/bodyDir"},
+		{"bodyDir outside", map[string]interface{}{"proxy": map[string]interface{}{"to": "http://localhost:1", "bodyDir": "recorded/../.."}}, "/stubs/0/responses/0/proxy/bodyDir"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body, err := post("/imposters", map[string]interface{}{
+				"protocol": "http",
+				"port":     10407,
+				"stubs":    []map[string]interface{}{{"responses": []map[string]interface{}{tt.response}}},
+			})
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode != 400 || strings.Join(errorPointers(body), ",") != tt.pointer {
+				t.Errorf("expected the path to be rejected at %s, got %d %v", tt.pointer, resp.StatusCode, body)
+			}
+		})
+	}
+
+	// Stubs added later are held to the same rule
+	resp, _, err := post("/imposters", map[string]interface{}{"protocol": "http", "port": 10407})
+	if err != nil || resp.StatusCode != 201 {
+		t.Fatalf("failed to create imposter: %v", err)
+	}
+	resp, body, _ := post("/imposters/10407/stubs", map[string]interface{}{
+		"stub": map[string]interface{}{"responses": []map[string]interface{}{{"is": map[string]interface{}{"bodyFile": "/etc/passwd"}}}},
+	})
+	if resp.StatusCode != 400 {
+		t.Errorf("expected an added stub reading /etc/passwd to be rejected, got %d %v", resp.StatusCode, body)
+	}
+}
+
+func TestProxyRecording_BodyFilesNeedBodyDir(t *testing.T) {
+	srv, err := tartuffe.NewServer(tartuffe.Options{})
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer srv.Close()
+
+	for _, response := range []map[string]interface{}{
+		{"is": map[string]interface{}{"bodyFile": "body.json"}},
+		{"proxy": map[string]interface{}{"to": "http://localhost:1", "bodyDir": "recorded"}},
+	} {
+		resp, body, err := doRequest("POST", srv.URL()+"/imposters", map[string]interface{}{
+			"protocol": "http",
+			"port":     10408,
+			"stubs":    []map[string]interface{}{{"responses": []map[string]interface{}{response}}},
+		})
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if resp.StatusCode != 400 || !strings.Contains(fmt.Sprint(body["errors"]), "--bodyDir") {
+			t.Errorf("expected %v to be rejected without --bodyDir, got %d %v", response, resp.StatusCode, body)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/config"
+)
+
+// tartuffe record tests
+
+func TestCLI_RecordWritesReplayableStubs(t *testing.T) {
+	calls := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-Request-Id", fmt.Sprintf("req-%d", calls))
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "%s %s", r.Method, r.URL.RequestURI())
+	}))
+	defer upstream.Close()
+
+	wd, _ := os.Getwd()
+	projectRoot := filepath.Join(wd, "..", "..")
+	binaryPath := filepath.Join(t.TempDir(), "tartuffe")
+	build := exec.Command("go", "build", "-o", binaryPath, "./cmd/tartuffe")
+	build.Dir = projectRoot
+	if output, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build binary: %v\n%s", err, output)
+	}
+
+	out := filepath.Join(t.TempDir(), "recording.json")
+	cmd := exec.Command(binaryPath, "record", "--target", upstream.URL, "--port", "10370", "--out", out)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start recorder: %v", err)
+	}
+	defer cmd.Process.Kill()
+
+	var ready bool
+	for i := 0; i < 50 && !ready; i++ {
+		time.Sleep(100 * time.Millisecond)
+		if resp, err := http.Get("http://localhost:10370/ping"); err == nil {
+			resp.Body.Close()
+			ready = true
+		}
+	}
+	if !ready {
+		t.Fatal("recording imposter never came up")
+	}
+	for _, path := range []string{"/ping", "/users?page=2", "/ping"} {
+		resp, err := http.Get("http://localhost:10370" + path)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	cmd.Process.Signal(syscall.SIGINT)
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("recorder exited with error: %v", err)
+	}
+
+	cfg, err := config.LoadFile(out, false)
+	if err != nil {
+		t.Fatalf("expected a loadable recording: %v", err)
+	}
+	if len(cfg.Imposters) != 1 || cfg.Imposters[0].Port != 10370 {
+		t.Fatalf("unexpected imposters: %+v", cfg.Imposters)
+	}
+	stubs := cfg.Imposters[0].Stubs
+	if len(stubs) != 2 {
+		t.Fatalf("expected one stub per distinct request, got %d", len(stubs))
+	}
+	for _, stub := range stubs {
+		if len(stub.Responses) != 1 || stub.Responses[0].Proxy != nil {
+			t.Errorf("expected a single recorded response per stub, got %+v", stub.Responses)
+			continue
+		}
+		if _, ok := stub.Responses[0].Is.Headers["X-Request-Id"]; ok {
+			t.Error("expected volatile X-Request-Id header to be dropped")
+		}
+	}
+}
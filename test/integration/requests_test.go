@@ -0,0 +1,252 @@
+package integration
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRequests_QueryAndVerifyHTTP tests filtering, paging and verifying the
+// requests recorded by an HTTP imposter
+func TestRequests_QueryAndVerifyHTTP(t *testing.T) {
+	defer cleanup(t)
+
+	post("/imposters", map[string]interface{}{
+		"protocol":       "http",
+		"port":           10396,
+		"recordRequests": true,
+	})
+
+	for i := 0; i < 5; i++ {
+		body := fmt.Sprintf(`{"order":%d,"item":"book"}`, i)
+		if i%2 == 1 {
+			body = fmt.Sprintf(`{"order":%d,"item":"pen"}`, i)
+		}
+		resp, err := http.Post("http://localhost:10396/orders", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed to call imposter: %v", err)
+		}
+		resp.Body.Close()
+	}
+	http.Get("http://localhost:10396/health")
+
+	predicates := url.QueryEscape(`[{"equals":{"method":"POST"}},{"contains":{"body":"book"}}]`)
+	resp, body, err := get("/imposters/10396/requests?limit=2&offset=1&predicates=" + predicates)
+	if err != nil {
+		t.Fatalf("GET requests failed: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d: %v", resp.StatusCode, body)
+	}
+	if body["total"] != float64(6) || body["matched"] != float64(3) {
+		t.Errorf("expected total 6 and matched 3, got %v and %v", body["total"], body["matched"])
+	}
+	requests := body["requests"].([]interface{})
+	if len(requests) != 2 {
+		t.Fatalf("expected a page of 2 requests, got %d", len(requests))
+	}
+	if first := requests[0].(map[string]interface{})["body"]; first != `{"order":2,"item":"book"}` {
+		t.Errorf("expected the page to start at the second match, got %v", first)
+	}
+
+	// A limit near the top of the int range must not overflow offset+limit
+	resp, body, err = get(fmt.Sprintf("/imposters/10396/requests?offset=1&limit=%d&predicates=%s", math.MaxInt64, predicates))
+	if err != nil {
+		t.Fatalf("GET requests failed: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200 for a huge limit, got %d: %v", resp.StatusCode, body)
+	}
+	if requests := body["requests"].([]interface{}); len(requests) != 2 {
+		t.Errorf("expected the 2 matches after the offset, got %d", len(requests))
+	}
+
+	future := url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339))
+	_, body, _ = get("/imposters/10396/requests?from=" + future)
+	if body["matched"] != float64(0) {
+		t.Errorf("expected no requests after %s, got %v", future, body["matched"])
+	}
+
+	resp, _, _ = get("/imposters/10396/requests?predicates=not-json")
+	if resp.StatusCode != 400 {
+		t.Errorf("expected 400 for invalid predicates, got %d", resp.StatusCode)
+	}
+
+	verifications := []struct {
+		body     map[string]interface{}
+		verified bool
+	}{
+		{map[string]interface{}{"predicates": []interface{}{map[string]interface{}{"contains": map[string]interface{}{"body": "pen"}}}, "count": 2}, true},
+		{map[string]interface{}{"predicates": []interface{}{map[string]interface{}{"contains": map[string]interface{}{"body": "pen"}}}, "count": 3}, false},
+		{map[string]interface{}{"predicates": []interface{}{map[string]interface{}{"equals": map[string]interface{}{"path": "/health"}}}}, true},
+		{map[string]interface{}{"predicates": []interface{}{map[string]interface{}{"equals": map[string]interface{}{"path": "/missing"}}}, "atMost": 0}, true},
+	}
+	for _, v := range verifications {
+		resp, body, err := post("/imposters/10396/requests/verify", v.body)
+		if err != nil {
+			t.Fatalf("verify failed: %v", err)
+		}
+		if resp.StatusCode != 200 || body["verified"] != v.verified {
+			t.Errorf("verify %v: expected verified %v, got %d %v", v.body, v.verified, resp.StatusCode, body)
+		}
+	}
+}
+
+// TestRequests_QueryTCP tests filtering the requests recorded by a TCP imposter
+func TestRequests_QueryTCP(t *testing.T) {
+	defer cleanup(t)
+
+	post("/imposters", map[string]interface{}{
+		"protocol":       "tcp",
+		"port":           10397,
+		"recordRequests": true,
+		"stubs": []interface{}{map[string]interface{}{
+			"responses": []interface{}{map[string]interface{}{"is": map[string]interface{}{"data": "ok"}}},
+		}},
+	})
+
+	for _, data := range []string{"PING", "LOGIN alice", "PING"} {
+		conn, err := net.Dial("tcp", "localhost:10397")
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		conn.Write([]byte(data))
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		conn.Read(make([]byte, 16))
+		conn.Close()
+	}
+
+	resp, body, err := post("/imposters/10397/requests/verify", map[string]interface{}{
+		"predicates": []interface{}{map[string]interface{}{"equals": map[string]interface{}{"data": "PING"}}},
+		"count":      2,
+	})
+	if err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if resp.StatusCode != 200 || body["verified"] != true {
+		t.Errorf("expected 2 PING requests, got %d %v", resp.StatusCode, body)
+	}
+}
+
+// TestRequests_Retention tests that an imposter keeps only the newest
+// requests its retention policy allows and reports how many it dropped
+func TestRequests_Retention(t *testing.T) {
+	defer cleanup(t)
+
+	// The counter outlives the imposter, so compare it before and after
+	droppedMetric := `mb_requests_dropped_total{imposter="10398",protocol="http"}`
+	droppedBefore := metricValue(t, droppedMetric)
+
+	resp, body, err := post("/imposters", map[string]interface{}{
+		"protocol":         "http",
+		"port":             10398,
+		"recordRequests":   true,
+		"requestRetention": map[string]interface{}{"maxCount": 2},
+	})
+	if err != nil || resp.StatusCode != 201 {
+		t.Fatalf("failed to create imposter: %v %v", err, body)
+	}
+
+	for i := 0; i < 5; i++ {
+		resp, err := http.Get(fmt.Sprintf("http://localhost:10398/item/%d", i))
+		if err != nil {
+			t.Fatalf("failed to call imposter: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	_, body, _ = get("/imposters/10398")
+	requests := body["requests"].([]interface{})
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 retained requests, got %d", len(requests))
+	}
+	if path := requests[0].(map[string]interface{})["path"]; path != "/item/3" {
+		t.Errorf("expected the oldest retained request to be /item/3, got %v", path)
+	}
+	if body["droppedRequests"] != float64(3) || body["numberOfRequests"] != float64(5) {
+		t.Errorf("expected 3 dropped of 5 requests, got %v of %v", body["droppedRequests"], body["numberOfRequests"])
+	}
+
+	_, body, _ = get("/imposters/10398?replayable=true")
+	if _, ok := body["droppedRequests"]; ok {
+		t.Error("expected replayable imposter to omit droppedRequests")
+	}
+
+	if dropped := metricValue(t, droppedMetric) - droppedBefore; dropped != 3 {
+		t.Errorf("expected mb_requests_dropped_total to count 3 dropped requests, got %v", dropped)
+	}
+
+	resp, body, _ = post("/imposters", map[string]interface{}{
+		"protocol":         "http",
+		"port":             10399,
+		"requestRetention": map[string]interface{}{"maxCount": -1},
+	})
+	if resp.StatusCode != 400 {
+		t.Errorf("expected 400 for a negative bound, got %d: %v", resp.StatusCode, body)
+	}
+}
+
+// TestRequests_MaxAgeOnRead tests that requests past their maxAge drop out
+// of an imposter that receives nothing more
+func TestRequests_MaxAgeOnRead(t *testing.T) {
+	defer cleanup(t)
+
+	resp, body, err := post("/imposters", map[string]interface{}{
+		"protocol":         "http",
+		"port":             10397,
+		"recordRequests":   true,
+		"requestRetention": map[string]interface{}{"maxAge": 300},
+	})
+	if err != nil || resp.StatusCode != 201 {
+		t.Fatalf("failed to create imposter: %v %v", err, body)
+	}
+
+	if resp, err := http.Get("http://localhost:10397/item"); err != nil {
+		t.Fatalf("failed to call imposter: %v", err)
+	} else {
+		resp.Body.Close()
+	}
+
+	_, body, _ = get("/imposters/10397/requests")
+	if body["matched"] != float64(1) {
+		t.Fatalf("expected the fresh request to be kept, got %v", body)
+	}
+
+	time.Sleep(400 * time.Millisecond)
+	_, body, _ = get("/imposters/10397")
+	if requests := body["requests"].([]interface{}); len(requests) != 0 {
+		t.Errorf("expected the expired request to be dropped, got %v", requests)
+	}
+	if body["droppedRequests"] != float64(1) {
+		t.Errorf("expected 1 dropped request, got %v", body["droppedRequests"])
+	}
+}
+
+// metricValue returns the value of a series on /metrics, or 0 when it has
+// not been recorded yet
+func metricValue(t *testing.T, series string) float64 {
+	t.Helper()
+	resp, err := http.Get(baseURL + "/metrics")
+	if err != nil {
+		t.Fatalf("failed to get metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	for _, line := range strings.Split(string(body), "\n") {
+		if value, ok := strings.CutPrefix(line, series+" "); ok {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatalf("invalid value for %s: %q", series, value)
+			}
+			return v
+		}
+	}
+	return 0
+}
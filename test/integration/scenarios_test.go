@@ -0,0 +1,174 @@
+package integration
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Scenario (state machine stub) tests
+
+func scenarioGet(t *testing.T, url string) string {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func scenarioStates(t *testing.T, body map[string]interface{}) map[string]string {
+	t.Helper()
+	states := make(map[string]string)
+	scenarios, ok := body["scenarios"].([]interface{})
+	if !ok {
+		t.Fatalf("expected scenarios array, got %v", body)
+	}
+	for _, s := range scenarios {
+		entry := s.(map[string]interface{})
+		states[entry["name"].(string)] = entry["state"].(string)
+	}
+	return states
+}
+
+func TestScenario_StateMachineAndAPI(t *testing.T) {
+	defer cleanup(t)
+
+	resp, _, err := post("/imposters", map[string]interface{}{
+		"protocol": "http",
+		"port":     10100,
+		"stubs": []map[string]interface{}{
+			{
+				"scenarioName":          "order",
+				"requiredScenarioState": "Started",
+				"newScenarioState":      "paid",
+				"predicates": []map[string]interface{}{
+					{"equals": map[string]interface{}{"method": "POST", "path": "/pay"}},
+				},
+				"responses": []map[string]interface{}{
+					{"is": map[string]interface{}{"body": "payment accepted"}},
+				},
+			},
+			{
+				"scenarioName":          "order",
+				"requiredScenarioState": "Started",
+				"predicates": []map[string]interface{}{
+					{"equals": map[string]interface{}{"path": "/status"}},
+				},
+				"responses": []map[string]interface{}{
+					{"is": map[string]interface{}{"body": "pending"}},
+				},
+			},
+			{
+				"scenarioName":          "order",
+				"requiredScenarioState": "paid",
+				"predicates": []map[string]interface{}{
+					{"equals": map[string]interface{}{"path": "/status"}},
+				},
+				"responses": []map[string]interface{}{
+					{"is": map[string]interface{}{"body": "paid"}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create imposter: %v", err)
+	}
+	if resp.StatusCode != 201 {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if body := scenarioGet(t, "http://localhost:10100/status"); body != "pending" {
+		t.Errorf("expected 'pending' before payment, got %q", body)
+	}
+
+	payResp, err := http.Post("http://localhost:10100/pay", "text/plain", nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	payResp.Body.Close()
+
+	if body := scenarioGet(t, "http://localhost:10100/status"); body != "paid" {
+		t.Errorf("expected 'paid' after payment, got %q", body)
+	}
+
+	// Inspect
+	resp, body, err := get("/imposters/10100/scenarios")
+	if err != nil {
+		t.Fatalf("failed to get scenarios: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if states := scenarioStates(t, body); states["order"] != "paid" {
+		t.Errorf("expected order scenario 'paid', got %v", states)
+	}
+
+	// Reset
+	resp, body, err = del("/imposters/10100/scenarios")
+	if err != nil {
+		t.Fatalf("failed to reset scenarios: %v", err)
+	}
+	if states := scenarioStates(t, body); states["order"] != "Started" {
+		t.Errorf("expected order scenario 'Started' after reset, got %v", states)
+	}
+	if body := scenarioGet(t, "http://localhost:10100/status"); body != "pending" {
+		t.Errorf("expected 'pending' after reset, got %q", body)
+	}
+
+	// Set explicitly
+	resp, body, err = put("/imposters/10100/scenarios/order", map[string]interface{}{"state": "paid"})
+	if err != nil {
+		t.Fatalf("failed to set scenario: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if body := scenarioGet(t, "http://localhost:10100/status"); body != "paid" {
+		t.Errorf("expected 'paid' after set, got %q", body)
+	}
+}
+
+func TestScenario_StubsRoundTrip(t *testing.T) {
+	defer cleanup(t)
+
+	post("/imposters", map[string]interface{}{
+		"protocol": "http",
+		"port":     10101,
+		"stubs": []map[string]interface{}{
+			{
+				"scenarioName":          "login",
+				"requiredScenarioState": "Started",
+				"newScenarioState":      "authenticated",
+				"responses": []map[string]interface{}{
+					{"is": map[string]interface{}{"statusCode": 200}},
+				},
+			},
+		},
+	})
+
+	_, body, err := get("/imposters/10101")
+	if err != nil {
+		t.Fatalf("failed to get imposter: %v", err)
+	}
+	stubs := body["stubs"].([]interface{})
+	stub := stubs[0].(map[string]interface{})
+	if stub["scenarioName"] != "login" || stub["requiredScenarioState"] != "Started" || stub["newScenarioState"] != "authenticated" {
+		t.Errorf("expected scenario fields to round-trip, got %v", stub)
+	}
+}
+
+func TestScenario_UnknownImposter(t *testing.T) {
+	resp, _, err := get("/imposters/10199/scenarios")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != 404 {
+		t.Errorf("expected status 404, got %d", resp.StatusCode)
+	}
+}
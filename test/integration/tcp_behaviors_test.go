@@ -414,3 +414,50 @@ func TestTCP_LargePacketSplitting(t *testing.T) {
 	// go-tartuffe may handle this differently, but should at least not crash
 	// The key requirement is that the imposter handles large data without errors
 }
+
+// TestTCP_ChaosFault tests that a chaos fault closes the TCP connection without data
+func TestTCP_ChaosFault(t *testing.T) {
+	defer cleanup(t)
+
+	resp, _, err := post("/imposters", map[string]interface{}{
+		"protocol": "tcp",
+		"port":     10002,
+		"stubs": []map[string]interface{}{
+			{
+				"responses": []map[string]interface{}{
+					{
+						"is": map[string]interface{}{"data": "should not arrive"},
+						"_behaviors": map[string]interface{}{
+							"chaos": map[string]interface{}{
+								"fault": map[string]interface{}{"probability": 1},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create imposter: %v", err)
+	}
+	if resp.StatusCode != 201 {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+
+	conn, err := net.DialTimeout("tcp", "localhost:10002", 2*time.Second)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("request")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err == nil {
+		t.Errorf("expected connection to be broken, got %q", string(buf[:n]))
+	}
+}
@@ -0,0 +1,136 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Response templating tests
+
+func TestTemplate_HTTPBodyAndHeaders(t *testing.T) {
+	defer cleanup(t)
+
+	resp, _, err := post("/imposters", map[string]interface{}{
+		"protocol": "http",
+		"port":     10200,
+		"stubs": []map[string]interface{}{
+			{
+				"responses": []map[string]interface{}{
+					{
+						"is": map[string]interface{}{
+							"_template":  true,
+							"statusCode": 201,
+							"headers": map[string]interface{}{
+								"Location": `/users/{{ .JSONPath "$.user.id" }}`,
+							},
+							"body": map[string]interface{}{
+								"id":      `{{ .JSONPath "$.user.id" }}`,
+								"name":    `{{ .JSONPath "$.user.name" | upper }}`,
+								"method":  "{{ .Request.Method }}",
+								"visits":  `{{ add (.State "visits" | default 0) 1 }}{{ .SetState "visits" (add (.State "visits" | default 0) 1) }}`,
+								"literal": "no template here",
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create imposter: %v", err)
+	}
+	if resp.StatusCode != 201 {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	for i, wantVisits := range []string{"1", "2"} {
+		impResp, err := http.Post("http://localhost:10200/users", "application/json",
+			strings.NewReader(`{"user": {"id": 17, "name": "ada"}}`))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		raw, _ := io.ReadAll(impResp.Body)
+		impResp.Body.Close()
+
+		if impResp.StatusCode != 201 {
+			t.Errorf("request %d: expected status 201, got %d", i, impResp.StatusCode)
+		}
+		if loc := impResp.Header.Get("Location"); loc != "/users/17" {
+			t.Errorf("request %d: expected Location /users/17, got %q", i, loc)
+		}
+
+		var body map[string]interface{}
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Fatalf("request %d: invalid JSON body %q: %v", i, raw, err)
+		}
+		if body["id"] != "17" || body["name"] != "ADA" || body["method"] != "POST" || body["literal"] != "no template here" {
+			t.Errorf("request %d: unexpected body %v", i, body)
+		}
+		if body["visits"] != wantVisits {
+			t.Errorf("request %d: expected visits %s, got %v", i, wantVisits, body["visits"])
+		}
+	}
+}
+
+func TestTemplate_NotRenderedWithoutOptIn(t *testing.T) {
+	defer cleanup(t)
+
+	post("/imposters", map[string]interface{}{
+		"protocol": "http",
+		"port":     10201,
+		"stubs": []map[string]interface{}{
+			{
+				"responses": []map[string]interface{}{
+					{"is": map[string]interface{}{"body": "{{ .Request.Path }}"}},
+				},
+			},
+		},
+	})
+
+	time.Sleep(100 * time.Millisecond)
+
+	impResp, err := http.Get("http://localhost:10201/path")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(impResp.Body)
+	impResp.Body.Close()
+
+	if string(body) != "{{ .Request.Path }}" {
+		t.Errorf("expected literal body, got %q", string(body))
+	}
+}
+
+func TestTemplate_InvalidTemplateReturns500(t *testing.T) {
+	defer cleanup(t)
+
+	post("/imposters", map[string]interface{}{
+		"protocol": "http",
+		"port":     10202,
+		"stubs": []map[string]interface{}{
+			{
+				"responses": []map[string]interface{}{
+					{"is": map[string]interface{}{"_template": true, "body": "{{ .Request.Path "}},
+				},
+			},
+		},
+	})
+
+	time.Sleep(100 * time.Millisecond)
+
+	impResp, err := http.Get("http://localhost:10202/")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	impResp.Body.Close()
+
+	if impResp.StatusCode != 500 {
+		t.Errorf("expected status 500, got %d", impResp.StatusCode)
+	}
+}
@@ -0,0 +1,163 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// errorPointers returns the pointer of each error in an error response
+func errorPointers(body map[string]interface{}) []string {
+	var pointers []string
+	errs, _ := body["errors"].([]interface{})
+	for _, e := range errs {
+		pointer, _ := e.(map[string]interface{})["pointer"].(string)
+		pointers = append(pointers, pointer)
+	}
+	return pointers
+}
+
+func TestValidate_RejectsInvalidImposter(t *testing.T) {
+	defer cleanup(t)
+
+	resp, body, err := post("/imposters", map[string]interface{}{
+		"protocol": "http",
+		"port":     10403,
+		"stubs": []map[string]interface{}{
+			{
+				"predicates": []map[string]interface{}{
+					{"equals": map[string]interface{}{"path": "/"}, "caseSensitve": true},
+				},
+				"responses": []map[string]interface{}{
+					{"is": map[string]interface{}{"statusCode": 200}, "behaviors": []map[string]interface{}{{"shellTransform": "cat"}}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != 400 {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+
+	pointers := errorPointers(body)
+	expected := []string{"/stubs/0/predicates/0/caseSensitve", "/stubs/0/responses/0/behaviors/0/shellTransform"}
+	if strings.Join(pointers, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected errors at %v, got %v", expected, body["errors"])
+	}
+
+	if resp, _, _ := get("/imposters/10403"); resp.StatusCode != 404 {
+		t.Errorf("expected the invalid imposter not to be created, got %d", resp.StatusCode)
+	}
+}
+
+func TestValidate_DryRunImposter(t *testing.T) {
+	defer cleanup(t)
+
+	resp, body, err := post("/imposters?dryRun=true", map[string]interface{}{
+		"protocol": "http",
+		"port":     10404,
+		"stubs": []map[string]interface{}{
+			{"responses": []map[string]interface{}{{"statusCode": 202}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d: %v", resp.StatusCode, body)
+	}
+	if body["port"] != float64(10404) || body["recordRequests"] != false {
+		t.Errorf("expected the imposter with defaults filled in, got %v", body)
+	}
+
+	if resp, _, _ := get("/imposters/10404"); resp.StatusCode != 404 {
+		t.Errorf("expected a dry run not to create the imposter, got %d", resp.StatusCode)
+	}
+
+	resp, body, _ = post("/imposters?dryRun=true", map[string]interface{}{"protocol": "http", "port": "10404"})
+	if resp.StatusCode != 400 || strings.Join(errorPointers(body), ",") != "/port" {
+		t.Errorf("expected a dry run to report the invalid port, got %d %v", resp.StatusCode, body)
+	}
+}
+
+func TestValidate_Stubs(t *testing.T) {
+	defer cleanup(t)
+
+	resp, _, err := post("/imposters", map[string]interface{}{
+		"protocol": "http",
+		"port":     10405,
+		"stubs": []map[string]interface{}{
+			{"id": "first", "responses": []map[string]interface{}{{"is": map[string]interface{}{"body": "first"}}}},
+		},
+	})
+	if err != nil || resp.StatusCode != 201 {
+		t.Fatalf("failed to create imposter: %v", err)
+	}
+
+	// An unknown fault in an added stub
+	resp, body, _ := post("/imposters/10405/stubs", map[string]interface{}{
+		"stub": map[string]interface{}{"responses": []map[string]interface{}{{"fault": "SLOW_LORIS"}}},
+	})
+	if resp.StatusCode != 400 || strings.Join(errorPointers(body), ",") != "/stub/responses/0/fault" {
+		t.Errorf("expected the fault to be rejected, got %d %v", resp.StatusCode, body)
+	}
+
+	// A patch that leaves the stub invalid
+	resp, body, _ = doRequest("PATCH", "/imposters/10405/stubs/by-id/first", map[string]interface{}{
+		"predicates": []map[string]interface{}{{"matchez": map[string]interface{}{"path": "x"}}},
+	})
+	if resp.StatusCode != 400 || len(errorPointers(body)) == 0 || errorPointers(body)[0] != "/predicates/0/matchez" {
+		t.Errorf("expected the patch to be rejected, got %d %v", resp.StatusCode, body)
+	}
+
+	// A dry run of a valid stub changes nothing
+	resp, body, _ = post("/imposters/10405/stubs?dryRun=true", map[string]interface{}{
+		"index": 0,
+		"stub":  map[string]interface{}{"responses": []map[string]interface{}{{"is": map[string]interface{}{"body": "second"}}}},
+	})
+	if resp.StatusCode != 200 {
+		t.Errorf("expected 200 for a dry run, got %d %v", resp.StatusCode, body)
+	}
+
+	_, imp, _ := get("/imposters/10405")
+	if stubs, _ := imp["stubs"].([]interface{}); len(stubs) != 1 {
+		t.Errorf("expected the dry runs to leave one stub, got %v", imp["stubs"])
+	}
+
+	// A dry run against a missing imposter
+	resp, _, _ = put("/imposters/10499/stubs?dryRun=true", map[string]interface{}{"stubs": []interface{}{}})
+	if resp.StatusCode != 404 {
+		t.Errorf("expected 404 for a missing imposter, got %d", resp.StatusCode)
+	}
+}
+
+func TestValidate_Command(t *testing.T) {
+	wd, _ := os.Getwd()
+	binaryPath := filepath.Join(t.TempDir(), "tartuffe")
+	build := exec.Command("go", "build", "-o", binaryPath, "./cmd/tartuffe")
+	build.Dir = filepath.Join(wd, "..", "..")
+	if output, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build binary: %v\n%s", err, output)
+	}
+
+	valid := filepath.Join(t.TempDir(), "valid.json")
+	os.WriteFile(valid, []byte(`{"imposters": [{"protocol": "http", "port": 10406, "stubs": [{"responses": [{"is": {"body": "ok"}}]}]}]}`), 0644)
+	output, err := exec.Command(binaryPath, "validate", valid).CombinedOutput()
+	if err != nil {
+		t.Fatalf("expected a valid config to pass: %v\n%s", err, output)
+	}
+
+	invalid := filepath.Join(t.TempDir(), "invalid.yaml")
+	os.WriteFile(invalid, []byte("imposters:\n  - protocol: http\n    port: 10406\n    stubs:\n      - responses:\n          - fault: NOPE\n"), 0644)
+	output, err = exec.Command(binaryPath, "validate", invalid).CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected an invalid config to fail, got:\n%s", output)
+	}
+	if !strings.Contains(string(output), "/imposters/0/stubs/0/responses/0/fault") {
+		t.Errorf("expected the problem's pointer in the output, got:\n%s", output)
+	}
+}
@@ -0,0 +1,128 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/api"
+	"github.com/TetsujinOni/go-tartuffe/internal/config"
+)
+
+// --watch hot reload tests
+
+// watchedWrites moves each write's modification time further on, so changes
+// are seen even on filesystems with coarse timestamps
+var watchedWrites int
+
+func writeWatched(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	watchedWrites++
+	later := time.Now().Add(time.Duration(watchedWrites) * time.Second)
+	os.Chtimes(path, later, later)
+}
+
+func watchedImposter(port int, body string) string {
+	return fmt.Sprintf(`{"protocol": "http", "port": %d, "recordRequests": true, `+
+		`"stubs": [{"responses": [{"is": {"body": %q}}]}]}`, port, body)
+}
+
+func getBody(t *testing.T, url string) (string, error) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body), nil
+}
+
+func TestWatch_ReloadsIncludedFiles(t *testing.T) {
+	defer cleanup(t)
+
+	dir := t.TempDir()
+	mainFile := filepath.Join(dir, "imposters.ejs")
+	aFile := filepath.Join(dir, "a.json")
+	bFile := filepath.Join(dir, "b.json")
+	writeWatched(t, aFile, watchedImposter(10350, "a1"))
+	writeWatched(t, bFile, watchedImposter(10351, "b1"))
+	writeWatched(t, mainFile, `{"imposters": [<%- include('a.json') %>, <%- include('b.json') %>]}`)
+
+	watcher := config.NewWatcher(config.WatchFile(config.LoadOptions{ConfigFile: mainFile}), 20*time.Millisecond)
+	cfg, err := watcher.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	reloader := api.NewConfigReloader(testServer)
+	if err := reloader.Apply(cfg.Imposters); err != nil {
+		t.Fatalf("failed to apply config: %v", err)
+	}
+
+	reloaded := make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.Watch(ctx, func(cfg *config.Config) {
+		if err := reloader.Apply(cfg.Imposters); err != nil {
+			t.Errorf("reload failed: %v", err)
+		}
+		reloaded <- struct{}{}
+	})
+	waitReload := func() {
+		select {
+		case <-reloaded:
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for reload")
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	for _, url := range []string{"http://localhost:10350/", "http://localhost:10351/"} {
+		if _, err := getBody(t, url); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+	}
+
+	// A stub change in an included file updates that imposter in place
+	writeWatched(t, aFile, watchedImposter(10350, "a2"))
+	waitReload()
+
+	if body, _ := getBody(t, "http://localhost:10350/"); body != "a2" {
+		t.Errorf("expected updated stub, got %q", body)
+	}
+	for _, port := range []string{"10350", "10351"} {
+		_, imp, err := get("/imposters/" + port)
+		if err != nil {
+			t.Fatalf("failed to get imposter: %v", err)
+		}
+		if n := len(imp["requests"].([]interface{})); n < 1 {
+			t.Errorf("expected recorded requests on %s to survive the reload, got %d", port, n)
+		}
+	}
+
+	// A broken edit is skipped and the running imposters are kept
+	writeWatched(t, mainFile, `{"imposters": [<%- include('a.json') %>,]}`)
+	time.Sleep(200 * time.Millisecond)
+	if body, _ := getBody(t, "http://localhost:10351/"); body != "b1" {
+		t.Errorf("expected imposter to survive a broken config, got %q", body)
+	}
+
+	// Dropping an imposter from the config stops it
+	writeWatched(t, mainFile, `{"imposters": [<%- include('a.json') %>]}`)
+	waitReload()
+
+	if _, err := getBody(t, "http://localhost:10351/"); err == nil {
+		t.Error("expected removed imposter to be stopped")
+	}
+	if body, _ := getBody(t, "http://localhost:10350/"); body != "a2" {
+		t.Errorf("expected unchanged imposter to keep serving, got %q", body)
+	}
+}
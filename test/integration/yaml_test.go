@@ -0,0 +1,130 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TetsujinOni/go-tartuffe/internal/config"
+	"github.com/TetsujinOni/go-tartuffe/internal/models"
+)
+
+// YAML config file tests
+
+func TestYAML_MultiDocumentConfig(t *testing.T) {
+	defer cleanup(t)
+
+	wd, _ := os.Getwd()
+	cfg, err := config.LoadFile(filepath.Join(wd, "..", "..", "test", "fixtures", "imposters", "multi.yaml"), false)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if len(cfg.Imposters) != 2 {
+		t.Fatalf("expected 2 imposters, got %d", len(cfg.Imposters))
+	}
+	if err := testServer.LoadImposters(cfg.Imposters); err != nil {
+		t.Fatalf("failed to load imposters: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := http.Get("http://localhost:10340/users/1")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	var user map[string]interface{}
+	if err := json.Unmarshal(body, &user); err != nil || user["name"] != "Ada" {
+		t.Errorf("expected YAML object body as JSON, got %s", body)
+	}
+
+	resp, err = http.Get("http://localhost:10341/")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 204 {
+		t.Errorf("expected imposters-list document to load, got %d", resp.StatusCode)
+	}
+}
+
+func TestYAML_InvalidConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.yml")
+	os.WriteFile(path, []byte("protocol: http\nport: 10342\n---\n- not an imposter\n"), 0644)
+
+	_, err := config.LoadFile(path, false)
+	if err == nil || !strings.Contains(err.Error(), "document 1") {
+		t.Errorf("expected error naming document 1, got %v", err)
+	}
+}
+
+func TestYAML_SaveFileRoundTrip(t *testing.T) {
+	imp := &models.Imposter{
+		Port:     10343,
+		Protocol: "http",
+		Stubs: []models.Stub{{
+			Responses: []models.Response{{Is: &models.IsResponse{StatusCode: 200, Body: "true"}}},
+		}},
+	}
+
+	path := filepath.Join(t.TempDir(), "saved.yaml")
+	if err := config.SaveFile([]*models.Imposter{imp}, path, false, true); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "imposters:\n") {
+		t.Errorf("expected block-style YAML, got:\n%s", data)
+	}
+
+	cfg, err := config.LoadFile(path, false)
+	if err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if len(cfg.Imposters) != 1 || cfg.Imposters[0].Port != 10343 {
+		t.Fatalf("unexpected imposters: %+v", cfg.Imposters)
+	}
+	if body := cfg.Imposters[0].Stubs[0].Responses[0].Is.Body; body != "true" {
+		t.Errorf("expected string body to stay a string, got %#v", body)
+	}
+}
+
+func TestYAML_GetImpostersAcceptHeader(t *testing.T) {
+	defer cleanup(t)
+
+	resp, _, err := post("/imposters", map[string]interface{}{
+		"protocol": "http",
+		"port":     10344,
+		"name":     "yaml view",
+	})
+	if err != nil {
+		t.Fatalf("failed to create imposter: %v", err)
+	}
+	resp.Body.Close()
+
+	for _, path := range []string{"/imposters", "/imposters/10344"} {
+		t.Run(path, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", baseURL+path, nil)
+			req.Header.Set("Accept", "application/yaml")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if ct := resp.Header.Get("Content-Type"); ct != "application/yaml" {
+				t.Errorf("expected application/yaml, got %q", ct)
+			}
+			if !strings.Contains(string(body), "name: yaml view") {
+				t.Errorf("expected YAML body, got:\n%s", body)
+			}
+		})
+	}
+}